@@ -2,24 +2,44 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
 )
 
 const (
 	// API V1
-	routeAPISettings = "/api/v1/settings"
+	routeAPISettings      = "/api/v1/settings"
+	routeAPICapabilities  = "/api/v1/capabilities"
+	routeAPIMoveApproval  = "/api/v1/move-approval"
+	routeAPIMoveSelection = "/api/v1/move-selection"
+	routeAPICanMove       = "/api/v1/can-move"
+	routeAPIAdminQueue    = "/api/v1/admin-queue"
+	routeAPIProvenance    = "/api/v1/provenance"
+	routeAPIMoveUndo      = "/api/v1/move-undo"
+	routeAPIBatch         = "/api/v1/batch"
+	routeAPIHistoryCSV    = "/api/v1/history.csv"
 
 	routeProfileImage = "/profile.png"
+
+	// Autocomplete
+	routeAutocompleteChannels = "/autocomplete/channels"
 )
 
+// dynamicChannelsUnavailableItem is the autocomplete item added when the
+// channels of some teams couldn't be loaded.
+const dynamicChannelsUnavailableItem = "channels-unavailable"
+
 func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Request) {
 	status, err := p.serveHTTP(c, w, r)
 	if err != nil {
@@ -39,8 +59,28 @@ func (p *Plugin) serveHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 	switch path := r.URL.Path; path {
 	case routeAPISettings:
 		return p.handleRouteAPISettings(w, r)
+	case routeAPICapabilities:
+		return p.handleRouteAPICapabilities(w, r)
+	case routeAPIMoveApproval:
+		return p.handleMoveApproval(w, r)
+	case routeAPIMoveSelection:
+		return p.handleMoveSelection(w, r)
+	case routeAPICanMove:
+		return p.handleCanMove(w, r)
+	case routeAPIAdminQueue:
+		return p.handleAdminQueue(w, r)
+	case routeAPIProvenance:
+		return p.handleProvenance(w, r)
+	case routeAPIMoveUndo:
+		return p.handleMoveUndo(w, r)
+	case routeAPIBatch:
+		return p.handleBatch(w, r)
+	case routeAPIHistoryCSV:
+		return p.handleHistoryCSV(w, r)
 	case routeProfileImage:
 		return p.handleProfileImage(w, r)
+	case routeAutocompleteChannels:
+		return p.handleDynamicChannels(w, r)
 	}
 
 	return respondErr(w, http.StatusNotFound, errors.New("not found"))
@@ -58,27 +98,434 @@ func (p *Plugin) handleRouteAPISettings(w http.ResponseWriter, r *http.Request)
 	}
 
 	var enabled bool
-	if p.getConfiguration().EnableWebUI && p.authorizedPluginUser(mattermostUserID) {
+	if p.getConfiguration().EnableWebUI && p.authorizedWebUIUser(mattermostUserID) {
 		enabled = true
 	}
 
 	return respondJSON(w,
 		struct {
-			EnableWebUI bool `json:"enable_web_ui"`
+			EnableWebUI     bool            `json:"enable_web_ui"`
+			Operations      map[string]bool `json:"operations"`
+			Degraded        bool            `json:"degraded"`
+			HealthIssues    []string        `json:"health_issues"`
+			MaintenanceMode bool            `json:"maintenance_mode"`
 		}{
-			EnableWebUI: enabled,
+			EnableWebUI:     enabled,
+			Operations:      p.getWebUIOperations(mattermostUserID, enabled),
+			Degraded:        p.isDegraded(),
+			HealthIssues:    p.getHealth().issues,
+			MaintenanceMode: p.blockedByMaintenanceMode(mattermostUserID),
 		},
 	)
 }
 
+// getWebUIOperations returns whether the web UI should offer each operation to
+// the user. Operations are offered when they are enabled and the role of the
+// user permits them. The commands run by the web UI enforce the same checks.
+func (p *Plugin) getWebUIOperations(userID string, webUIEnabled bool) map[string]bool {
+	config := p.getConfiguration()
+	operations := map[string]bool{
+		enabledOperationMove:   false,
+		enabledOperationCopy:   false,
+		enabledOperationAttach: false,
+		enabledOperationMerge:  false,
+	}
+	if !webUIEnabled {
+		return operations
+	}
+
+	for operation := range operations {
+		operations[operation] = config.OperationEnabled(operation)
+	}
+	if operations[enabledOperationMove] || operations[enabledOperationMerge] {
+		movePermitted := p.authorizedOperationUser(userID, config.MovePermittedRoleNames()) && !p.guestMovesBlocked(userID)
+		operations[enabledOperationMove] = operations[enabledOperationMove] && movePermitted
+		operations[enabledOperationMerge] = operations[enabledOperationMerge] && movePermitted
+	}
+	if operations[enabledOperationCopy] {
+		operations[enabledOperationCopy] = p.authorizedOperationUser(userID, config.CopyPermittedRoleNames())
+	}
+
+	return operations
+}
+
+func (p *Plugin) handleRouteAPICapabilities(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be GET", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+
+	return respondJSON(w,
+		struct {
+			Version      string          `json:"version"`
+			Capabilities map[string]bool `json:"capabilities"`
+		}{
+			Version:      manifest.Version,
+			Capabilities: p.getConfiguration().capabilities(),
+		},
+	)
+}
+
+// handleMoveApproval handles the approve and reject buttons of move approval
+// requests.
+func (p *Plugin) handleMoveApproval(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be POST", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+
+	request := model.PostActionIntegrationRequestFromJson(r.Body)
+	if request == nil {
+		return respondErr(w, http.StatusBadRequest, errors.New("unable to parse post action request"))
+	}
+	requestID, _ := request.Context["request_id"].(string)
+	action, _ := request.Context["action"].(string)
+	if requestID == "" {
+		return respondErr(w, http.StatusBadRequest, errors.New("missing move approval request ID"))
+	}
+
+	response, err := p.handleMoveApprovalAction(mattermostUserID, requestID, action)
+	if err != nil {
+		return respondErr(w, http.StatusInternalServerError, err)
+	}
+
+	return respondJSON(w, response)
+}
+
+// handleMoveUndo handles the Undo button shown while a move waits to
+// delete the original messages.
+func (p *Plugin) handleMoveUndo(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be POST", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+
+	request := model.PostActionIntegrationRequestFromJson(r.Body)
+	if request == nil {
+		return respondErr(w, http.StatusBadRequest, errors.New("unable to parse post action request"))
+	}
+	undoID, _ := request.Context["undo_id"].(string)
+	if undoID == "" {
+		return respondErr(w, http.StatusBadRequest, errors.New("missing move undo ID"))
+	}
+
+	return respondJSON(w, p.handleMoveUndoAction(mattermostUserID, undoID))
+}
+
+// handleAdminQueue handles the buttons of the admin queue.
+func (p *Plugin) handleAdminQueue(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be POST", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+
+	request := model.PostActionIntegrationRequestFromJson(r.Body)
+	if request == nil {
+		return respondErr(w, http.StatusBadRequest, errors.New("unable to parse post action request"))
+	}
+	action, _ := request.Context["action"].(string)
+
+	response, err := p.handleAdminQueueAction(mattermostUserID, action, request.Context)
+	if err != nil {
+		return respondErr(w, http.StatusBadRequest, err)
+	}
+
+	return respondJSON(w, response)
+}
+
+// handleMoveSelection moves the threads of the posts selected in the web UI
+// into a target channel.
+func (p *Plugin) handleMoveSelection(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be POST", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+	if !p.getConfiguration().EnableWebUI || !p.authorizedWebUIUser(mattermostUserID) {
+		return respondErr(w, http.StatusForbidden, errors.New("forbidden"))
+	}
+	if p.blockedByMaintenanceMode(mattermostUserID) {
+		return respondErr(w, http.StatusServiceUnavailable, errors.New(maintenanceModeMessage))
+	}
+
+	var request moveSelectionRequest
+	err := decodeJSON(&request, r.Body)
+	if err != nil {
+		return respondErr(w, http.StatusBadRequest, errors.Wrap(err, "unable to parse move selection request"))
+	}
+
+	result, message := p.moveSelection(mattermostUserID, &request)
+	if result == nil {
+		return respondErr(w, http.StatusBadRequest, errors.New(message))
+	}
+
+	return respondJSON(w, result)
+}
+
+// handleBatch runs several move and copy operations of the web UI with a
+// single request.
+func (p *Plugin) handleBatch(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be POST", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+	if !p.getConfiguration().EnableWebUI || !p.authorizedWebUIUser(mattermostUserID) {
+		return respondErr(w, http.StatusForbidden, errors.New("forbidden"))
+	}
+	if p.blockedByMaintenanceMode(mattermostUserID) {
+		return respondErr(w, http.StatusServiceUnavailable, errors.New(maintenanceModeMessage))
+	}
+
+	var request batchRequest
+	err := decodeJSON(&request, r.Body)
+	if err != nil {
+		return respondErr(w, http.StatusBadRequest, errors.Wrap(err, "unable to parse batch request"))
+	}
+
+	results, message := p.runBatch(mattermostUserID, &request)
+	if results == nil {
+		return respondErr(w, http.StatusBadRequest, errors.New(message))
+	}
+
+	return respondJSON(w, results)
+}
+
+// handleHistoryCSV exports the operation history as CSV for system admins.
+func (p *Plugin) handleHistoryCSV(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be GET", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+	if !p.API.HasPermissionTo(mattermostUserID, model.PERMISSION_MANAGE_SYSTEM) {
+		return respondErr(w, http.StatusForbidden, errors.New("forbidden"))
+	}
+
+	exportRange, err := parseHistoryExportRange(r.URL.Query())
+	if err != nil {
+		return respondErr(w, http.StatusBadRequest, err)
+	}
+
+	records, err := p.getOperationHistory(time.Time{})
+	if err != nil {
+		return respondErr(w, http.StatusInternalServerError, errors.Wrap(err, "unable to get operation history"))
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="wrangler-history.csv"`)
+	// Once rows are written, the status can no longer be changed, so write
+	// errors are only returned for logging.
+	err = writeOperationHistoryCSV(w, records, exportRange)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// handleCanMove returns whether the user may move the thread containing the
+// given post, so that the web UI can hide the move action when it isn't
+// available.
+func (p *Plugin) handleCanMove(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be GET", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+	if !p.getConfiguration().EnableWebUI || !p.authorizedWebUIUser(mattermostUserID) {
+		return respondErr(w, http.StatusForbidden, errors.New("forbidden"))
+	}
+
+	postID := r.URL.Query().Get("post_id")
+	if len(postID) == 0 {
+		return respondErr(w, http.StatusBadRequest, errors.New("missing post_id query parameter"))
+	}
+
+	result, err := p.canMoveThread(mattermostUserID, postID)
+	if err != nil {
+		return respondErr(w, http.StatusInternalServerError, errors.Wrap(err, "unable to check whether the thread can be moved"))
+	}
+
+	return respondJSON(w, result)
+}
+
+// handleProvenance returns where the given post was originally moved from,
+// for users who can read the channel of the post.
+func (p *Plugin) handleProvenance(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be GET", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+	if !p.authorizedPluginUser(mattermostUserID) {
+		return respondErr(w, http.StatusForbidden, errors.New("forbidden"))
+	}
+
+	postID := r.URL.Query().Get("post_id")
+	if len(postID) == 0 {
+		return respondErr(w, http.StatusBadRequest, errors.New("missing post_id query parameter"))
+	}
+
+	post, appErr := p.API.GetPost(postID)
+	if appErr != nil {
+		return respondErr(w, http.StatusNotFound, errors.Errorf("post %s not found", postID))
+	}
+	if !p.API.HasPermissionToChannel(mattermostUserID, post.ChannelId, model.PERMISSION_READ_CHANNEL) {
+		return respondErr(w, http.StatusNotFound, errors.Errorf("post %s not found", postID))
+	}
+
+	moves, err := p.getProvenance(postID)
+	if err != nil {
+		return respondErr(w, http.StatusInternalServerError, err)
+	}
+	if moves == nil {
+		moves = []provenanceRecord{}
+	}
+
+	return respondJSON(w,
+		struct {
+			PostID string             `json:"post_id"`
+			Moves  []provenanceRecord `json:"moves"`
+		}{
+			PostID: postID,
+			Moves:  moves,
+		},
+	)
+}
+
+// handleDynamicChannels returns the channels that can be used as the target
+// of a move or copy for the dynamic autocomplete of the slash command. Private
+// channels the Wrangler bot isn't a member of are left out, as the bot posts
+// made in the target channel would fail there. The autocomplete is requested
+// on every keystroke, so requests made within the configured interval of the
+// last complete list get that list again.
+func (p *Plugin) handleDynamicChannels(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodGet {
+		return respondErr(w, http.StatusMethodNotAllowed,
+			errors.Errorf("method %s is not allowed, must be GET", r.Method))
+	}
+
+	mattermostUserID := r.Header.Get("Mattermost-User-Id")
+	if mattermostUserID == "" {
+		return respondErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+	}
+
+	items := []model.AutocompleteListItem{}
+	if !p.authorizedPluginUser(mattermostUserID) {
+		return respondJSON(w, items)
+	}
+
+	interval := p.getConfiguration().ChannelAutocompleteInterval()
+	if recent, ok := p.channelAutocomplete.get(mattermostUserID, interval); ok {
+		return respondJSON(w, recent)
+	}
+
+	teams, appErr := p.API.GetTeamsForUser(mattermostUserID)
+	if appErr != nil {
+		return respondErr(w, http.StatusInternalServerError, errors.Wrap(appErr, "unable to get teams"))
+	}
+
+	// Teams whose channels can't be loaded are skipped so that the channels of
+	// the other teams can still be picked.
+	var failedTeams []string
+	for _, team := range teams {
+		channels, appErr := p.API.GetChannelsForTeamForUser(team.Id, mattermostUserID, false)
+		if appErr != nil {
+			p.API.LogWarn("Unable to get channels for autocomplete",
+				"error", appErr.Error(),
+				"user_id", mattermostUserID,
+				"team_id", team.Id,
+			)
+			failedTeams = append(failedTeams, team.Name)
+			continue
+		}
+
+		for _, channel := range channels {
+			if channel.IsGroupOrDirect() {
+				continue
+			}
+			if channel.Type == model.CHANNEL_PRIVATE {
+				if _, appErr = p.API.GetChannelMember(channel.Id, p.BotUserID); appErr != nil {
+					continue
+				}
+			}
+
+			items = append(items, model.AutocompleteListItem{
+				Item:     channel.Id,
+				Hint:     fmt.Sprintf("%s - %s", team.Name, channel.Name),
+				HelpText: channel.DisplayName,
+			})
+		}
+	}
+
+	if len(failedTeams) != 0 {
+		items = append(items, model.AutocompleteListItem{
+			Item:     dynamicChannelsUnavailableItem,
+			Hint:     "Some channels couldn't be loaded",
+			HelpText: fmt.Sprintf("The channels of %s couldn't be loaded; try again or enter the channel ID", strings.Join(failedTeams, ", ")),
+		})
+	} else if interval > 0 {
+		// Incomplete lists aren't kept so that trying again loads the
+		// channels that couldn't be loaded.
+		p.channelAutocomplete.set(mattermostUserID, items, interval)
+	}
+
+	return respondJSON(w, items)
+}
+
 func (p *Plugin) handleProfileImage(w http.ResponseWriter, r *http.Request) (int, error) {
+	if !p.getHealth().profileImageAvailable {
+		return respondErr(w, http.StatusServiceUnavailable, errors.New("profile image asset is missing; check the plugin logs"))
+	}
+
 	bundlePath, err := p.API.GetBundlePath()
 	if err != nil {
 		p.API.LogError("Unable to get bundle path, err=" + err.Error())
 		return respondErr(w, http.StatusInternalServerError, errors.New("internal error"))
 	}
 
-	img, err := os.Open(filepath.Join(bundlePath, "assets", "profile.png"))
+	img, err := os.Open(filepath.Join(bundlePath, profileImagePath))
 	if err != nil {
 		p.API.LogError("Unable to read profile image, err=" + err.Error())
 		return respondErr(w, http.StatusInternalServerError, errors.New("internal error"))
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesAPI(t *testing.T) {
+	plugin := &Plugin{}
+	plugin.SetAPI(&plugintest.API{})
+	plugin.setConfiguration(&configuration{
+		EnableWebUI:                   true,
+		MoveThreadToAnotherTeamEnable: false,
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAPICapabilities, nil)
+		status, err := plugin.serveHTTP(nil, w, r)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("capabilities", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAPICapabilities, nil)
+		r.Header.Set("Mattermost-User-Id", "user1")
+		status, err := plugin.serveHTTP(nil, w, r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var resp struct {
+			Version      string          `json:"version"`
+			Capabilities map[string]bool `json:"capabilities"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, manifest.Version, resp.Version)
+		assert.True(t, resp.Capabilities["web_ui"])
+		assert.False(t, resp.Capabilities["cross_team_move"])
+		assert.True(t, resp.Capabilities["reactions_preservation"])
+		assert.False(t, resp.Capabilities["move_reminders"])
+		assert.False(t, resp.Capabilities["import_thread"])
+		assert.False(t, resp.Capabilities["move_requests"])
+	})
+
+	t.Run("experimental features", func(t *testing.T) {
+		config := &configuration{ExperimentalFeatures: "reminders,request-move"}
+		capabilities := config.capabilities()
+		assert.True(t, capabilities["move_reminders"])
+		assert.False(t, capabilities["import_thread"])
+		// Move requests also need an approval channel.
+		assert.False(t, capabilities["move_requests"])
+
+		config.MoveApprovalChannelID = model.NewId()
+		assert.True(t, config.capabilities()["move_requests"])
+	})
+}
+
+func TestSettingsAPIMaintenanceMode(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("HasPermissionTo", "user1", model.PERMISSION_MANAGE_SYSTEM).Return(false)
+
+	plugin := &Plugin{}
+	plugin.SetAPI(api)
+	plugin.setConfiguration(&configuration{MaintenanceMode: true, MaintenanceModeAdminBypass: true})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, routeAPISettings, nil)
+	r.Header.Set("Mattermost-User-Id", "user1")
+	status, err := plugin.serveHTTP(nil, w, r)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	var resp struct {
+		MaintenanceMode bool `json:"maintenance_mode"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.MaintenanceMode)
+}
+
+func TestSettingsAPIWebUIPermittedRoles(t *testing.T) {
+	getEnabled := func(t *testing.T, config *configuration) bool {
+		api := &plugintest.API{}
+		api.On("HasPermissionTo", "user1", model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		api.On("GetUser", "user1").Return(&model.User{Id: "user1", Email: "user1@example.com", Roles: model.SYSTEM_USER_ROLE_ID}, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(config)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAPISettings, nil)
+		r.Header.Set("Mattermost-User-Id", "user1")
+		status, err := plugin.serveHTTP(nil, w, r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var resp struct {
+			EnableWebUI bool `json:"enable_web_ui"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		return resp.EnableWebUI
+	}
+
+	t.Run("defaults to command authorization", func(t *testing.T) {
+		assert.True(t, getEnabled(t, &configuration{EnableWebUI: true}))
+		assert.False(t, getEnabled(t, &configuration{EnableWebUI: true, AllowedEmailDomain: "mattermost.com"}))
+	})
+
+	t.Run("role not permitted", func(t *testing.T) {
+		assert.False(t, getEnabled(t, &configuration{EnableWebUI: true, WebUIPermittedRoles: model.SYSTEM_ADMIN_ROLE_ID}))
+	})
+
+	t.Run("role permitted", func(t *testing.T) {
+		assert.True(t, getEnabled(t, &configuration{
+			EnableWebUI:         true,
+			AllowedEmailDomain:  "example.com",
+			WebUIPermittedRoles: model.SYSTEM_ADMIN_ROLE_ID + ", " + model.SYSTEM_USER_ROLE_ID,
+		}))
+	})
+
+	t.Run("role permitted without command authorization", func(t *testing.T) {
+		assert.False(t, getEnabled(t, &configuration{
+			EnableWebUI:         true,
+			AllowedEmailDomain:  "mattermost.com",
+			WebUIPermittedRoles: model.SYSTEM_ADMIN_ROLE_ID + ", " + model.SYSTEM_USER_ROLE_ID,
+		}))
+	})
+}
+
+func TestSettingsAPIOperations(t *testing.T) {
+	getOperations := func(t *testing.T, config *configuration) map[string]bool {
+		api := &plugintest.API{}
+		api.On("HasPermissionTo", "user1", model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		api.On("GetUser", "user1").Return(&model.User{Id: "user1", Email: "user1@example.com", Roles: model.SYSTEM_USER_ROLE_ID}, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(config)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAPISettings, nil)
+		r.Header.Set("Mattermost-User-Id", "user1")
+		status, err := plugin.serveHTTP(nil, w, r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var resp struct {
+			Operations map[string]bool `json:"operations"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		return resp.Operations
+	}
+
+	t.Run("all operations by default", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{"move": true, "copy": true, "attach": true, "merge": true}, getOperations(t, &configuration{EnableWebUI: true}))
+	})
+
+	t.Run("only the enabled operations", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{"move": true, "copy": true, "attach": false, "merge": false}, getOperations(t, &configuration{EnableWebUI: true, EnabledOperations: "move, Copy"}))
+		assert.Equal(t, map[string]bool{"move": false, "copy": false, "attach": false, "merge": true}, getOperations(t, &configuration{EnableWebUI: true, EnabledOperations: "merge"}))
+	})
+
+	t.Run("operations the role doesn't permit", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{"move": false, "copy": true, "attach": true, "merge": false}, getOperations(t, &configuration{EnableWebUI: true, MovePermittedRoles: model.SYSTEM_ADMIN_ROLE_ID}))
+	})
+
+	t.Run("web UI disabled", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{"move": false, "copy": false, "attach": false, "merge": false}, getOperations(t, &configuration{EnabledOperations: "move"}))
+	})
+}
+
+func TestDynamicChannelsAPI(t *testing.T) {
+	team := &model.Team{Id: model.NewId(), Name: "team1"}
+	publicChannel := &model.Channel{Id: model.NewId(), TeamId: team.Id, Name: "public", DisplayName: "Public", Type: model.CHANNEL_OPEN}
+	joinedPrivateChannel := &model.Channel{Id: model.NewId(), TeamId: team.Id, Name: "joined-private", DisplayName: "Joined Private", Type: model.CHANNEL_PRIVATE}
+	privateChannel := &model.Channel{Id: model.NewId(), TeamId: team.Id, Name: "private", DisplayName: "Private", Type: model.CHANNEL_PRIVATE}
+	directChannel := &model.Channel{Id: model.NewId(), Type: model.CHANNEL_DIRECT}
+
+	api := &plugintest.API{}
+	api.On("GetTeamsForUser", "user1").Return([]*model.Team{team}, nil)
+	api.On("GetChannelsForTeamForUser", team.Id, "user1", false).Return([]*model.Channel{publicChannel, joinedPrivateChannel, privateChannel, directChannel}, nil)
+	api.On("GetChannelMember", joinedPrivateChannel.Id, "bot1").Return(&model.ChannelMember{}, nil)
+	api.On("GetChannelMember", privateChannel.Id, "bot1").Return(nil, model.NewAppError("test", "not found", nil, "", http.StatusNotFound))
+
+	plugin := &Plugin{BotUserID: "bot1"}
+	plugin.SetAPI(api)
+	plugin.setConfiguration(&configuration{})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAutocompleteChannels, nil)
+		status, err := plugin.serveHTTP(nil, w, r)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("channels the bot can post in", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAutocompleteChannels+"?user_input=", nil)
+		r.Header.Set("Mattermost-User-Id", "user1")
+		status, err := plugin.serveHTTP(nil, w, r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var items []model.AutocompleteListItem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+		require.Len(t, items, 2)
+		assert.Equal(t, publicChannel.Id, items[0].Item)
+		assert.Equal(t, "team1 - public", items[0].Hint)
+		assert.Equal(t, "Public", items[0].HelpText)
+		assert.Equal(t, joinedPrivateChannel.Id, items[1].Item)
+	})
+
+	t.Run("guests only get the channels they are a member of", func(t *testing.T) {
+		guestChannel := &model.Channel{Id: model.NewId(), TeamId: team.Id, Name: "guest-public", DisplayName: "Guest Public", Type: model.CHANNEL_OPEN}
+		api := &plugintest.API{}
+		api.On("GetTeamsForUser", "guest1").Return([]*model.Team{team}, nil)
+		api.On("GetChannelsForTeamForUser", team.Id, "guest1", false).Return([]*model.Channel{guestChannel}, nil)
+
+		plugin := &Plugin{BotUserID: "bot1"}
+		plugin.SetAPI(api)
+		// Guests may still copy threads, so the channels are listed even
+		// when they can't move threads.
+		plugin.setConfiguration(&configuration{BlockGuestMoves: true})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAutocompleteChannels+"?user_input=", nil)
+		r.Header.Set("Mattermost-User-Id", "guest1")
+		status, err := plugin.serveHTTP(nil, w, r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var items []model.AutocompleteListItem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+		require.Len(t, items, 1)
+		assert.Equal(t, guestChannel.Id, items[0].Item)
+		api.AssertNotCalled(t, "GetPublicChannelsForTeam", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("channels of a team fail to load", func(t *testing.T) {
+		otherTeam := &model.Team{Id: model.NewId(), Name: "team2"}
+		api := &plugintest.API{}
+		mockLogs(api)
+		api.On("GetTeamsForUser", "user1").Return([]*model.Team{otherTeam, team}, nil)
+		api.On("GetChannelsForTeamForUser", otherTeam.Id, "user1", false).Return(nil, &model.AppError{Message: "unavailable"})
+		api.On("GetChannelsForTeamForUser", team.Id, "user1", false).Return([]*model.Channel{publicChannel}, nil)
+
+		plugin := &Plugin{BotUserID: "bot1"}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(&configuration{})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAutocompleteChannels+"?user_input=", nil)
+		r.Header.Set("Mattermost-User-Id", "user1")
+		status, err := plugin.serveHTTP(nil, w, r)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var items []model.AutocompleteListItem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+		require.Len(t, items, 2)
+		assert.Equal(t, publicChannel.Id, items[0].Item)
+		assert.Equal(t, dynamicChannelsUnavailableItem, items[1].Item)
+		assert.Contains(t, items[1].HelpText, "The channels of team2 couldn't be loaded")
+		api.AssertCalled(t, "LogWarn", "Unable to get channels for autocomplete", "error", mock.Anything, "user_id", "user1", "team_id", otherTeam.Id)
+	})
+
+	t.Run("requests within the interval get the last list", func(t *testing.T) {
+		currentTime := time.Now()
+		now = func() time.Time { return currentTime }
+		defer func() { now = time.Now }()
+
+		for _, tc := range []struct {
+			name     string
+			interval string
+			elapsed  time.Duration
+			loads    int
+		}{
+			{"default interval", "", 500 * time.Millisecond, 1},
+			{"interval over", "", time.Second, 2},
+			{"configured interval", "5000", 3 * time.Second, 1},
+			{"disabled", "0", 0, 2},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				api := &plugintest.API{}
+				api.On("GetTeamsForUser", "user1").Return([]*model.Team{team}, nil)
+				api.On("GetTeamsForUser", "user2").Return([]*model.Team{team}, nil)
+				api.On("GetChannelsForTeamForUser", team.Id, mock.Anything, false).Return([]*model.Channel{publicChannel}, nil)
+
+				plugin := &Plugin{BotUserID: "bot1"}
+				plugin.SetAPI(api)
+				plugin.setConfiguration(&configuration{ChannelAutocompleteIntervalMilliseconds: tc.interval})
+				require.NoError(t, plugin.configuration.IsValid())
+
+				request := func(userID string) []model.AutocompleteListItem {
+					w := httptest.NewRecorder()
+					r := httptest.NewRequest(http.MethodGet, routeAutocompleteChannels+"?user_input=", nil)
+					r.Header.Set("Mattermost-User-Id", userID)
+					status, err := plugin.serveHTTP(nil, w, r)
+					require.NoError(t, err)
+					require.Equal(t, http.StatusOK, status)
+
+					var items []model.AutocompleteListItem
+					require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+					return items
+				}
+
+				first := request("user1")
+				currentTime = currentTime.Add(tc.elapsed)
+				second := request("user1")
+				assert.Equal(t, first, second)
+				require.Len(t, second, 1)
+				assert.Equal(t, publicChannel.Id, second[0].Item)
+				api.AssertNumberOfCalls(t, "GetTeamsForUser", tc.loads)
+
+				// The lists of other users are loaded separately.
+				request("user2")
+				api.AssertCalled(t, "GetTeamsForUser", "user2")
+			})
+		}
+	})
+}
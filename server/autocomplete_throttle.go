@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// recentAutocompleteItems are the autocomplete items last returned to a user.
+type recentAutocompleteItems struct {
+	at    time.Time
+	items []model.AutocompleteListItem
+}
+
+// autocompleteThrottle keeps the channel lists recently returned to each user
+// by the dynamic autocomplete, which is requested again on every keystroke.
+// The channels don't depend on what the user typed, so a recent list can be
+// returned as is instead of being computed again. The zero value is ready to
+// use.
+type autocompleteThrottle struct {
+	lock   sync.Mutex
+	recent map[string]recentAutocompleteItems
+}
+
+// get returns the items last returned to the user if they were returned less
+// than the interval ago.
+func (t *autocompleteThrottle) get(userID string, interval time.Duration) ([]model.AutocompleteListItem, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	recent, ok := t.recent[userID]
+	if !ok || now().Sub(recent.at) >= interval {
+		return nil, false
+	}
+
+	return recent.items, true
+}
+
+// set records the items returned to the user. Lists older than the interval
+// are dropped so that the users who stopped typing aren't kept in memory.
+func (t *autocompleteThrottle) set(userID string, items []model.AutocompleteListItem, interval time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	current := now()
+	if t.recent == nil {
+		t.recent = make(map[string]recentAutocompleteItems)
+	}
+	for id, recent := range t.recent {
+		if current.Sub(recent.at) >= interval {
+			delete(t.recent, id)
+		}
+	}
+	t.recent[userID] = recentAutocompleteItems{at: current, items: items}
+}
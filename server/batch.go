@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// maxBatchOperations is the largest number of operations of a single batch.
+const maxBatchOperations = 20
+
+// Types of batch operations.
+const (
+	batchOperationMove = "move"
+	batchOperationCopy = "copy"
+)
+
+// batchRequest is the body of a batch API request.
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+}
+
+// batchOperation moves or copies the threads of the selected posts into a
+// target channel.
+type batchOperation struct {
+	Type                    string   `json:"type"`
+	PostIDs                 []string `json:"post_ids"`
+	ChannelID               string   `json:"channel_id"`
+	ConfirmIntegrationPosts bool     `json:"confirm_integration_posts"`
+	ConfirmPlaybookPosts    bool     `json:"confirm_playbook_posts"`
+}
+
+// batchOperationResult reports the outcome of one operation of a batch.
+type batchOperationResult struct {
+	Type           string                      `json:"type"`
+	ChannelID      string                      `json:"channel_id"`
+	SucceededCount int                         `json:"succeeded_count"`
+	FailedCount    int                         `json:"failed_count"`
+	Threads        []moveSelectionThreadResult `json:"threads"`
+}
+
+// runBatch runs the operations of the batch in order on behalf of the user,
+// who must already be authorized to use the web UI. The roles of each type of
+// operation are checked once for the whole batch, and the combined size of
+// the threads of all operations must be within the max thread count. Each
+// thread is then moved or copied with the same checks as the commands.
+func (p *Plugin) runBatch(userID string, request *batchRequest) ([]batchOperationResult, string) {
+	if len(request.Operations) == 0 {
+		return nil, "the batch has no operations"
+	}
+	if len(request.Operations) > maxBatchOperations {
+		return nil, fmt.Sprintf("the batch has %d operations, but at most %d operations can be run at once", len(request.Operations), maxBatchOperations)
+	}
+
+	config := p.getConfiguration()
+	var selectedCount int
+	checkedRoles := make(map[string]bool)
+	for i, operation := range request.Operations {
+		var roles []string
+		switch operation.Type {
+		case batchOperationMove:
+			roles = config.MovePermittedRoleNames()
+		case batchOperationCopy:
+			roles = config.CopyPermittedRoleNames()
+		default:
+			return nil, fmt.Sprintf("operation %d has unknown type %q; the type must be %s or %s", i+1, operation.Type, batchOperationMove, batchOperationCopy)
+		}
+		if len(operation.PostIDs) == 0 {
+			return nil, fmt.Sprintf("operation %d has no selected posts", i+1)
+		}
+		if len(operation.ChannelID) == 0 {
+			return nil, fmt.Sprintf("operation %d has no target channel", i+1)
+		}
+
+		if !checkedRoles[operation.Type] {
+			// The batch operation types are named like the enabled operations.
+			if !config.OperationEnabled(operation.Type) {
+				return nil, fmt.Sprintf("%s operations are disabled on this server", operation.Type)
+			}
+			if !p.authorizedOperationUser(userID, roles) {
+				return nil, fmt.Sprintf("your role doesn't permit you to %s threads", operation.Type)
+			}
+			if operation.Type == batchOperationMove && p.guestMovesBlocked(userID) {
+				return nil, "guest accounts are not permitted to move threads"
+			}
+			checkedRoles[operation.Type] = true
+		}
+		selectedCount += len(operation.PostIDs)
+	}
+	if selectedCount > maxMoveSelectionPosts {
+		return nil, fmt.Sprintf("%d posts were selected, but at most %d posts can be handled in one batch", selectedCount, maxMoveSelectionPosts)
+	}
+
+	threads := make([][]*selectedThread, len(request.Operations))
+	failed := make([][]moveSelectionThreadResult, len(request.Operations))
+	var combinedCount int
+	for i, operation := range request.Operations {
+		threads[i], failed[i] = p.collectSelectedThreads(userID, operation.PostIDs)
+		combinedCount += countSelectedPosts(threads[i])
+	}
+	if config.MaxThreadCountMoveSizeInt() != 0 && config.MaxThreadCountMoveSizeInt() < combinedCount {
+		if !p.canBypassMaxCount(userID, config) {
+			return nil, fmt.Sprintf("the threads of the batch are %d posts long combined, but only up to %d posts can be moved or copied", combinedCount, config.MaxThreadCountMoveSizeInt())
+		}
+	}
+
+	results := make([]batchOperationResult, 0, len(request.Operations))
+	for i, operation := range request.Operations {
+		run := p.runCopyThreadCommand
+		if operation.Type == batchOperationMove {
+			var flags []string
+			if operation.ConfirmIntegrationPosts {
+				flags = append(flags, "--"+flagMoveThreadConfirmIntegration)
+			}
+			if operation.ConfirmPlaybookPosts {
+				flags = append(flags, "--"+flagMoveThreadConfirmPlaybook)
+			}
+			run = func(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+				return p.moveThreadCommand(append(args, flags...), extra, false)
+			}
+		}
+
+		threadResults := p.runSelectedThreads(userID, threads[i], operation.ChannelID, operation.Type, run)
+		result := batchOperationResult{
+			Type:        operation.Type,
+			ChannelID:   operation.ChannelID,
+			FailedCount: len(failed[i]),
+			Threads:     append(failed[i], threadResults...),
+		}
+		for _, threadResult := range threadResults {
+			if threadResult.Success {
+				result.SucceededCount++
+			} else {
+				result.FailedCount++
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, ""
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchAPI(t *testing.T) {
+	setup := func(config *configuration) (*threadTestFixture, *Plugin, *model.Post) {
+		f := newThreadTestFixture(2)
+
+		otherRoot := &model.Post{
+			Id:        model.NewId(),
+			UserId:    model.NewId(),
+			ChannelId: f.originalChannel.Id,
+			Message:   "This is another root message",
+			CreateAt:  0,
+		}
+		otherList := model.NewPostList()
+		otherList.AddPost(otherRoot)
+		otherList.AddOrder(otherRoot.Id)
+		f.api.On("GetPostThread", otherRoot.Id).Return(otherList, nil)
+
+		plugin := &Plugin{BotUserID: model.NewId()}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+
+		return f, plugin, otherRoot
+	}
+
+	post := func(plugin *Plugin, userID string, request batchRequest) (*httptest.ResponseRecorder, int, error) {
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, routeAPIBatch, bytes.NewReader(body))
+		if len(userID) != 0 {
+			r.Header.Set("Mattermost-User-Id", userID)
+		}
+		status, err := plugin.serveHTTP(nil, w, r)
+
+		return w, status, err
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{EnableWebUI: true})
+		_, status, err := post(plugin, "", batchRequest{Operations: []batchOperation{
+			{Type: batchOperationMove, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+		}})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("role not permitted to use the web UI", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{EnableWebUI: true, WebUIPermittedRoles: model.SYSTEM_ADMIN_ROLE_ID})
+		_, status, err := post(plugin, f.rootPost.UserId, batchRequest{Operations: []batchOperation{
+			{Type: batchOperationMove, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+		}})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("role permitted without command authorization", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{EnableWebUI: true, AllowedEmailDomain: "mattermost.com", WebUIPermittedRoles: model.SYSTEM_USER_ROLE_ID})
+		f.unsetMock("GetUser")
+		f.api.On("GetUser", f.rootPost.UserId).Return(&model.User{Id: f.rootPost.UserId, Email: "user@example.com", Roles: model.SYSTEM_USER_ROLE_ID}, nil)
+
+		_, status, err := post(plugin, f.rootPost.UserId, batchRequest{Operations: []batchOperation{
+			{Type: batchOperationMove, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+			{Type: batchOperationCopy, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+		}})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("unknown operation type", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{EnableWebUI: true})
+		_, status, err := post(plugin, f.rootPost.UserId, batchRequest{Operations: []batchOperation{
+			{Type: batchOperationMove, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+			{Type: "merge", PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+		}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `operation 2 has unknown type "merge"`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("role not permitted for one operation type", func(t *testing.T) {
+		f, plugin, otherRoot := setup(&configuration{EnableWebUI: true, CopyPermittedRoles: "channel_admin"})
+		_, status, err := post(plugin, f.rootPost.UserId, batchRequest{Operations: []batchOperation{
+			{Type: batchOperationMove, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+			{Type: batchOperationCopy, PostIDs: []string{otherRoot.Id}, ChannelID: f.targetChannel.Id},
+		}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "your role doesn't permit you to copy threads")
+		assert.Equal(t, http.StatusBadRequest, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("operation type disabled", func(t *testing.T) {
+		f, plugin, otherRoot := setup(&configuration{EnableWebUI: true, EnabledOperations: "move"})
+		_, status, err := post(plugin, f.rootPost.UserId, batchRequest{Operations: []batchOperation{
+			{Type: batchOperationMove, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+			{Type: batchOperationCopy, PostIDs: []string{otherRoot.Id}, ChannelID: f.targetChannel.Id},
+		}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "copy operations are disabled on this server")
+		assert.Equal(t, http.StatusBadRequest, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("combined size over the limit", func(t *testing.T) {
+		f, plugin, otherRoot := setup(&configuration{EnableWebUI: true, MoveThreadMaxCount: "3"})
+		_, status, err := post(plugin, f.rootPost.UserId, batchRequest{Operations: []batchOperation{
+			{Type: batchOperationMove, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+			{Type: batchOperationCopy, PostIDs: []string{otherRoot.Id}, ChannelID: f.targetChannel.Id},
+		}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the threads of the batch are 4 posts long combined")
+		assert.Equal(t, http.StatusBadRequest, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("move and copy", func(t *testing.T) {
+		f, plugin, otherRoot := setup(&configuration{EnableWebUI: true, MoveThreadMaxCount: "4"})
+		missingID := model.NewId()
+		f.api.On("GetPostThread", missingID).Return(nil, &model.AppError{Message: "not found"})
+
+		w, status, err := post(plugin, f.rootPost.UserId, batchRequest{Operations: []batchOperation{
+			{Type: batchOperationCopy, PostIDs: []string{otherRoot.Id, missingID}, ChannelID: f.targetChannel.Id},
+			{Type: batchOperationMove, PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var results []batchOperationResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+		require.Len(t, results, 2)
+
+		assert.Equal(t, batchOperationCopy, results[0].Type)
+		assert.Equal(t, 1, results[0].SucceededCount)
+		assert.Equal(t, 1, results[0].FailedCount)
+		require.Len(t, results[0].Threads, 2)
+		assert.Equal(t, []string{missingID}, results[0].Threads[0].SelectedPostIDs)
+		assert.Equal(t, otherRoot.Id, results[0].Threads[1].RootID)
+		assert.Contains(t, results[0].Threads[1].Message, "Thread copy complete")
+
+		assert.Equal(t, batchOperationMove, results[1].Type)
+		assert.Equal(t, 1, results[1].SucceededCount)
+		assert.Equal(t, 0, results[1].FailedCount)
+		require.Len(t, results[1].Threads, 1)
+		assert.Contains(t, results[1].Threads[0].Message, "A thread has been moved")
+
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+		f.api.AssertNotCalled(t, "DeletePost", otherRoot.Id)
+	})
+}
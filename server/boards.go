@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// boardsPluginID is the ID of the Boards plugin, formerly named Focalboard.
+const boardsPluginID = "focalboard"
+
+// maxBoardsCardTitleRunes bounds the length of the title of a card.
+const maxBoardsCardTitleRunes = 200
+
+// boardIDRegexp matches the IDs of boards.
+var boardIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+// boardsBlock is a block of the Boards API. Cards and their content are
+// blocks.
+type boardsBlock struct {
+	ID       string                 `json:"id"`
+	BoardID  string                 `json:"boardId"`
+	ParentID string                 `json:"parentId"`
+	Type     string                 `json:"type"`
+	Title    string                 `json:"title"`
+	Fields   map[string]interface{} `json:"fields"`
+	Schema   int64                  `json:"schema"`
+	CreateAt int64                  `json:"createAt"`
+	UpdateAt int64                  `json:"updateAt"`
+}
+
+// boardsPluginRunning returns true if the Boards plugin is installed and
+// running, so that cards can be created on its boards.
+func (p *Plugin) boardsPluginRunning() bool {
+	status, appErr := p.API.GetPluginStatus(boardsPluginID)
+	if appErr != nil {
+		return false
+	}
+
+	return status.State == model.PluginStateRunning
+}
+
+// boardsCardTitle returns the title of the card of a moved thread, which is
+// the first line of its root message.
+func boardsCardTitle(message string) string {
+	title := firstMessageLine(message)
+	if len(title) == 0 {
+		return "Untitled thread"
+	}
+	if utf8.RuneCountInString(title) > maxBoardsCardTitleRunes {
+		title = string([]rune(title)[:maxBoardsCardTitleRunes]) + "..."
+	}
+
+	return title
+}
+
+// createBoardsCard creates a card linking to the moved thread on the
+// configured board through the inter-plugin API of the Boards plugin. The card
+// is created on behalf of the given user, so the Boards plugin checks that the
+// user may edit the board.
+func (p *Plugin) createBoardsCard(userID, rootMessage, threadLink string) error {
+	boardID := p.getConfiguration().BoardsCardBoardID
+	createAt := model.GetMillis()
+	// Boards prefixes the IDs of its blocks with their type.
+	card := boardsBlock{
+		ID:       "c" + model.NewId(),
+		BoardID:  boardID,
+		ParentID: boardID,
+		Type:     "card",
+		Title:    boardsCardTitle(rootMessage),
+		Schema:   1,
+		CreateAt: createAt,
+		UpdateAt: createAt,
+	}
+	text := boardsBlock{
+		ID:       "a" + model.NewId(),
+		BoardID:  boardID,
+		ParentID: card.ID,
+		Type:     "text",
+		Title:    fmt.Sprintf("Moved thread: %s", threadLink),
+		Fields:   map[string]interface{}{},
+		Schema:   1,
+		CreateAt: createAt,
+		UpdateAt: createAt,
+	}
+	card.Fields = map[string]interface{}{
+		"properties":   map[string]interface{}{},
+		"contentOrder": []string{text.ID},
+	}
+
+	body, err := json.Marshal([]boardsBlock{card, text})
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal card blocks")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/%s/api/v2/boards/%s/blocks", boardsPluginID, boardID), bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "unable to create card request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Mattermost-User-Id", userID)
+	// Boards refuses requests without it to protect against CSRF.
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+
+	resp := p.API.PluginHTTP(req)
+	if resp == nil {
+		return errors.New("no response from the Boards plugin")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the Boards plugin answered with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
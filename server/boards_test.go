@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveThreadCreateCard(t *testing.T) {
+	boardID := "b" + model.NewId()
+
+	setup := func(config *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+		f.rootPost.Message = "## Login page is broken\nIt fails on Safari."
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+		require.NoError(t, plugin.configuration.IsValid())
+
+		return f, plugin
+	}
+
+	t.Run("creates a card", func(t *testing.T) {
+		f, plugin := setup(&configuration{BoardsCardBoardID: boardID})
+		f.api.On("GetPluginStatus", boardsPluginID).Return(&model.PluginStatus{State: model.PluginStateRunning}, nil)
+		var req *http.Request
+		var blocks []boardsBlock
+		f.api.On("PluginHTTP", mock.Anything).Return(func(r *http.Request) *http.Response {
+			req = r
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&blocks))
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader([]byte("[]")))}
+		})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id, "--create-card"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "\nA card linking to the moved thread was created on the board.\n")
+
+		require.NotNil(t, req)
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/focalboard/api/v2/boards/"+boardID+"/blocks", req.URL.Path)
+		assert.Equal(t, f.rootPost.UserId, req.Header.Get("Mattermost-User-Id"))
+		assert.Equal(t, "XMLHttpRequest", req.Header.Get("X-Requested-With"))
+
+		require.Len(t, blocks, 2)
+		assert.Equal(t, "card", blocks[0].Type)
+		assert.Equal(t, boardID, blocks[0].BoardID)
+		assert.Equal(t, "Login page is broken", blocks[0].Title)
+		assert.Equal(t, []interface{}{blocks[1].ID}, blocks[0].Fields["contentOrder"])
+		assert.Equal(t, "text", blocks[1].Type)
+		assert.Equal(t, blocks[0].ID, blocks[1].ParentID)
+		assert.Equal(t, "Moved thread: "+makePostLink("test.sampledomain.com", f.team.Name, f.newPost.Id), blocks[1].Title)
+	})
+
+	t.Run("card creation fails", func(t *testing.T) {
+		f, plugin := setup(&configuration{BoardsCardBoardID: boardID})
+		f.api.On("GetPluginStatus", boardsPluginID).Return(&model.PluginStatus{State: model.PluginStateRunning}, nil)
+		f.api.On("PluginHTTP", mock.Anything).Return(&http.Response{StatusCode: http.StatusForbidden, Body: ioutil.NopCloser(bytes.NewReader(nil))})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id, "--create-card"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		assert.Contains(t, resp.Text, "\nThe thread was moved, but the card could not be created on the board.\n")
+	})
+
+	t.Run("no board configured", func(t *testing.T) {
+		f, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id, "--create-card"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: no board is configured for the --create-card flag", resp.Text)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("Boards not installed", func(t *testing.T) {
+		f, plugin := setup(&configuration{BoardsCardBoardID: boardID})
+		f.api.On("GetPluginStatus", boardsPluginID).Return(nil, model.NewAppError("GetPluginStatus", "app.plugin.not_installed.app_error", nil, "", http.StatusNotFound))
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id, "--create-card"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: the Boards plugin isn't installed or enabled, so no card can be created for the --create-card flag", resp.Text)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+		f.api.AssertNotCalled(t, "PluginHTTP", mock.Anything)
+	})
+}
@@ -7,8 +7,21 @@ import (
 	"github.com/pkg/errors"
 )
 
-// PostBotDM posts a DM as the Wrangler bot user.
+// PostBotDM posts a DM as the Wrangler bot user. The DM is skipped if the
+// server-wide notification DM limit has been reached.
 func (p *Plugin) PostBotDM(userID, message string) error {
+	allowed, err := p.reserveNotificationDM()
+	if err != nil {
+		return errors.Wrap(err, "unable to check notification DM limit")
+	}
+	if !allowed {
+		p.API.LogWarn("Notification DM limit reached; skipping DM",
+			"user_id", userID,
+			"max_notification_dms_per_hour", p.getConfiguration().MaxNotificationDMsPerHour,
+		)
+		return nil
+	}
+
 	channel, appError := p.API.GetDirectChannel(userID, p.BotUserID)
 	if appError != nil {
 		return errors.Wrap(appError, "unable to get direct channel")
@@ -29,6 +42,21 @@ func (p *Plugin) PostBotDM(userID, message string) error {
 	return nil
 }
 
+// getAttributionAuthor returns the ID of the user who authors the notices left
+// in a moved or copied thread of the given channel. The user who ran the
+// command only authors them when configured to and when they may post in the
+// channel; otherwise the bot does.
+func (p *Plugin) getAttributionAuthor(userID, channelID string) string {
+	if p.getConfiguration().AttributionAuthorValue() != attributionAuthorActor {
+		return p.BotUserID
+	}
+	if !p.API.HasPermissionToChannel(userID, channelID, model.PERMISSION_CREATE_POST) {
+		return p.BotUserID
+	}
+
+	return userID
+}
+
 // PostToChannelByIDAsBot posts a message to the provided channel.
 func (p *Plugin) PostToChannelByIDAsBot(channelID, message string) error {
 	_, appError := p.API.CreatePost(&model.Post{
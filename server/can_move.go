@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// canMoveResult reports whether a user may move the thread of a post.
+type canMoveResult struct {
+	CanMove bool   `json:"can_move"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// canMoveThread returns whether the user may move the thread containing the
+// post, along with the reason if not. It runs the checks of the move thread
+// command that don't depend on the target channel, so a thread it allows may
+// still be refused for some target channels.
+func (p *Plugin) canMoveThread(userID, postID string) (*canMoveResult, error) {
+	if p.blockedByMaintenanceMode(userID) {
+		return &canMoveResult{Reason: maintenanceModeMessage}, nil
+	}
+	if !p.getConfiguration().OperationEnabled(enabledOperationMove) {
+		return &canMoveResult{Reason: "moving threads is disabled on this server"}, nil
+	}
+	if !p.authorizedOperationUser(userID, p.getConfiguration().MovePermittedRoleNames()) {
+		return &canMoveResult{Reason: "your role doesn't permit you to move threads"}, nil
+	}
+	if p.guestMovesBlocked(userID) {
+		return &canMoveResult{Reason: "guest accounts are not permitted to move threads"}, nil
+	}
+	if p.accountTooNew(userID) {
+		return &canMoveResult{Reason: fmt.Sprintf("your account must be at least %d day(s) old to move threads", p.getConfiguration().MinAccountAgeDaysInt())}, nil
+	}
+
+	thread, message := p.getSelectedThread(userID, postID)
+	if thread == nil {
+		return &canMoveResult{Reason: message}, nil
+	}
+	if thread.wpl.RootPost().DeleteAt != 0 {
+		return &canMoveResult{Reason: "that post no longer exists"}, nil
+	}
+
+	config := p.getConfiguration()
+	extra := &model.CommandArgs{
+		UserId:    userID,
+		ChannelId: thread.channel.Id,
+		TeamId:    thread.channel.TeamId,
+	}
+
+	response := validateSourceChannelType(thread.channel, config)
+	if response == nil {
+		response = p.validateRemovalFromChannel(thread.channel, extra)
+	}
+	if response == nil {
+		var err error
+		response, err = p.validateThreadNotLocked(thread.wpl, extra)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if response != nil {
+		return &canMoveResult{Reason: strings.TrimPrefix(response.Text, "Error: ")}, nil
+	}
+
+	wpl := thread.wpl
+	if config.MaxThreadCountMoveSizeInt() != 0 && config.MaxThreadCountMoveSizeInt() < wpl.NumPosts() && !p.canBypassMaxCount(userID, config) {
+		return &canMoveResult{Reason: fmt.Sprintf("the thread is %d posts long, but only threads of up to %d posts can be moved", wpl.NumPosts(), config.MaxThreadCountMoveSizeInt())}, nil
+	}
+	maxAuthors := config.MaxAuthorsPerMoveInt()
+	if maxAuthors != 0 && wpl.NumAuthors() > maxAuthors {
+		return &canMoveResult{Reason: fmt.Sprintf("the thread has %d distinct authors, but only threads with up to %d authors can be moved", wpl.NumAuthors(), maxAuthors)}, nil
+	}
+	if config.IntegrationPostsPolicy() == integrationPostsBlock && p.countIntegrationPosts(wpl.Posts) != 0 {
+		return &canMoveResult{Reason: "the thread contains messages posted by bots or integrations, and Wrangler is configured to not move them"}, nil
+	}
+	if config.PlaybookPostsPolicy() == playbookPostsBlock && countPlaybookPosts(wpl.Posts) != 0 {
+		return &canMoveResult{Reason: "the thread contains messages linked to playbook runs, and Wrangler is configured to not move them"}, nil
+	}
+
+	return &canMoveResult{CanMove: true}, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanMoveAPI(t *testing.T) {
+	setup := func(config *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(2)
+		f.api.On("GetPostThread", f.replies[0].Id).Return(f.postList, nil)
+		f.api.On("HasPermissionTo", f.rootPost.UserId, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+
+		return f, plugin
+	}
+
+	get := func(t *testing.T, plugin *Plugin, userID, postID string) (*canMoveResult, int, error) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAPICanMove+"?post_id="+postID, nil)
+		r.Header.Set("Mattermost-User-Id", userID)
+		status, err := plugin.serveHTTP(nil, w, r)
+		if err != nil {
+			return nil, status, err
+		}
+
+		var result canMoveResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+
+		return &result, status, nil
+	}
+
+	t.Run("web UI disabled", func(t *testing.T) {
+		f, plugin := setup(&configuration{})
+
+		_, status, err := get(t, plugin, f.rootPost.UserId, f.rootPost.Id)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("role not permitted to use the web UI", func(t *testing.T) {
+		f, plugin := setup(&configuration{EnableWebUI: true, WebUIPermittedRoles: model.SYSTEM_ADMIN_ROLE_ID})
+
+		_, status, err := get(t, plugin, f.rootPost.UserId, f.replies[0].Id)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("role permitted without command authorization", func(t *testing.T) {
+		f, plugin := setup(&configuration{EnableWebUI: true, AllowedEmailDomain: "mattermost.com", WebUIPermittedRoles: model.SYSTEM_USER_ROLE_ID})
+		f.unsetMock("GetUser")
+		f.api.On("GetUser", f.rootPost.UserId).Return(&model.User{Id: f.rootPost.UserId, Email: "user@example.com", Roles: model.SYSTEM_USER_ROLE_ID}, nil)
+
+		_, status, err := get(t, plugin, f.rootPost.UserId, f.replies[0].Id)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("missing post ID", func(t *testing.T) {
+		f, plugin := setup(&configuration{EnableWebUI: true})
+
+		_, status, err := get(t, plugin, f.rootPost.UserId, "")
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("movable", func(t *testing.T) {
+		f, plugin := setup(&configuration{EnableWebUI: true})
+
+		result, status, err := get(t, plugin, f.rootPost.UserId, f.replies[0].Id)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+		assert.True(t, result.CanMove)
+		assert.Empty(t, result.Reason)
+	})
+
+	t.Run("thread too long", func(t *testing.T) {
+		f, plugin := setup(&configuration{EnableWebUI: true, MoveThreadMaxCount: "2"})
+
+		result, _, err := get(t, plugin, f.rootPost.UserId, f.rootPost.Id)
+		require.NoError(t, err)
+		assert.False(t, result.CanMove)
+		assert.Equal(t, "the thread is 3 posts long, but only threads of up to 2 posts can be moved", result.Reason)
+	})
+
+	t.Run("locked thread", func(t *testing.T) {
+		f, plugin := setup(&configuration{EnableWebUI: true, LockEmoji: "lock"})
+		f.reactions[f.rootPost.Id] = []*model.Reaction{{UserId: model.NewId(), PostId: f.rootPost.Id, EmojiName: "lock"}}
+
+		result, _, err := get(t, plugin, f.rootPost.UserId, f.rootPost.Id)
+		require.NoError(t, err)
+		assert.False(t, result.CanMove)
+		assert.Equal(t, "this thread is locked with the :lock: reaction and can't be moved; remove the reaction to move it", result.Reason)
+	})
+
+	t.Run("private channel", func(t *testing.T) {
+		f, plugin := setup(&configuration{EnableWebUI: true})
+		f.originalChannel.Type = model.CHANNEL_PRIVATE
+
+		result, _, err := get(t, plugin, f.rootPost.UserId, f.rootPost.Id)
+		require.NoError(t, err)
+		assert.False(t, result.CanMove)
+		assert.Equal(t, "Wrangler is currently configured to not allow moving posts from private channels", result.Reason)
+	})
+}
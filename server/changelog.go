@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const changelogPostIDKey = "changelog_post_id"
+
+// changelogHeader starts every changelog post.
+const changelogHeader = "#### Changelog\n"
+
+// maxChangelogEntryRunes bounds the length of a single changelog entry.
+const maxChangelogEntryRunes = 500
+
+// formatChangelogEntry returns the changelog line for the given entry, dated
+// with the current day and linked to the moved thread. Entries are kept on a
+// single line.
+func formatChangelogEntry(entry, threadLink string) string {
+	entry = strings.Join(strings.Fields(entry), " ")
+	if utf8.RuneCountInString(entry) > maxChangelogEntryRunes {
+		entry = string([]rune(entry)[:maxChangelogEntryRunes]) + "..."
+	}
+
+	return fmt.Sprintf("- %s: %s ([thread](%s))", now().UTC().Format("2006-01-02"), entry, threadLink)
+}
+
+// appendChangelogEntry appends the entry to the running changelog post in the
+// configured changelog channel. A new changelog post is created when there is
+// none yet, when it was deleted, or when it is too long for another entry.
+func (p *Plugin) appendChangelogEntry(entry, threadLink string) error {
+	channelID := p.getConfiguration().ChangelogChannelID
+	line := formatChangelogEntry(entry, threadLink)
+
+	changelogPost, err := p.getChangelogPost(channelID)
+	if err != nil {
+		return err
+	}
+	if changelogPost != nil {
+		message := changelogPost.Message + "\n" + line
+		if utf8.RuneCountInString(message) <= model.POST_MESSAGE_MAX_RUNES_V2 {
+			changelogPost.Message = message
+			_, appErr := p.API.UpdatePost(changelogPost)
+			if appErr != nil {
+				return errors.Wrap(appErr, "unable to update changelog post")
+			}
+			return nil
+		}
+	}
+
+	newPost, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		Message:   changelogHeader + "\n" + line,
+	})
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to create changelog post")
+	}
+
+	appErr = p.API.KVSet(changelogPostIDKey, []byte(newPost.Id))
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to store changelog post ID")
+	}
+
+	return nil
+}
+
+// getChangelogPost returns the running changelog post of the channel, or nil
+// if there is none.
+func (p *Plugin) getChangelogPost(channelID string) (*model.Post, error) {
+	postID, appErr := p.API.KVGet(changelogPostIDKey)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "unable to get changelog post ID")
+	}
+	if len(postID) == 0 {
+		return nil, nil
+	}
+
+	post, appErr := p.API.GetPost(string(postID))
+	if appErr != nil || post.DeleteAt != 0 || post.ChannelId != channelID {
+		return nil, nil
+	}
+
+	return post, nil
+}
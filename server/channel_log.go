@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	channelLogKeyPrefix = "log_thread_"
+
+	channelLogRootMessage = "Wrangler log: messages moved out of this channel are recorded in this thread."
+)
+
+func getChannelLogKey(channelID string) string {
+	return channelLogKeyPrefix + channelID
+}
+
+// recordChannelLog adds a line about an operation run by the given user to the
+// log thread of the channel when the channel log is enabled. Errors are logged
+// as they must not fail the operation itself.
+func (p *Plugin) recordChannelLog(channelID, userID, action string) {
+	if !p.getConfiguration().EnableChannelLogThread {
+		return
+	}
+
+	username := "Someone"
+	user, appErr := p.API.GetUser(userID)
+	if appErr == nil {
+		username = "@" + user.Username
+	}
+
+	err := p.appendToChannelLog(channelID, fmt.Sprintf("%s %s", username, action))
+	if err != nil {
+		p.API.LogError("Unable to record operation in channel log thread",
+			"error", err.Error(),
+			"channel_id", channelID,
+		)
+	}
+}
+
+// appendToChannelLog posts the line as a reply to the log thread of the
+// channel. The log thread is created the first time it is needed, or again if
+// its root post was deleted.
+func (p *Plugin) appendToChannelLog(channelID, line string) error {
+	var rootID string
+	found, err := p.kvGetJSON(getChannelLogKey(channelID), &rootID)
+	if err != nil {
+		return err
+	}
+	if found {
+		rootPost, appErr := p.API.GetPost(rootID)
+		if appErr != nil || rootPost.DeleteAt != 0 {
+			found = false
+		}
+	}
+
+	if !found {
+		rootPost, appErr := p.API.CreatePost(&model.Post{
+			UserId:    p.BotUserID,
+			ChannelId: channelID,
+			Message:   channelLogRootMessage,
+		})
+		if appErr != nil {
+			return errors.Wrap(appErr, "unable to create channel log thread")
+		}
+		rootID = rootPost.Id
+
+		err = p.kvSetJSON(getChannelLogKey(channelID), rootID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		RootId:    rootID,
+		ParentId:  rootID,
+		Message:   line,
+	})
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to add line to channel log thread")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordChannelLog(t *testing.T) {
+	channelID := model.NewId()
+	logRootPost := &model.Post{Id: model.NewId(), ChannelId: channelID}
+	isLogRoot := func(post *model.Post) bool {
+		return post.RootId == "" && post.Message == channelLogRootMessage
+	}
+	isLogLine := func(message string) interface{} {
+		return mock.MatchedBy(func(post *model.Post) bool {
+			return post.RootId == logRootPost.Id && post.Message == message
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		f := newThreadTestFixture(1)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{})
+
+		plugin.recordChannelLog(channelID, model.NewId(), "moved a thread")
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("log thread is created once and reused", func(t *testing.T) {
+		f := newThreadTestFixture(1)
+		f.unsetMock("CreatePost")
+		f.api.On("CreatePost", mock.MatchedBy(isLogRoot)).Return(logRootPost, nil)
+		f.api.On("CreatePost", mock.Anything).Return(f.newPost, nil)
+		f.api.On("GetPost", logRootPost.Id).Return(logRootPost, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{EnableChannelLogThread: true})
+
+		plugin.recordChannelLog(channelID, model.NewId(), "moved thread 1")
+		plugin.recordChannelLog(channelID, model.NewId(), "moved thread 2")
+
+		f.api.AssertNumberOfCalls(t, "CreatePost", 3)
+		f.api.AssertCalled(t, "CreatePost", isLogLine("@active.user moved thread 1"))
+		f.api.AssertCalled(t, "CreatePost", isLogLine("@active.user moved thread 2"))
+
+		var rootID string
+		found, err := plugin.kvGetJSON(getChannelLogKey(channelID), &rootID)
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, logRootPost.Id, rootID)
+	})
+
+	t.Run("deleted log thread is recreated", func(t *testing.T) {
+		f := newThreadTestFixture(1)
+		f.unsetMock("CreatePost")
+		f.api.On("CreatePost", mock.MatchedBy(isLogRoot)).Return(logRootPost, nil)
+		f.api.On("CreatePost", mock.Anything).Return(f.newPost, nil)
+		f.api.On("GetPost", "deleted-root").Return(&model.Post{Id: "deleted-root", DeleteAt: 1}, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{EnableChannelLogThread: true})
+		require.NoError(t, plugin.kvSetJSON(getChannelLogKey(channelID), "deleted-root"))
+
+		plugin.recordChannelLog(channelID, model.NewId(), "moved a thread")
+
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(isLogRoot))
+		f.api.AssertCalled(t, "CreatePost", isLogLine("@active.user moved a thread"))
+	})
+
+	t.Run("recorded on move", func(t *testing.T) {
+		f := newThreadTestFixture(1)
+		f.api.On("GetPost", mock.AnythingOfType("string")).Return(f.newPost, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{EnableChannelLogThread: true})
+
+		_, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == f.originalChannel.Id && post.Message == channelLogRootMessage
+		}))
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == f.originalChannel.Id &&
+				post.Message == "@active.user moved a thread of 2 message(s) to test.sampledomain.com/team-1/pl/"+f.newPost.Id
+		}))
+	})
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
@@ -14,18 +16,52 @@ const helpText = `Wrangler Plugin - Slash Command Help
 
 %s
 
+%s
+
+%s
+
+%s
+
+%s
+
+%s
+
+%s
+
+%s
+
+%s
+
 /wrangler attach message [MESSAGE_ID_TO_ATTACH] [ROOT_MESSAGE_ID]
   Attach a given message to a thread in the same channel
     - Obtain the message IDs by running '/wrangler list messages' or via the 'Permalink' message dropdown option (it's the last part of the URL)
 
-/wrangler list channels [flags]
-  List the IDs of all channels you have joined
-	Flags:
 %s
-/wrangler list messages [flags]
-  List the IDs of recent messages in this channel
-    Flags:
+
+%s
+%s
+%s
+
+%s
+
+%s
+
+%s
+
 %s
+
+%s
+
+%s
+
+%s
+
+%s
+
+%s
+
+%s
+
 /wrangler info
   Shows plugin information`
 
@@ -33,21 +69,143 @@ func getHelp() string {
 	return codeBlock(fmt.Sprintf(
 		helpText,
 		getMoveThreadUsage(),
-		copyThreadUsage,
-		getListChannelsFlagSet().FlagUsages(),
-		getListMessagesFlagSet().FlagUsages(),
+		getCopyThreadUsage(),
+		copyPinnedUsage,
+		getMergeThreadUsage(),
+		routeThreadUsage,
+		archiveThreadUsage,
+		getExportThreadUsage(),
+		quoteThreadUsage,
+		importUsage,
+		threadUsage,
+		cancelReminderUsage,
+		getListChannelsUsage(),
+		getListMessagesUsage(),
+		listSourcesUsage,
+		prefsUsage,
+		statsUsage,
+		mineUsage,
+		requestMoveUsage,
+		adminQueueUsage,
+		adminPurgeUserUsage,
+		getAdminVerifyUsage(),
+		adminExportConfigUsage,
+		adminImportConfigUsage,
+		getSimulateMoveUsage(),
 	))
 }
 
-func getCommand(autocomplete bool) *model.Command {
+// getNoArgsHelp returns the help shown when the given command, such as "move"
+// or "move thread", is run without arguments, or false when the command
+// doesn't need any. The help of commands that move or copy threads includes
+// examples with the IDs of the channel and thread the command was run in.
+func getNoArgsHelp(command string, extra *model.CommandArgs) (string, bool) {
+	var usages []string
+	switch command {
+	case "move", "move thread":
+		usages = []string{getMoveThreadUsage(), getThreadExamples("move thread", "Move", extra)}
+	case "copy":
+		usages = []string{getCopyThreadUsage(), copyPinnedUsage, getThreadExamples("copy thread", "Copy", extra)}
+	case "copy thread":
+		usages = []string{getCopyThreadUsage(), getThreadExamples("copy thread", "Copy", extra)}
+	case "copy pinned":
+		usages = []string{copyPinnedUsage}
+	case "merge", "merge thread":
+		usages = []string{getMergeThreadUsage()}
+		if len(extra.RootId) != 0 {
+			usages = append(usages, fmt.Sprintf("Examples for this thread:\n  /wrangler merge thread MESSAGE_ID %s\n    Merge another message, along with its thread, into the thread you're viewing", extra.RootId))
+		}
+	case "route", "route thread":
+		usages = []string{routeThreadUsage}
+	case "archive", "archive thread":
+		usages = []string{archiveThreadUsage}
+	case "export", "export thread":
+		usages = []string{getExportThreadUsage()}
+	case "quote", "quote thread":
+		usages = []string{quoteThreadUsage}
+	case "import":
+		usages = []string{importUsage}
+	case "thread":
+		usages = []string{threadUsage}
+	case "attach", "attach message":
+		usages = []string{attachMessageUsage}
+	case "cancel", "cancel reminder":
+		usages = []string{cancelReminderUsage}
+	case "list":
+		usages = []string{getListChannelsUsage(), getListMessagesUsage(), listSourcesUsage}
+	case "prefs":
+		usages = []string{prefsUsage}
+	case "admin":
+		usages = []string{adminQueueUsage, adminPurgeUserUsage, getAdminVerifyUsage(), adminExportConfigUsage, adminImportConfigUsage}
+	case "admin purge-user":
+		usages = []string{adminPurgeUserUsage}
+	case "admin import-config":
+		usages = []string{adminImportConfigUsage}
+	case "request", "request move":
+		usages = []string{requestMoveUsage}
+	case "simulate", "simulate move":
+		usages = []string{getSimulateMoveUsage()}
+	default:
+		return "", false
+	}
+
+	for i, usage := range usages {
+		usages[i] = strings.TrimRight(usage, "\n")
+	}
+
+	return codeBlock(strings.Join(usages, "\n\n")), true
+}
+
+// getThreadExamples returns examples of the given thread command pre-filled
+// with the IDs of the channel and thread the command was run in.
+func getThreadExamples(command, verb string, extra *model.CommandArgs) string {
+	examples := "Examples for this channel:\n  /wrangler list messages\n    List the IDs of recent messages in this channel"
+	if len(extra.RootId) != 0 {
+		examples += fmt.Sprintf("\n  /wrangler %s %s CHANNEL_ID\n    %s the thread you're viewing to another channel", command, extra.RootId, verb)
+	}
+	examples += fmt.Sprintf("\n  /wrangler %s MESSAGE_ID CHANNEL_ID\n    %s a thread of this channel to another channel", command, verb)
+	if len(extra.ChannelId) != 0 {
+		examples += fmt.Sprintf("\n  /wrangler %s MESSAGE_ID %s\n    %s a thread of another channel to this channel", command, extra.ChannelId, verb)
+	}
+
+	return examples
+}
+
+// experimentalCommands are the commands that are only available when their
+// experimental feature is enabled, keyed by the command name shown in the
+// autocomplete description.
+var experimentalCommands = map[string]string{
+	"import":       experimentalFeatureImport,
+	"request move": experimentalFeatureRequestMove,
+}
+
+// autocompleteCommands are the commands listed in the autocomplete
+// description.
+var autocompleteCommands = []string{
+	"move thread", "copy thread", "copy pinned", "merge thread", "route thread", "archive thread", "export thread", "quote thread", "import", "thread", "attach message",
+	"list messages", "list channels", "list sources", "cancel reminder", "prefs", "stats", "mine", "request move",
+	"admin queue", "admin purge-user", "admin verify", "admin export-config", "admin import-config", "simulate move", "info",
+}
+
+// getCommand returns the Wrangler command. The experimental commands that
+// aren't enabled are left out of its autocomplete.
+func getCommand(config *configuration) *model.Command {
+	var commands []string
+	for _, command := range autocompleteCommands {
+		if feature, ok := experimentalCommands[command]; ok && !config.ExperimentalFeatureEnabled(feature) {
+			continue
+		}
+		commands = append(commands, command)
+	}
+
 	return &model.Command{
 		Trigger:          "wrangler",
 		DisplayName:      "Wrangler",
 		Description:      "Manage Mattermost messages!",
-		AutoComplete:     autocomplete,
-		AutoCompleteDesc: "Available commands: move thread, copy thread, attach message, list messages, list channels, info",
+		AutoComplete:     config.CommandAutoCompleteEnable,
+		AutoCompleteDesc: "Available commands: " + strings.Join(commands, ", "),
 		AutoCompleteHint: "[command]",
-		AutocompleteData: getAutocompleteData(),
+		AutocompleteData: getAutocompleteData(config),
 	}
 }
 
@@ -65,16 +223,34 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 	if !p.authorizedPluginUser(args.UserId) {
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Permission denied. Please talk to your system administrator to get access."), nil
 	}
+	if p.blockedByCommandChannel(args) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.getCommandChannelMessage()), nil
+	}
+	if p.commandTooLong(args.Command) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the command is %d characters long, but Wrangler only accepts commands of up to %d characters.", utf8.RuneCountInString(args.Command), p.getConfiguration().MaxCommandLengthInt())), nil
+	}
 
-	stringArgs := strings.Split(args.Command, " ")
+	stringArgs := splitCommandArgs(args.Command)
 
 	if len(stringArgs) < 2 {
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getHelp()), nil
 	}
 
 	command := stringArgs[1]
+	// commandName includes the subcommand, such as "move thread", and picks
+	// the help shown when the command is run without arguments.
+	commandName := command
+	if len(stringArgs) > 2 {
+		commandName += " " + stringArgs[2]
+	}
 
 	var handler func([]string, *model.CommandArgs) (*model.CommandResponse, bool, error)
+	// mutating is set for commands that change messages or the data and
+	// configuration of Wrangler; they are disabled in maintenance mode.
+	var mutating bool
+	// experimentalCommand is set for the commands that are only available when
+	// their experimental feature is enabled.
+	var experimentalCommand string
 
 	switch command {
 	case "move":
@@ -85,6 +261,7 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 		switch stringArgs[2] {
 		case "thread":
 			handler = p.runMoveThreadCommand
+			mutating = true
 			stringArgs = stringArgs[3:]
 		}
 	case "copy":
@@ -95,8 +272,72 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 		switch stringArgs[2] {
 		case "thread":
 			handler = p.runCopyThreadCommand
+			mutating = true
+			stringArgs = stringArgs[3:]
+		case "pinned":
+			handler = p.runCopyPinnedCommand
+			mutating = true
+			stringArgs = stringArgs[3:]
+		}
+	case "merge":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "thread":
+			handler = p.runMergeThreadCommand
+			mutating = true
+			stringArgs = stringArgs[3:]
+		}
+	case "route":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "thread":
+			handler = p.runRouteThreadCommand
+			mutating = true
+			stringArgs = stringArgs[3:]
+		}
+	case "archive":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "thread":
+			handler = p.runArchiveThreadCommand
+			mutating = true
+			stringArgs = stringArgs[3:]
+		}
+	case "export":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "thread":
+			handler = p.runExportThreadCommand
+			stringArgs = stringArgs[3:]
+		}
+	case "quote":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "thread":
+			handler = p.runQuoteThreadCommand
+			mutating = true
 			stringArgs = stringArgs[3:]
 		}
+	case "import":
+		handler = p.runImportCommand
+		mutating = true
+		experimentalCommand = "import"
+		stringArgs = stringArgs[2:]
 	case "attach":
 		if len(stringArgs) < 3 {
 			break
@@ -105,6 +346,17 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 		switch stringArgs[2] {
 		case "message":
 			handler = p.runAttachMessageCommand
+			mutating = true
+			stringArgs = stringArgs[3:]
+		}
+	case "cancel":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "reminder":
+			handler = p.runCancelReminderCommand
 			stringArgs = stringArgs[3:]
 		}
 	case "list":
@@ -119,6 +371,67 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 		case "messages":
 			handler = p.runListMessagesCommand
 			stringArgs = stringArgs[3:]
+		case "sources":
+			handler = p.runListSourcesCommand
+			stringArgs = stringArgs[3:]
+		}
+	case "thread":
+		handler = p.runThreadCommand
+		mutating = true
+		stringArgs = stringArgs[2:]
+	case "prefs":
+		handler = p.runPrefsCommand
+		stringArgs = stringArgs[2:]
+	case "stats":
+		handler = p.runStatsCommand
+		stringArgs = stringArgs[2:]
+	case "mine":
+		handler = p.runMineCommand
+		stringArgs = stringArgs[2:]
+	case "admin":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "queue":
+			handler = p.runAdminQueueCommand
+			stringArgs = stringArgs[3:]
+		case "purge-user":
+			handler = p.runAdminPurgeUserCommand
+			mutating = true
+			stringArgs = stringArgs[3:]
+		case "verify":
+			handler = p.runAdminVerifyCommand
+			stringArgs = stringArgs[3:]
+		case "export-config":
+			handler = p.runAdminExportConfigCommand
+			stringArgs = stringArgs[3:]
+		case "import-config":
+			handler = p.runAdminImportConfigCommand
+			mutating = true
+			stringArgs = stringArgs[3:]
+		}
+	case "request":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "move":
+			handler = p.runRequestMoveCommand
+			experimentalCommand = "request move"
+			stringArgs = stringArgs[3:]
+		}
+	case "simulate":
+		if len(stringArgs) < 3 {
+			break
+		}
+
+		switch stringArgs[2] {
+		case "move":
+			handler = p.runSimulateMoveCommand
+			stringArgs = stringArgs[3:]
 		}
 	case "info":
 		handler = p.runInfoCommand
@@ -126,10 +439,27 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 	}
 
 	if handler == nil {
+		if help, ok := getNoArgsHelp(commandName, args); ok && len(stringArgs) == 2 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, help), nil
+		}
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getHelp()), nil
 	}
 
-	resp, userError, err := handler(stringArgs, args)
+	if mutating && p.blockedByMaintenanceMode(args.UserId) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, maintenanceModeMessage), nil
+	}
+
+	if feature, ok := experimentalCommands[experimentalCommand]; ok && !p.getConfiguration().ExperimentalFeatureEnabled(feature) {
+		return getExperimentalFeatureDisabledResponse("/wrangler "+experimentalCommand, feature), nil
+	}
+
+	if len(stringArgs) == 0 {
+		if help, ok := getNoArgsHelp(commandName, args); ok {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, help), nil
+		}
+	}
+
+	resp, userError, err := handler(stringArgs, p.retargetCommandArgs(args, stringArgs))
 
 	if err != nil {
 		p.API.LogError(err.Error())
@@ -151,6 +481,22 @@ func (p *Plugin) runInfoCommand(args []string, extra *model.CommandArgs) (*model
 	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, resp), false, nil
 }
 
+const maintenanceModeMessage = "Wrangler is temporarily in maintenance mode. Please try again later."
+
+// blockedByMaintenanceMode returns true if maintenance mode is enabled and the
+// user isn't allowed to bypass it.
+func (p *Plugin) blockedByMaintenanceMode(userID string) bool {
+	config := p.getConfiguration()
+	if !config.MaintenanceMode {
+		return false
+	}
+	if config.MaintenanceModeAdminBypass && p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		return false
+	}
+
+	return true
+}
+
 func (p *Plugin) authorizedPluginUser(userID string) bool {
 	config := p.getConfiguration()
 
@@ -173,23 +519,172 @@ func (p *Plugin) authorizedPluginUser(userID string) bool {
 	return true
 }
 
-func getAutocompleteData() *model.AutocompleteData {
-	wrangler := model.NewAutocompleteData("wrangler", "[command]", "Available commands: move, copy, attach, list, info, help")
+// authorizedWebUIUser returns true if the user may use the Wrangler web UI.
+// Web UI users must be allowed to run Wrangler commands and, when web UI roles
+// are configured, also hold one of them.
+func (p *Plugin) authorizedWebUIUser(userID string) bool {
+	if !p.authorizedPluginUser(userID) {
+		return false
+	}
+
+	roles := p.getConfiguration().WebUIPermittedRoleNames()
+	if len(roles) == 0 {
+		return true
+	}
+
+	return p.userHasRole(userID, roles)
+}
+
+// authorizedOperationUser returns true if the user may run the operation
+// restricted to the given roles. No roles means the operation is only
+// restricted by the command authorization.
+func (p *Plugin) authorizedOperationUser(userID string, roles []string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+
+	return p.userHasRole(userID, roles)
+}
+
+// guestMovesBlocked returns true if guests are forbidden from moving and
+// merging threads and the user is a guest. Users that can't be looked up are
+// treated as guests while guests are forbidden.
+func (p *Plugin) guestMovesBlocked(userID string) bool {
+	if !p.getConfiguration().BlockGuestMoves {
+		return false
+	}
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return true
+	}
+
+	return user.IsGuest()
+}
+
+// accountTooNew returns true if the account of the user is younger than the
+// configured minimum account age. System admins are exempt, and users that
+// can't be looked up are treated as too new while a minimum is configured.
+func (p *Plugin) accountTooNew(userID string) bool {
+	days := p.getConfiguration().MinAccountAgeDaysInt()
+	if days == 0 || p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		return false
+	}
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return true
+	}
+
+	createdAt := time.Unix(0, user.CreateAt*int64(time.Millisecond))
+	return now().Before(createdAt.Add(time.Duration(days) * 24 * time.Hour))
+}
+
+// getAccountTooNewResponse returns the response to a user whose account is
+// younger than the configured minimum account age.
+func (p *Plugin) getAccountTooNewResponse(operation string) *model.CommandResponse {
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: your account must be at least %d day(s) old to %s", p.getConfiguration().MinAccountAgeDaysInt(), operation))
+}
+
+// userHasRole returns true if the user has at least one of the roles.
+func (p *Plugin) userHasRole(userID string, roles []string) bool {
+	user, err := p.API.GetUser(userID)
+	if err != nil {
+		return false
+	}
+	for _, role := range roles {
+		if user.IsInRole(role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getOperationDisabledResponse returns the response to an operation that
+// isn't among the enabled operations.
+func getOperationDisabledResponse(operation string) *model.CommandResponse {
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: %s is disabled on this server; please talk to your system administrator to get access", operation))
+}
+
+// getExperimentalFeatureDisabledResponse returns the response to a command or
+// flag of an experimental feature that isn't enabled.
+func getExperimentalFeatureDisabledResponse(command, feature string) *model.CommandResponse {
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: %s is an experimental feature that isn't enabled on this server; please ask your system administrator to enable the %s experimental feature", command, feature))
+}
+
+// getOperationRolesResponse returns the response to a user who doesn't have
+// any of the roles permitted to run the operation.
+func getOperationRolesResponse(operation string) *model.CommandResponse {
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: your role doesn't permit you to %s; please talk to your system administrator to get access", operation))
+}
+
+// getAutocompleteData returns the autocomplete of the Wrangler command. The
+// experimental commands that aren't enabled are left out.
+func getAutocompleteData(config *configuration) *model.AutocompleteData {
+	channelsFetchURL := fmt.Sprintf("plugins/%s%s", manifest.Id, routeAutocompleteChannels)
+
+	wrangler := model.NewAutocompleteData("wrangler", "[command]", "")
 
 	move := model.NewAutocompleteData("move", "[subcommand]", "Move messages")
 	moveThread := model.NewAutocompleteData("thread", "[MESSAGE_ID] [CHANNEL_ID]", "Move a message and the thread it belongs to")
 	moveThread.AddTextArgument("The ID of the message to be moved", "[MESSAGE_ID]", "")
-	moveThread.AddTextArgument("The ID of the channel where the message will be moved to", "[CHANNEL_ID]", "")
+	moveThread.AddDynamicListArgument("The ID of the channel where the message will be moved to", channelsFetchURL, true)
 	move.AddCommand(moveThread)
 	wrangler.AddCommand(move)
 
 	copy := model.NewAutocompleteData("copy", "[subcommand]", "Copy messages")
 	copyThread := model.NewAutocompleteData("thread", "[MESSAGE_ID] [CHANNEL_ID]", "Copy a message and the thread it belongs to")
 	copyThread.AddTextArgument("The ID of the message to be copied", "[MESSAGE_ID]", "")
-	copyThread.AddTextArgument("The ID of the channel where the message will be copied to", "[CHANNEL_ID]", "")
+	copyThread.AddDynamicListArgument("The ID of the channel where the message will be copied to", channelsFetchURL, true)
 	copy.AddCommand(copyThread)
+	copyPinned := model.NewAutocompleteData("pinned", "[CHANNEL_ID]", "Copy the pinned messages of this channel and pin them in another channel")
+	copyPinned.AddDynamicListArgument("The ID of the channel where the pinned messages will be copied to", channelsFetchURL, true)
+	copy.AddCommand(copyPinned)
 	wrangler.AddCommand(copy)
 
+	merge := model.NewAutocompleteData("merge", "[subcommand]", "Merge messages")
+	mergeThread := model.NewAutocompleteData("thread", "[MESSAGE_ID] [ROOT_MESSAGE_ID]", "Merge a message and the thread it belongs to into another thread")
+	mergeThread.AddTextArgument("The ID of the message to be merged", "[MESSAGE_ID]", "")
+	mergeThread.AddTextArgument("The root message ID of the thread to merge into", "[ROOT_MESSAGE_ID]", "")
+	merge.AddCommand(mergeThread)
+	wrangler.AddCommand(merge)
+
+	route := model.NewAutocompleteData("route", "[subcommand]", "Route messages with the configured rules")
+	routeThread := model.NewAutocompleteData("thread", "[MESSAGE_ID]", "Move a message and the thread it belongs to with the first matching routing rule")
+	routeThread.AddTextArgument("The ID of the message to be routed", "[MESSAGE_ID]", "")
+	route.AddCommand(routeThread)
+	wrangler.AddCommand(route)
+
+	archive := model.NewAutocompleteData("archive", "[subcommand]", "Archive messages to the configured archive channel")
+	archiveThread := model.NewAutocompleteData("thread", "[MESSAGE_ID]", "Move a message and the thread it belongs to to the archive channel of this channel")
+	archiveThread.AddTextArgument("The ID of the message to be archived", "[MESSAGE_ID]", "")
+	archive.AddCommand(archiveThread)
+	wrangler.AddCommand(archive)
+
+	export := model.NewAutocompleteData("export", "[subcommand]", "Export messages")
+	exportThread := model.NewAutocompleteData("thread", "[MESSAGE_ID]", "Send yourself a transcript of a message and the thread it belongs to")
+	exportThread.AddTextArgument("The ID of the message to be exported", "[MESSAGE_ID]", "")
+	export.AddCommand(exportThread)
+	wrangler.AddCommand(export)
+
+	quote := model.NewAutocompleteData("quote", "[subcommand]", "Quote messages")
+	quoteThread := model.NewAutocompleteData("thread", "[MESSAGE_ID] [CHANNEL_ID]", "Quote a message and the thread it belongs to in a single message")
+	quoteThread.AddTextArgument("The ID of the message to be quoted", "[MESSAGE_ID]", "")
+	quoteThread.AddDynamicListArgument("The ID of the channel where the thread will be quoted", channelsFetchURL, true)
+	quote.AddCommand(quoteThread)
+	wrangler.AddCommand(quote)
+
+	if config.ExperimentalFeatureEnabled(experimentalFeatureImport) {
+		importThread := model.NewAutocompleteData("import", "[CHANNEL_ID] [MESSAGE_ID]", "Recreate a thread from a JSON transcript in a channel")
+		importThread.AddDynamicListArgument("The ID of the channel where the thread will be recreated", channelsFetchURL, true)
+		importThread.AddTextArgument("The ID of the message with the JSON transcript attached", "[MESSAGE_ID]", "")
+		wrangler.AddCommand(importThread)
+	}
+
+	thread := model.NewAutocompleteData("thread", "[MESSAGE_ID] [CHANNEL_ID]", "Move or copy a message and the thread it belongs to depending on your default action")
+	thread.AddTextArgument("The ID of the message to be moved or copied", "[MESSAGE_ID]", "")
+	thread.AddDynamicListArgument("The ID of the channel where the message will be moved or copied to", channelsFetchURL, true)
+	wrangler.AddCommand(thread)
+
 	attach := model.NewAutocompleteData("attach", "[subcommand]", "Attach messages")
 	attachMessage := model.NewAutocompleteData("message", "[MESSAGE_ID_TO_ATTACH] [ROOT_MESSAGE_ID]", "Attach a message to a thread in the channel")
 	attachMessage.AddTextArgument("The ID of the message to be attached", "[MESSAGE_ID_TO_ATTACH]", "")
@@ -197,18 +692,89 @@ func getAutocompleteData() *model.AutocompleteData {
 	attach.AddCommand(attachMessage)
 	wrangler.AddCommand(attach)
 
+	cancel := model.NewAutocompleteData("cancel", "[subcommand]", "Cancel scheduled actions")
+	cancelReminder := model.NewAutocompleteData("reminder", "[REMINDER_ID]", "Cancel a move thread reminder")
+	cancelReminder.AddTextArgument("The ID of the reminder to cancel", "[REMINDER_ID]", "")
+	cancel.AddCommand(cancelReminder)
+	wrangler.AddCommand(cancel)
+
 	list := model.NewAutocompleteData("list", "[subcommand]", "Lists IDs for channels and messages")
 	listChannels := model.NewAutocompleteData("channels", "[optional flags]", "List channel IDs that you have joined")
 	listMessages := model.NewAutocompleteData("messages", "[optional flags]", "List message IDs in this channel")
+	listSources := model.NewAutocompleteData("sources", "", "List channel IDs that you can move messages from")
 	list.AddCommand(listChannels)
 	list.AddCommand(listMessages)
+	list.AddCommand(listSources)
 	wrangler.AddCommand(list)
 
+	prefs := model.NewAutocompleteData("prefs", "[subcommand]", "Show or change your Wrangler preferences")
+	prefsShow := model.NewAutocompleteData("show", "", "Show your Wrangler preferences")
+	prefsSet := model.NewAutocompleteData("set", "[preference] [value]", "Change a Wrangler preference")
+	prefsSet.AddStaticListArgument("The preference to change", true, []model.AutocompleteListItem{
+		{Item: prefDefaultAction, Hint: "[move|copy]", HelpText: "The action run by '/wrangler thread'"},
+		{Item: prefDefaultDestination, Hint: "[CHANNEL_ID|none]", HelpText: "The channel that threads are moved or copied to when the destination is omitted"},
+		{Item: prefSuccessVerbosity, Hint: "[minimal|normal|detailed]", HelpText: "How much detail the summaries of moves and copies include"},
+	})
+	prefsSet.AddTextArgument("The new value", "[value]", "")
+	prefs.AddCommand(prefsShow)
+	prefs.AddCommand(prefsSet)
+	wrangler.AddCommand(prefs)
+
+	stats := model.NewAutocompleteData("stats", "", "Show the channels most threads were moved, copied or merged out of and into")
+	stats.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	wrangler.AddCommand(stats)
+
+	mine := model.NewAutocompleteData("mine", "", "List your pending Wrangler actions, recent operations and limits")
+	wrangler.AddCommand(mine)
+
+	if config.ExperimentalFeatureEnabled(experimentalFeatureRequestMove) {
+		request := model.NewAutocompleteData("request", "[subcommand]", "Ask the approvers to wrangle messages")
+		requestMove := model.NewAutocompleteData("move", "[MESSAGE_ID] [CHANNEL_ID]", "Ask the approvers to move a message and the thread it belongs to")
+		requestMove.AddTextArgument("The ID of the message to be moved", "[MESSAGE_ID]", "")
+		requestMove.AddDynamicListArgument("The ID of the channel where the message will be moved to", channelsFetchURL, true)
+		request.AddCommand(requestMove)
+		wrangler.AddCommand(request)
+	}
+
+	admin := model.NewAutocompleteData("admin", "[subcommand]", "System admin tools")
+	admin.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	adminQueue := model.NewAutocompleteData("queue", "", "List all scheduled reminders and pending move approval requests")
+	adminQueue.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	admin.AddCommand(adminQueue)
+	adminPurgeUser := model.NewAutocompleteData("purge-user", "[@username]", "Delete the Wrangler data stored for a user")
+	adminPurgeUser.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	adminPurgeUser.AddTextArgument("The user to purge the data of", "[@username]", "")
+	admin.AddCommand(adminPurgeUser)
+	adminVerify := model.NewAutocompleteData("verify", "[--repair]", "Check moved messages for moves that didn't complete cleanly")
+	adminVerify.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	admin.AddCommand(adminVerify)
+	adminExportConfig := model.NewAutocompleteData("export-config", "", "Send yourself the Wrangler configuration as a JSON file")
+	adminExportConfig.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	admin.AddCommand(adminExportConfig)
+	adminImportConfig := model.NewAutocompleteData("import-config", "[MESSAGE_ID]", "Apply the Wrangler configuration from a JSON file attached to a message")
+	adminImportConfig.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	adminImportConfig.AddTextArgument("The ID of the message with the configuration file", "[MESSAGE_ID]", "")
+	admin.AddCommand(adminImportConfig)
+	wrangler.AddCommand(admin)
+
+	simulate := model.NewAutocompleteData("simulate", "[subcommand]", "Run the checks of an operation without changing anything")
+	simulate.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	simulateMove := model.NewAutocompleteData("move", "[MESSAGE_ID] [CHANNEL_ID] [--as @user]", "Report which checks of a thread move pass or fail for a user")
+	simulateMove.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	simulate.AddCommand(simulateMove)
+	wrangler.AddCommand(simulate)
+
 	info := model.NewAutocompleteData("info", "", "Shows plugin information")
 	wrangler.AddCommand(info)
 
 	help := model.NewAutocompleteData("help", "", "Shows detailed help information")
 	wrangler.AddCommand(help)
 
+	var commands []string
+	for _, command := range wrangler.SubCommands {
+		commands = append(commands, command.Trigger)
+	}
+	wrangler.HelpText = "Available commands: " + strings.Join(commands, ", ")
+
 	return wrangler
 }
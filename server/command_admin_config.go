@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const adminExportConfigUsage = `/wrangler admin export-config
+  Send yourself the Wrangler configuration as a JSON file by DM, such as to replicate it on another server
+    - Only available to system admins
+    - Secrets are redacted and must be re-entered before the file is imported`
+
+const adminImportConfigUsage = `/wrangler admin import-config [MESSAGE_ID]
+  Apply the Wrangler configuration from a JSON file attached to a given message
+    - Only available to system admins
+    - The file is created by '/wrangler admin export-config'; settings left out of it keep their current value
+    - The configuration is validated before it is saved`
+
+// configExportFilename is the name of the file the configuration is exported
+// to.
+const configExportFilename = "wrangler-config.json"
+
+// maxConfigImportFileSize is the largest configuration file that is imported.
+const maxConfigImportFileSize = 1024 * 1024
+
+// secretSettings are the settings that are redacted when the configuration is
+// exported.
+var secretSettings = []string{
+	"RoutingEndpointAuthHeader",
+}
+
+// getConfigurationSettings returns the settings of the configuration keyed by
+// their name.
+func getConfigurationSettings(c *configuration) (map[string]interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal configuration")
+	}
+	var settings map[string]interface{}
+	err = json.Unmarshal(data, &settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal configuration")
+	}
+
+	return settings, nil
+}
+
+// exportConfiguration returns the configuration as indented JSON with the
+// settings sorted by name, so that exports of different servers can be
+// compared. Secrets that are set are replaced by a placeholder.
+func exportConfiguration(c *configuration) ([]byte, error) {
+	settings, err := getConfigurationSettings(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range secretSettings {
+		if value, ok := settings[name].(string); ok && len(value) != 0 {
+			settings[name] = model.FAKE_SETTING
+		}
+	}
+
+	return json.MarshalIndent(settings, "", "  ")
+}
+
+// importConfiguration returns the current configuration with the settings of
+// the JSON applied on top of it. The error is meant for the user. Unknown
+// settings and redacted secrets are refused.
+func importConfiguration(current *configuration, data []byte) (*configuration, error) {
+	var settings map[string]json.RawMessage
+	err := json.Unmarshal(data, &settings)
+	if err != nil {
+		return nil, errors.Wrap(err, "the file isn't a JSON object")
+	}
+	for _, name := range secretSettings {
+		var value string
+		if raw, ok := settings[name]; ok && json.Unmarshal(raw, &value) == nil && value == model.FAKE_SETTING {
+			return nil, errors.Errorf("%s is redacted; replace it with its actual value, or leave it out to keep the current value", name)
+		}
+	}
+
+	imported := current.Clone()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(imported)
+	if err != nil {
+		return nil, errors.Wrap(err, "the file doesn't match the Wrangler configuration")
+	}
+	err = imported.IsValid()
+	if err != nil {
+		return nil, errors.Wrap(err, "the configuration is invalid")
+	}
+
+	return imported, nil
+}
+
+func (p *Plugin) runAdminExportConfigCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: only system admins can export the Wrangler configuration"), true, nil
+	}
+	if len(args) != 0 {
+		return getCommandArgsErrorResponse(errors.New("the command doesn't take any arguments"), adminExportConfigUsage), true, nil
+	}
+
+	data, err := exportConfiguration(p.getConfiguration())
+	if err != nil {
+		return nil, false, err
+	}
+
+	channel, appErr := p.API.GetDirectChannel(extra.UserId, p.BotUserID)
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to get direct channel")
+	}
+	fileInfo, appErr := p.API.UploadFile(data, channel.Id, configExportFilename)
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to upload configuration file")
+	}
+	_, appErr = p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channel.Id,
+		Message:   "The Wrangler configuration is attached. Secrets are redacted and must be re-entered before it is imported.",
+		FileIds:   []string{fileInfo.Id},
+	})
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to post configuration file")
+	}
+
+	p.API.LogInfo("Wrangler configuration exported", "user_id", extra.UserId)
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "The Wrangler configuration was sent to you by DM as a JSON file."), false, nil
+}
+
+func (p *Plugin) runAdminImportConfigCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: only system admins can import the Wrangler configuration"), true, nil
+	}
+	positional, err := parseCommandArgs(args, nil, messageIDArg.withName("configuration message ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, adminImportConfigUsage), true, nil
+	}
+	postID := positional[0]
+
+	post, appErr := p.API.GetPost(postID)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	if len(post.FileIds) != 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: the message must have exactly one JSON configuration file attached"), true, nil
+	}
+	fileInfo, appErr := p.API.GetFileInfo(post.FileIds[0])
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to get configuration file info")
+	}
+	if fileInfo.Size > maxConfigImportFileSize {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the configuration file is larger than the %d MB import limit", maxConfigImportFileSize/1024/1024)), true, nil
+	}
+	data, appErr := p.API.GetFile(fileInfo.Id)
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to get configuration file")
+	}
+
+	current := p.getConfiguration()
+	imported, err := importConfiguration(current, data)
+	if err != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the configuration wasn't imported: %s", err.Error())), true, nil
+	}
+
+	currentSettings, err := getConfigurationSettings(current)
+	if err != nil {
+		return nil, false, err
+	}
+	importedSettings, err := getConfigurationSettings(imported)
+	if err != nil {
+		return nil, false, err
+	}
+	var changed []string
+	for name, value := range importedSettings {
+		if currentSettings[name] != value {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	if len(changed) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "The imported configuration matches the current one, so nothing was changed."), false, nil
+	}
+
+	appErr = p.API.SavePluginConfig(importedSettings)
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to save plugin configuration")
+	}
+
+	p.API.LogInfo("Wrangler configuration imported",
+		"user_id", extra.UserId,
+		"configuration_post_id", postID,
+		"changed_settings", changed,
+	)
+
+	msg := fmt.Sprintf("The Wrangler configuration was imported. %d setting(s) changed:\n", len(changed))
+	for _, name := range changed {
+		msg += fmt.Sprintf("- %s\n", name)
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminExportConfigCommand(t *testing.T) {
+	adminID := model.NewId()
+	userID := model.NewId()
+	botID := model.NewId()
+	dmChannelID := model.NewId()
+
+	setup := func(config *configuration) (*plugintest.API, *Plugin) {
+		api := &plugintest.API{}
+		mockLogs(api)
+		api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+		api.On("HasPermissionTo", userID, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		api.On("GetDirectChannel", adminID, botID).Return(&model.Channel{Id: dmChannelID}, nil)
+		api.On("UploadFile", mock.Anything, dmChannelID, configExportFilename).Return(&model.FileInfo{Id: "file1"}, nil)
+		api.On("CreatePost", mock.Anything).Return(&model.Post{}, nil)
+
+		plugin := &Plugin{BotUserID: botID}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(config)
+
+		return api, plugin
+	}
+
+	t.Run("admin only", func(t *testing.T) {
+		api, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runAdminExportConfigCommand([]string{}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: only system admins can export the Wrangler configuration", resp.Text)
+		api.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("exports the configuration with the secrets redacted", func(t *testing.T) {
+		api, plugin := setup(&configuration{
+			AllowedEmailDomain:        "example.com",
+			EnableWebUI:               true,
+			RoutingEndpointAuthHeader: "Bearer secret",
+			RoutingEndpointURL:        "https://router.example.com/route",
+		})
+
+		resp, isUserError, err := plugin.runAdminExportConfigCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "The Wrangler configuration was sent to you by DM as a JSON file.", resp.Text)
+
+		var exported map[string]interface{}
+		api.AssertCalled(t, "UploadFile", mock.MatchedBy(func(data []byte) bool {
+			return json.Unmarshal(data, &exported) == nil
+		}), dmChannelID, configExportFilename)
+		assert.Equal(t, "example.com", exported["AllowedEmailDomain"])
+		assert.Equal(t, true, exported["EnableWebUI"])
+		assert.Equal(t, model.FAKE_SETTING, exported["RoutingEndpointAuthHeader"])
+		api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == dmChannelID && post.UserId == botID && len(post.FileIds) == 1 && post.FileIds[0] == "file1"
+		}))
+	})
+
+	t.Run("unset secrets are left empty", func(t *testing.T) {
+		data, err := exportConfiguration(&configuration{})
+		require.NoError(t, err)
+
+		var exported map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &exported))
+		assert.Equal(t, "", exported["RoutingEndpointAuthHeader"])
+	})
+}
+
+func TestAdminImportConfigCommand(t *testing.T) {
+	adminID := model.NewId()
+	userID := model.NewId()
+	postID := model.NewId()
+	fileID := model.NewId()
+
+	setup := func(data string) (*plugintest.API, *Plugin) {
+		api := &plugintest.API{}
+		mockLogs(api)
+		api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+		api.On("HasPermissionTo", userID, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		api.On("GetPost", postID).Return(&model.Post{Id: postID, FileIds: model.StringArray{fileID}}, nil)
+		api.On("GetFileInfo", fileID).Return(&model.FileInfo{Id: fileID, Size: int64(len(data))}, nil)
+		api.On("GetFile", fileID).Return([]byte(data), nil)
+		api.On("SavePluginConfig", mock.Anything).Return(nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(&configuration{
+			AllowedEmailDomain:        "example.com",
+			RoutingEndpointAuthHeader: "Bearer secret",
+			RoutingEndpointURL:        "https://router.example.com/route",
+		})
+
+		return api, plugin
+	}
+
+	t.Run("admin only", func(t *testing.T) {
+		api, plugin := setup(`{}`)
+
+		resp, isUserError, err := plugin.runAdminImportConfigCommand([]string{postID}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: only system admins can import the Wrangler configuration", resp.Text)
+		api.AssertNotCalled(t, "SavePluginConfig", mock.Anything)
+	})
+
+	t.Run("missing message ID", func(t *testing.T) {
+		_, plugin := setup(`{}`)
+
+		resp, isUserError, err := plugin.runAdminImportConfigCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "configuration message ID")
+	})
+
+	t.Run("applies the settings on top of the current configuration", func(t *testing.T) {
+		api, plugin := setup(`{"EnableWebUI": true, "MovedHashtag": "#moved"}`)
+
+		resp, isUserError, err := plugin.runAdminImportConfigCommand([]string{postID}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "The Wrangler configuration was imported. 2 setting(s) changed:\n- EnableWebUI\n- MovedHashtag\n", resp.Text)
+		api.AssertCalled(t, "SavePluginConfig", mock.MatchedBy(func(settings map[string]interface{}) bool {
+			return settings["EnableWebUI"] == true &&
+				settings["MovedHashtag"] == "#moved" &&
+				settings["AllowedEmailDomain"] == "example.com" &&
+				settings["RoutingEndpointAuthHeader"] == "Bearer secret"
+		}))
+	})
+
+	t.Run("unchanged configuration", func(t *testing.T) {
+		api, plugin := setup(`{"AllowedEmailDomain": "example.com"}`)
+
+		resp, isUserError, err := plugin.runAdminImportConfigCommand([]string{postID}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "The imported configuration matches the current one, so nothing was changed.", resp.Text)
+		api.AssertNotCalled(t, "SavePluginConfig", mock.Anything)
+	})
+
+	t.Run("redacted secret is refused", func(t *testing.T) {
+		api, plugin := setup(`{"RoutingEndpointAuthHeader": "` + model.FAKE_SETTING + `"}`)
+
+		resp, isUserError, err := plugin.runAdminImportConfigCommand([]string{postID}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: the configuration wasn't imported: RoutingEndpointAuthHeader is redacted; replace it with its actual value, or leave it out to keep the current value", resp.Text)
+		api.AssertNotCalled(t, "SavePluginConfig", mock.Anything)
+	})
+
+	t.Run("unknown setting is refused", func(t *testing.T) {
+		api, plugin := setup(`{"NotASetting": true}`)
+
+		resp, isUserError, err := plugin.runAdminImportConfigCommand([]string{postID}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: the configuration wasn't imported: the file doesn't match the Wrangler configuration")
+		api.AssertNotCalled(t, "SavePluginConfig", mock.Anything)
+	})
+
+	t.Run("invalid configuration is refused", func(t *testing.T) {
+		api, plugin := setup(`{"MoveThreadMaxCount": "many"}`)
+
+		resp, isUserError, err := plugin.runAdminImportConfigCommand([]string{postID}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: the configuration wasn't imported: the configuration is invalid")
+		api.AssertNotCalled(t, "SavePluginConfig", mock.Anything)
+	})
+
+	t.Run("not JSON", func(t *testing.T) {
+		api, plugin := setup(`EnableWebUI=true`)
+
+		resp, isUserError, err := plugin.runAdminImportConfigCommand([]string{postID}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: the configuration wasn't imported: the file isn't a JSON object")
+		api.AssertNotCalled(t, "SavePluginConfig", mock.Anything)
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const adminPurgeUserUsage = `/wrangler admin purge-user [@USERNAME]
+  Delete the Wrangler data stored for a user, such as when they are offboarded
+    - Only available to system admins
+    - Removes their preferences, scheduled reminders, pending move requests and their operations in the operation history`
+
+func (p *Plugin) runAdminPurgeUserCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: only system admins can purge Wrangler user data"), true, nil
+	}
+	if len(args) != 1 {
+		return getCommandArgsErrorResponse(errors.New("expected exactly one @username or user ID"), adminPurgeUserUsage), true, nil
+	}
+
+	userID, err := p.resolveUserArg(args[0])
+	if err != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, err.Error()), true, nil
+	}
+
+	removed, err := p.purgeUserData(userID)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "unable to purge Wrangler data of user %s", userID)
+	}
+
+	p.API.LogInfo("Wrangler user data purged",
+		"user_id", extra.UserId,
+		"purged_user_id", userID,
+	)
+
+	msg := fmt.Sprintf("#### Wrangler data of %s purged\n\nRemoved:\n", p.getUserMention(userID))
+	for _, line := range removed {
+		msg += fmt.Sprintf("- %s\n", line)
+	}
+	msg += "\nRetained: the provenance of moved messages, which describes messages of shared channels and expires with the history retention, and the daily move counts of channels, which aren't tied to users."
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// purgeUserData deletes the data stored for the user and returns a line
+// describing each kind of data removed. Undo buttons of moves still waiting
+// to delete their original messages are only kept in memory and expire with
+// the move deletion delay, so they are left alone.
+func (p *Plugin) purgeUserData(userID string) ([]string, error) {
+	var removed []string
+
+	found, err := p.deleteUserPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		removed = append(removed, "preferences")
+	} else {
+		removed = append(removed, "no preferences were set")
+	}
+
+	count, err := p.removeUserReminders(userID)
+	if err != nil {
+		return nil, err
+	}
+	removed = append(removed, fmt.Sprintf("%d scheduled reminder(s)", count))
+
+	count, err = p.removeUserMoveApprovals(userID)
+	if err != nil {
+		return nil, err
+	}
+	removed = append(removed, fmt.Sprintf("%d pending move approval request(s)", count))
+
+	appErr := p.API.KVDelete(getMoveRequestQuotaKey(userID))
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "unable to delete move request quota")
+	}
+	removed = append(removed, "the daily count of move requests")
+
+	count, err = p.removeUserOperationHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+	removed = append(removed, fmt.Sprintf("%d operation(s) from the operation history", count))
+
+	return removed, nil
+}
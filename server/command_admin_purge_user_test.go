@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminPurgeUserCommand(t *testing.T) {
+	adminID := model.NewId()
+	userID := model.NewId()
+	otherUserID := model.NewId()
+
+	setup := func() *Plugin {
+		api := &plugintest.API{}
+		newMockKVStore(api)
+		mockLogs(api)
+		api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+		api.On("HasPermissionTo", userID, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		api.On("GetUser", userID).Return(&model.User{Id: userID, Username: "leaver"}, nil)
+		api.On("GetUserByUsername", "leaver").Return(&model.User{Id: userID, Username: "leaver"}, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(&configuration{})
+
+		return plugin
+	}
+
+	t.Run("admin only", func(t *testing.T) {
+		plugin := setup()
+
+		resp, isUserError, err := plugin.runAdminPurgeUserCommand([]string{"@leaver"}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: only system admins can purge Wrangler user data", resp.Text)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		plugin := setup()
+
+		resp, isUserError, err := plugin.runAdminPurgeUserCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: expected exactly one @username or user ID")
+	})
+
+	t.Run("purges only the data of the user", func(t *testing.T) {
+		plugin := setup()
+
+		for _, id := range []string{userID, otherUserID} {
+			require.NoError(t, plugin.setUserPreferences(id, &userPreferences{DefaultAction: defaultActionMove}))
+			_, err := plugin.addReminder(id, "post1", "http://example.com/post1", time.Hour)
+			require.NoError(t, err)
+			request := &moveApprovalRequest{ID: model.NewId(), UserID: id, Args: []string{"post1", "channel1"}}
+			require.NoError(t, plugin.kvSetJSON(getMoveApprovalKey(request.ID), request))
+			plugin.recordOperation(operationMove, id, "source1", "target1", 2)
+		}
+
+		resp, isUserError, err := plugin.runAdminPurgeUserCommand([]string{"@leaver"}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "- preferences\n")
+		assert.Contains(t, resp.Text, "- 1 scheduled reminder(s)\n")
+		assert.Contains(t, resp.Text, "- 1 pending move approval request(s)\n")
+		assert.Contains(t, resp.Text, "- 1 operation(s) from the operation history\n")
+		assert.Contains(t, resp.Text, "Retained:")
+
+		prefs, err := plugin.getUserPreferences(userID)
+		require.NoError(t, err)
+		assert.Empty(t, prefs.DefaultAction)
+		prefs, err = plugin.getUserPreferences(otherUserID)
+		require.NoError(t, err)
+		assert.Equal(t, defaultActionMove, prefs.DefaultAction)
+
+		reminders, err := plugin.getReminders()
+		require.NoError(t, err)
+		require.Len(t, reminders, 1)
+		assert.Equal(t, otherUserID, reminders[0].UserID)
+
+		approvals, err := plugin.getPendingMoveApprovals()
+		require.NoError(t, err)
+		require.Len(t, approvals, 1)
+		assert.Equal(t, otherUserID, approvals[0].UserID)
+
+		records, err := plugin.getOperationHistory(time.Time{})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, otherUserID, records[0].UserID)
+
+		resp, _, err = plugin.runAdminPurgeUserCommand([]string{"@leaver"}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, "- no preferences were set\n")
+		assert.Contains(t, resp.Text, "- 0 scheduled reminder(s)\n")
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const adminQueueUsage = `/wrangler admin queue
+  List all scheduled reminders and pending move approval requests
+    - Only available to system admins
+    - Each entry can be canceled, approved or rejected with its buttons`
+
+const adminQueueActionCancelReminder = "cancel_reminder"
+
+func (p *Plugin) runAdminQueueCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: only system admins can view the Wrangler admin queue"), true, nil
+	}
+
+	approvals, err := p.getPendingMoveApprovals()
+	if err != nil {
+		return nil, false, err
+	}
+	reminders, err := p.getReminders()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(approvals) == 0 && len(reminders) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "There are no pending move approval requests or scheduled reminders."), false, nil
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].RemindAt < reminders[j].RemindAt
+	})
+
+	var attachments []*model.SlackAttachment
+	approvalURL := fmt.Sprintf("/plugins/%s%s", manifest.Id, routeAPIMoveApproval)
+	for _, request := range approvals {
+		target := "unknown"
+		if len(request.Args) >= 2 {
+			target = fmt.Sprintf("message %s to channel %s", request.Args[0], request.Args[1])
+		}
+		attachments = append(attachments, &model.SlackAttachment{
+			Title: fmt.Sprintf("Move approval %s", request.ID),
+			Text:  fmt.Sprintf("Requested by %s: move %s", p.getUserMention(request.UserID), target),
+			Actions: []*model.PostAction{
+				newAdminQueueButton("Approve", approvalURL, map[string]interface{}{"request_id": request.ID, "action": moveApprovalActionApprove}),
+				newAdminQueueButton("Reject", approvalURL, map[string]interface{}{"request_id": request.ID, "action": moveApprovalActionReject}),
+			},
+		})
+	}
+
+	queueURL := fmt.Sprintf("/plugins/%s%s", manifest.Id, routeAPIAdminQueue)
+	for _, r := range reminders {
+		attachments = append(attachments, &model.SlackAttachment{
+			Title: fmt.Sprintf("Reminder %s", r.ID),
+			Text: fmt.Sprintf("Scheduled by %s for %s: %s",
+				p.getUserMention(r.UserID), time.Unix(0, r.RemindAt).UTC().Format(time.RFC1123), r.PostLink),
+			Actions: []*model.PostAction{
+				newAdminQueueButton("Cancel", queueURL, map[string]interface{}{"reminder_id": r.ID, "action": adminQueueActionCancelReminder}),
+			},
+		})
+	}
+
+	resp := getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+		"#### Wrangler admin queue\n\n%d pending move approval request(s) and %d scheduled reminder(s).",
+		len(approvals), len(reminders),
+	))
+	resp.Attachments = attachments
+
+	return resp, false, nil
+}
+
+func newAdminQueueButton(name, url string, context map[string]interface{}) *model.PostAction {
+	return &model.PostAction{
+		Name: name,
+		Type: model.POST_ACTION_TYPE_BUTTON,
+		Integration: &model.PostActionIntegration{
+			URL:     url,
+			Context: context,
+		},
+	}
+}
+
+// handleAdminQueueAction handles a button of the admin queue on behalf of the
+// given user and returns the response to the button action.
+func (p *Plugin) handleAdminQueueAction(userID, action string, context map[string]interface{}) (*model.PostActionIntegrationResponse, error) {
+	if !p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		return &model.PostActionIntegrationResponse{EphemeralText: "Only system admins can handle the Wrangler admin queue."}, nil
+	}
+	if action != adminQueueActionCancelReminder {
+		return nil, fmt.Errorf("unknown admin queue action %s", action)
+	}
+
+	reminderID, _ := context["reminder_id"].(string)
+	if len(strings.TrimSpace(reminderID)) == 0 {
+		return nil, fmt.Errorf("missing reminder ID")
+	}
+
+	found, err := p.cancelReminder("", reminderID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &model.PostActionIntegrationResponse{EphemeralText: fmt.Sprintf("Reminder %s is no longer scheduled.", reminderID)}, nil
+	}
+
+	p.API.LogInfo("Wrangler reminder canceled from the admin queue",
+		"user_id", userID,
+		"reminder_id", reminderID,
+	)
+
+	return &model.PostActionIntegrationResponse{EphemeralText: fmt.Sprintf("Reminder %s was canceled.", reminderID)}, nil
+}
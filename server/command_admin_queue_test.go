@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminQueueCommand(t *testing.T) {
+	adminID := model.NewId()
+	userID := model.NewId()
+
+	setup := func() (*plugintest.API, *Plugin) {
+		api := &plugintest.API{}
+		newMockKVStore(api)
+		mockLogs(api)
+		api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+		api.On("HasPermissionTo", userID, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		api.On("GetUser", userID).Return(&model.User{Id: userID, Username: "requester"}, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(&configuration{})
+
+		return api, plugin
+	}
+
+	t.Run("admin only", func(t *testing.T) {
+		_, plugin := setup()
+
+		resp, isUserError, err := plugin.runAdminQueueCommand([]string{}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: only system admins can view the Wrangler admin queue", resp.Text)
+	})
+
+	t.Run("empty queue", func(t *testing.T) {
+		_, plugin := setup()
+
+		resp, isUserError, err := plugin.runAdminQueueCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "There are no pending move approval requests or scheduled reminders.", resp.Text)
+	})
+
+	t.Run("approvals and reminders of all users", func(t *testing.T) {
+		_, plugin := setup()
+		request := &moveApprovalRequest{ID: model.NewId(), UserID: userID, Args: []string{"post1", "channel1"}}
+		require.NoError(t, plugin.kvSetJSON(getMoveApprovalKey(request.ID), request))
+		reminderID, err := plugin.addReminder(userID, "post2", "http://example.com/post2", time.Hour)
+		require.NoError(t, err)
+
+		resp, isUserError, err := plugin.runAdminQueueCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "1 pending move approval request(s) and 1 scheduled reminder(s).")
+		require.Len(t, resp.Attachments, 2)
+
+		assert.Equal(t, "Move approval "+request.ID, resp.Attachments[0].Title)
+		assert.Equal(t, "Requested by @requester: move message post1 to channel channel1", resp.Attachments[0].Text)
+		require.Len(t, resp.Attachments[0].Actions, 2)
+		assert.Equal(t, moveApprovalActionApprove, resp.Attachments[0].Actions[0].Integration.Context["action"])
+
+		assert.Equal(t, "Reminder "+reminderID, resp.Attachments[1].Title)
+		require.Len(t, resp.Attachments[1].Actions, 1)
+		assert.Equal(t, reminderID, resp.Attachments[1].Actions[0].Integration.Context["reminder_id"])
+	})
+
+	t.Run("cancel reminder", func(t *testing.T) {
+		api, plugin := setup()
+		reminderID, err := plugin.addReminder(userID, "post2", "http://example.com/post2", time.Hour)
+		require.NoError(t, err)
+		context := map[string]interface{}{"reminder_id": reminderID}
+
+		response, err := plugin.handleAdminQueueAction(userID, adminQueueActionCancelReminder, context)
+		require.NoError(t, err)
+		assert.Equal(t, "Only system admins can handle the Wrangler admin queue.", response.EphemeralText)
+
+		response, err = plugin.handleAdminQueueAction(adminID, adminQueueActionCancelReminder, context)
+		require.NoError(t, err)
+		assert.Equal(t, "Reminder "+reminderID+" was canceled.", response.EphemeralText)
+		api.AssertCalled(t, "LogInfo", "Wrangler reminder canceled from the admin queue", "user_id", adminID, "reminder_id", reminderID)
+
+		reminders, err := plugin.getReminders()
+		require.NoError(t, err)
+		assert.Empty(t, reminders)
+
+		response, err = plugin.handleAdminQueueAction(adminID, adminQueueActionCancelReminder, context)
+		require.NoError(t, err)
+		assert.Equal(t, "Reminder "+reminderID+" is no longer scheduled.", response.EphemeralText)
+	})
+
+	t.Run("approve as a system admin outside the approval channel", func(t *testing.T) {
+		api, plugin := setup()
+		api.On("GetChannelMember", mock.AnythingOfType("string"), adminID).Return(nil, &model.AppError{Message: "not found"})
+		plugin.setConfiguration(&configuration{MoveApprovalChannelID: model.NewId()})
+
+		response, err := plugin.handleMoveApprovalAction(adminID, model.NewId(), moveApprovalActionReject)
+		require.NoError(t, err)
+		assert.Equal(t, "This move request was already handled.", response.EphemeralText)
+	})
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const adminVerifyUsage = `/wrangler admin verify [flags]
+  Check the provenance of moved messages for moves that didn't complete cleanly
+    - Only available to system admins
+    - Nothing is changed unless --repair is set
+	Flags:
+%s`
+
+const flagAdminVerifyRepair = "repair"
+
+// maxVerifyIssuesListed bounds the number of issues of each kind listed in
+// the report.
+const maxVerifyIssuesListed = 20
+
+// Kinds of issues found when verifying the provenance of moved messages.
+const (
+	// provenanceIssueDuplicate is an original that is still there next to the
+	// moved message.
+	provenanceIssueDuplicate = "duplicate"
+	// provenanceIssueLost is a moved message that was deleted along with its
+	// original.
+	provenanceIssueLost = "lost"
+	// provenanceIssueStale is a moved message that was deleted while its
+	// original is still there.
+	provenanceIssueStale = "stale"
+)
+
+// provenanceIssue is an inconsistency between a provenance record and the
+// posts it describes.
+type provenanceIssue struct {
+	kind   string
+	record provenanceRecord
+	// original is the original post, set for duplicates.
+	original *model.Post
+}
+
+func getAdminVerifyFlagSet() *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet("admin verify", pflag.ContinueOnError)
+	flagSet.Bool(flagAdminVerifyRepair, false, "Delete the originals that weren't deleted and the outdated provenance records")
+
+	return flagSet
+}
+
+func getAdminVerifyUsage() string {
+	return fmt.Sprintf(adminVerifyUsage, getAdminVerifyFlagSet().FlagUsages())
+}
+
+func (p *Plugin) runAdminVerifyCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: only system admins can verify Wrangler moves"), true, nil
+	}
+
+	flagSet := getAdminVerifyFlagSet()
+	err := flagSet.Parse(args)
+	if err != nil {
+		return getCommandArgsErrorResponse(err, getAdminVerifyUsage()), true, nil
+	}
+	if len(flagSet.Args()) != 0 {
+		return getCommandArgsErrorResponse(errors.New("the command doesn't take any arguments"), getAdminVerifyUsage()), true, nil
+	}
+	repair, err := flagSet.GetBool(flagAdminVerifyRepair)
+	if err != nil {
+		return nil, false, err
+	}
+
+	checked, issues, err := p.verifyProvenance()
+	if err != nil {
+		return nil, false, err
+	}
+
+	msg := fmt.Sprintf("#### Verification of moved messages\n\nChecked %d moved message(s) and found %d issue(s).\n", checked, len(issues))
+	if len(issues) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+	}
+
+	channelNames := make(map[string]string)
+	msg += p.formatProvenanceIssues(issues, provenanceIssueDuplicate, channelNames,
+		"Originals that weren't deleted",
+		"The moved message exists, but its original is still there, so the message is duplicated. Repairing deletes the original.",
+	)
+	msg += p.formatProvenanceIssues(issues, provenanceIssueLost, channelNames,
+		"Moved messages missing along with their originals",
+		"Both the moved message and its original were deleted. Wrangler can't restore deleted messages, so they can only be recovered from a backup.",
+	)
+	msg += p.formatProvenanceIssues(issues, provenanceIssueStale, channelNames,
+		"Moved messages missing",
+		"The moved message was deleted, but its original is still there, so nothing was lost. Repairing removes the outdated provenance record.",
+	)
+
+	if !repair {
+		msg += fmt.Sprintf("\nNothing was changed. Run the command again with --%s to fix the issues that can be repaired.", flagAdminVerifyRepair)
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+	}
+
+	repaired, skipped := p.repairProvenanceIssues(issues)
+
+	p.API.LogInfo("Wrangler repaired moved messages",
+		"user_id", extra.UserId,
+		"issue_count", len(issues),
+		"repaired_count", repaired,
+	)
+
+	msg += fmt.Sprintf("\nRepaired %d issue(s).\n", repaired)
+	for _, line := range skipped {
+		msg += fmt.Sprintf("- %s\n", line)
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// verifyProvenance checks every provenance record against the posts it
+// describes and returns the number of records checked and the issues found.
+// Originals kept on purpose, such as redirects, aren't issues.
+func (p *Plugin) verifyProvenance() (int, []provenanceIssue, error) {
+	keys, err := p.kvListKeysWithPrefix(provenanceKeyPrefix)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var checked int
+	var issues []provenanceIssue
+	for _, key := range keys {
+		if strings.HasPrefix(key, threadCopiesKeyPrefix) {
+			continue
+		}
+
+		var record provenanceRecord
+		found, err := p.kvGetJSON(key, &record)
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "unable to get provenance record")
+		}
+		if !found {
+			// The record expired in the meantime.
+			continue
+		}
+		checked++
+
+		movedPost, err := p.getExistingPost(record.PostID)
+		if err != nil {
+			return 0, nil, err
+		}
+		original, err := p.getExistingPost(record.OriginalPostID)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch {
+		case movedPost == nil && original == nil:
+			issues = append(issues, provenanceIssue{kind: provenanceIssueLost, record: record})
+		case movedPost == nil:
+			issues = append(issues, provenanceIssue{kind: provenanceIssueStale, record: record})
+		case original != nil && !record.OriginalKept && original.GetProp(movedRedirectProp) == nil:
+			issues = append(issues, provenanceIssue{kind: provenanceIssueDuplicate, record: record, original: original})
+		}
+	}
+
+	return checked, issues, nil
+}
+
+// getExistingPost returns the post with the given ID, or nil when it doesn't
+// exist or was deleted.
+func (p *Plugin) getExistingPost(postID string) (*model.Post, error) {
+	post, appErr := p.API.GetPost(postID)
+	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(appErr, "unable to get post %s", postID)
+	}
+	if post.DeleteAt != 0 {
+		return nil, nil
+	}
+
+	return post, nil
+}
+
+// formatProvenanceIssues returns the report section of the issues of the given
+// kind, or an empty string when there are none.
+func (p *Plugin) formatProvenanceIssues(issues []provenanceIssue, kind string, channelNames map[string]string, title, description string) string {
+	var lines []string
+	for _, issue := range issues {
+		if issue.kind != kind {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- original `%s` in %s, moved as `%s` to %s",
+			issue.record.OriginalPostID, p.getStatsChannelName(issue.record.OriginalChannelID, channelNames),
+			issue.record.PostID, p.getStatsChannelName(issue.record.TargetChannelID, channelNames),
+		))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	msg := fmt.Sprintf("\n##### %s (%d)\n\n%s\n\n", title, len(lines), description)
+	if len(lines) > maxVerifyIssuesListed {
+		lines = append(lines[:maxVerifyIssuesListed], fmt.Sprintf("- and %d more", len(lines)-maxVerifyIssuesListed))
+	}
+
+	return msg + strings.Join(lines, "\n") + "\n"
+}
+
+// repairProvenanceIssues fixes the issues that can be repaired and returns
+// the number of repaired issues and a line for each issue that was skipped.
+// Lost messages can't be repaired. Original root posts are only deleted when
+// all of their replies were moved as well, since deleting a root post also
+// deletes its replies.
+func (p *Plugin) repairProvenanceIssues(issues []provenanceIssue) (int, []string) {
+	duplicates := make(map[string]bool)
+	for _, issue := range issues {
+		if issue.kind == provenanceIssueDuplicate {
+			duplicates[issue.original.Id] = true
+		}
+	}
+
+	var repaired int
+	var skipped []string
+	deletedRoots := make(map[string]bool)
+	// Root posts go first so that the replies deleted along with them aren't
+	// deleted again.
+	for _, issue := range issues {
+		if issue.kind != provenanceIssueDuplicate || len(issue.original.RootId) != 0 {
+			continue
+		}
+		postList, appErr := p.API.GetPostThread(issue.original.Id)
+		if appErr != nil {
+			skipped = append(skipped, fmt.Sprintf("unable to get the thread of original `%s`: %s", issue.original.Id, appErr.Error()))
+			continue
+		}
+		var unmoved int
+		for _, post := range buildWranglerPostList(postList).Posts {
+			if !duplicates[post.Id] {
+				unmoved++
+			}
+		}
+		if unmoved != 0 {
+			skipped = append(skipped, fmt.Sprintf("original `%s` was kept since its thread has %d message(s) that weren't moved", issue.original.Id, unmoved))
+			continue
+		}
+		appErr = p.API.DeletePost(issue.original.Id)
+		if appErr != nil {
+			skipped = append(skipped, fmt.Sprintf("unable to delete original `%s`: %s", issue.original.Id, appErr.Error()))
+			continue
+		}
+		deletedRoots[issue.original.Id] = true
+		repaired++
+	}
+
+	for _, issue := range issues {
+		switch {
+		case issue.kind == provenanceIssueStale:
+			appErr := p.API.KVDelete(getProvenanceKey(issue.record.PostID))
+			if appErr != nil {
+				skipped = append(skipped, fmt.Sprintf("unable to remove the provenance record of `%s`: %s", issue.record.PostID, appErr.Error()))
+				continue
+			}
+			repaired++
+		case issue.kind == provenanceIssueLost:
+			skipped = append(skipped, fmt.Sprintf("original `%s` and moved message `%s` can't be restored by Wrangler", issue.record.OriginalPostID, issue.record.PostID))
+		case len(issue.original.RootId) != 0:
+			if deletedRoots[issue.original.RootId] {
+				repaired++
+				continue
+			}
+			appErr := p.API.DeletePost(issue.original.Id)
+			if appErr != nil {
+				skipped = append(skipped, fmt.Sprintf("unable to delete original `%s`: %s", issue.original.Id, appErr.Error()))
+				continue
+			}
+			repaired++
+		}
+	}
+
+	return repaired, skipped
+}
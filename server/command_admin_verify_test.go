@@ -0,0 +1,182 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminVerifyCommand(t *testing.T) {
+	adminID := model.NewId()
+	userID := model.NewId()
+	sourceChannel := &model.Channel{Id: model.NewId(), Name: "source"}
+	targetChannel := &model.Channel{Id: model.NewId(), Name: "target"}
+
+	type setupResult struct {
+		api    *plugintest.API
+		plugin *Plugin
+		posts  map[string]*model.Post
+		store  *mockKVStore
+	}
+	setup := func() setupResult {
+		r := setupResult{api: &plugintest.API{}, posts: make(map[string]*model.Post)}
+		r.store = newMockKVStore(r.api)
+		mockLogs(r.api)
+		r.api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+		r.api.On("HasPermissionTo", userID, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		r.api.On("GetChannel", sourceChannel.Id).Return(sourceChannel, nil)
+		r.api.On("GetChannel", targetChannel.Id).Return(targetChannel, nil)
+		r.api.On("GetPost", mock.AnythingOfType("string")).Return(
+			func(postID string) *model.Post {
+				return r.posts[postID]
+			},
+			func(postID string) *model.AppError {
+				if _, ok := r.posts[postID]; !ok {
+					return model.NewAppError("GetPost", "app.post.get.app_error", nil, "", http.StatusNotFound)
+				}
+				return nil
+			},
+		)
+		r.api.On("GetPostThread", mock.AnythingOfType("string")).Return(
+			func(rootID string) *model.PostList {
+				postList := model.NewPostList()
+				for _, post := range r.posts {
+					if post.DeleteAt == 0 && (post.Id == rootID || post.RootId == rootID) {
+						postList.AddPost(post)
+						postList.AddOrder(post.Id)
+					}
+				}
+				return postList
+			},
+			nil,
+		)
+		r.api.On("DeletePost", mock.AnythingOfType("string")).Return(
+			func(postID string) *model.AppError {
+				for _, post := range r.posts {
+					if post.Id == postID || post.RootId == postID {
+						post.DeleteAt = 1
+					}
+				}
+				return nil
+			},
+		)
+
+		r.plugin = &Plugin{}
+		r.plugin.SetAPI(r.api)
+		r.plugin.setConfiguration(&configuration{})
+
+		return r
+	}
+	addPost := func(r setupResult, rootID string) *model.Post {
+		post := &model.Post{Id: model.NewId(), RootId: rootID}
+		r.posts[post.Id] = post
+		return post
+	}
+	addRecord := func(r setupResult, record provenanceRecord) provenanceRecord {
+		if len(record.PostID) == 0 {
+			record.PostID = model.NewId()
+		}
+		if len(record.OriginalPostID) == 0 {
+			record.OriginalPostID = model.NewId()
+		}
+		record.OriginalChannelID = sourceChannel.Id
+		record.TargetChannelID = targetChannel.Id
+		record.Operation = operationMove
+		require.NoError(t, r.plugin.kvSetJSON(getProvenanceKey(record.PostID), record))
+		return record
+	}
+
+	t.Run("admin only", func(t *testing.T) {
+		r := setup()
+
+		resp, isUserError, err := r.plugin.runAdminVerifyCommand([]string{}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: only system admins can verify Wrangler moves", resp.Text)
+	})
+
+	t.Run("unexpected arguments", func(t *testing.T) {
+		r := setup()
+
+		resp, isUserError, err := r.plugin.runAdminVerifyCommand([]string{"all"}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "the command doesn't take any arguments")
+	})
+
+	t.Run("nothing to verify", func(t *testing.T) {
+		r := setup()
+
+		resp, isUserError, err := r.plugin.runAdminVerifyCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "#### Verification of moved messages\n\nChecked 0 moved message(s) and found 0 issue(s).\n", resp.Text)
+	})
+
+	t.Run("reports issues without changing anything", func(t *testing.T) {
+		r := setup()
+		// A completed move.
+		addRecord(r, provenanceRecord{PostID: addPost(r, "").Id})
+		// Originals kept on purpose.
+		addRecord(r, provenanceRecord{PostID: addPost(r, "").Id, OriginalPostID: addPost(r, "").Id, OriginalKept: true})
+		redirect := addPost(r, "")
+		redirect.AddProp(movedRedirectProp, model.NewId())
+		addRecord(r, provenanceRecord{PostID: addPost(r, "").Id, OriginalPostID: redirect.Id})
+		// Issues.
+		duplicate := addRecord(r, provenanceRecord{PostID: addPost(r, "").Id, OriginalPostID: addPost(r, model.NewId()).Id})
+		lost := addRecord(r, provenanceRecord{})
+		stale := addRecord(r, provenanceRecord{OriginalPostID: addPost(r, "").Id})
+		// Copies of threads share the prefix of provenance records.
+		r.plugin.recordThreadCopy(model.NewId(), targetChannel.Id, model.NewId())
+
+		resp, isUserError, err := r.plugin.runAdminVerifyCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "Checked 6 moved message(s) and found 3 issue(s).")
+		assert.Contains(t, resp.Text, "##### Originals that weren't deleted (1)")
+		assert.Contains(t, resp.Text, "- original `"+duplicate.OriginalPostID+"` in ~source, moved as `"+duplicate.PostID+"` to ~target")
+		assert.Contains(t, resp.Text, "##### Moved messages missing along with their originals (1)")
+		assert.Contains(t, resp.Text, "- original `"+lost.OriginalPostID+"`")
+		assert.Contains(t, resp.Text, "##### Moved messages missing (1)")
+		assert.Contains(t, resp.Text, "- original `"+stale.OriginalPostID+"`")
+		assert.Contains(t, resp.Text, "Nothing was changed. Run the command again with --repair")
+
+		r.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+		r.api.AssertNotCalled(t, "KVDelete", mock.Anything)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		r := setup()
+		// A thread whose originals weren't deleted.
+		root := addPost(r, "")
+		reply := addPost(r, root.Id)
+		addRecord(r, provenanceRecord{PostID: addPost(r, "").Id, OriginalPostID: root.Id})
+		addRecord(r, provenanceRecord{PostID: addPost(r, "").Id, OriginalPostID: reply.Id})
+		// A thread with a reply that wasn't moved.
+		partialRoot := addPost(r, "")
+		addPost(r, partialRoot.Id)
+		addRecord(r, provenanceRecord{PostID: addPost(r, "").Id, OriginalPostID: partialRoot.Id})
+		lost := addRecord(r, provenanceRecord{})
+		stale := addRecord(r, provenanceRecord{OriginalPostID: addPost(r, "").Id})
+
+		resp, isUserError, err := r.plugin.runAdminVerifyCommand([]string{"--repair"}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "found 5 issue(s)")
+		assert.Contains(t, resp.Text, "Repaired 3 issue(s).")
+		assert.Contains(t, resp.Text, "- original `"+partialRoot.Id+"` was kept since its thread has 1 message(s) that weren't moved")
+		assert.Contains(t, resp.Text, "- original `"+lost.OriginalPostID+"` and moved message `"+lost.PostID+"` can't be restored by Wrangler")
+
+		assert.NotZero(t, root.DeleteAt)
+		assert.NotZero(t, reply.DeleteAt)
+		assert.Zero(t, partialRoot.DeleteAt)
+		r.api.AssertNumberOfCalls(t, "DeletePost", 1)
+		assert.NotContains(t, r.store.data, getProvenanceKey(stale.PostID))
+		assert.Contains(t, r.store.data, getProvenanceKey(lost.PostID))
+	})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const archiveThreadUsage = `/wrangler archive thread [MESSAGE_ID] [flags]
+  Move a given message, along with the thread it belongs to, to the archive channel of this channel
+    - Archive channels are set in the plugin configuration
+    - Accepts the same flags as '/wrangler move thread'`
+
+func (p *Plugin) runArchiveThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	// The remaining args are passed on to the move thread command.
+	positional, err := parseCommandArgs(args, getMoveThreadFlagSet(), messageIDArg)
+	if err != nil {
+		return getCommandArgsErrorResponse(err, archiveThreadUsage), true, nil
+	}
+	postID := positional[0]
+
+	channelID := p.getArchiveChannelID(extra.ChannelId)
+	if len(channelID) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("No archive channel is configured for this channel. Choose the destination yourself with `/wrangler move thread %s [CHANNEL_ID]`.", postID)), true, nil
+	}
+
+	p.API.LogInfo("Wrangler is archiving a thread",
+		"user_id", extra.UserId,
+		"original_post_id", postID,
+		"target_channel_id", channelID,
+	)
+
+	moveArgs := append([]string{postID, channelID}, args[1:]...)
+	return p.runMoveThreadCommand(moveArgs, extra)
+}
+
+// getArchiveChannelID returns the ID of the channel that threads of the given
+// channel are archived to, or an empty string if there is none.
+func (p *Plugin) getArchiveChannelID(channelID string) string {
+	config := p.getConfiguration()
+	destination, ok := config.SourceToDestinationMapping()[channelID]
+	if ok {
+		return destination
+	}
+
+	return config.DefaultArchiveChannelID
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveThreadCommand(t *testing.T) {
+	setup := func(config func(f *threadTestFixture) *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config(f))
+		require.NoError(t, plugin.configuration.IsValid())
+
+		return f, plugin
+	}
+
+	t.Run("no arguments", func(t *testing.T) {
+		_, plugin := setup(func(f *threadTestFixture) *configuration { return &configuration{} })
+		resp, isUserError, err := plugin.runArchiveThreadCommand([]string{}, &model.CommandArgs{})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing arguments")
+	})
+
+	t.Run("no archive channel", func(t *testing.T) {
+		f, plugin := setup(func(f *threadTestFixture) *configuration {
+			return &configuration{SourceToDestinationMap: fmt.Sprintf(`{"%s": "%s"}`, model.NewId(), f.targetChannel.Id)}
+		})
+		resp, isUserError, err := plugin.runArchiveThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "No archive channel is configured for this channel")
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("mapped destination", func(t *testing.T) {
+		f, plugin := setup(func(f *threadTestFixture) *configuration {
+			return &configuration{
+				SourceToDestinationMap:  fmt.Sprintf(`{"%s": "%s"}`, f.originalChannel.Id, f.targetChannel.Id),
+				DefaultArchiveChannelID: model.NewId(),
+			}
+		})
+		resp, isUserError, err := plugin.runArchiveThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == f.targetChannel.Id
+		}))
+	})
+
+	t.Run("default archive channel", func(t *testing.T) {
+		f, plugin := setup(func(f *threadTestFixture) *configuration {
+			return &configuration{DefaultArchiveChannelID: f.targetChannel.Id}
+		})
+		resp, isUserError, err := plugin.runArchiveThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/spf13/pflag"
+)
+
+const (
+	defaultMaxCommandLength = 1000
+	minMaxCommandLength     = 100
+	maxMaxCommandLength     = model.POST_MESSAGE_MAX_RUNES_V2
+)
+
+// commandArg describes an argument that a command expects at a given position
+// among its positional arguments.
+type commandArg struct {
+	name string
+	// hint tells users where to find a valid value.
+	hint string
+}
+
+var (
+	messageIDArg = commandArg{
+		name: "message ID",
+		hint: "obtain it by running '/wrangler list messages' or via the 'Permalink' message dropdown option (it's the last part of the URL)",
+	}
+	channelIDArg = commandArg{
+		name: "channel ID",
+		hint: "obtain it by running '/wrangler list channels' or via the channel 'View Info' option",
+	}
+)
+
+// withName returns a copy of the argument with a more specific name, such as
+// "destination channel ID".
+func (a commandArg) withName(name string) commandArg {
+	a.name = name
+	return a
+}
+
+// parseCommandArgs separates the positional arguments of a command from its
+// flags and checks them against the expected arguments, which must all be
+// Mattermost IDs. Flags may appear before, between or after the positional
+// arguments. The flag set may be nil for commands without flags. The returned
+// error is meant to be shown to the user as is.
+func parseCommandArgs(args []string, flagSet *pflag.FlagSet, expected ...commandArg) ([]string, error) {
+	var positional []string
+	if flagSet != nil {
+		err := flagSet.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		positional = flagSet.Args()
+	} else {
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-") {
+				return nil, fmt.Errorf("unknown flag %s; this command doesn't accept flags", arg)
+			}
+		}
+		positional = args
+	}
+
+	if len(positional) == 0 {
+		names := make([]string, len(expected))
+		for i, arg := range expected {
+			names[i] = "the " + arg.name
+		}
+		return nil, fmt.Errorf("missing arguments; expected %s", strings.Join(names, " and "))
+	}
+	if len(positional) < len(expected) {
+		return nil, fmt.Errorf("missing %s after the %s", expected[len(positional)].name, expected[len(positional)-1].name)
+	}
+	if len(positional) > len(expected) {
+		return nil, fmt.Errorf("unexpected argument %s after the %s", positional[len(expected)], expected[len(expected)-1].name)
+	}
+
+	for i, arg := range expected {
+		if !model.IsValidId(positional[i]) {
+			return nil, fmt.Errorf("%s is not a valid %s; %s", positional[i], arg.name, arg.hint)
+		}
+	}
+
+	return positional, nil
+}
+
+// getCommandArgsErrorResponse returns the response to a command with invalid
+// arguments, along with its usage.
+func getCommandArgsErrorResponse(err error, usage string) *model.CommandResponse {
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, codeBlock(fmt.Sprintf("Error: %s\n\n%s", err.Error(), usage)))
+}
+
+// commandTooLong returns true if the command is longer than the configured
+// maximum length.
+func (p *Plugin) commandTooLong(command string) bool {
+	return utf8.RuneCountInString(command) > p.getConfiguration().MaxCommandLengthInt()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommandArgs(t *testing.T) {
+	postID := model.NewId()
+	channelID := model.NewId()
+	expected := []commandArg{messageIDArg, channelIDArg.withName("destination channel ID")}
+
+	tests := []struct {
+		name    string
+		args    []string
+		noFlags bool
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "no arguments",
+			args:    []string{},
+			wantErr: "missing arguments; expected the message ID and the destination channel ID",
+		},
+		{
+			name:    "only flags",
+			args:    []string{"--preview"},
+			wantErr: "missing arguments; expected the message ID and the destination channel ID",
+		},
+		{
+			name:    "missing second argument",
+			args:    []string{postID, "--preview"},
+			wantErr: "missing destination channel ID after the message ID",
+		},
+		{
+			name:    "too many arguments",
+			args:    []string{postID, channelID, "extra"},
+			wantErr: "unexpected argument extra after the destination channel ID",
+		},
+		{
+			name:    "invalid ID",
+			args:    []string{postID, "town-square"},
+			wantErr: "town-square is not a valid destination channel ID; obtain it by running '/wrangler list channels'",
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{postID, channelID, "--unknown"},
+			wantErr: "unknown flag: --unknown",
+		},
+		{
+			name:    "flag without value",
+			args:    []string{postID, channelID, "--changelog"},
+			wantErr: "flag needs an argument: --changelog",
+		},
+		{
+			name:    "flag for a command without flags",
+			args:    []string{postID, channelID, "--preview"},
+			noFlags: true,
+			wantErr: "unknown flag --preview; this command doesn't accept flags",
+		},
+		{
+			name: "valid arguments",
+			args: []string{postID, channelID},
+			want: []string{postID, channelID},
+		},
+		{
+			name: "flags before and between arguments",
+			args: []string{"--preview", postID, "--changelog", "a b", channelID},
+			want: []string{postID, channelID},
+		},
+		{
+			name:    "valid arguments without flags",
+			args:    []string{postID, channelID},
+			noFlags: true,
+			want:    []string{postID, channelID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flagSet := getMoveThreadFlagSet()
+			if tt.noFlags {
+				flagSet = nil
+			}
+
+			positional, err := parseCommandArgs(tt.args, flagSet, expected...)
+			if len(tt.wantErr) != 0 {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, positional)
+		})
+	}
+}
+
+func TestMalformedCommandArgs(t *testing.T) {
+	// The API is only used to look up the default destination preference of
+	// the user when a move or copy omits the destination.
+	f := newThreadTestFixture(0)
+	var plugin Plugin
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{})
+
+	postID := model.NewId()
+	tests := []struct {
+		name    string
+		run     func([]string, *model.CommandArgs) (*model.CommandResponse, bool, error)
+		args    []string
+		wantErr string
+	}{
+		{"move thread without destination", plugin.runMoveThreadCommand, []string{postID}, "Error: missing destination channel ID after the message ID"},
+		{"move thread with unknown flag", plugin.runMoveThreadCommand, []string{postID, model.NewId(), "--force"}, "Error: unknown flag: --force"},
+		{"copy thread with permalink", plugin.runCopyThreadCommand, []string{"https://example.com/team/pl/" + postID, model.NewId()}, "is not a valid message ID"},
+		{"merge thread without target", plugin.runMergeThreadCommand, []string{postID}, "Error: missing target message ID after the message ID"},
+		{"export thread with extra argument", plugin.runExportThreadCommand, []string{postID, "json"}, "Error: unexpected argument json after the message ID"},
+		{"quote thread with flag", plugin.runQuoteThreadCommand, []string{postID, model.NewId(), "--preview"}, "Error: unknown flag --preview; this command doesn't accept flags"},
+		{"import with channel name", plugin.runImportCommand, []string{"town-square", postID}, "Error: town-square is not a valid destination channel ID"},
+		{"copy pinned without channel", plugin.runCopyPinnedCommand, []string{}, "Error: missing arguments; expected the destination channel ID"},
+		{"attach message with one argument", plugin.runAttachMessageCommand, []string{postID}, "Error: missing root message ID after the ID of the message to attach"},
+		{"archive thread with invalid ID", plugin.runArchiveThreadCommand, []string{"latest"}, "Error: latest is not a valid message ID"},
+		{"route thread with unknown flag", plugin.runRouteThreadCommand, []string{postID, "--channel=1"}, "Error: unknown flag: --channel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, isUserError, err := tt.run(tt.args, &model.CommandArgs{})
+			require.NoError(t, err)
+			assert.True(t, isUserError)
+			assert.Contains(t, resp.Text, tt.wantErr)
+		})
+	}
+}
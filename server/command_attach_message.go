@@ -7,23 +7,20 @@ import (
 	"github.com/pkg/errors"
 )
 
-const attachMessageCommand = `Error: missing arguments
-
-/wrangler attach message [MESSAGE_ID_TO_BE_ATTACHED] [ROOT_MESSAGE_ID]
+const attachMessageUsage = `/wrangler attach message [MESSAGE_ID_TO_BE_ATTACHED] [ROOT_MESSAGE_ID]
 	Attach a given message to a thread in the same channel
-	  - Obtain the message IDs by running '/wrangler list messages' or via the 'Permalink' message dropdown option (it's the last part of the URL)
-`
-
-func getAttachMessageCommand() string {
-	return codeBlock(attachMessageCommand)
-}
+	  - Obtain the message IDs by running '/wrangler list messages' or via the 'Permalink' message dropdown option (it's the last part of the URL)`
 
 func (p *Plugin) runAttachMessageCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
-	if len(args) < 2 {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getAttachMessageCommand()), true, nil
+	if !p.getConfiguration().OperationEnabled(enabledOperationAttach) {
+		return getOperationDisabledResponse("attaching messages"), true, nil
 	}
-	postToBeAttachedID := args[0]
-	postToAttachToID := args[1]
+	positional, err := parseCommandArgs(args, nil, messageIDArg.withName("ID of the message to attach"), messageIDArg.withName("root message ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, attachMessageUsage), true, nil
+	}
+	postToBeAttachedID := positional[0]
+	postToAttachToID := positional[1]
 
 	if postToBeAttachedID == postToAttachToID {
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: the two provided message IDs should not be the same"), true, nil
@@ -44,6 +41,9 @@ func (p *Plugin) runAttachMessageCommand(args []string, extra *model.CommandArgs
 	if postToAttachTo.ChannelId != extra.ChannelId {
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: unable to attach message to a thread in another channel"), true, nil
 	}
+	if attachesToOwnThread(postToBeAttached, postToAttachTo) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "The message is already part of that thread, so nothing was changed."), false, nil
+	}
 	if len(postToBeAttached.RootId) != 0 || len(postToBeAttached.ParentId) != 0 {
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: the message to be attached is already part of a thread"), true, nil
 	}
@@ -63,10 +63,16 @@ func (p *Plugin) runAttachMessageCommand(args []string, extra *model.CommandArgs
 		return nil, false, errors.Wrap(appErr, "failed to lookup lookup team")
 	}
 
+	// The message is always attached to the thread of the given message, so
+	// the true root is used even when the given message is a reply.
 	newRootID := postToAttachTo.Id
 	if len(postToAttachTo.RootId) != 0 {
 		newRootID = postToAttachTo.RootId
 	}
+	newParentID := newRootID
+	if p.getConfiguration().AttachAsDirectReply {
+		newParentID = postToAttachTo.Id
+	}
 	cleanupID := postToBeAttached.Id
 
 	// Begin attaching message to the thread.
@@ -74,6 +80,7 @@ func (p *Plugin) runAttachMessageCommand(args []string, extra *model.CommandArgs
 		"user_id", extra.UserId,
 		"post_to_be_attached", postToBeAttachedID,
 		"new_root_id", newRootID,
+		"new_parent_id", newParentID,
 	)
 
 	if len(postToBeAttached.FileIds) != 0 {
@@ -113,7 +120,7 @@ func (p *Plugin) runAttachMessageCommand(args []string, extra *model.CommandArgs
 
 	cleanPostID(postToBeAttached)
 	postToBeAttached.RootId = newRootID
-	postToBeAttached.ParentId = newRootID
+	postToBeAttached.ParentId = newParentID
 
 	newPost, appErr := p.API.CreatePost(postToBeAttached)
 	if appErr != nil {
@@ -161,3 +168,19 @@ func (p *Plugin) postAttachMessageBotDM(userID, newPostLink string) error {
 		"Someone wrangled one of your messages into a thread for you: %s", newPostLink,
 	))
 }
+
+// attachesToOwnThread returns true when the message to attach already belongs
+// to the thread of the message to attach to, which makes the attach a no-op.
+// This also covers the root of a thread being attached to one of its replies.
+func attachesToOwnThread(postToBeAttached, postToAttachTo *model.Post) bool {
+	targetRootID := postToAttachTo.Id
+	if len(postToAttachTo.RootId) != 0 {
+		targetRootID = postToAttachTo.RootId
+	}
+	attachedRootID := postToBeAttached.Id
+	if len(postToBeAttached.RootId) != 0 {
+		attachedRootID = postToBeAttached.RootId
+	}
+
+	return attachedRootID == targetRootID
+}
@@ -38,6 +38,16 @@ func TestAttachMessageCommand(t *testing.T) {
 		RootId:    rootID,
 		ParentId:  rootID,
 	}
+	threadRootPost := &model.Post{
+		Id:        rootID,
+		ChannelId: channel1.Id,
+	}
+	postInSameThread := &model.Post{
+		Id:        model.NewId(),
+		ChannelId: channel1.Id,
+		RootId:    rootID,
+		ParentId:  rootID,
+	}
 	postInAnotherChannel := &model.Post{
 		Id:        model.NewId(),
 		ChannelId: model.NewId(),
@@ -71,6 +81,8 @@ func TestAttachMessageCommand(t *testing.T) {
 	api.On("GetPost", postToAttachTo.Id).Return(postToAttachTo, nil)
 	api.On("GetPost", postInThreadAlready.Id).Return(postInThreadAlready, nil)
 	api.On("GetPost", postInAnotherChannel.Id).Return(postInAnotherChannel, nil)
+	api.On("GetPost", threadRootPost.Id).Return(threadRootPost, nil)
+	api.On("GetPost", postInSameThread.Id).Return(postInSameThread, nil)
 	api.On("GetPost", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(nil, model.NewAppError("where", model.NewId(), nil, "not found", 0))
 	api.On("CreatePost", mock.Anything, mock.Anything).Return(mockGeneratePost(), nil)
 	api.On("DeletePost", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(nil)
@@ -97,6 +109,7 @@ func TestAttachMessageCommand(t *testing.T) {
 		mock.AnythingOfTypeArgument("string"),
 		mock.AnythingOfTypeArgument("string"),
 	).Return(nil)
+	mockLogs(api)
 
 	var plugin Plugin
 	plugin.SetAPI(api)
@@ -109,10 +122,10 @@ func TestAttachMessageCommand(t *testing.T) {
 	})
 
 	t.Run("one arg", func(t *testing.T) {
-		resp, isUserError, err := plugin.runAttachMessageCommand([]string{"id1"}, &model.CommandArgs{ChannelId: channel1.Id})
+		resp, isUserError, err := plugin.runAttachMessageCommand([]string{model.NewId()}, &model.CommandArgs{ChannelId: channel1.Id})
 		require.NoError(t, err)
 		assert.True(t, isUserError)
-		assert.Contains(t, resp.Text, "Error: missing arguments")
+		assert.Contains(t, resp.Text, "Error: missing root message ID after the ID of the message to attach")
 	})
 
 	t.Run("post IDs are the same", func(t *testing.T) {
@@ -175,6 +188,27 @@ func TestAttachMessageCommand(t *testing.T) {
 		assert.Contains(t, resp.Text, "Error: the message to be attached is already part of a thread")
 	})
 
+	t.Run("attach message to its own thread", func(t *testing.T) {
+		for name, targetID := range map[string]string{
+			"root":          threadRootPost.Id,
+			"another reply": postInSameThread.Id,
+		} {
+			t.Run(name, func(t *testing.T) {
+				resp, isUserError, err := plugin.runAttachMessageCommand([]string{postInThreadAlready.Id, targetID}, &model.CommandArgs{ChannelId: channel1.Id})
+				require.NoError(t, err)
+				assert.False(t, isUserError)
+				assert.Equal(t, "The message is already part of that thread, so nothing was changed.", resp.Text)
+			})
+		}
+	})
+
+	t.Run("attach root message to one of its replies", func(t *testing.T) {
+		resp, isUserError, err := plugin.runAttachMessageCommand([]string{threadRootPost.Id, postInSameThread.Id}, &model.CommandArgs{ChannelId: channel1.Id})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "The message is already part of that thread, so nothing was changed.", resp.Text)
+	})
+
 	t.Run("attach message successfully", func(t *testing.T) {
 		plugin.setConfiguration(&configuration{MoveThreadToAnotherTeamEnable: true})
 		require.NoError(t, plugin.configuration.IsValid())
@@ -185,3 +219,77 @@ func TestAttachMessageCommand(t *testing.T) {
 		assert.Contains(t, resp.Text, "Message successfully attached to thread")
 	})
 }
+
+func TestAttachMessageThreadRoot(t *testing.T) {
+	channel := &model.Channel{
+		Id:   model.NewId(),
+		Name: "channel1",
+		Type: model.CHANNEL_OPEN,
+	}
+	rootPost := &model.Post{
+		Id:        model.NewId(),
+		UserId:    model.NewId(),
+		ChannelId: channel.Id,
+	}
+	replyPost := &model.Post{
+		Id:        model.NewId(),
+		UserId:    model.NewId(),
+		ChannelId: channel.Id,
+		RootId:    rootPost.Id,
+		ParentId:  rootPost.Id,
+	}
+	userID := model.NewId()
+
+	setupAPI := func(createdPost **model.Post) (*plugintest.API, string) {
+		postToBeAttached := &model.Post{
+			Id:        model.NewId(),
+			UserId:    userID,
+			ChannelId: channel.Id,
+		}
+
+		api := &plugintest.API{}
+		api.On("GetPost", postToBeAttached.Id).Return(postToBeAttached, nil)
+		api.On("GetPost", rootPost.Id).Return(rootPost, nil)
+		api.On("GetPost", replyPost.Id).Return(replyPost, nil)
+		api.On("GetTeam", mock.AnythingOfType("string")).Return(&model.Team{Id: model.NewId(), Name: "team1"}, nil)
+		api.On("GetReactions", postToBeAttached.Id).Return(nil, nil)
+		api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(func(post *model.Post) *model.Post {
+			*createdPost = post.Clone()
+			return mockGeneratePost()
+		}, nil)
+		api.On("DeletePost", postToBeAttached.Id).Return(nil)
+		mockLogs(api)
+
+		return api, postToBeAttached.Id
+	}
+
+	for _, tc := range []struct {
+		name             string
+		targetID         string
+		directReply      bool
+		expectedParentID string
+	}{
+		{"attach to root", rootPost.Id, false, rootPost.Id},
+		{"attach to reply", replyPost.Id, false, rootPost.Id},
+		{"attach to root as direct reply", rootPost.Id, true, rootPost.Id},
+		{"attach to reply as direct reply", replyPost.Id, true, replyPost.Id},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var createdPost *model.Post
+			api, postToBeAttachedID := setupAPI(&createdPost)
+
+			var plugin Plugin
+			plugin.SetAPI(api)
+			plugin.setConfiguration(&configuration{AttachAsDirectReply: tc.directReply})
+
+			resp, isUserError, err := plugin.runAttachMessageCommand([]string{postToBeAttachedID, tc.targetID}, &model.CommandArgs{ChannelId: channel.Id, UserId: userID})
+			require.NoError(t, err)
+			assert.False(t, isUserError)
+			assert.Contains(t, resp.Text, "Message successfully attached to thread")
+
+			require.NotNil(t, createdPost)
+			assert.Equal(t, rootPost.Id, createdPost.RootId)
+			assert.Equal(t, tc.expectedParentID, createdPost.ParentId)
+		})
+	}
+}
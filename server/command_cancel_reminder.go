@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const cancelReminderUsage = `/wrangler cancel reminder [REMINDER_ID]
+  Cancel a reminder scheduled with '/wrangler move thread --remind'`
+
+func getCancelReminderMessage() string {
+	return codeBlock(fmt.Sprintf("`Error: missing arguments\n\n%s", cancelReminderUsage))
+}
+
+func (p *Plugin) runCancelReminderCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if len(args) < 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getCancelReminderMessage()), true, nil
+	}
+	reminderID := args[0]
+
+	found, err := p.cancelReminder(extra.UserId, reminderID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: no reminder with ID %s is scheduled for you", reminderID)), true, nil
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Reminder canceled"), false, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// blockedByCommandChannel returns true if a command channel is configured, the
+// command wasn't run in it and the user isn't allowed to bypass it.
+func (p *Plugin) blockedByCommandChannel(args *model.CommandArgs) bool {
+	config := p.getConfiguration()
+	if len(config.CommandChannelID) == 0 || args.ChannelId == config.CommandChannelID {
+		return false
+	}
+	if config.CommandChannelAdminBypass && p.API.HasPermissionTo(args.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return false
+	}
+
+	return true
+}
+
+// getCommandChannelMessage returns the response to commands that were run
+// outside of the command channel.
+func (p *Plugin) getCommandChannelMessage() string {
+	config := p.getConfiguration()
+	channel, appErr := p.API.GetChannel(config.CommandChannelID)
+	if appErr != nil {
+		return "Wrangler commands can only be run in the designated Wrangler channel. Please talk to your system administrator to find it."
+	}
+
+	return fmt.Sprintf("Wrangler commands can only be run in ~%s.", channel.Name)
+}
+
+// retargetCommandArgs returns the command args to run a command typed in the
+// command channel with. When the first argument is the ID of a message in a
+// channel the user is a member of, the command is run as if it was typed in
+// that channel so that the same rules apply as anywhere else. Otherwise the
+// args are returned unchanged.
+func (p *Plugin) retargetCommandArgs(args *model.CommandArgs, commandArgs []string) *model.CommandArgs {
+	config := p.getConfiguration()
+	if len(config.CommandChannelID) == 0 || args.ChannelId != config.CommandChannelID || len(commandArgs) == 0 {
+		return args
+	}
+	if !model.IsValidId(commandArgs[0]) {
+		return args
+	}
+
+	post, appErr := p.API.GetPost(commandArgs[0])
+	if appErr != nil || post.ChannelId == args.ChannelId {
+		return args
+	}
+	_, appErr = p.API.GetChannelMember(post.ChannelId, args.UserId)
+	if appErr != nil {
+		return args
+	}
+	channel, appErr := p.API.GetChannel(post.ChannelId)
+	if appErr != nil {
+		return args
+	}
+
+	retargeted := *args
+	retargeted.ChannelId = channel.Id
+	retargeted.RootId = ""
+	retargeted.ParentId = ""
+	if len(channel.TeamId) != 0 {
+		retargeted.TeamId = channel.TeamId
+	}
+
+	return &retargeted
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const copyPinnedUsage = `/wrangler copy pinned [CHANNEL_ID]
+  Copy all pinned messages of this channel to a given channel and pin them there
+    - This can be on any channel in any team that you have joined
+    - Obtain the channel ID by running '/wrangler list channels' or via the channel 'View Info' option`
+
+// pinnedScanPageSize is the number of channel posts requested at a time while
+// looking for pinned posts.
+const pinnedScanPageSize = 200
+
+func (p *Plugin) runCopyPinnedCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.getConfiguration().OperationEnabled(enabledOperationCopy) {
+		return getOperationDisabledResponse("copying messages"), true, nil
+	}
+	if !p.authorizedOperationUser(extra.UserId, p.getConfiguration().CopyPermittedRoleNames()) {
+		return getOperationRolesResponse("copy pinned messages"), true, nil
+	}
+	if p.accountTooNew(extra.UserId) {
+		return p.getAccountTooNewResponse("copy pinned messages"), true, nil
+	}
+	positional, err := parseCommandArgs(args, nil, channelIDArg.withName("destination channel ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, copyPinnedUsage), true, nil
+	}
+	channelID := positional[0]
+
+	if channelID == extra.ChannelId {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: the target channel must be different from this channel"), true, nil
+	}
+
+	originalChannel, appErr := p.API.GetChannel(extra.ChannelId)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", extra.ChannelId)
+	}
+	_, appErr = p.API.GetChannelMember(channelID, extra.UserId)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", channelID)), true, nil
+	}
+	targetChannel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", channelID)
+	}
+
+	pinnedPosts, err := p.getPinnedPosts(originalChannel.Id)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(pinnedPosts) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "This channel has no pinned messages to copy"), false, nil
+	}
+
+	// The pinned posts are validated together so that the max thread count
+	// applies to the whole copy.
+	wpl := &WranglerPostList{Posts: pinnedPosts}
+	wpl.updateMetadata()
+	response, userErr, err := p.validateMoveOrCopy(wpl, originalChannel, targetChannel, extra)
+	if response != nil || err != nil {
+		return response, userErr, err
+	}
+
+	response, err = p.validateFileLimits(wpl, "copied")
+	if response != nil || err != nil {
+		return response, true, err
+	}
+
+	p.API.LogInfo("Wrangler is copying pinned messages",
+		"user_id", extra.UserId,
+		"original_channel_id", originalChannel.Id,
+		"new_channel_id", targetChannel.Id,
+		"pinned_post_count", len(pinnedPosts),
+	)
+
+	var copied, skipped int
+	fileLimits := p.getConfiguration().FileLimiter()
+	for _, post := range pinnedPosts {
+		err = p.copyPinnedPost(post, targetChannel, fileLimits)
+		if err != nil {
+			p.API.LogError("Unable to copy pinned message",
+				"error", err.Error(),
+				"post_id", post.Id,
+			)
+			skipped++
+			continue
+		}
+		copied++
+	}
+
+	p.API.LogInfo("Wrangler pinned message copy complete",
+		"user_id", extra.UserId,
+		"new_channel_id", targetChannel.Id,
+		"copied_post_count", copied,
+		"skipped_post_count", skipped,
+	)
+
+	msg := fmt.Sprintf("%d pinned message(s) were copied and pinned in ~%s.", copied, targetChannel.Name)
+	if skipped != 0 {
+		msg += fmt.Sprintf(" %d pinned message(s) could not be copied and were skipped; check the plugin logs for details.", skipped)
+	}
+	msg += getSkippedFilesNote(fileLimits)
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// getPinnedPosts returns the pinned posts of a channel, oldest first.
+func (p *Plugin) getPinnedPosts(channelID string) ([]*model.Post, error) {
+	var pinnedPosts []*model.Post
+	for page := 0; ; page++ {
+		channelPosts, appErr := p.API.GetPostsForChannel(channelID, page, pinnedScanPageSize)
+		if appErr != nil {
+			return nil, errors.Wrap(appErr, "unable to get channel posts to find pinned messages")
+		}
+
+		for _, post := range channelPosts.ToSlice() {
+			if post.IsPinned && !post.IsSystemMessage() {
+				pinnedPosts = append(pinnedPosts, post)
+			}
+		}
+
+		if len(channelPosts.Order) < pinnedScanPageSize {
+			break
+		}
+	}
+
+	sort.Slice(pinnedPosts, func(i, j int) bool {
+		return pinnedPosts[i].CreateAt < pinnedPosts[j].CreateAt
+	})
+
+	return pinnedPosts, nil
+}
+
+// copyPinnedPost recreates a pinned post as a new pinned root post in the
+// target channel. Pinned replies are copied without their thread. The file
+// limits, when set, are shared by all the pinned posts that are copied.
+func (p *Plugin) copyPinnedPost(post *model.Post, targetChannel *model.Channel, fileLimits *fileLimiter) error {
+	rootPost := post.Clone()
+	rootPost.RootId = ""
+	rootPost.ParentId = ""
+
+	wpl := &WranglerPostList{Posts: []*model.Post{rootPost}}
+	wpl.updateMetadata()
+	newPost, err := p.copyWranglerPostlist(context.Background(), wpl, targetChannel, copyOptions{fileLimits: fileLimits})
+	if err != nil {
+		return err
+	}
+
+	if !newPost.IsPinned {
+		newPost.IsPinned = true
+		_, appErr := p.API.UpdatePost(newPost)
+		if appErr != nil {
+			return errors.Wrap(appErr, "unable to pin copied post")
+		}
+	}
+
+	return nil
+}
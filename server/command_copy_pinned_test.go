@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyPinnedCommand(t *testing.T) {
+	team := &model.Team{Id: model.NewId(), Name: "team-1"}
+	originalChannel := &model.Channel{Id: model.NewId(), TeamId: team.Id, Name: "original-channel", Type: model.CHANNEL_OPEN}
+	targetChannel := &model.Channel{Id: model.NewId(), TeamId: team.Id, Name: "target-channel", Type: model.CHANNEL_OPEN}
+	userID := model.NewId()
+
+	newPost := func(message string, createAt int64, pinned bool) *model.Post {
+		return &model.Post{
+			Id:        model.NewId(),
+			UserId:    userID,
+			ChannelId: originalChannel.Id,
+			Message:   message,
+			CreateAt:  createAt,
+			IsPinned:  pinned,
+		}
+	}
+	pinned1 := newPost("pinned 1", 1000, true)
+	pinned2 := newPost("pinned 2", 2000, true)
+	failing := newPost("failing", 3000, true)
+	pinnedReply := newPost("pinned reply", 4000, true)
+	pinnedReply.RootId = pinned1.Id
+	pinnedReply.ParentId = pinned1.Id
+	unpinned := newPost("unpinned", 5000, false)
+
+	postList := model.NewPostList()
+	for _, post := range []*model.Post{pinnedReply, unpinned, failing, pinned2, pinned1} {
+		postList.AddPost(post)
+		postList.AddOrder(post.Id)
+	}
+
+	setup := func(config *configuration) (*plugintest.API, *Plugin) {
+		api := &plugintest.API{}
+		api.On("GetChannel", originalChannel.Id).Return(originalChannel, nil)
+		api.On("GetChannel", targetChannel.Id).Return(targetChannel, nil)
+		api.On("GetChannelMember", targetChannel.Id, userID).Return(mockGenerateChannelMember(), nil)
+		api.On("GetPostsForChannel", originalChannel.Id, 0, pinnedScanPageSize).Return(postList, nil)
+		api.On("GetReactions", mock.AnythingOfType("string")).Return(nil, nil)
+		api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == failing.Message
+		})).Return(nil, model.NewAppError("test", "failed", nil, "", http.StatusInternalServerError))
+		api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == pinned1.Message
+		})).Return(&model.Post{Id: model.NewId(), IsPinned: true}, nil)
+		api.On("CreatePost", mock.Anything).Return(&model.Post{Id: model.NewId()}, nil)
+		api.On("UpdatePost", mock.Anything).Return(&model.Post{}, nil)
+		mockLogs(api)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(config)
+
+		return api, plugin
+	}
+
+	extra := &model.CommandArgs{ChannelId: originalChannel.Id, UserId: userID}
+
+	t.Run("missing args", func(t *testing.T) {
+		_, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runCopyPinnedCommand([]string{}, extra)
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing arguments; expected the destination channel ID")
+	})
+
+	t.Run("same channel", func(t *testing.T) {
+		_, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runCopyPinnedCommand([]string{originalChannel.Id}, extra)
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: the target channel must be different from this channel", resp.Text)
+	})
+
+	t.Run("over the max thread count", func(t *testing.T) {
+		api, plugin := setup(&configuration{MoveThreadMaxCount: "3"})
+
+		resp, isUserError, err := plugin.runCopyPinnedCommand([]string{targetChannel.Id}, extra)
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: the thread is 4 posts long, but this command is configured to only move threads of up to 3 posts", resp.Text)
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("copy pinned messages", func(t *testing.T) {
+		api, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runCopyPinnedCommand([]string{targetChannel.Id}, extra)
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "3 pinned message(s) were copied and pinned in ~target-channel. 1 pinned message(s) could not be copied and were skipped; check the plugin logs for details.", resp.Text)
+
+		api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == unpinned.Message
+		}))
+		api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == pinnedReply.Message && post.RootId == "" && post.ChannelId == targetChannel.Id
+		}))
+		// Only the posts that weren't pinned on creation are updated.
+		api.AssertNumberOfCalls(t, "UpdatePost", 2)
+	})
+}
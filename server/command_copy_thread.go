@@ -1,28 +1,117 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
 )
 
-const copyThreadUsage = `/wrangler copy thread [MESSAGE_ID] [CHANNEL_ID]
+const (
+	copyThreadUsage = `/wrangler copy thread [MESSAGE_ID] [CHANNEL_ID]
   Copy a given message, along with the thread it belongs to, to a given channel
     - This can be on any channel in any team that you have joined
     - Obtain the message ID by running '/wrangler list messages' or via the 'Permalink' message dropdown option (it's the last part of the URL)
-    - Obtain the channel ID by running '/wrangler list channels' or via the channel 'View Info' option`
+    - Obtain the channel ID by running '/wrangler list channels' or via the channel 'View Info' option
+    - The channel ID can be omitted once you set a default destination with '/wrangler prefs set default-destination'
+	Flags:
+%s`
 
-func getCopyThreadMessage() string {
-	return codeBlock(fmt.Sprintf("`Error: missing arguments\n\n%s", copyThreadUsage))
+	flagCopyThreadLimit          = "limit"
+	flagCopyThreadCollapse       = "collapse"
+	flagCopyThreadAllowDuplicate = "allow-duplicate"
+	flagCopyThreadAllowBlocked   = "allow-blocked-content"
+)
+
+type copyThreadOptions struct {
+	limit               int
+	collapse            bool
+	allowDuplicate      bool
+	allowBlockedContent bool
+	dateRange           dateRange
+}
+
+func getCopyThreadFlagSet() *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet("copy thread", pflag.ContinueOnError)
+	flagSet.Int(flagCopyThreadLimit, 0, "Only copy the root message and the first given number of replies. Leave unset to copy the whole thread")
+	flagSet.Bool(flagCopyThreadCollapse, false, "Merge consecutive messages by the same author into single messages in the copy")
+	flagSet.Bool(flagCopyThreadAllowDuplicate, false, "Copy the thread even if it was already copied to the channel when Wrangler is configured to warn about repeated copies")
+	flagSet.Bool(flagCopyThreadAllowBlocked, false, "Copy a thread with messages matching a blocked content pattern (system admins only)")
+	addDateRangeFlags(flagSet, "copy")
+
+	return flagSet
+}
+
+func parseCopyThreadFlagArgs(args []string) (copyThreadOptions, error) {
+	var options copyThreadOptions
+
+	flagSet := getCopyThreadFlagSet()
+	err := flagSet.Parse(args)
+	if err != nil {
+		return options, errors.Wrap(err, "unable to parse copy thread flag args")
+	}
+
+	options.limit, err = flagSet.GetInt(flagCopyThreadLimit)
+	if err != nil {
+		return options, err
+	}
+	if options.limit < 0 {
+		return options, fmt.Errorf("%s (%d) must not be negative", flagCopyThreadLimit, options.limit)
+	}
+
+	options.collapse, err = flagSet.GetBool(flagCopyThreadCollapse)
+	if err != nil {
+		return options, err
+	}
+
+	options.allowDuplicate, err = flagSet.GetBool(flagCopyThreadAllowDuplicate)
+	if err != nil {
+		return options, err
+	}
+
+	options.allowBlockedContent, err = flagSet.GetBool(flagCopyThreadAllowBlocked)
+	if err != nil {
+		return options, err
+	}
+
+	options.dateRange, err = parseDateRangeFlags(flagSet)
+	if err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+func getCopyThreadUsage() string {
+	return fmt.Sprintf(copyThreadUsage, getCopyThreadFlagSet().FlagUsages())
 }
 
 func (p *Plugin) runCopyThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
-	if len(args) < 2 {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getCopyThreadMessage()), true, nil
+	if !p.getConfiguration().OperationEnabled(enabledOperationCopy) {
+		return getOperationDisabledResponse("copying threads"), true, nil
+	}
+	if !p.authorizedOperationUser(extra.UserId, p.getConfiguration().CopyPermittedRoleNames()) {
+		return getOperationRolesResponse("copy threads"), true, nil
+	}
+	if p.accountTooNew(extra.UserId) {
+		return p.getAccountTooNewResponse("copy threads"), true, nil
+	}
+	args, response, err := p.withDefaultDestination(args, getCopyThreadFlagSet(), extra.UserId)
+	if response != nil || err != nil {
+		return response, true, err
 	}
-	postID := args[0]
-	channelID := args[1]
+	positional, err := parseCommandArgs(args, getCopyThreadFlagSet(), messageIDArg, channelIDArg.withName("destination channel ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, getCopyThreadUsage()), true, nil
+	}
+	options, err := parseCopyThreadFlagArgs(args)
+	if err != nil {
+		return nil, true, err
+	}
+	postID := positional[0]
+	channelID := positional[1]
 
 	postListResponse, appErr := p.API.GetPostThread(postID)
 	if appErr != nil {
@@ -30,6 +119,26 @@ func (p *Plugin) runCopyThreadCommand(args []string, extra *model.CommandArgs) (
 	}
 	wpl := buildWranglerPostList(postListResponse)
 
+	// Trimming happens before validation so that the max thread count applies
+	// to the messages that are actually copied.
+	var totalReplies, matchedReplies int
+	if options.dateRange.isSet() && wpl.NumPosts() != 0 {
+		totalReplies = wpl.NumPosts() - 1
+		matchedReplies = wpl.FilterRepliesByDateRange(options.dateRange)
+		if matchedReplies == 0 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: none of the %d replies of the thread were posted %s", totalReplies, options.dateRange)), true, nil
+		}
+	}
+	var truncated bool
+	if options.limit != 0 {
+		truncated = wpl.TrimReplies(options.limit)
+	}
+	var collapsed int
+	if options.collapse {
+		config := p.getConfiguration()
+		collapsed = wpl.CollapseConsecutivePosts(config.CollapseGap(), config.CollapseSeparator())
+	}
+
 	originalChannel, appErr := p.API.GetChannel(extra.ChannelId)
 	if appErr != nil {
 		return nil, false, fmt.Errorf("unable to get channel with ID %s", extra.ChannelId)
@@ -48,28 +157,107 @@ func (p *Plugin) runCopyThreadCommand(args []string, extra *model.CommandArgs) (
 		return response, userErr, err
 	}
 
+	response, err = p.validateFileLimits(wpl, "copied")
+	if response != nil || err != nil {
+		return response, true, err
+	}
+
+	blockedPattern := findBlockedContentPattern(wpl.Posts, p.getConfiguration().BlockedContentPatternsList())
+	if blockedPattern != nil {
+		if !options.allowBlockedContent || !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread contains a message matching the blocked content pattern `%s`, which may be sensitive. Remove the sensitive content before copying the thread; system admins can copy it anyway with --%s.", blockedPattern.String(), flagCopyThreadAllowBlocked)), true, nil
+		}
+
+		p.API.LogWarn("Wrangler blocked content override used",
+			"user_id", extra.UserId,
+			"original_post_id", wpl.RootPost().Id,
+			"pattern", blockedPattern.String(),
+		)
+	}
+
 	targetTeam, appErr := p.API.GetTeam(targetChannel.TeamId)
 	if appErr != nil {
 		return nil, false, fmt.Errorf("unable to get team with ID %s", targetChannel.TeamId)
 	}
 
+	response, err = p.checkDuplicateCopy(wpl.RootPost().Id, targetChannel, targetTeam, options)
+	if response != nil || err != nil {
+		return response, true, err
+	}
+
+	ctx, cancel := p.newOperationContext()
+	defer cancel()
+
+	release, response := p.acquireOperationSlot(ctx, "copy", extra)
+	if response != nil {
+		return response, false, nil
+	}
+	defer release()
+
 	p.API.LogInfo("Wrangler is copying a thread",
 		"user_id", extra.UserId,
 		"original_post_id", wpl.RootPost().Id,
 		"original_channel_id", originalChannel.Id,
 	)
 
-	newRootPost, err := p.copyWranglerPostlist(wpl, targetChannel)
+	originalTeam, appErr := p.API.GetTeam(extra.TeamId)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get team with ID %s", extra.TeamId)
+	}
+	originalPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, originalTeam.Name, wpl.RootPost().Id)
+
+	var copyOpts copyOptions
+	config := p.getConfiguration()
+	if targetChannel.TeamId != originalChannel.TeamId || config.IsArchiveChannel(targetChannel.Id) {
+		copyOpts.compression = config.ImageCompressor()
+	}
+	if config.LinkBackToOriginalValue() != linkBackOff {
+		copyOpts.rootLinkBack = originalPostLink
+	}
+	copyOpts.fileLimits = config.FileLimiter()
+	copyOpts.removeBroadcasts = config.ReplyBroadcastsValue() == replyBroadcastsRemove
+	copyOpts.provenance = newProvenanceTracker()
+
+	newRootPost, err := p.copyWranglerPostlist(ctx, wpl, targetChannel, copyOpts)
+	if errors.Cause(err) == context.DeadlineExceeded {
+		// Copies that reach the timeout are removed again so that no partial
+		// thread is left in the target channel.
+		for _, newPostID := range copyOpts.provenance.newPostIDs() {
+			appErr = p.API.DeletePost(newPostID)
+			if appErr != nil {
+				p.API.LogError("Unable to delete partially copied post", "new_post_id", newPostID, "error", appErr.Error())
+			}
+		}
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread copy took longer than the configured timeout of %s and was stopped. Try copying a smaller thread, or ask a system admin to increase the operation timeout.", config.OperationTimeout())), false, nil
+	}
 	if err != nil {
 		return nil, false, err
 	}
 
+	footer := "This thread was copied from another channel"
+	compressed := copyOpts.compression != nil && copyOpts.compression.compressedCount != 0
+	if truncated || compressed {
+		if truncated {
+			footer += fmt.Sprintf(
+				"\n\nThe thread was truncated to its first %d replies; the full thread is available at %s",
+				options.limit, originalPostLink,
+			)
+		}
+		if compressed {
+			footer += fmt.Sprintf(
+				"\n\n%d image(s) were compressed to save storage; the original files are available at %s",
+				copyOpts.compression.compressedCount, originalPostLink,
+			)
+		}
+	}
+	footer += getSkippedFilesNote(copyOpts.fileLimits)
+
 	_, appErr = p.API.CreatePost(&model.Post{
-		UserId:    p.BotUserID,
+		UserId:    p.getAttributionAuthor(extra.UserId, targetChannel.Id),
 		RootId:    newRootPost.Id,
 		ParentId:  newRootPost.Id,
 		ChannelId: targetChannel.Id,
-		Message:   "This thread was copied from another channel",
+		Message:   footer,
 	})
 	if appErr != nil {
 		return nil, false, errors.Wrap(appErr, "unable to create new bot post")
@@ -77,7 +265,7 @@ func (p *Plugin) runCopyThreadCommand(args []string, extra *model.CommandArgs) (
 
 	newPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, targetTeam.Name, newRootPost.Id)
 	_, appErr = p.API.CreatePost(&model.Post{
-		UserId:    p.BotUserID,
+		UserId:    p.getAttributionAuthor(extra.UserId, originalChannel.Id),
 		RootId:    wpl.RootPost().Id,
 		ParentId:  wpl.RootPost().Id,
 		ChannelId: originalChannel.Id,
@@ -92,6 +280,8 @@ func (p *Plugin) runCopyThreadCommand(args []string, extra *model.CommandArgs) (
 		"new_post_id", newRootPost.Id,
 		"new_channel_id", channelID,
 	)
+	p.recordOperation(operationCopy, extra.UserId, originalChannel.Id, channelID, wpl.NumPosts())
+	p.recordThreadCopy(wpl.RootPost().Id, targetChannel.Id, newRootPost.Id)
 
 	if extra.UserId != wpl.RootPost().UserId {
 		// The wrangled thread was not started by the user running the command.
@@ -105,5 +295,51 @@ func (p *Plugin) runCopyThreadCommand(args []string, extra *model.CommandArgs) (
 		}
 	}
 
-	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Thread copy complete"), false, nil
+	msg := "Thread copy complete"
+	verbosity := p.getSuccessVerbosity(extra.UserId)
+	if verbosity == successVerbosityMinimal {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+	}
+	if truncated {
+		msg += fmt.Sprintf("; only the root message and its first %d replies were copied", options.limit)
+	}
+	if options.dateRange.isSet() {
+		msg += fmt.Sprintf("\n\n%d of %d replies were posted %s", matchedReplies, totalReplies, options.dateRange)
+	}
+	if collapsed != 0 {
+		msg += fmt.Sprintf("\n\n%d consecutive message(s) were collapsed into previous messages by the same author", collapsed)
+	}
+	if verbosity == successVerbosityDetailed {
+		msg += fmt.Sprintf(
+			"\n\nThe copy is at %s\n\n| Team | Channel | Messages | Authors |\n| -- | -- | -- | -- |\n| %s | %s | %d | %d |",
+			newPostLink, targetTeam.DisplayName, targetChannel.DisplayName, wpl.NumPosts(), wpl.NumAuthors(),
+		)
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// checkDuplicateCopy returns a response when the thread was already copied to
+// the target channel and Wrangler is configured to warn about or skip
+// repeated copies.
+func (p *Plugin) checkDuplicateCopy(originalRootID string, targetChannel *model.Channel, targetTeam *model.Team, options copyThreadOptions) (*model.CommandResponse, error) {
+	policy := p.getConfiguration().DuplicateCopiesValue()
+	if policy == duplicateCopiesAllow || (policy == duplicateCopiesWarn && options.allowDuplicate) {
+		return nil, nil
+	}
+
+	copyID, err := p.findThreadCopy(originalRootID, targetChannel.Id)
+	if err != nil {
+		return nil, err
+	}
+	if len(copyID) == 0 {
+		return nil, nil
+	}
+
+	copyLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, targetTeam.Name, copyID)
+	if policy == duplicateCopiesSkip {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("This thread was already copied to ~%s, so it wasn't copied again: %s", targetChannel.Name, copyLink)), nil
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: this thread was already copied to ~%s: %s. Run the command again with --%s to copy it anyway.", targetChannel.Name, copyLink, flagCopyThreadAllowDuplicate)), nil
 }
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const (
+	exportThreadUsage = `/wrangler export thread [MESSAGE_ID] [flags]
+  Export a given message, along with the thread it belongs to, as a transcript sent to you by DM
+    - Transcripts that are too long for a single message are split or attached as a file depending on the plugin configuration
+    - JSON transcripts are always attached as a file and can be imported with '/wrangler import'
+	Flags:
+%s`
+
+	flagExportThreadFormat = "format"
+
+	exportFormatMarkdown = "markdown"
+	exportFormatJSON     = "json"
+)
+
+// threadTranscriptVersion is the version of the JSON transcript format.
+const threadTranscriptVersion = 1
+
+// threadTranscript is the JSON transcript of a thread. The first post is the
+// root post.
+type threadTranscript struct {
+	Version    int              `json:"version"`
+	ChannelID  string           `json:"channel_id"`
+	ExportedAt int64            `json:"exported_at"`
+	Posts      []transcriptPost `json:"posts"`
+}
+
+type transcriptPost struct {
+	Username string `json:"username"`
+	Message  string `json:"message"`
+	CreateAt int64  `json:"create_at"`
+}
+
+type exportThreadOptions struct {
+	format string
+}
+
+func getExportThreadFlagSet() *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet("export thread", pflag.ContinueOnError)
+	flagSet.String(flagExportThreadFormat, exportFormatMarkdown, "The transcript format, either markdown or json")
+
+	return flagSet
+}
+
+func parseExportThreadFlagArgs(args []string) (exportThreadOptions, error) {
+	var options exportThreadOptions
+
+	flagSet := getExportThreadFlagSet()
+	err := flagSet.Parse(args)
+	if err != nil {
+		return options, errors.Wrap(err, "unable to parse export thread flag args")
+	}
+
+	options.format, err = flagSet.GetString(flagExportThreadFormat)
+	if err != nil {
+		return options, err
+	}
+	if options.format != exportFormatMarkdown && options.format != exportFormatJSON {
+		return options, errors.Errorf("--%s must be %s or %s", flagExportThreadFormat, exportFormatMarkdown, exportFormatJSON)
+	}
+
+	return options, nil
+}
+
+func getExportThreadUsage() string {
+	return fmt.Sprintf(exportThreadUsage, getExportThreadFlagSet().FlagUsages())
+}
+
+// maxTranscriptPostRunes is the longest part of a transcript that is posted as
+// a single message.
+const maxTranscriptPostRunes = model.POST_MESSAGE_MAX_RUNES_V2
+
+const transcriptTimeFormat = "2006-01-02 15:04 MST"
+
+func (p *Plugin) runExportThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	positional, err := parseCommandArgs(args, getExportThreadFlagSet(), messageIDArg)
+	if err != nil {
+		return getCommandArgsErrorResponse(err, getExportThreadUsage()), true, nil
+	}
+	options, err := parseExportThreadFlagArgs(args)
+	if err != nil {
+		return nil, true, err
+	}
+	postID := positional[0]
+
+	postListResponse, appErr := p.API.GetPostThread(postID)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	wpl := buildWranglerPostList(postListResponse)
+	if wpl.NumPosts() == 0 {
+		return nil, false, fmt.Errorf("unable to get thread of post with ID %s", postID)
+	}
+
+	// Only members of the channel of the thread may read it.
+	_, appErr = p.API.GetChannelMember(wpl.RootPost().ChannelId, extra.UserId)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+
+	channel, appErr := p.API.GetDirectChannel(extra.UserId, p.BotUserID)
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to get direct channel")
+	}
+
+	usernames := p.getAuthorUsernames(wpl.Posts)
+	if options.format == exportFormatJSON {
+		err = p.postTranscriptJSON(channel.Id, wpl, usernames)
+		if err != nil {
+			return nil, false, err
+		}
+
+		p.API.LogInfo("Wrangler exported a thread",
+			"user_id", extra.UserId,
+			"original_post_id", wpl.RootPost().Id,
+			"format", options.format,
+		)
+
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("A JSON transcript of the thread of %d message(s) was sent to you by DM.", wpl.NumPosts())), false, nil
+	}
+
+	entries := renderTranscriptEntries(wpl, usernames, p.getUserLocation(extra.UserId))
+	chunks, splittable := splitTranscript(entries, maxTranscriptPostRunes)
+	attach := len(chunks) > 1 && (!splittable || p.getConfiguration().ExportOversizedAsFile())
+
+	if attach {
+		err = p.postTranscriptFile(channel.Id, fmt.Sprintf("thread-%s.md", wpl.RootPost().Id), []byte(strings.Join(entries, "\n\n")))
+	} else {
+		err = p.postTranscriptChunks(channel.Id, chunks)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.API.LogInfo("Wrangler exported a thread",
+		"user_id", extra.UserId,
+		"original_post_id", wpl.RootPost().Id,
+		"transcript_part_count", len(chunks),
+		"attached", attach,
+	)
+
+	msg := fmt.Sprintf("A transcript of the thread of %d message(s) was sent to you by DM.", wpl.NumPosts())
+	if attach {
+		msg += " It was too long for a single message and was attached as a file."
+	} else if len(chunks) > 1 {
+		msg += fmt.Sprintf(" It was too long for a single message and was split across %d messages in a thread.", len(chunks))
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// getAuthorUsernames returns the usernames of the authors of the posts, keyed
+// by user ID. Authors that can't be looked up are left out.
+func (p *Plugin) getAuthorUsernames(posts []*model.Post) map[string]string {
+	usernames := make(map[string]string)
+	checked := make(map[string]bool)
+	for _, post := range posts {
+		if checked[post.UserId] {
+			continue
+		}
+		checked[post.UserId] = true
+
+		user, appErr := p.API.GetUser(post.UserId)
+		if appErr == nil {
+			usernames[post.UserId] = user.Username
+		}
+	}
+
+	return usernames
+}
+
+// renderTranscriptEntries returns the transcript entry of each post of the
+// thread, naming the author and the time it was posted in the given time zone.
+func renderTranscriptEntries(wpl *WranglerPostList, usernames map[string]string, location *time.Location) []string {
+	var entries []string
+	for _, post := range wpl.Posts {
+		author := "unknown user"
+		if username, ok := usernames[post.UserId]; ok {
+			author = "@" + username
+		}
+
+		createAt := time.Unix(0, post.CreateAt*int64(time.Millisecond)).In(location)
+		entries = append(entries, fmt.Sprintf("**%s** (%s):\n%s", author, createAt.Format(transcriptTimeFormat), post.Message))
+	}
+
+	return entries
+}
+
+// splitTranscript packs the transcript entries into parts of at most maxRunes
+// runes. Entries are only split when they don't fit in a part on their own,
+// and then only between lines outside of code blocks. False is returned if
+// a code block or a line is too long to fit in a part.
+func splitTranscript(entries []string, maxRunes int) ([]string, bool) {
+	var units []string
+	for _, entry := range entries {
+		if utf8.RuneCountInString(entry) <= maxRunes {
+			units = append(units, entry)
+			continue
+		}
+
+		blocks := splitOutsideCodeBlocks(entry)
+		for _, block := range blocks {
+			if utf8.RuneCountInString(block) > maxRunes {
+				return []string{strings.Join(entries, "\n\n")}, false
+			}
+		}
+		units = append(units, packTranscriptUnits(blocks, "\n", maxRunes)...)
+	}
+
+	return packTranscriptUnits(units, "\n\n", maxRunes), true
+}
+
+// packTranscriptUnits joins consecutive units with the separator into parts of
+// at most maxRunes runes. Units must not be longer than maxRunes.
+func packTranscriptUnits(units []string, separator string, maxRunes int) []string {
+	var parts []string
+	var current []string
+	var currentRunes int
+	for _, unit := range units {
+		unitRunes := utf8.RuneCountInString(unit)
+		if len(current) != 0 && currentRunes+len(separator)+unitRunes > maxRunes {
+			parts = append(parts, strings.Join(current, separator))
+			current, currentRunes = nil, 0
+		}
+		if len(current) != 0 {
+			currentRunes += len(separator)
+		}
+		current = append(current, unit)
+		currentRunes += unitRunes
+	}
+	if len(current) != 0 {
+		parts = append(parts, strings.Join(current, separator))
+	}
+
+	return parts
+}
+
+// splitOutsideCodeBlocks splits the text into its lines, keeping each fenced
+// code block together.
+func splitOutsideCodeBlocks(text string) []string {
+	var blocks []string
+	var codeBlock []string
+	for _, line := range strings.Split(text, "\n") {
+		isFence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		switch {
+		case len(codeBlock) != 0:
+			codeBlock = append(codeBlock, line)
+			if isFence {
+				blocks = append(blocks, strings.Join(codeBlock, "\n"))
+				codeBlock = nil
+			}
+		case isFence:
+			codeBlock = []string{line}
+		default:
+			blocks = append(blocks, line)
+		}
+	}
+	if len(codeBlock) != 0 {
+		// Unterminated code blocks run to the end of the message.
+		blocks = append(blocks, strings.Join(codeBlock, "\n"))
+	}
+
+	return blocks
+}
+
+// postTranscriptChunks posts the first part of the transcript as a new post
+// and any further parts as replies to it.
+func (p *Plugin) postTranscriptChunks(channelID string, chunks []string) error {
+	var rootID string
+	for i, chunk := range chunks {
+		post, appErr := p.API.CreatePost(&model.Post{
+			UserId:    p.BotUserID,
+			ChannelId: channelID,
+			RootId:    rootID,
+			ParentId:  rootID,
+			Message:   chunk,
+		})
+		if appErr != nil {
+			return errors.Wrapf(appErr, "unable to post part %d of the transcript", i+1)
+		}
+		if i == 0 {
+			rootID = post.Id
+		}
+	}
+
+	return nil
+}
+
+// postTranscriptJSON posts the JSON transcript of the thread as an attached
+// file.
+func (p *Plugin) postTranscriptJSON(channelID string, wpl *WranglerPostList, usernames map[string]string) error {
+	transcript := threadTranscript{
+		Version:    threadTranscriptVersion,
+		ChannelID:  wpl.RootPost().ChannelId,
+		ExportedAt: model.GetMillis(),
+	}
+	for _, post := range wpl.Posts {
+		transcript.Posts = append(transcript.Posts, transcriptPost{
+			Username: usernames[post.UserId],
+			Message:  post.Message,
+			CreateAt: post.CreateAt,
+		})
+	}
+
+	data, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal JSON transcript")
+	}
+
+	return p.postTranscriptFile(channelID, fmt.Sprintf("thread-%s.json", wpl.RootPost().Id), data)
+}
+
+// postTranscriptFile posts the transcript as an attached file.
+func (p *Plugin) postTranscriptFile(channelID, filename string, transcript []byte) error {
+	fileInfo, appErr := p.API.UploadFile(transcript, channelID, filename)
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to upload transcript file")
+	}
+
+	_, appErr = p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		Message:   "The transcript of the thread is attached.",
+		FileIds:   []string{fileInfo.Id},
+	})
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to post transcript file")
+	}
+
+	return nil
+}
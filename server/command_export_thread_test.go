@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportThreadCommand(t *testing.T) {
+	setup := func(replyCount int, config *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(replyCount)
+		f.api.On("UploadFile", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&model.FileInfo{Id: model.NewId()}, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+
+		return f, plugin
+	}
+
+	t.Run("no arguments", func(t *testing.T) {
+		_, plugin := setup(1, &configuration{})
+		resp, isUserError, err := plugin.runExportThreadCommand([]string{}, &model.CommandArgs{})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing arguments")
+	})
+
+	t.Run("short thread", func(t *testing.T) {
+		f, plugin := setup(2, &configuration{})
+		resp, isUserError, err := plugin.runExportThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "A transcript of the thread of 3 message(s) was sent to you by DM.", resp.Text)
+
+		f.api.AssertNumberOfCalls(t, "CreatePost", 1)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return strings.HasPrefix(post.Message, "**@active.user** (1970-01-01 00:00 UTC):\nThis is the root message") &&
+				strings.Contains(post.Message, "This is reply 2")
+		}))
+	})
+
+	longThread := func(f *threadTestFixture) {
+		for i, reply := range f.replies {
+			reply.Message = strings.Repeat("This is a long reply. ", 200)
+			if i == 10 {
+				reply.Message = "```\n" + strings.Repeat("code line\n", 500) + "```"
+			}
+		}
+	}
+
+	t.Run("very long thread is split", func(t *testing.T) {
+		f, plugin := setup(100, &configuration{})
+		longThread(f)
+
+		var messages []string
+		f.unsetMock("CreatePost")
+		f.api.On("CreatePost", mock.Anything).Return(func(post *model.Post) *model.Post {
+			messages = append(messages, post.Message)
+			return f.newPost
+		}, nil)
+
+		resp, isUserError, err := plugin.runExportThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "was split across")
+
+		require.True(t, len(messages) > 1)
+		for _, message := range messages {
+			assert.True(t, utf8.RuneCountInString(message) <= maxTranscriptPostRunes)
+			assert.Equal(t, 0, strings.Count(message, "```")%2, "code block split across messages")
+		}
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.RootId == f.newPost.Id
+		}))
+		f.api.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("very long thread as file", func(t *testing.T) {
+		f, plugin := setup(100, &configuration{OversizedTranscripts: oversizedTranscriptsFile})
+		longThread(f)
+
+		resp, isUserError, err := plugin.runExportThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "was attached as a file")
+		f.api.AssertCalled(t, "UploadFile", mock.Anything, mock.AnythingOfType("string"), "thread-"+f.rootPost.Id+".md")
+		f.api.AssertNumberOfCalls(t, "CreatePost", 1)
+	})
+}
+
+func TestSplitTranscript(t *testing.T) {
+	t.Run("entries are kept whole", func(t *testing.T) {
+		chunks, ok := splitTranscript([]string{"aaaa", "bbbb", "cccc"}, 10)
+		require.True(t, ok)
+		assert.Equal(t, []string{"aaaa\n\nbbbb", "cccc"}, chunks)
+	})
+
+	t.Run("long entries are split outside of code blocks", func(t *testing.T) {
+		chunks, ok := splitTranscript([]string{"line 1\n```\ncode\n```\nline 2"}, 16)
+		require.True(t, ok)
+		assert.Equal(t, []string{"line 1", "```\ncode\n```", "line 2"}, chunks)
+	})
+
+	t.Run("oversized code block", func(t *testing.T) {
+		chunks, ok := splitTranscript([]string{"```\n" + strings.Repeat("code\n", 10) + "```"}, 16)
+		require.False(t, ok)
+		assert.Len(t, chunks, 1)
+	})
+}
+
+func TestTranscriptTimeZone(t *testing.T) {
+	setup := func(timezone model.StringMap, config *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+		f.rootPost.CreateAt = time.Date(2020, time.June, 1, 12, 30, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+		f.replies[0].CreateAt = f.rootPost.CreateAt + 1
+		f.unsetMock("GetUser")
+		f.api.On("GetUser", mock.AnythingOfType("string")).Return(&model.User{Id: model.NewId(), Username: "active.user", Timezone: timezone}, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+		require.NoError(t, plugin.configuration.IsValid())
+
+		return f, plugin
+	}
+	newYork := model.StringMap{"useAutomaticTimezone": "false", "manualTimezone": "America/New_York"}
+
+	t.Run("export in the time zone of the user", func(t *testing.T) {
+		f, plugin := setup(newYork, &configuration{})
+
+		_, _, err := plugin.runExportThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return strings.HasPrefix(post.Message, "**@active.user** (2020-06-01 08:30 EDT):\nThis is the root message")
+		}))
+	})
+
+	t.Run("configured default for users without a time zone", func(t *testing.T) {
+		f, plugin := setup(nil, &configuration{DefaultTimezone: "Europe/Paris"})
+
+		_, _, err := plugin.runExportThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return strings.HasPrefix(post.Message, "**@active.user** (2020-06-01 14:30 CEST):\nThis is the root message")
+		}))
+	})
+
+	t.Run("original timestamp header of a move", func(t *testing.T) {
+		f, plugin := setup(newYork, &configuration{EnableOriginalTimestampHeader: true})
+
+		_, _, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "**Originally posted on Jun 1, 2020 at 08:30 EDT**\n\nThis is the root message"
+		}))
+	})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const importUsage = `/wrangler import [CHANNEL_ID] [MESSAGE_ID]
+  Recreate a thread from a JSON transcript in a given channel
+    - The message must have the JSON transcript attached, as created by '/wrangler export thread --format=json'
+    - Authors are matched by username; messages by authors who don't exist or aren't members of the channel are recreated under the Wrangler bot
+    - Requires the 'import' experimental feature`
+
+// maxImportFileSize is the largest JSON transcript that is imported.
+const maxImportFileSize = 10 * 1024 * 1024
+
+func (p *Plugin) runImportCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	positional, err := parseCommandArgs(args, nil, channelIDArg.withName("destination channel ID"), messageIDArg.withName("transcript message ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, importUsage), true, nil
+	}
+	channelID := positional[0]
+	postID := positional[1]
+
+	_, appErr := p.API.GetChannelMember(channelID, extra.UserId)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", channelID)), true, nil
+	}
+	targetChannel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", channelID)
+	}
+
+	post, appErr := p.API.GetPost(postID)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	_, appErr = p.API.GetChannelMember(post.ChannelId, extra.UserId)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	if len(post.FileIds) != 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: the message must have exactly one JSON transcript attached"), true, nil
+	}
+
+	fileInfo, appErr := p.API.GetFileInfo(post.FileIds[0])
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to get transcript file info")
+	}
+	if fileInfo.Size > maxImportFileSize {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the transcript file is larger than the %d MB import limit", maxImportFileSize/1024/1024)), true, nil
+	}
+	data, appErr := p.API.GetFile(fileInfo.Id)
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to get transcript file")
+	}
+
+	transcript, err := parseThreadTranscript(data)
+	if err != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the attached file is not a valid thread transcript: %s", err.Error())), true, nil
+	}
+
+	config := p.getConfiguration()
+	if config.MaxThreadCountMoveSizeInt() != 0 && config.MaxThreadCountMoveSizeInt() < len(transcript.Posts) {
+		if !p.canBypassMaxCount(extra.UserId, config) {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread is %d posts long, but this command is configured to only move threads of up to %d posts", len(transcript.Posts), config.MaxThreadCountMoveSizeInt())), true, nil
+		}
+	}
+
+	p.API.LogInfo("Wrangler is importing a thread",
+		"user_id", extra.UserId,
+		"transcript_post_id", postID,
+		"new_channel_id", targetChannel.Id,
+		"post_count", len(transcript.Posts),
+	)
+
+	posts, botAttributed := p.mapTranscriptAuthors(transcript, targetChannel)
+	wpl := &WranglerPostList{Posts: posts}
+	wpl.updateMetadata()
+	newRootPost, err := p.copyWranglerPostlist(context.Background(), wpl, targetChannel, copyOptions{botAttributedAuthors: botAttributed})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "unable to recreate imported thread")
+	}
+
+	p.API.LogInfo("Wrangler thread import complete",
+		"user_id", extra.UserId,
+		"new_post_id", newRootPost.Id,
+		"new_channel_id", targetChannel.Id,
+	)
+
+	newPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, p.getTeamName(targetChannel.TeamId), newRootPost.Id)
+	msg := fmt.Sprintf("A thread of %d message(s) has been imported: %s", len(transcript.Posts), newPostLink)
+	if len(botAttributed) != 0 {
+		msg += fmt.Sprintf("\nMessages by %d author(s) who don't exist here or aren't members of ~%s were recreated under the Wrangler bot.", len(botAttributed), targetChannel.Name)
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// parseThreadTranscript parses and validates a JSON thread transcript.
+func parseThreadTranscript(data []byte) (*threadTranscript, error) {
+	var transcript threadTranscript
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&transcript)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse JSON")
+	}
+
+	if transcript.Version != threadTranscriptVersion {
+		return nil, fmt.Errorf("unsupported transcript version %d", transcript.Version)
+	}
+	if len(transcript.Posts) == 0 {
+		return nil, errors.New("the transcript contains no messages")
+	}
+	for i, post := range transcript.Posts {
+		if len(strings.TrimSpace(post.Message)) == 0 {
+			return nil, fmt.Errorf("message %d is empty", i+1)
+		}
+		if post.CreateAt <= 0 {
+			return nil, fmt.Errorf("message %d has no valid create_at time", i+1)
+		}
+		if i != 0 && post.CreateAt < transcript.Posts[i-1].CreateAt {
+			return nil, fmt.Errorf("message %d is older than the message before it", i+1)
+		}
+	}
+
+	return &transcript, nil
+}
+
+// mapTranscriptAuthors returns the posts of the transcript authored by the
+// users with matching usernames. Posts by authors who don't exist, are
+// deactivated or aren't members of the target channel are attributed to
+// placeholder IDs that are returned along with their usernames so that the
+// posts are recreated under the Wrangler bot.
+func (p *Plugin) mapTranscriptAuthors(transcript *threadTranscript, targetChannel *model.Channel) ([]*model.Post, map[string]string) {
+	userIDs := make(map[string]string)
+	botAttributed := make(map[string]string)
+
+	var posts []*model.Post
+	for _, transcriptPost := range transcript.Posts {
+		username := strings.ToLower(strings.TrimPrefix(transcriptPost.Username, "@"))
+		userID, ok := userIDs[username]
+		if !ok {
+			userID = p.findImportAuthor(username, targetChannel)
+			if len(userID) == 0 {
+				// A placeholder ID keeps the attribution per author.
+				userID = model.NewId()
+				if len(username) == 0 {
+					username = "unknown"
+				}
+				botAttributed[userID] = username
+			}
+			userIDs[username] = userID
+		}
+
+		posts = append(posts, &model.Post{
+			UserId:   userID,
+			Message:  transcriptPost.Message,
+			CreateAt: transcriptPost.CreateAt,
+		})
+	}
+
+	return posts, botAttributed
+}
+
+// findImportAuthor returns the ID of the active user with the given username
+// if they are a member of the target channel, or an empty string otherwise.
+func (p *Plugin) findImportAuthor(username string, targetChannel *model.Channel) string {
+	if len(username) == 0 {
+		return ""
+	}
+
+	user, appErr := p.API.GetUserByUsername(username)
+	if appErr != nil || user.DeleteAt != 0 {
+		return ""
+	}
+	_, appErr = p.API.GetChannelMember(targetChannel.Id, user.Id)
+	if appErr != nil {
+		return ""
+	}
+
+	return user.Id
+}
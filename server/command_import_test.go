@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCommand(t *testing.T) {
+	validTranscript := `{
+  "version": 1,
+  "channel_id": "channel1",
+  "exported_at": 100,
+  "posts": [
+    {"username": "active.user", "message": "This is the root message", "create_at": 1},
+    {"username": "missing.user", "message": "This is reply 1", "create_at": 2},
+    {"username": "active.user", "message": "This is reply 2", "create_at": 3}
+  ]
+}`
+
+	setup := func(transcript string) (*threadTestFixture, *Plugin, *model.Post) {
+		f := newThreadTestFixture(0)
+
+		fileInfo := &model.FileInfo{Id: model.NewId(), Size: int64(len(transcript))}
+		transcriptPost := &model.Post{
+			Id:        model.NewId(),
+			ChannelId: f.originalChannel.Id,
+			FileIds:   []string{fileInfo.Id},
+		}
+		f.api.On("GetPost", transcriptPost.Id).Return(transcriptPost, nil)
+		f.api.On("GetFileInfo", fileInfo.Id).Return(fileInfo, nil)
+		f.api.On("GetFile", fileInfo.Id).Return([]byte(transcript), nil)
+		f.api.On("GetUserByUsername", "active.user").Return(&model.User{Id: "activeuserid", Username: "active.user"}, nil)
+		f.api.On("GetUserByUsername", "missing.user").Return(nil, &model.AppError{Message: "not found"})
+
+		plugin := &Plugin{BotUserID: "botid"}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{})
+
+		return f, plugin, transcriptPost
+	}
+
+	t.Run("no arguments", func(t *testing.T) {
+		_, plugin, _ := setup(validTranscript)
+		resp, isUserError, err := plugin.runImportCommand([]string{}, &model.CommandArgs{})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing arguments")
+	})
+
+	t.Run("no attachment", func(t *testing.T) {
+		f, plugin, transcriptPost := setup(validTranscript)
+		transcriptPost.FileIds = nil
+
+		resp, isUserError, err := plugin.runImportCommand([]string{f.targetChannel.Id, transcriptPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: the message must have exactly one JSON transcript attached", resp.Text)
+	})
+
+	t.Run("invalid transcripts", func(t *testing.T) {
+		for name, transcript := range map[string]string{
+			"malformed":     `{"version": 1, "posts": [`,
+			"wrong version": `{"version": 2, "posts": [{"username": "active.user", "message": "hi", "create_at": 1}]}`,
+			"unknown field": `{"version": 1, "extra": true, "posts": [{"username": "active.user", "message": "hi", "create_at": 1}]}`,
+			"no posts":      `{"version": 1, "posts": []}`,
+			"out of order":  `{"version": 1, "posts": [{"username": "a", "message": "hi", "create_at": 2}, {"username": "a", "message": "hi", "create_at": 1}]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				f, plugin, transcriptPost := setup(transcript)
+
+				resp, isUserError, err := plugin.runImportCommand([]string{f.targetChannel.Id, transcriptPost.Id}, f.commandArgs())
+				require.NoError(t, err)
+				assert.True(t, isUserError)
+				assert.Contains(t, resp.Text, "Error: the attached file is not a valid thread transcript")
+				f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+			})
+		}
+	})
+
+	t.Run("valid transcript", func(t *testing.T) {
+		f, plugin, transcriptPost := setup(validTranscript)
+
+		resp, isUserError, err := plugin.runImportCommand([]string{f.targetChannel.Id, transcriptPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread of 3 message(s) has been imported")
+		assert.Contains(t, resp.Text, "Messages by 1 author(s) who don't exist here or aren't members of ~target-channel were recreated under the Wrangler bot.")
+
+		f.api.AssertNumberOfCalls(t, "CreatePost", 3)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.UserId == "activeuserid" && post.Message == "This is the root message" &&
+				post.ChannelId == f.targetChannel.Id && len(post.RootId) == 0
+		}))
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.UserId == "botid" && post.RootId == f.newPost.Id &&
+				strings.HasPrefix(post.Message, "_Originally posted by @missing.user_")
+		}))
+		f.api.AssertNotCalled(t, "GetReactions", mock.Anything)
+	})
+}
+
+func TestExportThreadCommandJSON(t *testing.T) {
+	f := newThreadTestFixture(2)
+	var uploaded []byte
+	f.api.On("UploadFile", mock.Anything, mock.AnythingOfType("string"), "thread-"+f.rootPost.Id+".json").Return(func(data []byte, channelID, filename string) *model.FileInfo {
+		uploaded = data
+		return &model.FileInfo{Id: model.NewId()}
+	}, nil)
+
+	plugin := &Plugin{}
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{})
+
+	resp, isUserError, err := plugin.runExportThreadCommand([]string{f.rootPost.Id, "--format=json"}, f.commandArgs())
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+	assert.Equal(t, "A JSON transcript of the thread of 3 message(s) was sent to you by DM.", resp.Text)
+
+	transcript, err := parseThreadTranscript(uploaded)
+	require.NoError(t, err)
+	require.Len(t, transcript.Posts, 3)
+	assert.Equal(t, f.originalChannel.Id, transcript.ChannelID)
+	assert.Equal(t, transcriptPost{Username: "active.user", Message: "This is the root message", CreateAt: 1}, transcript.Posts[0])
+	assert.Equal(t, "This is reply 2", transcript.Posts[2].Message)
+}
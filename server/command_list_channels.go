@@ -9,6 +9,11 @@ import (
 )
 
 const (
+	listChannelsUsage = `/wrangler list channels [flags]
+  List the IDs of all channels you have joined
+	Flags:
+%s`
+
 	flagTeamFilter    = "team-filter"
 	flagChannelFilter = "channel-filter"
 )
@@ -26,6 +31,10 @@ func getListChannelsFlagSet() *pflag.FlagSet {
 	return listChannelsFlagSet
 }
 
+func getListChannelsUsage() string {
+	return fmt.Sprintf(listChannelsUsage, getListChannelsFlagSet().FlagUsages())
+}
+
 func parseListChannelsArgs(args []string) (listChannelsOptions, error) {
 	var options listChannelsOptions
 
@@ -97,5 +106,5 @@ func (p *Plugin) runListChannelsCommand(args []string, extra *model.CommandArgs)
 		msg = "No results found"
 	}
 
-	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+	return p.getTemporaryResponse(extra.UserId, msg), false, nil
 }
@@ -3,19 +3,26 @@ package main
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/spf13/pflag"
 )
 
 const (
+	listMessagesUsage = `/wrangler list messages [flags]
+  List the IDs of recent messages in this channel
+    Flags:
+%s`
+
 	flagListMessagesCount = "count"
 	minListMessagesCount  = 1
 	maxListMessagesCount  = 100
 
-	flagListMessagesTrimLength = "trim-length"
-	minListMessagesTrimLength  = 10
-	maxListMessagesTrimLength  = 500
+	flagListMessagesTrimLength    = "trim-length"
+	defaultListMessagesTrimLength = 80
+	minListMessagesTrimLength     = 10
+	maxListMessagesTrimLength     = 500
 )
 
 type listMessagesOptions struct {
@@ -26,12 +33,16 @@ type listMessagesOptions struct {
 func getListMessagesFlagSet() *pflag.FlagSet {
 	listMessagesFlagSet := pflag.NewFlagSet("list messages", pflag.ContinueOnError)
 	listMessagesFlagSet.Int(flagListMessagesCount, 20, fmt.Sprintf("Number of messages to return. Must be between %d and %d", minListMessagesCount, maxListMessagesCount))
-	listMessagesFlagSet.Int(flagListMessagesTrimLength, 50, fmt.Sprintf("The max character count of messages listed before they are trimmed. Must be between %d and %d", minListMessagesTrimLength, maxListMessagesTrimLength))
+	listMessagesFlagSet.Int(flagListMessagesTrimLength, 0, fmt.Sprintf("The max character count of messages listed before they are trimmed (defaults to the plugin configuration). Must be between %d and %d", minListMessagesTrimLength, maxListMessagesTrimLength))
 
 	return listMessagesFlagSet
 }
 
-func parseListMessagesArgs(args []string) (listMessagesOptions, error) {
+func getListMessagesUsage() string {
+	return fmt.Sprintf(listMessagesUsage, getListMessagesFlagSet().FlagUsages())
+}
+
+func parseListMessagesArgs(args []string, defaultTrimLength int) (listMessagesOptions, error) {
 	var options listMessagesOptions
 
 	listMessagesFlagSet := getListMessagesFlagSet()
@@ -48,9 +59,12 @@ func parseListMessagesArgs(args []string) (listMessagesOptions, error) {
 		return options, fmt.Errorf("%s (%d) must be between %d and %d", flagListMessagesCount, options.count, minListMessagesCount, maxListMessagesCount)
 	}
 
-	options.trimLength, err = listMessagesFlagSet.GetInt(flagListMessagesTrimLength)
-	if err != nil {
-		return options, err
+	options.trimLength = defaultTrimLength
+	if listMessagesFlagSet.Changed(flagListMessagesTrimLength) {
+		options.trimLength, err = listMessagesFlagSet.GetInt(flagListMessagesTrimLength)
+		if err != nil {
+			return options, err
+		}
 	}
 	if options.trimLength < minListMessagesTrimLength || options.trimLength > maxListMessagesTrimLength {
 		return options, fmt.Errorf("%s (%d) must be between %d and %d", flagListMessagesTrimLength, options.trimLength, minListMessagesTrimLength, maxListMessagesTrimLength)
@@ -60,7 +74,7 @@ func parseListMessagesArgs(args []string) (listMessagesOptions, error) {
 }
 
 func (p *Plugin) runListMessagesCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
-	options, err := parseListMessagesArgs(args)
+	options, err := parseListMessagesArgs(args, p.getConfiguration().ListMessagesTrimLengthInt())
 	if err != nil {
 		return nil, true, err
 	}
@@ -70,16 +84,29 @@ func (p *Plugin) runListMessagesCommand(args []string, extra *model.CommandArgs)
 		return nil, false, appErr
 	}
 
+	var trimmed bool
 	msg := fmt.Sprintf("The last %d messages in this channel:\n", options.count)
 	for _, post := range channelPosts.ToSlice() {
 		if post.IsSystemMessage() {
 			msg += "[     system message     ] - <skipped>\n"
 		} else {
-			msg += fmt.Sprintf("%s - %s\n", post.Id, cleanAndTrimMessage(post.Message, options.trimLength))
+			cleaned := cleanMessage(post.Message)
+			if utf8.RuneCountInString(cleaned) > options.trimLength {
+				trimmed = true
+			}
+			msg += fmt.Sprintf("%s - %s\n", post.Id, trimMessage(cleaned, options.trimLength))
 		}
 	}
 
 	msg = codeBlock(strings.TrimRight(msg, "\n"))
+	if trimmed {
+		// The IDs are the last part of the permalinks, so the full messages
+		// are one link away.
+		msg += fmt.Sprintf("\nMessages longer than %d characters were trimmed. Read them in full at %s",
+			options.trimLength,
+			makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, p.getTeamName(extra.TeamId), "MESSAGE_ID"),
+		)
+	}
 
-	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+	return p.getTemporaryResponse(extra.UserId, msg), false, nil
 }
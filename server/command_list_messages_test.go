@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mattermost/mattermost-server/v5/model"
@@ -100,6 +101,33 @@ func TestMessagelListCommand(t *testing.T) {
 		assert.False(t, isUserError)
 		assert.Contains(t, resp.Text, "[     system message     ] - <skipped>")
 	})
+
+	t.Run("long messages are trimmed to the configured length", func(t *testing.T) {
+		testPostList := mockGeneratePostList(1, testChannel.Id, false)
+		post := testPostList.ToSlice()[0]
+		post.Message = strings.Repeat("a", 30)
+
+		siteURL := "test.sampledomain.com"
+		api := &plugintest.API{}
+		api.On("GetPostsForChannel", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(testPostList, nil)
+		api.On("GetConfig").Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: &siteURL}})
+		api.On("GetTeam", mock.AnythingOfType("string")).Return(&model.Team{Name: "team-1"}, nil)
+
+		var plugin Plugin
+		plugin.SetAPI(api)
+		plugin.setConfiguration(&configuration{ListMessagesTrimLength: "20"})
+
+		resp, isUserError, err := plugin.runListMessagesCommand([]string{}, &model.CommandArgs{ChannelId: testChannel.Id})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, post.Id+" - "+strings.Repeat("a", 20)+"...\n")
+		assert.Contains(t, resp.Text, "Messages longer than 20 characters were trimmed. Read them in full at test.sampledomain.com/team-1/pl/MESSAGE_ID")
+
+		resp, _, err = plugin.runListMessagesCommand([]string{"--trim-length=30"}, &model.CommandArgs{ChannelId: testChannel.Id})
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, post.Id+" - "+post.Message+"\n")
+		assert.NotContains(t, resp.Text, "were trimmed")
+	})
 }
 
 func TestCleanMessage(t *testing.T) {
@@ -155,6 +183,11 @@ func TestTrimMessage(t *testing.T) {
 			message: "123456789012345678901234567890123456789012345678901",
 			want:    "12345678901234567890123456789012345678901234567890...",
 		},
+		{
+			name:    "multibyte characters",
+			message: strings.Repeat("é", 51),
+			want:    strings.Repeat("é", 50) + "...",
+		},
 	}
 
 	for _, tt := range tests {
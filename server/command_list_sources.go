@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const listSourcesUsage = `/wrangler list sources
+  List the IDs of channels you have joined and whether you can move messages from them`
+
+func (p *Plugin) runListSourcesCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	config := p.getConfiguration()
+
+	teams, appErr := p.API.GetTeamsForUser(extra.UserId)
+	if appErr != nil {
+		return nil, false, appErr
+	}
+
+	var msg string
+	for _, team := range teams {
+		channels, appErr := p.API.GetChannelsForTeamForUser(team.Id, extra.UserId, false)
+		if appErr != nil {
+			return nil, false, appErr
+		}
+
+		newChannelGroup := fmt.Sprintf("%s\n", team.Name)
+		var channelCount int
+		for _, channel := range channels {
+			if channel.IsGroupOrDirect() {
+				continue
+			}
+			channelCount++
+
+			if config.MoveFromChannelTypeEnabled(channel.Type) {
+				newChannelGroup += fmt.Sprintf("[allowed] %s - %s\n", channel.Id, channel.Name)
+			} else {
+				newChannelGroup += fmt.Sprintf("[blocked] %s - %s (moving from private channels is disabled)\n", channel.Id, channel.Name)
+			}
+		}
+		if channelCount == 0 {
+			continue
+		}
+
+		newChannelGroup = strings.TrimRight(newChannelGroup, "\n")
+		msg += codeBlock(newChannelGroup) + "\n"
+	}
+
+	if len(msg) == 0 {
+		msg = "No results found\n"
+	}
+
+	msg += fmt.Sprintf("\nDirect message channels: %s\nGroup message channels: %s",
+		allowedOrBlocked(config.MoveThreadFromDirectMessageChannelEnable),
+		allowedOrBlocked(config.MoveThreadFromGroupMessageChannelEnable),
+	)
+
+	return p.getTemporaryResponse(extra.UserId, msg), false, nil
+}
+
+func allowedOrBlocked(allowed bool) string {
+	if allowed {
+		return "allowed"
+	}
+
+	return "blocked"
+}
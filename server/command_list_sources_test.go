@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSourcesCommand(t *testing.T) {
+	teams := mockGenerateTeams(2)
+	openChannel := &model.Channel{Id: model.NewId(), Name: "open-channel", Type: model.CHANNEL_OPEN}
+	privateChannel := &model.Channel{Id: model.NewId(), Name: "private-channel", Type: model.CHANNEL_PRIVATE}
+	directChannel := &model.Channel{Id: model.NewId(), Name: "direct-channel", Type: model.CHANNEL_DIRECT}
+
+	api := &plugintest.API{}
+	api.On("GetTeamsForUser", mock.AnythingOfType("string")).Return(teams, nil)
+	api.On("GetChannelsForTeamForUser", teams[0].Id, mock.AnythingOfType("string"), false).Return([]*model.Channel{openChannel, privateChannel, directChannel}, nil)
+	api.On("GetChannelsForTeamForUser", teams[1].Id, mock.AnythingOfType("string"), false).Return([]*model.Channel{directChannel}, nil)
+
+	var plugin Plugin
+	plugin.SetAPI(api)
+
+	t.Run("private channels blocked", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{MoveThreadFromGroupMessageChannelEnable: true})
+
+		resp, isUserError, err := plugin.runListSourcesCommand([]string{}, &model.CommandArgs{UserId: model.NewId()})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, teams[0].Name)
+		assert.NotContains(t, resp.Text, teams[1].Name)
+		assert.Contains(t, resp.Text, "[allowed] "+openChannel.Id+" - open-channel")
+		assert.Contains(t, resp.Text, "[blocked] "+privateChannel.Id+" - private-channel")
+		assert.NotContains(t, resp.Text, "direct-channel")
+		assert.Contains(t, resp.Text, "Direct message channels: blocked")
+		assert.Contains(t, resp.Text, "Group message channels: allowed")
+	})
+
+	t.Run("private channels allowed", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{MoveThreadFromPrivateChannelEnable: true})
+
+		resp, _, err := plugin.runListSourcesCommand([]string{}, &model.CommandArgs{UserId: model.NewId()})
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, "[allowed] "+privateChannel.Id+" - private-channel")
+		assert.NotContains(t, resp.Text, "[blocked]")
+	})
+}
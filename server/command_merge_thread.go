@@ -0,0 +1,415 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const (
+	mergeThreadUsage = `/wrangler merge thread [MESSAGE_ID] [ROOT_MESSAGE_ID]
+  Merge a given message, along with the thread it belongs to, into another thread
+    - The other thread can be in any channel in any team that you have joined
+    - Use the '/wrangler list' commands to get message IDs
+	Flags:
+%s`
+
+	flagMergeThreadDedupe             = "dedupe"
+	flagMergeThreadAllowBlocked       = "allow-blocked-content"
+	flagMergeThreadConfirmIntegration = "confirm-integration-posts"
+	flagMergeThreadConfirmPlaybook    = "confirm-playbook-posts"
+	flagMergeThreadConfirmDefault     = "confirm-default-channel"
+)
+
+type mergeThreadOptions struct {
+	dedupe                  bool
+	allowBlockedContent     bool
+	confirmIntegrationPosts bool
+	confirmPlaybookPosts    bool
+	confirmDefaultChannel   bool
+}
+
+func getMergeThreadFlagSet() *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet("merge thread", pflag.ContinueOnError)
+	flagSet.Bool(flagMergeThreadDedupe, false, "Skip messages with the same author and text as a message already in the resulting thread")
+	flagSet.Bool(flagMergeThreadAllowBlocked, false, "Merge a thread with messages matching a blocked content pattern (system admins only)")
+	flagSet.Bool(flagMergeThreadConfirmIntegration, false, "Merge a thread with messages posted by bots or integrations")
+	flagSet.Bool(flagMergeThreadConfirmPlaybook, false, "Merge a thread with messages linked to playbook runs")
+	flagSet.Bool(flagMergeThreadConfirmDefault, false, "Confirm merging a thread into a thread of a default channel of a team, such as Town Square")
+
+	return flagSet
+}
+
+func parseMergeThreadFlagArgs(args []string) (mergeThreadOptions, error) {
+	var options mergeThreadOptions
+
+	flagSet := getMergeThreadFlagSet()
+	err := flagSet.Parse(args)
+	if err != nil {
+		return options, errors.Wrap(err, "unable to parse merge thread flag args")
+	}
+
+	options.dedupe, err = flagSet.GetBool(flagMergeThreadDedupe)
+	if err != nil {
+		return options, err
+	}
+
+	options.allowBlockedContent, err = flagSet.GetBool(flagMergeThreadAllowBlocked)
+	if err != nil {
+		return options, err
+	}
+
+	options.confirmIntegrationPosts, err = flagSet.GetBool(flagMergeThreadConfirmIntegration)
+	if err != nil {
+		return options, err
+	}
+
+	options.confirmPlaybookPosts, err = flagSet.GetBool(flagMergeThreadConfirmPlaybook)
+	if err != nil {
+		return options, err
+	}
+
+	options.confirmDefaultChannel, err = flagSet.GetBool(flagMergeThreadConfirmDefault)
+	if err != nil {
+		return options, err
+	}
+
+	return options, nil
+}
+
+func getMergeThreadUsage() string {
+	return fmt.Sprintf(mergeThreadUsage, getMergeThreadFlagSet().FlagUsages())
+}
+
+func (p *Plugin) runMergeThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.getConfiguration().OperationEnabled(enabledOperationMerge) {
+		return getOperationDisabledResponse("merging threads"), true, nil
+	}
+	if !p.authorizedOperationUser(extra.UserId, p.getConfiguration().MovePermittedRoleNames()) {
+		return getOperationRolesResponse("merge threads"), true, nil
+	}
+	if p.guestMovesBlocked(extra.UserId) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: guest accounts are not permitted to merge threads"), true, nil
+	}
+	if p.accountTooNew(extra.UserId) {
+		return p.getAccountTooNewResponse("merge threads"), true, nil
+	}
+	positional, err := parseCommandArgs(args, getMergeThreadFlagSet(), messageIDArg, messageIDArg.withName("target message ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, getMergeThreadUsage()), true, nil
+	}
+	options, err := parseMergeThreadFlagArgs(args)
+	if err != nil {
+		return nil, true, err
+	}
+	postID := positional[0]
+	targetPostID := positional[1]
+	if postID == targetPostID {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: a thread can't be merged into itself; the two message IDs must be different"), true, nil
+	}
+
+	postListResponse, appErr := p.API.GetPostThread(postID)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	wpl := buildWranglerPostList(postListResponse)
+
+	targetPostListResponse, appErr := p.API.GetPostThread(targetPostID)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", targetPostID)), true, nil
+	}
+	targetWPL := buildWranglerPostList(targetPostListResponse)
+	if targetWPL.NumPosts() == 0 {
+		return nil, false, errors.New("The target wrangler post list contains no posts")
+	}
+
+	if wpl.NumPosts() != 0 && wpl.RootPost().Id == targetWPL.RootPost().Id {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: both messages belong to the same thread; a thread can't be merged into one of its own messages"), true, nil
+	}
+
+	originalChannel, appErr := p.API.GetChannel(extra.ChannelId)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", extra.ChannelId)
+	}
+	targetChannelID := targetWPL.RootPost().ChannelId
+	_, appErr = p.API.GetChannelMember(targetChannelID, extra.UserId)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", targetChannelID)), true, nil
+	}
+	targetChannel, appErr := p.API.GetChannel(targetChannelID)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", targetChannelID)
+	}
+
+	response, userErr, err := p.validateMoveOrCopy(wpl, originalChannel, targetChannel, extra)
+	if response != nil || err != nil {
+		return response, userErr, err
+	}
+
+	response, err = p.validateFileLimits(wpl, "merged")
+	if response != nil || err != nil {
+		return response, true, err
+	}
+
+	response = p.validateRemovalFromChannel(originalChannel, extra)
+	if response != nil {
+		return response, true, nil
+	}
+
+	response, err = p.validateThreadNotLocked(wpl, extra)
+	if err != nil {
+		return nil, false, err
+	}
+	if response != nil {
+		return response, true, nil
+	}
+
+	// Merges can't be queued for approval, so threads of channels that need
+	// approval for moves can only be moved.
+	if p.requiresMoveApproval(originalChannel, extra.UserId) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: moving threads out of this channel requires approval, so its threads can't be merged into other threads; use `/wrangler move thread` to request the move instead"), true, nil
+	}
+
+	var skippedCount int
+	if p.getConfiguration().SkipDeactivatedUserPosts() && wpl.NumPosts() > 1 {
+		skippedCount = wpl.RemoveRepliesByAuthors(p.getDeactivatedAuthors(wpl.Posts[1:]))
+	}
+
+	maxAuthors := p.getConfiguration().MaxAuthorsPerMoveInt()
+	if maxAuthors != 0 && wpl.NumAuthors() > maxAuthors {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread has %d distinct authors, but this command is configured to only merge threads with up to %d authors", wpl.NumAuthors(), maxAuthors)), true, nil
+	}
+
+	blockedPattern := findBlockedContentPattern(wpl.Posts, p.getConfiguration().BlockedContentPatternsList())
+	if blockedPattern != nil {
+		if !options.allowBlockedContent || !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread contains a message matching the blocked content pattern `%s`, which may be sensitive. Remove the sensitive content before merging the thread; system admins can merge it anyway with --%s.", blockedPattern.String(), flagMergeThreadAllowBlocked)), true, nil
+		}
+
+		p.API.LogWarn("Wrangler blocked content override used",
+			"user_id", extra.UserId,
+			"original_post_id", wpl.RootPost().Id,
+			"pattern", blockedPattern.String(),
+		)
+	}
+
+	targetTeam, appErr := p.API.GetTeam(targetChannel.TeamId)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get team with ID %s", targetChannel.TeamId)
+	}
+
+	originalRootPost := wpl.RootPost().Clone()
+	mergedCount := wpl.NumPosts()
+
+	var duplicateCount int
+	if options.dedupe {
+		wpl.Posts, duplicateCount = dedupePosts(targetWPL.Posts, wpl.Posts)
+		wpl.updateMetadata()
+	}
+
+	maxMergeSize := p.getConfiguration().MaxMergeSizeInt()
+	combinedCount := targetWPL.NumPosts() + wpl.NumPosts()
+	if maxMergeSize != 0 && combinedCount > maxMergeSize {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the merged thread would have %d messages combined, but merges are limited to threads of up to %d messages", combinedCount, maxMergeSize)), true, nil
+	}
+
+	integrationPolicy := p.getConfiguration().IntegrationPostsPolicy()
+	if integrationPolicy != integrationPostsAllow {
+		integrationCount := p.countIntegrationPosts(wpl.Posts)
+		if integrationCount != 0 {
+			if integrationPolicy == integrationPostsBlock {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread contains %d message(s) posted by bots or integrations, and Wrangler is configured to not move them", integrationCount)), true, nil
+			}
+			if !options.confirmIntegrationPosts {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: the thread contains %d message(s) posted by bots or integrations. Integrations often edit or reply to their own messages, which stops working once the messages are merged into another thread. Run the command again with --%s to merge the thread anyway.", integrationCount, flagMergeThreadConfirmIntegration)), true, nil
+			}
+		}
+	}
+
+	playbookPolicy := p.getConfiguration().PlaybookPostsPolicy()
+	if playbookPolicy != playbookPostsAllow {
+		playbookCount := countPlaybookPosts(wpl.Posts)
+		if playbookCount != 0 {
+			if playbookPolicy == playbookPostsBlock {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread contains %d message(s) linked to playbook runs, and Wrangler is configured to not move them", playbookCount)), true, nil
+			}
+			if !options.confirmPlaybookPosts {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: the thread contains %d message(s) linked to playbook runs. The merged messages keep their playbook props, but the runs may still point to the original messages and show them as missing. Run the command again with --%s to merge the thread anyway.", playbookCount, flagMergeThreadConfirmPlaybook)), true, nil
+			}
+		}
+	}
+
+	// Authors who aren't members of the target channel are handled like in
+	// moves; the user merging the thread is always a member.
+	nonMemberPolicy := p.getConfiguration().NonMemberAuthorsPolicy()
+	deactivatedAuthors := p.getDeactivatedAuthors(wpl.Posts)
+	nonMemberAuthors := p.getNonMemberAuthors(wpl.Posts, targetChannel, deactivatedAuthors)
+	if len(nonMemberAuthors) != 0 && nonMemberPolicy == nonMemberAuthorsAbort {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread can't be merged because some of its authors aren't members of ~%s: %s", targetChannel.Name, formatUsernames(nonMemberAuthors))), true, nil
+	}
+
+	defaultChannelPolicy := p.getConfiguration().DefaultChannelMovesPolicy()
+	if defaultChannelPolicy != defaultChannelMovesAllow && p.isTeamDefaultChannel(targetChannel) && !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		if defaultChannelPolicy == defaultChannelMovesBlock {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: ~%s is a default channel that every member of the team is in, and Wrangler is configured to not merge threads into threads of default channels", targetChannel.Name)), true, nil
+		}
+		if !options.confirmDefaultChannel {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: ~%s is a default channel that every member of the team is in, so the merged messages may notify all of them. Run the command again with --%s to merge the thread anyway.", targetChannel.Name, flagMergeThreadConfirmDefault)), true, nil
+		}
+	}
+
+	// Merges take threads out of the channel just like moves, so they count
+	// towards the same daily quota.
+	quotaReached, err := p.moveQuotaReached(originalChannel.Id, extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+	if quotaReached {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the daily limit of %d thread moves out of ~%s was reached; try again tomorrow", p.getConfiguration().MaxMovesPerChannelPerDayInt(), originalChannel.Name)), true, nil
+	}
+
+	// The messages of deactivated authors are recreated under the bot, along
+	// with those of non-members unless they are added to the target channel.
+	botAttributedAuthors := deactivatedAuthors
+	if nonMemberPolicy == nonMemberAuthorsBot {
+		for userID, username := range nonMemberAuthors {
+			botAttributedAuthors[userID] = username
+		}
+	} else {
+		for userID, username := range nonMemberAuthors {
+			_, appErr = p.API.AddChannelMember(targetChannel.Id, userID)
+			if appErr != nil {
+				p.API.LogError("Unable to add thread author to target channel", "user_id", userID, "channel_id", targetChannel.Id, "error", appErr.Error())
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to add @%s to ~%s; the thread was not merged", username, targetChannel.Name)), true, nil
+			}
+		}
+	}
+
+	ctx, cancel := p.newOperationContext()
+	defer cancel()
+
+	release, response := p.acquireOperationSlot(ctx, "merge", extra)
+	if response != nil {
+		return response, false, nil
+	}
+	defer release()
+
+	// The correlation ID ties the log entries of this merge together and is
+	// shared with the user if the merge has to be rolled back.
+	correlationID := model.NewId()
+
+	p.API.LogInfo("Wrangler is merging a thread",
+		"user_id", extra.UserId,
+		"original_post_id", originalRootPost.Id,
+		"original_channel_id", originalChannel.Id,
+		"target_root_id", targetWPL.RootPost().Id,
+		"correlation_id", correlationID,
+	)
+
+	provenance := newProvenanceTracker()
+	fileLimits := p.getConfiguration().FileLimiter()
+	if wpl.NumPosts() != 0 {
+		_, err = p.copyWranglerPostlist(ctx, wpl, targetChannel, copyOptions{
+			rootID:               targetWPL.RootPost().Id,
+			props:                movedPostProps(originalChannel, extra.UserId, p.getConfiguration()),
+			provenance:           provenance,
+			fileLimits:           fileLimits,
+			removeBroadcasts:     p.getConfiguration().ReplyBroadcastsValue() == replyBroadcastsRemove,
+			botAttributedAuthors: botAttributedAuthors,
+		})
+		if err != nil {
+			// The target thread existed before, so only the merged posts are
+			// removed from it.
+			return p.rollbackMovePosts(correlationID, "copying the messages to the target thread", err, provenance.newPostIDs(), extra), false, nil
+		}
+		p.rewriteMovedPermalinks(provenance)
+	}
+
+	if err = p.checkMoveChannels(originalChannel.Id, targetChannel.Id); err != nil {
+		return p.rollbackMovePosts(correlationID, "checking the channels before deleting the original messages", err, provenance.newPostIDs(), extra), false, nil
+	}
+
+	newPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, targetTeam.Name, targetWPL.RootPost().Id)
+
+	// Cleanup is handled by simply deleting the root post. Any comments/replies
+	// are automatically marked as deleted for us.
+	appErr = p.API.DeletePost(originalRootPost.Id)
+	if appErr != nil {
+		return p.handleOriginalDeleteFailure(correlationID, errors.Wrap(appErr, "unable to delete post"), provenance.newPostIDs(), newPostLink, mergedCount-duplicateCount, originalChannel, targetChannel, extra), false, nil
+	}
+
+	p.API.LogInfo("Wrangler thread merge complete",
+		"user_id", extra.UserId,
+		"target_root_id", targetWPL.RootPost().Id,
+		"merged_post_count", mergedCount-duplicateCount,
+		"duplicate_post_count", duplicateCount,
+		"correlation_id", correlationID,
+	)
+
+	p.recordChannelLog(originalChannel.Id, extra.UserId, fmt.Sprintf("merged a thread of %d message(s) into %s", mergedCount-duplicateCount, newPostLink))
+	p.recordOperation(operationMerge, extra.UserId, originalChannel.Id, targetWPL.RootPost().ChannelId, mergedCount-duplicateCount)
+	p.recordChannelMove(originalChannel.Id)
+	p.recordProvenance(provenance, operationMerge, extra.UserId, originalChannel.Id, targetWPL.RootPost().ChannelId)
+	if extra.UserId != originalRootPost.UserId {
+		// The wrangled thread was not started by the user running the command.
+		// Send a DM to the user who created the root message to let them know.
+		err := p.postMergeThreadBotDM(originalRootPost.UserId, newPostLink)
+		if err != nil {
+			p.API.LogError("Unable to send merge-thread DM to user",
+				"error", err.Error(),
+				"user_id", originalRootPost.UserId,
+			)
+		}
+	}
+
+	msg := fmt.Sprintf("A thread has been merged: %s\n", newPostLink)
+	msg += fmt.Sprintf("\n%d message(s) were merged into the thread.", mergedCount-duplicateCount)
+	if options.dedupe {
+		msg += fmt.Sprintf(" %d duplicate message(s) were skipped.", duplicateCount)
+	}
+	if skippedCount != 0 {
+		msg += fmt.Sprintf("\n%d message(s) by deactivated users were left out of the merged thread.", skippedCount)
+	}
+	msg += getSkippedFilesNote(fileLimits)
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// dedupePosts returns the posts that are not identical to an existing post or
+// to a post earlier in the list, along with the number of skipped posts. Posts
+// are identical when they have the same author and the same trimmed message
+// text. Posts without text, such as file-only posts, are never skipped.
+func dedupePosts(existing, posts []*model.Post) ([]*model.Post, int) {
+	key := func(post *model.Post) string {
+		return post.UserId + "\x00" + strings.TrimSpace(post.Message)
+	}
+
+	seen := make(map[string]bool)
+	for _, post := range existing {
+		seen[key(post)] = true
+	}
+
+	var deduped []*model.Post
+	var skipped int
+	for _, post := range posts {
+		if len(strings.TrimSpace(post.Message)) != 0 {
+			if seen[key(post)] {
+				skipped++
+				continue
+			}
+			seen[key(post)] = true
+		}
+		deduped = append(deduped, post)
+	}
+
+	return deduped, skipped
+}
+
+func (p *Plugin) postMergeThreadBotDM(userID, newPostLink string) error {
+	return p.PostBotDM(userID, fmt.Sprintf(
+		"Someone wrangled a thread you started into another thread for you: %s", newPostLink,
+	))
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const mineUsage = `/wrangler mine
+  List your pending Wrangler actions
+    - Shows your scheduled reminders, pending move requests, recent operations and how much of your limits you used`
+
+// mineRecentOperationsCount is the number of recent operations listed by the
+// mine command.
+const mineRecentOperationsCount = 10
+
+func (p *Plugin) runMineCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if len(args) != 0 {
+		return getCommandArgsErrorResponse(errors.New("the command doesn't take any arguments"), mineUsage), true, nil
+	}
+
+	reminders, err := p.getReminders()
+	if err != nil {
+		return nil, false, err
+	}
+	approvals, err := p.getPendingMoveApprovals()
+	if err != nil {
+		return nil, false, err
+	}
+	records, err := p.getOperationHistory(time.Unix(0, 0))
+	if err != nil {
+		return nil, false, err
+	}
+
+	location := p.getUserLocation(extra.UserId)
+	msg := "#### Your pending Wrangler actions\n\n"
+
+	var userReminders []reminder
+	for _, r := range reminders {
+		if r.UserID == extra.UserId {
+			userReminders = append(userReminders, r)
+		}
+	}
+	sort.Slice(userReminders, func(i, j int) bool {
+		return userReminders[i].RemindAt < userReminders[j].RemindAt
+	})
+	msg += "##### Scheduled reminders\n\n"
+	if len(userReminders) == 0 {
+		msg += "No reminders are scheduled.\n"
+	}
+	for _, r := range userReminders {
+		msg += fmt.Sprintf("- `%s` for %s: %s\n", r.ID, time.Unix(0, r.RemindAt).In(location).Format(time.RFC1123), r.PostLink)
+	}
+	if len(userReminders) != 0 {
+		msg += "\nRun `/wrangler cancel reminder ID` to cancel one of them.\n"
+	}
+
+	msg += "\n##### Pending move requests\n\n"
+	var requestCount int
+	for _, request := range approvals {
+		if request.UserID != extra.UserId {
+			continue
+		}
+		requestCount++
+		target := "unknown"
+		if len(request.Args) >= 2 {
+			target = fmt.Sprintf("message %s to channel %s", request.Args[0], request.Args[1])
+		}
+		msg += fmt.Sprintf("- `%s`: move %s\n", request.ID, target)
+	}
+	if requestCount == 0 {
+		msg += "No move requests are waiting for approval.\n"
+	}
+
+	msg += "\n##### Recent operations\n\n"
+	var recent []operationRecord
+	for i := len(records) - 1; i >= 0 && len(recent) < mineRecentOperationsCount; i-- {
+		if records[i].UserID == extra.UserId {
+			recent = append(recent, records[i])
+		}
+	}
+	if len(recent) == 0 {
+		msg += "No moves, copies or merges are recorded in the operation history.\n"
+	}
+	channelNames := make(map[string]string)
+	for _, record := range recent {
+		msg += fmt.Sprintf("- %s: %s of %d message(s) from %s to %s\n",
+			time.Unix(0, record.Timestamp).In(location).Format(time.RFC1123), record.Type, record.PostCount,
+			p.getStatsChannelName(record.SourceChannelID, channelNames), p.getStatsChannelName(record.TargetChannelID, channelNames),
+		)
+	}
+	undoCount := p.undos.countUser(extra.UserId)
+	if undoCount != 0 {
+		msg += fmt.Sprintf("\n%d move(s) are still waiting to delete their original messages and can be undone with their Undo button.\n", undoCount)
+	}
+
+	limits, err := p.getMineLimits(extra.UserId, len(userReminders))
+	if err != nil {
+		return nil, false, err
+	}
+	msg += "\n##### Limits\n\n"
+	if len(limits) == 0 {
+		msg += "No Wrangler limits apply to you.\n"
+	}
+	for _, limit := range limits {
+		msg += fmt.Sprintf("- %s\n", limit)
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// getMineLimits returns a line for each configured per-user limit describing
+// how much of it the user used.
+func (p *Plugin) getMineLimits(userID string, reminderCount int) ([]string, error) {
+	config := p.getConfiguration()
+	var limits []string
+
+	maxReminders := config.MaxScheduledJobsPerUserInt()
+	if maxReminders != 0 && !p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		limits = append(limits, fmt.Sprintf("Scheduled reminders: %d of %d", reminderCount, maxReminders))
+	}
+
+	maxRequests := config.MaxMoveRequestsPerUserPerDayInt()
+	if len(config.MoveApprovalChannelID) != 0 && maxRequests != 0 {
+		count, err := p.getMoveRequestCount(userID)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, fmt.Sprintf("Move requests today (UTC): %d of %d", count, maxRequests))
+	}
+
+	return limits, nil
+}
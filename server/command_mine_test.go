@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMineCommand(t *testing.T) {
+	userID := model.NewId()
+	otherUserID := model.NewId()
+	sourceChannel := &model.Channel{Id: model.NewId(), Name: "source"}
+	targetChannel := &model.Channel{Id: model.NewId(), Name: "target"}
+
+	setup := func(config *configuration) (*plugintest.API, *Plugin) {
+		api := &plugintest.API{}
+		newMockKVStore(api)
+		mockLogs(api)
+		api.On("HasPermissionTo", userID, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		api.On("GetUser", userID).Return(&model.User{Id: userID}, nil)
+		api.On("GetChannel", sourceChannel.Id).Return(sourceChannel, nil)
+		api.On("GetChannel", targetChannel.Id).Return(targetChannel, nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(config)
+
+		return api, plugin
+	}
+
+	t.Run("nothing pending", func(t *testing.T) {
+		_, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runMineCommand([]string{}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "No reminders are scheduled.")
+		assert.Contains(t, resp.Text, "No move requests are waiting for approval.")
+		assert.Contains(t, resp.Text, "No moves, copies or merges are recorded in the operation history.")
+		assert.Contains(t, resp.Text, "No Wrangler limits apply to you.")
+	})
+
+	t.Run("unexpected arguments", func(t *testing.T) {
+		_, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runMineCommand([]string{"all"}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "the command doesn't take any arguments")
+	})
+
+	t.Run("only the actions of the user", func(t *testing.T) {
+		_, plugin := setup(&configuration{
+			MaxScheduledJobsPerUser: "5",
+			MoveApprovalChannelID:   model.NewId(),
+		})
+		reminderID, err := plugin.addReminder(userID, "post1", "http://example.com/post1", time.Hour)
+		require.NoError(t, err)
+		otherReminderID, err := plugin.addReminder(otherUserID, "post2", "http://example.com/post2", time.Hour)
+		require.NoError(t, err)
+		request := &moveApprovalRequest{ID: model.NewId(), UserID: userID, Args: []string{"post3", targetChannel.Id}}
+		require.NoError(t, plugin.kvSetJSON(getMoveApprovalKey(request.ID), request))
+		otherRequest := &moveApprovalRequest{ID: model.NewId(), UserID: otherUserID, Args: []string{"post4", targetChannel.Id}}
+		require.NoError(t, plugin.kvSetJSON(getMoveApprovalKey(otherRequest.ID), otherRequest))
+		plugin.recordMoveRequest(userID)
+		plugin.recordOperation(operationMove, userID, sourceChannel.Id, targetChannel.Id, 3)
+		plugin.recordOperation(operationCopy, otherUserID, sourceChannel.Id, targetChannel.Id, 7)
+		plugin.undos.add(model.NewId(), pendingMoveUndo{userID: userID, cancel: func() {}})
+
+		resp, isUserError, err := plugin.runMineCommand([]string{}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+
+		assert.Contains(t, resp.Text, "- `"+reminderID+"` for ")
+		assert.Contains(t, resp.Text, ": http://example.com/post1\n")
+		assert.NotContains(t, resp.Text, otherReminderID)
+		assert.Contains(t, resp.Text, "- `"+request.ID+"`: move message post3 to channel "+targetChannel.Id)
+		assert.NotContains(t, resp.Text, otherRequest.ID)
+		assert.Contains(t, resp.Text, ": move of 3 message(s) from ~source to ~target")
+		assert.NotContains(t, resp.Text, "copy of 7 message(s)")
+		assert.Contains(t, resp.Text, "1 move(s) are still waiting to delete their original messages and can be undone with their Undo button.")
+		assert.Contains(t, resp.Text, "- Scheduled reminders: 1 of 5")
+		assert.Contains(t, resp.Text, "- Move requests today (UTC): 1 of 5")
+	})
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/pkg/errors"
@@ -13,64 +17,346 @@ const (
   Move a given message, along with the thread it belongs to, to a given channel
     - This can be on any channel in any team that you have joined
 	- Use the '/wrangler list' commands to get message and channel IDs
+	- The channel ID can be omitted once you set a default destination with '/wrangler prefs set default-destination'
 	Flags:
 %s`
 
 	flagMoveThreadShowMessageSummary = "show-root-message-in-summary"
+	flagMoveThreadRepliesOnly        = "replies-only"
+	flagMoveThreadCheckPermalinks    = "check-permalinks"
+	flagMoveThreadRemind             = "remind"
+	flagMoveThreadPreview            = "preview"
+	flagMoveThreadConfirmIntegration = "confirm-integration-posts"
+	flagMoveThreadChangelog          = "changelog"
+	flagMoveThreadFeedbackPoll       = "feedback-poll"
+	flagMoveThreadConfirmLarge       = "confirm-large-destination"
+	flagMoveThreadAllowBlocked       = "allow-blocked-content"
+	flagMoveThreadConfirmPlaybook    = "confirm-playbook-posts"
+	flagMoveThreadRootOnly           = "root-only"
+	flagMoveThreadConfirmDiscard     = "confirm-discard-replies"
+	flagMoveThreadIntoThread         = "into-thread"
+	flagMoveThreadLeaveRedirect      = "leave-redirect"
+	flagMoveThreadConsolidate        = "consolidate"
+	flagMoveThreadCreateCard         = "create-card"
+	flagMoveThreadConfirmDefault     = "confirm-default-channel"
 )
 
+type moveThreadOptions struct {
+	showRootMessageInSummary bool
+	repliesOnly              bool
+	checkPermalinks          bool
+	remind                   time.Duration
+	preview                  bool
+	confirmIntegrationPosts  bool
+	confirmPlaybookPosts     bool
+	confirmLargeDestination  bool
+	confirmDefaultChannel    bool
+	allowBlockedContent      bool
+	changelog                string
+	feedbackPoll             bool
+	dateRange                dateRange
+	rootOnly                 bool
+	confirmDiscardReplies    bool
+	// keepOriginalThread is set for root-only moves that leave the replies in
+	// the original thread, which then can't be deleted.
+	keepOriginalThread bool
+	// intoThread is the permalink or ID of an existing root post in the
+	// target channel that the moved posts are recreated as replies to.
+	intoThread string
+	// leaveRedirect keeps the original root post as a permanent redirect to
+	// the moved thread instead of deleting it.
+	leaveRedirect bool
+	// consolidate moves the thread as a single combined post instead of
+	// recreating each of its posts.
+	consolidate bool
+	// createCard creates a card linking to the moved thread on the configured
+	// board of the Boards plugin.
+	createCard bool
+}
+
 func getMoveThreadFlagSet() *pflag.FlagSet {
 	flagSet := pflag.NewFlagSet("move thread", pflag.ContinueOnError)
 	flagSet.Bool(flagMoveThreadShowMessageSummary, true, "Show the root message in the post-move summary")
+	flagSet.Bool(flagMoveThreadRepliesOnly, false, "Move only the replies and leave the root message in the original channel (defaults to the plugin configuration)")
+	flagSet.Bool(flagMoveThreadCheckPermalinks, false, "Report recent messages in the original channel with permalinks that will break after the move")
+	flagSet.Duration(flagMoveThreadRemind, 0, "Send yourself a reminder DM linking to the moved thread after the given duration (e.g. 30m or 2h); requires the reminders experimental feature")
+	flagSet.Bool(flagMoveThreadPreview, false, "Show what would be moved and where without moving anything")
+	flagSet.Bool(flagMoveThreadConfirmIntegration, false, "Confirm moving a thread with messages posted by bots or integrations")
+	flagSet.Bool(flagMoveThreadConfirmPlaybook, false, "Confirm moving a thread with messages linked to playbook runs")
+	flagSet.Bool(flagMoveThreadConfirmLarge, false, "Confirm moving a thread into a channel with many messages")
+	flagSet.Bool(flagMoveThreadConfirmDefault, false, "Confirm moving a thread into a default channel of a team, such as Town Square")
+	flagSet.Bool(flagMoveThreadAllowBlocked, false, "Move a thread with messages matching a blocked content pattern (system admins only)")
+	flagSet.String(flagMoveThreadChangelog, "", "Append the given quoted entry, dated and linked to the moved thread, to the changelog post")
+	flagSet.Bool(flagMoveThreadFeedbackPoll, false, "Leave a poll in the original channel asking whether the thread should have stayed there")
+	flagSet.Bool(flagMoveThreadRootOnly, false, "Move only the root message and discard its replies (system admins only)")
+	flagSet.Bool(flagMoveThreadConfirmDiscard, false, "Confirm deleting the replies of a thread moved with --root-only")
+	flagSet.String(flagMoveThreadIntoThread, "", "Move all messages as replies to an existing thread in the destination channel, given by the permalink or ID of its root message")
+	flagSet.Bool(flagMoveThreadLeaveRedirect, false, "Keep the original root message as a permanent redirect to the moved thread so that links to it keep working (defaults to the plugin configuration)")
+	flagSet.Bool(flagMoveThreadConsolidate, false, "Move the whole thread as a single combined message naming the author and time of each message")
+	flagSet.Bool(flagMoveThreadCreateCard, false, "Create a card linking to the moved thread on the configured board of the Boards plugin")
+	addDateRangeFlags(flagSet, "move")
 
 	return flagSet
 }
 
-func parseMoveThreadFlagArgs(args []string) (bool, error) {
+func parseMoveThreadFlagArgs(args []string, config *configuration) (moveThreadOptions, error) {
+	var options moveThreadOptions
+
 	flagSet := getMoveThreadFlagSet()
 	err := flagSet.Parse(args)
 	if err != nil {
-		return false, errors.Wrap(err, "unable to parse move thread flag args")
+		return options, errors.Wrap(err, "unable to parse move thread flag args")
+	}
+
+	options.showRootMessageInSummary, err = flagSet.GetBool(flagMoveThreadShowMessageSummary)
+	if err != nil {
+		return options, err
+	}
+
+	options.checkPermalinks, err = flagSet.GetBool(flagMoveThreadCheckPermalinks)
+	if err != nil {
+		return options, err
+	}
+
+	options.preview, err = flagSet.GetBool(flagMoveThreadPreview)
+	if err != nil {
+		return options, err
 	}
 
-	return flagSet.GetBool(flagMoveThreadShowMessageSummary)
+	options.confirmIntegrationPosts, err = flagSet.GetBool(flagMoveThreadConfirmIntegration)
+	if err != nil {
+		return options, err
+	}
+
+	options.confirmPlaybookPosts, err = flagSet.GetBool(flagMoveThreadConfirmPlaybook)
+	if err != nil {
+		return options, err
+	}
+
+	options.confirmLargeDestination, err = flagSet.GetBool(flagMoveThreadConfirmLarge)
+	if err != nil {
+		return options, err
+	}
+
+	options.confirmDefaultChannel, err = flagSet.GetBool(flagMoveThreadConfirmDefault)
+	if err != nil {
+		return options, err
+	}
+
+	options.allowBlockedContent, err = flagSet.GetBool(flagMoveThreadAllowBlocked)
+	if err != nil {
+		return options, err
+	}
+
+	options.changelog, err = flagSet.GetString(flagMoveThreadChangelog)
+	if err != nil {
+		return options, err
+	}
+
+	options.feedbackPoll, err = flagSet.GetBool(flagMoveThreadFeedbackPoll)
+	if err != nil {
+		return options, err
+	}
+
+	options.remind, err = flagSet.GetDuration(flagMoveThreadRemind)
+	if err != nil {
+		return options, err
+	}
+	if options.remind < 0 {
+		return options, errors.Errorf("--%s must be a positive duration", flagMoveThreadRemind)
+	}
+
+	options.dateRange, err = parseDateRangeFlags(flagSet)
+	if err != nil {
+		return options, err
+	}
+
+	options.repliesOnly = config.MoveRepliesOnly
+	if flagSet.Changed(flagMoveThreadRepliesOnly) {
+		options.repliesOnly, err = flagSet.GetBool(flagMoveThreadRepliesOnly)
+		if err != nil {
+			return options, err
+		}
+	}
+
+	options.leaveRedirect = config.EnableMoveRedirectStub
+	if flagSet.Changed(flagMoveThreadLeaveRedirect) {
+		options.leaveRedirect, err = flagSet.GetBool(flagMoveThreadLeaveRedirect)
+		if err != nil {
+			return options, err
+		}
+	}
+
+	options.rootOnly, err = flagSet.GetBool(flagMoveThreadRootOnly)
+	if err != nil {
+		return options, err
+	}
+	options.confirmDiscardReplies, err = flagSet.GetBool(flagMoveThreadConfirmDiscard)
+	if err != nil {
+		return options, err
+	}
+	if options.rootOnly {
+		if flagSet.Changed(flagMoveThreadRepliesOnly) && options.repliesOnly {
+			return options, errors.Errorf("--%s can't be combined with --%s", flagMoveThreadRootOnly, flagMoveThreadRepliesOnly)
+		}
+		if options.dateRange.isSet() {
+			return options, errors.Errorf("--%s can't be combined with --%s or --%s", flagMoveThreadRootOnly, flagAfter, flagBefore)
+		}
+		if flagSet.Changed(flagMoveThreadLeaveRedirect) && options.leaveRedirect {
+			return options, errors.Errorf("--%s can't be combined with --%s", flagMoveThreadRootOnly, flagMoveThreadLeaveRedirect)
+		}
+		// Moving only the root message overrides the configured defaults.
+		// The discarded replies would otherwise be kept under the redirect.
+		options.repliesOnly = false
+		options.leaveRedirect = false
+	}
+
+	options.intoThread, err = flagSet.GetString(flagMoveThreadIntoThread)
+	if err != nil {
+		return options, err
+	}
+	if len(options.intoThread) != 0 {
+		if flagSet.Changed(flagMoveThreadRepliesOnly) && options.repliesOnly {
+			return options, errors.Errorf("--%s can't be combined with --%s", flagMoveThreadIntoThread, flagMoveThreadRepliesOnly)
+		}
+		if options.rootOnly {
+			return options, errors.Errorf("--%s can't be combined with --%s", flagMoveThreadIntoThread, flagMoveThreadRootOnly)
+		}
+		if options.dateRange.isSet() {
+			return options, errors.Errorf("--%s can't be combined with --%s or --%s", flagMoveThreadIntoThread, flagAfter, flagBefore)
+		}
+		// The whole thread is moved into the existing thread, overriding the
+		// configured default.
+		options.repliesOnly = false
+	}
+
+	options.consolidate, err = flagSet.GetBool(flagMoveThreadConsolidate)
+	if err != nil {
+		return options, err
+	}
+	if options.consolidate {
+		if flagSet.Changed(flagMoveThreadRepliesOnly) && options.repliesOnly {
+			return options, errors.Errorf("--%s can't be combined with --%s", flagMoveThreadConsolidate, flagMoveThreadRepliesOnly)
+		}
+		if options.rootOnly {
+			return options, errors.Errorf("--%s can't be combined with --%s", flagMoveThreadConsolidate, flagMoveThreadRootOnly)
+		}
+		if len(options.intoThread) != 0 {
+			return options, errors.Errorf("--%s can't be combined with --%s", flagMoveThreadConsolidate, flagMoveThreadIntoThread)
+		}
+		if options.dateRange.isSet() {
+			return options, errors.Errorf("--%s can't be combined with --%s or --%s", flagMoveThreadConsolidate, flagAfter, flagBefore)
+		}
+		// The whole thread is consolidated, overriding the configured default.
+		options.repliesOnly = false
+	}
+
+	options.createCard, err = flagSet.GetBool(flagMoveThreadCreateCard)
+	if err != nil {
+		return options, err
+	}
+
+	return options, nil
 }
 
 func getMoveThreadUsage() string {
 	return fmt.Sprintf(moveThreadUsage, getMoveThreadFlagSet().FlagUsages())
 }
 
-func getMoveThreadMessage() string {
-	return codeBlock(fmt.Sprintf("`Error: missing arguments\n\n%s", getMoveThreadUsage()))
+func (p *Plugin) runMoveThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	return p.moveThreadCommand(args, extra, false)
 }
 
-func (p *Plugin) runMoveThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
-	if len(args) < 2 {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getMoveThreadMessage()), true, nil
+// moveThreadCommand runs the move thread command. Moves that require approval
+// are sent to the approvers instead unless they were already approved.
+func (p *Plugin) moveThreadCommand(args []string, extra *model.CommandArgs, approved bool) (*model.CommandResponse, bool, error) {
+	if !p.getConfiguration().OperationEnabled(enabledOperationMove) {
+		return getOperationDisabledResponse("moving threads"), true, nil
+	}
+	if !p.authorizedOperationUser(extra.UserId, p.getConfiguration().MovePermittedRoleNames()) {
+		return getOperationRolesResponse("move threads"), true, nil
 	}
-	showRootMessageInSummary, err := parseMoveThreadFlagArgs(args)
+	if p.guestMovesBlocked(extra.UserId) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: guest accounts are not permitted to move threads"), true, nil
+	}
+	if p.accountTooNew(extra.UserId) {
+		return p.getAccountTooNewResponse("move threads"), true, nil
+	}
+	args, response, err := p.withDefaultDestination(args, getMoveThreadFlagSet(), extra.UserId)
+	if response != nil || err != nil {
+		return response, true, err
+	}
+	positional, err := parseCommandArgs(args, getMoveThreadFlagSet(), messageIDArg, channelIDArg.withName("destination channel ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, getMoveThreadUsage()), true, nil
+	}
+	options, err := parseMoveThreadFlagArgs(args, p.getConfiguration())
 	if err != nil {
 		return nil, false, err
 	}
-	postID := args[0]
-	channelID := args[1]
+	if options.remind != 0 && !p.getConfiguration().ExperimentalFeatureEnabled(experimentalFeatureReminders) {
+		return getExperimentalFeatureDisabledResponse("--"+flagMoveThreadRemind, experimentalFeatureReminders), true, nil
+	}
+	if len(strings.TrimSpace(options.changelog)) != 0 && len(p.getConfiguration().ChangelogChannelID) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: no changelog channel is configured for the --changelog flag"), true, nil
+	}
+	if options.createCard && len(p.getConfiguration().BoardsCardBoardID) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: no board is configured for the --create-card flag"), true, nil
+	}
+	if options.createCard && !p.boardsPluginRunning() {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: the Boards plugin isn't installed or enabled, so no card can be created for the --create-card flag"), true, nil
+	}
+	if options.feedbackPoll && !p.getConfiguration().EnableMoveFeedbackPoll {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: feedback polls are not enabled for the --feedback-poll flag"), true, nil
+	}
+	if options.rootOnly && !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: only system admins can move a thread with --%s", flagMoveThreadRootOnly)), true, nil
+	}
+	postID := positional[0]
+	channelID := positional[1]
 
 	postListResponse, appErr := p.API.GetPostThread(postID)
 	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: that post no longer exists; ensure the ID %s is correct", postID)), true, nil
+		}
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
 	}
 	wpl := buildWranglerPostList(postListResponse)
+	if wpl.NumPosts() == 0 || wpl.RootPost().DeleteAt != 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: that post no longer exists"), true, nil
+	}
+
+	var totalReplies, matchedReplies int
+	if options.dateRange.isSet() {
+		totalReplies = wpl.NumPosts() - 1
+		matchedReplies = wpl.FilterRepliesByDateRange(options.dateRange)
+		if matchedReplies == 0 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: none of the %d replies of the thread were posted %s", totalReplies, options.dateRange)), true, nil
+		}
+		// Deleting the root post would also delete the replies outside of the
+		// date range, so only the matching replies are moved.
+		options.repliesOnly = true
+	}
 
 	originalChannel, appErr := p.API.GetChannel(extra.ChannelId)
 	if appErr != nil {
 		return nil, false, fmt.Errorf("unable to get channel with ID %s", extra.ChannelId)
 	}
-	_, appErr = p.API.GetChannelMember(channelID, extra.UserId)
-	if appErr != nil {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", channelID)), true, nil
+	// System admins with the cross-team override may move threads into any
+	// existing channel, so only the channel itself is looked up for them.
+	override := p.canOverrideTargetChannel(extra.UserId)
+	if !override {
+		_, appErr = p.API.GetChannelMember(channelID, extra.UserId)
+		if appErr != nil {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", channelID)), true, nil
+		}
 	}
 	targetChannel, appErr := p.API.GetChannel(channelID)
 	if appErr != nil {
+		if override {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist", channelID)), true, nil
+		}
 		return nil, false, fmt.Errorf("unable to get channel with ID %s", channelID)
 	}
 
@@ -79,76 +365,849 @@ func (p *Plugin) runMoveThreadCommand(args []string, extra *model.CommandArgs) (
 		return response, userErr, err
 	}
 
+	response, err = p.validateFileLimits(wpl, "moved")
+	if response != nil || err != nil {
+		return response, true, err
+	}
+
+	response = p.validateRemovalFromChannel(originalChannel, extra)
+	if response != nil {
+		return response, true, nil
+	}
+
+	var intoRootPost *model.Post
+	if len(options.intoThread) != 0 {
+		intoRootPost, response = p.getIntoThreadRootPost(options.intoThread, wpl, targetChannel)
+		if response != nil {
+			return response, true, nil
+		}
+	}
+
+	response, err = p.validateThreadNotLocked(wpl, extra)
+	if err != nil {
+		return nil, false, err
+	}
+	if response != nil {
+		return response, true, nil
+	}
+
+	// A lone root post is moved as a single message.
+	singlePost := wpl.NumPosts() == 1
+
+	var discardedReplies int
+	if options.rootOnly {
+		discardedReplies = wpl.NumPosts() - 1
+		wpl.TrimReplies(0)
+		options.keepOriginalThread = discardedReplies != 0 && p.getConfiguration().RootOnlyKeepReplies
+	}
+
+	var skippedCount int
+	if p.getConfiguration().SkipDeactivatedUserPosts() && wpl.NumPosts() > 1 {
+		skippedCount = wpl.RemoveRepliesByAuthors(p.getDeactivatedAuthors(wpl.Posts[1:]))
+	}
+
+	maxAuthors := p.getConfiguration().MaxAuthorsPerMoveInt()
+	if maxAuthors != 0 && wpl.NumAuthors() > maxAuthors {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread has %d distinct authors, but this command is configured to only move threads with up to %d authors", wpl.NumAuthors(), maxAuthors)), true, nil
+	}
+
+	if options.repliesOnly && wpl.NumPosts() < 2 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: the thread has no replies to move"), true, nil
+	}
+
 	targetTeam, appErr := p.API.GetTeam(targetChannel.TeamId)
 	if appErr != nil {
 		return nil, false, fmt.Errorf("unable to get team with ID %s", targetChannel.TeamId)
 	}
 
+	var movedPosts []*model.Post
+	if options.repliesOnly {
+		movedPosts = wpl.Posts[1:]
+	} else {
+		movedPosts = wpl.Posts
+	}
+
+	var linkingPosts []*model.Post
+	if options.checkPermalinks {
+		linkingPosts, err = p.findPermalinksToPosts(originalChannel.Id, movedPosts)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Authors who aren't members of the target channel are either added to it
+	// or abort the move. Otherwise, their posts are recreated under the bot.
+	nonMemberPolicy := p.getConfiguration().NonMemberAuthorsPolicy()
+	var nonMemberAuthors map[string]string
+	if nonMemberPolicy != nonMemberAuthorsBot {
+		nonMemberAuthors = p.getNonMemberAuthors(wpl.Posts, targetChannel, p.getDeactivatedAuthors(wpl.Posts))
+	}
+	if len(nonMemberAuthors) != 0 && nonMemberPolicy == nonMemberAuthorsAbort {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread can't be moved because some of its authors aren't members of ~%s: %s", targetChannel.Name, formatUsernames(nonMemberAuthors))), true, nil
+	}
+
+	blockedPattern := findBlockedContentPattern(movedPosts, p.getConfiguration().BlockedContentPatternsList())
+	if blockedPattern != nil {
+		if !options.allowBlockedContent || !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread contains a message matching the blocked content pattern `%s`, which may be sensitive. Remove the sensitive content before moving the thread; system admins can move it anyway with --%s.", blockedPattern.String(), flagMoveThreadAllowBlocked)), true, nil
+		}
+
+		p.API.LogWarn("Wrangler blocked content override used",
+			"user_id", extra.UserId,
+			"original_post_id", wpl.RootPost().Id,
+			"pattern", blockedPattern.String(),
+		)
+	}
+
+	if options.preview {
+		return p.previewMove(movedPosts, targetChannel, targetTeam, options.checkPermalinks, linkingPosts, extra.UserId), false, nil
+	}
+
+	integrationPolicy := p.getConfiguration().IntegrationPostsPolicy()
+	if integrationPolicy != integrationPostsAllow {
+		integrationCount := p.countIntegrationPosts(movedPosts)
+		if integrationCount != 0 {
+			if integrationPolicy == integrationPostsBlock {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread contains %d message(s) posted by bots or integrations, and Wrangler is configured to not move them", integrationCount)), true, nil
+			}
+			if !options.confirmIntegrationPosts {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: the thread contains %d message(s) posted by bots or integrations. Integrations often edit or reply to their own messages, which stops working once the messages are moved. Run the command again with --%s to move the thread anyway.", integrationCount, flagMoveThreadConfirmIntegration)), true, nil
+			}
+		}
+	}
+
+	playbookPolicy := p.getConfiguration().PlaybookPostsPolicy()
+	if playbookPolicy != playbookPostsAllow {
+		playbookCount := countPlaybookPosts(movedPosts)
+		if playbookCount != 0 {
+			if playbookPolicy == playbookPostsBlock {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread contains %d message(s) linked to playbook runs, and Wrangler is configured to not move them", playbookCount)), true, nil
+			}
+			if !options.confirmPlaybookPosts {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: the thread contains %d message(s) linked to playbook runs. The moved messages keep their playbook props, but the runs may still point to the original messages and show them as missing. Run the command again with --%s to move the thread anyway.", playbookCount, flagMoveThreadConfirmPlaybook)), true, nil
+			}
+		}
+	}
+
+	warningCount := p.getConfiguration().DestinationPostCountWarningInt()
+	if warningCount != 0 && targetChannel.TotalMsgCount+int64(len(movedPosts)) > int64(warningCount) {
+		if !options.confirmLargeDestination {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: ~%s already has %d messages, and moving %d more would bring it over the %d messages Wrangler is configured to warn about. Run the command again with --%s to move the thread anyway.", targetChannel.Name, targetChannel.TotalMsgCount, len(movedPosts), warningCount, flagMoveThreadConfirmLarge)), true, nil
+		}
+
+		p.API.LogInfo("Wrangler is moving a thread into a large channel",
+			"user_id", extra.UserId,
+			"target_channel_id", targetChannel.Id,
+			"target_post_count", targetChannel.TotalMsgCount,
+			"moved_post_count", len(movedPosts),
+		)
+	}
+
+	defaultChannelPolicy := p.getConfiguration().DefaultChannelMovesPolicy()
+	if defaultChannelPolicy != defaultChannelMovesAllow && p.isTeamDefaultChannel(targetChannel) && !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		if defaultChannelPolicy == defaultChannelMovesBlock {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: ~%s is a default channel that every member of the team is in, and Wrangler is configured to not move threads into default channels", targetChannel.Name)), true, nil
+		}
+		if !options.confirmDefaultChannel {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: ~%s is a default channel that every member of the team is in, so the moved messages may notify all of them. Run the command again with --%s to move the thread anyway.", targetChannel.Name, flagMoveThreadConfirmDefault)), true, nil
+		}
+	}
+
+	if discardedReplies != 0 && !options.keepOriginalThread && !options.confirmDiscardReplies {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Warning: moving only the root message deletes its %d replies, which can't be recovered. Run the command again with --%s to move the root message anyway.", discardedReplies, flagMoveThreadConfirmDiscard)), true, nil
+	}
+
+	quotaReached, err := p.moveQuotaReached(originalChannel.Id, extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+	if quotaReached {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the daily limit of %d thread moves out of ~%s was reached; try again tomorrow", p.getConfiguration().MaxMovesPerChannelPerDayInt(), originalChannel.Name)), true, nil
+	}
+
+	if options.remind != 0 {
+		var limitReached bool
+		limitReached, err = p.scheduledJobLimitReached(extra.UserId)
+		if err != nil {
+			return nil, false, err
+		}
+		if limitReached {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: you already have the maximum of %d scheduled reminders; cancel one with `/wrangler cancel reminder` or run the move without --%s", p.getConfiguration().MaxScheduledJobsPerUserInt(), flagMoveThreadRemind)), true, nil
+		}
+	}
+
+	if !approved && p.requiresMoveApproval(originalChannel, extra.UserId) {
+		request := &moveApprovalRequest{
+			ID:        model.NewId(),
+			UserID:    extra.UserId,
+			ChannelID: extra.ChannelId,
+			TeamID:    extra.TeamId,
+			Args:      args,
+		}
+		err = p.requestMoveApproval(request, wpl.RootPost(), len(movedPosts), originalChannel, targetChannel)
+		if err != nil {
+			return nil, false, err
+		}
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Moving threads out of this channel requires approval. Your request was sent to the approvers and you will get a DM once it is handled."), false, nil
+	}
+
+	for userID, username := range nonMemberAuthors {
+		_, appErr = p.API.AddChannelMember(targetChannel.Id, userID)
+		if appErr != nil {
+			p.API.LogError("Unable to add thread author to target channel", "user_id", userID, "channel_id", targetChannel.Id, "error", appErr.Error())
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to add @%s to ~%s; the thread was not moved", username, targetChannel.Name)), true, nil
+		}
+	}
+
+	var resp *model.CommandResponse
+	switch {
+	case intoRootPost != nil:
+		resp, userErr, err = p.moveThreadIntoThread(wpl, originalChannel, targetChannel, targetTeam, intoRootPost, extra, options)
+	case options.consolidate:
+		resp, userErr, err = p.moveThreadConsolidated(wpl, originalChannel, targetChannel, targetTeam, extra, options)
+	case options.repliesOnly:
+		resp, userErr, err = p.moveThreadReplies(wpl, originalChannel, targetChannel, targetTeam, extra, options)
+	default:
+		resp, userErr, err = p.moveThread(wpl, originalChannel, targetChannel, targetTeam, extra, options)
+	}
+	if resp == nil || err != nil {
+		return resp, userErr, err
+	}
+
+	if singlePost {
+		resp.Text += "\nThe thread had no replies, so a single message was moved."
+	}
+
+	if options.dateRange.isSet() {
+		resp.Text += fmt.Sprintf("\n%d of %d replies were posted %s and moved.", matchedReplies, totalReplies, options.dateRange)
+	}
+
+	if skippedCount != 0 {
+		resp.Text += fmt.Sprintf("\n%d message(s) by deactivated users were left out of the moved thread.", skippedCount)
+	}
+
+	if discardedReplies != 0 {
+		if options.keepOriginalThread {
+			resp.Text += fmt.Sprintf("\nOnly the root message was moved; its %d replies were left in the original thread.", discardedReplies)
+		} else {
+			resp.Text += fmt.Sprintf("\nOnly the root message was moved; its %d replies were discarded.", discardedReplies)
+		}
+	}
+
+	if searchIndexedInBackground(p.API.GetConfig()) {
+		resp.Text += "\nThe search index is updated in the background, so the moved messages may take a few moments to appear in search results."
+	}
+
+	if options.checkPermalinks {
+		p.logBrokenPermalinks(linkingPosts, extra.UserId)
+		resp.Text += formatBrokenPermalinks(linkingPosts, *p.API.GetConfig().ServiceSettings.SiteURL, p.getTeamName(extra.TeamId))
+	}
+
+	return resp, userErr, err
+}
+
+// previewMove returns a summary of the posts that would be moved without
+// changing anything. The moved root post doesn't exist yet, so the link to the
+// target channel is shown instead of the post link.
+func (p *Plugin) previewMove(movedPosts []*model.Post, targetChannel *model.Channel, targetTeam *model.Team, checkPermalinks bool, linkingPosts []*model.Post, userID string) *model.CommandResponse {
+	siteURL := *p.API.GetConfig().ServiceSettings.SiteURL
+
+	msg := fmt.Sprintf("Preview: %d message(s) by %d author(s) would be moved to ~%s. Nothing was changed.\n", len(movedPosts), countAuthors(movedPosts), targetChannel.Name)
+	msg += fmt.Sprintf("\nDestination channel: %s\n", makeChannelLink(siteURL, targetTeam.Name, targetChannel.Name))
+	msg += "The link to the moved thread is generated when the move is run without --preview."
+	if checkPermalinks {
+		msg += fmt.Sprintf("\n\n%d recent message(s) in the original channel contain permalinks that would stop resolving after the move.", len(linkingPosts))
+	}
+
+	return p.getTemporaryResponse(userID, msg)
+}
+
+// moveThread moves a whole thread to the target channel.
+func (p *Plugin) moveThread(wpl *WranglerPostList, originalChannel, targetChannel *model.Channel, targetTeam *model.Team, extra *model.CommandArgs, options moveThreadOptions) (*model.CommandResponse, bool, error) {
+	// The correlation ID ties the log entries of this move together and is
+	// shared with the user if the move has to be rolled back.
+	correlationID := model.NewId()
+
+	ctx, cancel := p.newOperationContext()
+	defer cancel()
+
+	release, response := p.acquireOperationSlot(ctx, "move", extra)
+	if response != nil {
+		return response, false, nil
+	}
+	defer release()
+
 	// Begin creating the new thread.
 	p.API.LogInfo("Wrangler is moving a thread",
 		"user_id", extra.UserId,
 		"original_post_id", wpl.RootPost().Id,
 		"original_channel_id", originalChannel.Id,
+		"correlation_id", correlationID,
 	)
 
 	// To simulate the move, we first copy the original messages(s) to the
 	// new channel and later delete the original messages(s).
-	newRootPost, err := p.copyWranglerPostlist(wpl, targetChannel)
+	postOptions := p.getMoveCopyOptions(wpl, originalChannel, targetChannel, extra.UserId)
+	postOptions.provenance = newProvenanceTracker()
+	if options.keepOriginalThread || options.leaveRedirect {
+		// Only link back when the original root message is kept, either with
+		// its replies or as a redirect.
+		postOptions.rootLinkBack = p.getMoveLinkBack(extra.TeamId, wpl.RootPost().Id)
+	}
+	newRootPost, err := p.copyWranglerPostlist(ctx, wpl, targetChannel, postOptions)
 	if err != nil {
-		return nil, false, err
+		return p.rollbackMove(correlationID, "copying the messages to the target channel", err, newRootPost, extra), false, nil
 	}
+	p.rewriteMovedPermalinks(postOptions.provenance)
 
-	_, appErr = p.API.CreatePost(&model.Post{
-		UserId:    p.BotUserID,
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.getAttributionAuthor(extra.UserId, targetChannel.Id),
 		RootId:    newRootPost.Id,
 		ParentId:  newRootPost.Id,
-		ChannelId: channelID,
-		Message:   "This thread was moved from another channel",
+		ChannelId: targetChannel.Id,
+		Message:   "This thread was moved from another channel" + p.getMoverMention(extra.UserId) + getCompressedImagesNote(postOptions.compression) + getSkippedFilesNote(postOptions.fileLimits),
 	})
 	if appErr != nil {
-		return nil, false, errors.Wrap(appErr, "unable to create new bot post")
+		return p.rollbackMove(correlationID, "creating the moved thread note", errors.Wrap(appErr, "unable to create new bot post"), newRootPost, extra), false, nil
 	}
 
-	// Cleanup is handled by simply deleting the root post. Any comments/replies
-	// are automatically marked as deleted for us.
-	appErr = p.API.DeletePost(wpl.RootPost().Id)
-	if appErr != nil {
-		return nil, false, errors.Wrap(appErr, "unable to delete post")
+	// Give clients time to sync the new thread before the original vanishes.
+	// The original thread is only removed if the move finished in time.
+	undone, err := p.waitForMoveUndo(ctx, p.getConfiguration().MoveDeletionDelay(), extra)
+	if err != nil {
+		return p.rollbackMove(correlationID, "waiting for the undo window", err, newRootPost, extra), false, nil
+	}
+	if undone {
+		return p.undoMove(correlationID, newRootPost, extra), false, nil
+	}
+	if err = p.checkMoveChannels(originalChannel.Id, targetChannel.Id); err != nil {
+		return p.rollbackMove(correlationID, "checking the channels before deleting the original messages", err, newRootPost, extra), false, nil
+	}
+
+	newPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, targetTeam.Name, newRootPost.Id)
+	var redirectNote string
+	switch {
+	case options.keepOriginalThread:
+		// Deleting the root post would also delete the replies that are left
+		// in the original thread, so it points to the moved root message
+		// instead.
+		postOptions.provenance.keep(wpl.RootPost().Id)
+		_, appErr = p.API.CreatePost(&model.Post{
+			UserId:    p.getAttributionAuthor(extra.UserId, originalChannel.Id),
+			RootId:    wpl.RootPost().Id,
+			ParentId:  wpl.RootPost().Id,
+			ChannelId: originalChannel.Id,
+			Message:   fmt.Sprintf("The root message of this thread was moved to %s; the replies were left here", newPostLink),
+		})
+		if appErr != nil {
+			return p.rollbackMove(correlationID, "creating the note in the original thread", errors.Wrap(appErr, "unable to create new bot post"), newRootPost, extra), false, nil
+		}
+	case options.leaveRedirect:
+		var numDeleted int
+		redirectNote, numDeleted, err = p.replaceWithRedirectStub(wpl, newRootPost.Id, newPostLink)
+		if err != nil {
+			return p.handleRedirectStubFailure(correlationID, err, numDeleted, []string{newRootPost.Id}, newPostLink, wpl, originalChannel, targetChannel, extra), false, nil
+		}
+		postOptions.provenance.keep(wpl.RootPost().Id)
+	default:
+		// Cleanup is handled by simply deleting the root post. Any
+		// comments/replies are automatically marked as deleted for us.
+		appErr = p.API.DeletePost(wpl.RootPost().Id)
+		if appErr != nil {
+			return p.handleOriginalDeleteFailure(correlationID, errors.Wrap(appErr, "unable to delete post"), []string{newRootPost.Id}, newPostLink, wpl.NumPosts(), originalChannel, targetChannel, extra), false, nil
+		}
 	}
 
 	p.API.LogInfo("Wrangler thread move complete",
 		"user_id", extra.UserId,
 		"new_post_id", newRootPost.Id,
-		"new_channel_id", channelID,
+		"new_channel_id", targetChannel.Id,
+		"correlation_id", correlationID,
 	)
 
-	newPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, targetTeam.Name, newRootPost.Id)
-	if extra.UserId != wpl.RootPost().UserId {
+	return p.completeMove(completedMove{
+		correlationID:  correlationID,
+		summary:        "A thread has been moved",
+		channelLogText: fmt.Sprintf("moved a thread of %d message(s) to %s", wpl.NumPosts(), newPostLink),
+		wpl:            wpl,
+		newRootPost:    newRootPost,
+		newPostLink:    newPostLink,
+		numPosts:       wpl.NumPosts(),
+		numAuthors:     wpl.NumAuthors(),
+		rootMoved:      true,
+		newThread:      true,
+		notes:          redirectNote,
+		groupMentions:  postOptions.groupMentions,
+		provenance:     postOptions.provenance,
+	}, originalChannel, targetChannel, targetTeam, extra, options), false, nil
+}
+
+// completedMove describes a move whose messages were all copied and whose
+// original messages were removed, for the steps shared by all kinds of moves.
+type completedMove struct {
+	correlationID string
+	// summary starts the message for the user, before the permalink.
+	summary        string
+	channelLogText string
+	wpl            *WranglerPostList
+	// newRootPost is the root post the moved messages are in, which existed
+	// before the move when moving into an existing thread.
+	newRootPost *model.Post
+	newPostLink string
+	numPosts    int
+	numAuthors  int
+	// rootMoved is whether the original root message was moved, rather than
+	// left in place with only its replies moved.
+	rootMoved bool
+	// newThread is whether the move started a new thread in the target
+	// channel.
+	newThread bool
+	// notes are added to the message for the user after the move details.
+	notes         string
+	groupMentions *groupMentionFilter
+	provenance    *provenanceTracker
+}
+
+// completeMove records a completed move, notifies the users concerned by it and
+// runs the extras requested for it. It returns the response for the user.
+func (p *Plugin) completeMove(move completedMove, originalChannel, targetChannel *model.Channel, targetTeam *model.Team, extra *model.CommandArgs, options moveThreadOptions) *model.CommandResponse {
+	rootPost := move.wpl.RootPost()
+
+	p.recordChannelLog(originalChannel.Id, extra.UserId, move.channelLogText)
+	record := p.recordOperation(operationMove, extra.UserId, originalChannel.Id, targetChannel.Id, move.numPosts)
+	p.recordChannelMove(originalChannel.Id)
+	p.recordProvenance(move.provenance, operationMove, extra.UserId, originalChannel.Id, targetChannel.Id)
+	p.notifyHighImpactMove(originalChannel, targetChannel, move.numPosts, move.numAuthors, extra.UserId, move.newPostLink)
+	p.notifyMoveSummary(record, originalChannel, targetChannel, move.newPostLink)
+	if move.rootMoved && extra.UserId != rootPost.UserId {
 		// The wrangled thread was not started by the user running the command.
 		// Send a DM to the user who created the root message to let them know.
-		err := p.postMoveThreadBotDM(wpl.RootPost().UserId, newPostLink)
+		err := p.postMoveThreadBotDM(rootPost.UserId, move.newPostLink)
 		if err != nil {
 			p.API.LogError("Unable to send move-thread DM to user",
 				"error", err.Error(),
-				"user_id", wpl.RootPost().UserId,
+				"user_id", rootPost.UserId,
 			)
 		}
 	}
 
-	msg := fmt.Sprintf("A thread has been moved: %s\n", newPostLink)
-	msg += fmt.Sprintf(
-		"\n| Team | Channel | Messages |\n| -- | -- | -- |\n| %s | %s | %d |\n\n",
-		targetTeam.DisplayName, targetChannel.DisplayName, wpl.NumPosts(),
+	msg := fmt.Sprintf("%s: %s\n", move.summary, move.newPostLink)
+	if move.rootMoved {
+		msg += formatMoveDetails(p.getSuccessVerbosity(extra.UserId), targetTeam, targetChannel, move.numPosts, move.numAuthors, rootPost.Message, options.showRootMessageInSummary, move.correlationID)
+	} else {
+		// The root message stays in place, so it is never quoted.
+		msg += formatMoveDetails(p.getSuccessVerbosity(extra.UserId), targetTeam, targetChannel, move.numPosts, move.numAuthors, "", false, move.correlationID)
+	}
+	msg += move.notes
+	msg += getGroupMentionsNote(move.groupMentions)
+	msg += p.scheduleMoveReminder(options, extra.UserId, move.newRootPost.Id, move.newPostLink)
+	msg += p.appendMoveChangelog(options, extra.UserId, move.newPostLink)
+	msg += p.createMoveCard(options, extra.UserId, rootPost.Message, move.newPostLink)
+	msg += p.leaveFeedbackPoll(options, extra.UserId, originalChannel.Id, move.newPostLink)
+	msg += p.updateMoveKnowledgeIndex(targetChannel, rootPost.Message, extra.UserId, move.newPostLink)
+	if move.newThread {
+		msg += p.postMovedThreadSummary(move.wpl.Posts, targetChannel, move.newRootPost, extra.UserId, move.newPostLink)
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_IN_CHANNEL, msg)
+}
+
+// getMoverMention returns the mention of the user who moved a thread for the
+// note in the moved thread, so that it is clear who owns the follow-up. It is
+// empty unless enabled or when the user can't be found.
+func (p *Plugin) getMoverMention(userID string) string {
+	if !p.getConfiguration().MentionMoverInMovedThreads {
+		return ""
+	}
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		p.API.LogError("Unable to get the user who moved a thread to mention them", "user_id", userID, "error", appErr.Error())
+		return ""
+	}
+
+	return " by @" + user.Username
+}
+
+// formatMoveDetails returns the details that follow the permalink in the
+// summary of a completed move at the given verbosity. Minimal summaries have
+// none, while detailed ones always quote the root message, when given, and
+// add the correlation ID of the move.
+func formatMoveDetails(verbosity string, targetTeam *model.Team, targetChannel *model.Channel, numPosts, numAuthors int, rootMessage string, showRootMessage bool, correlationID string) string {
+	if verbosity == successVerbosityMinimal {
+		return ""
+	}
+
+	msg := fmt.Sprintf(
+		"\n| Team | Channel | Messages | Authors |\n| -- | -- | -- | -- |\n| %s | %s | %d | %d |\n\n",
+		targetTeam.DisplayName, targetChannel.DisplayName, numPosts, numAuthors,
 	)
-	if showRootMessageInSummary {
+	if len(rootMessage) != 0 && (showRootMessage || verbosity == successVerbosityDetailed) {
 		msg += fmt.Sprintf("Original Thread Root Message:\n%s\n",
 			quoteBlock(cleanAndTrimMessage(
-				wpl.RootPost().Message, 500),
+				rootMessage, 500),
 			),
 		)
 	}
+	if verbosity == successVerbosityDetailed {
+		msg += fmt.Sprintf("Reference: %s\n", correlationID)
+	}
+
+	return msg
+}
+
+// getMoveCopyOptions returns the options used to recreate posts that are
+// being moved from the original channel to the target channel by the given
+// user.
+func (p *Plugin) getMoveCopyOptions(wpl *WranglerPostList, originalChannel, targetChannel *model.Channel, userID string) copyOptions {
+	config := p.getConfiguration()
+
+	botAttributedAuthors := p.getDeactivatedAuthors(wpl.Posts)
+	if config.NonMemberAuthorsPolicy() == nonMemberAuthorsBot {
+		for userID, username := range p.getNonMemberAuthors(wpl.Posts, targetChannel, botAttributedAuthors) {
+			botAttributedAuthors[userID] = username
+		}
+		// Noting that the moving user posted their own messages is redundant,
+		// so their messages are recreated under their own account instead.
+		if config.KeepMoverAsAuthor {
+			delete(botAttributedAuthors, userID)
+		}
+	}
+
+	var compression *imageCompressor
+	if config.IsArchiveChannel(targetChannel.Id) {
+		compression = config.ImageCompressor()
+	}
+	rootAnnotation, rootReaction := p.getResolutionAnnotation(targetChannel.Id, userID)
+
+	return copyOptions{
+		rootHashtag:          config.MovedHashtag,
+		rootStateAction:      config.ChannelStateActionsMap()[targetChannel.Id],
+		rootAnnotation:       rootAnnotation,
+		rootReaction:         rootReaction,
+		rootTimestampHeader:  config.EnableOriginalTimestampHeader,
+		timestampLocation:    p.getUserLocation(userID),
+		rootHeader:           config.DestinationHeader(originalChannel, targetChannel.Id),
+		textTransforms:       config.MoveTextTransformsForChannel(originalChannel.Id),
+		ticketLinks:          config.TicketLinkerForChannel(targetChannel.Id),
+		props:                movedPostProps(originalChannel, userID, config),
+		footer:               config.MovedFromFooterForChannel(originalChannel, targetChannel.Id),
+		concurrency:          config.PostCreationConcurrencyInt(),
+		botAttributedAuthors: botAttributedAuthors,
+		mentionPolicy:        config.MentionPolicyValue(),
+		groupMentions:        p.getGroupMentionFilter(wpl.Posts),
+		removeBroadcasts:     config.ReplyBroadcastsValue() == replyBroadcastsRemove,
+		compression:          compression,
+		fileLimits:           config.FileLimiter(),
+	}
+}
+
+// getMoveLinkBack returns the permalink of the original root post that the
+// moved root post links back to, or an empty string when moves don't link
+// back. It must only be used when the original root post survives the move.
+// The team the move was run from is used since direct and group message
+// channels have none.
+func (p *Plugin) getMoveLinkBack(teamID, rootPostID string) string {
+	if p.getConfiguration().LinkBackToOriginalValue() != linkBackCopiesAndMoves {
+		return ""
+	}
+	teamName := p.getTeamName(teamID)
+	if len(teamName) == 0 {
+		return ""
+	}
+
+	return makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, teamName, rootPostID)
+}
+
+// moveThreadReplies moves the replies of a thread to the target channel while
+// leaving the root post in place. The root post is copied to the target
+// channel so that the replies keep their context, and a note pointing to the
+// new location replaces the replies in the original thread.
+func (p *Plugin) moveThreadReplies(wpl *WranglerPostList, originalChannel, targetChannel *model.Channel, targetTeam *model.Team, extra *model.CommandArgs, options moveThreadOptions) (*model.CommandResponse, bool, error) {
+	correlationID := model.NewId()
+
+	ctx, cancel := p.newOperationContext()
+	defer cancel()
+
+	release, response := p.acquireOperationSlot(ctx, "move", extra)
+	if response != nil {
+		return response, false, nil
+	}
+	defer release()
+
+	p.API.LogInfo("Wrangler is moving thread replies",
+		"user_id", extra.UserId,
+		"original_post_id", wpl.RootPost().Id,
+		"original_channel_id", originalChannel.Id,
+		"correlation_id", correlationID,
+	)
+
+	postOptions := p.getMoveCopyOptions(wpl, originalChannel, targetChannel, extra.UserId)
+	postOptions.provenance = newProvenanceTracker()
+	postOptions.rootLinkBack = p.getMoveLinkBack(extra.TeamId, wpl.RootPost().Id)
+	postOptions.provenance.keep(wpl.RootPost().Id)
+	newRootPost, err := p.copyWranglerPostlist(ctx, wpl, targetChannel, postOptions)
+	if err != nil {
+		return p.rollbackMove(correlationID, "copying the messages to the target channel", err, newRootPost, extra), false, nil
+	}
+	p.rewriteMovedPermalinks(postOptions.provenance)
+
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.getAttributionAuthor(extra.UserId, targetChannel.Id),
+		RootId:    newRootPost.Id,
+		ParentId:  newRootPost.Id,
+		ChannelId: targetChannel.Id,
+		Message:   "The replies in this thread were moved from another channel" + p.getMoverMention(extra.UserId) + getSkippedFilesNote(postOptions.fileLimits),
+	})
+	if appErr != nil {
+		return p.rollbackMove(correlationID, "creating the moved thread note", errors.Wrap(appErr, "unable to create new bot post"), newRootPost, extra), false, nil
+	}
 
-	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_IN_CHANNEL, msg), false, nil
+	undone, err := p.waitForMoveUndo(ctx, p.getConfiguration().MoveDeletionDelay(), extra)
+	if err != nil {
+		return p.rollbackMove(correlationID, "waiting for the undo window", err, newRootPost, extra), false, nil
+	}
+	if undone {
+		return p.undoMove(correlationID, newRootPost, extra), false, nil
+	}
+	if err = p.checkMoveChannels(originalChannel.Id, targetChannel.Id); err != nil {
+		return p.rollbackMove(correlationID, "checking the channels before deleting the original messages", err, newRootPost, extra), false, nil
+	}
+
+	newPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, targetTeam.Name, newRootPost.Id)
+	for i, post := range wpl.Posts[1:] {
+		appErr = p.API.DeletePost(post.Id)
+		if appErr != nil && i == 0 {
+			// Nothing was deleted yet, so the move can still be rolled back
+			// or kept as a copy.
+			return p.handleOriginalDeleteFailure(correlationID, errors.Wrap(appErr, "unable to delete post"), []string{newRootPost.Id}, newPostLink, wpl.NumPosts()-1, originalChannel, targetChannel, extra), false, nil
+		}
+		if appErr != nil {
+			return p.reportPartialOriginalDelete(correlationID, errors.Wrap(appErr, "unable to delete post"), newPostLink, i, wpl.NumPosts()-1, originalChannel, extra), false, nil
+		}
+	}
+
+	_, appErr = p.API.CreatePost(&model.Post{
+		UserId:    p.getAttributionAuthor(extra.UserId, originalChannel.Id),
+		RootId:    wpl.RootPost().Id,
+		ParentId:  wpl.RootPost().Id,
+		ChannelId: originalChannel.Id,
+		Message:   fmt.Sprintf("The replies in this thread were moved to %s", newPostLink),
+	})
+	if appErr != nil {
+		// The replies were already moved, so a missing note doesn't fail
+		// the move.
+		p.API.LogError("Unable to create the note in the original thread",
+			"error", appErr.Error(),
+			"post_id", wpl.RootPost().Id,
+			"correlation_id", correlationID,
+		)
+	}
+
+	p.API.LogInfo("Wrangler thread replies move complete",
+		"user_id", extra.UserId,
+		"new_post_id", newRootPost.Id,
+		"new_channel_id", targetChannel.Id,
+		"correlation_id", correlationID,
+	)
+
+	return p.completeMove(completedMove{
+		correlationID:  correlationID,
+		summary:        "The replies of a thread have been moved",
+		channelLogText: fmt.Sprintf("moved %d message(s) out of a thread to %s", wpl.NumPosts()-1, newPostLink),
+		wpl:            wpl,
+		newRootPost:    newRootPost,
+		newPostLink:    newPostLink,
+		numPosts:       wpl.NumPosts() - 1,
+		numAuthors:     countAuthors(wpl.Posts[1:]),
+		newThread:      true,
+		groupMentions:  postOptions.groupMentions,
+		provenance:     postOptions.provenance,
+	}, originalChannel, targetChannel, targetTeam, extra, options), false, nil
+}
+
+// scheduleMoveReminder schedules the reminder requested with the remind flag
+// and returns a note about it to add to the move summary.
+func (p *Plugin) scheduleMoveReminder(options moveThreadOptions, userID, newPostID, newPostLink string) string {
+	if options.remind == 0 {
+		return ""
+	}
+
+	reminderID, err := p.addReminder(userID, newPostID, newPostLink, options.remind)
+	if err != nil {
+		p.API.LogError("Unable to schedule move reminder",
+			"error", err.Error(),
+			"user_id", userID,
+		)
+		return "\nThe thread was moved, but the reminder could not be scheduled.\n"
+	}
+
+	return fmt.Sprintf("\nA reminder will be sent to you in %s. Run `/wrangler cancel reminder %s` to cancel it.\n", options.remind, reminderID)
+}
+
+// appendMoveChangelog appends the entry given with the changelog flag to the
+// changelog post and returns a note for the move summary.
+func (p *Plugin) appendMoveChangelog(options moveThreadOptions, userID, newPostLink string) string {
+	if len(strings.TrimSpace(options.changelog)) == 0 {
+		return ""
+	}
+
+	err := p.appendChangelogEntry(options.changelog, newPostLink)
+	if err != nil {
+		p.API.LogError("Unable to append move changelog entry",
+			"error", err.Error(),
+			"user_id", userID,
+		)
+		return "\nThe thread was moved, but the changelog entry could not be added.\n"
+	}
+
+	return "\nThe changelog entry was added.\n"
+}
+
+// createMoveCard creates the card requested with the create card flag and
+// returns a note for the move summary.
+func (p *Plugin) createMoveCard(options moveThreadOptions, userID, rootMessage, newPostLink string) string {
+	if !options.createCard {
+		return ""
+	}
+
+	err := p.createBoardsCard(userID, rootMessage, newPostLink)
+	if err != nil {
+		p.API.LogError("Unable to create move card",
+			"error", err.Error(),
+			"user_id", userID,
+		)
+		return "\nThe thread was moved, but the card could not be created on the board.\n"
+	}
+
+	return "\nA card linking to the moved thread was created on the board.\n"
+}
+
+// leaveFeedbackPoll posts the poll requested with the feedback poll flag in
+// the original channel and returns a note for the move summary.
+func (p *Plugin) leaveFeedbackPoll(options moveThreadOptions, userID, originalChannelID, newPostLink string) string {
+	if !options.feedbackPoll {
+		return ""
+	}
+
+	err := p.postFeedbackPoll(originalChannelID, newPostLink)
+	if err != nil {
+		p.API.LogError("Unable to post move feedback poll",
+			"error", err.Error(),
+			"user_id", userID,
+		)
+		return "\nThe thread was moved, but the feedback poll could not be posted.\n"
+	}
+
+	return "\nA feedback poll was left in the original channel.\n"
+}
+
+// checkMoveChannels returns an error if the original or target channel of a
+// move was deleted or archived, which makes it read-only. It runs right before
+// the original messages are deleted as either channel may have changed while
+// the messages were being copied.
+func (p *Plugin) checkMoveChannels(originalChannelID, targetChannelID string) error {
+	originalChannel, appErr := p.API.GetChannel(originalChannelID)
+	if appErr != nil || originalChannel.DeleteAt != 0 {
+		return errors.New("the original channel was deleted or archived during the move")
+	}
+	targetChannel, appErr := p.API.GetChannel(targetChannelID)
+	if appErr != nil || targetChannel.DeleteAt != 0 {
+		return errors.New("the target channel was deleted or archived during the move")
+	}
+	return nil
+}
+
+// rollbackMove removes the messages already copied to the target channel by a
+// failed move and returns a response explaining which step failed. The original
+// thread is left untouched by all steps that can be rolled back.
+func (p *Plugin) rollbackMove(correlationID, step string, stepErr error, newRootPost *model.Post, extra *model.CommandArgs) *model.CommandResponse {
+	var newPostIDs []string
+	if newRootPost != nil {
+		// Deleting the new root post also deletes any copied replies.
+		newPostIDs = []string{newRootPost.Id}
+	}
+
+	return p.rollbackMovePosts(correlationID, step, stepErr, newPostIDs, extra)
+}
+
+// rollbackMovePosts rolls back a failed move by deleting the given posts that
+// it created in the target channel and returns the response for the user.
+func (p *Plugin) rollbackMovePosts(correlationID, step string, stepErr error, newPostIDs []string, extra *model.CommandArgs) *model.CommandResponse {
+	p.API.LogError("Wrangler thread move failed; rolling back",
+		"user_id", extra.UserId,
+		"step", step,
+		"error", stepErr.Error(),
+		"correlation_id", correlationID,
+	)
+
+	rollbackMsg := "Nothing was changed."
+	for _, newPostID := range newPostIDs {
+		appErr := p.API.DeletePost(newPostID)
+		if appErr != nil {
+			p.API.LogError("Wrangler thread move rollback failed",
+				"new_post_id", newPostID,
+				"error", appErr.Error(),
+				"correlation_id", correlationID,
+			)
+			rollbackMsg = "The original thread was not changed, but some copied messages could not be removed from the target channel."
+		}
+	}
+
+	if errors.Cause(stepErr) == context.DeadlineExceeded {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+			"Error: the thread move took longer than the configured timeout of %s and was rolled back. %s\n\nTry moving a smaller thread, or ask a system admin to increase the operation timeout.\nReference: %s",
+			p.getConfiguration().OperationTimeout(), rollbackMsg, correlationID,
+		))
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+		"Error: the thread move failed while %s and was rolled back. %s\n\nReason: %s\nReference: %s",
+		step, rollbackMsg, stepErr.Error(), correlationID,
+	))
+}
+
+// handleOriginalDeleteFailure handles a move whose original messages couldn't
+// be deleted after they were copied to the target channel. Depending on the
+// configuration, the move is either rolled back or kept as a copy, which
+// leaves the original messages in place next to the copied ones.
+func (p *Plugin) handleOriginalDeleteFailure(correlationID string, deleteErr error, newPostIDs []string, newPostLink string, numPosts int, originalChannel, targetChannel *model.Channel, extra *model.CommandArgs) *model.CommandResponse {
+	if p.getConfiguration().OriginalDeleteFailureValue() != originalDeleteFailureCopy {
+		return p.rollbackMovePosts(correlationID, "deleting the original thread", deleteErr, newPostIDs, extra)
+	}
+
+	p.API.LogWarn("Wrangler could not delete the original messages of a move; keeping it as a copy",
+		"user_id", extra.UserId,
+		"error", deleteErr.Error(),
+		"correlation_id", correlationID,
+	)
+
+	p.recordChannelLog(originalChannel.Id, extra.UserId, fmt.Sprintf("copied a thread of %d message(s) to %s after its original messages could not be deleted", numPosts, newPostLink))
+	p.recordOperation(operationCopy, extra.UserId, originalChannel.Id, targetChannel.Id, numPosts)
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+		"Warning: the original messages could not be deleted, so the thread was copied instead of moved: %s\n\nThe original messages were kept in ~%s and can be deleted manually.\n\nReason: %s\nReference: %s",
+		newPostLink, originalChannel.Name, deleteErr.Error(), correlationID,
+	))
+}
+
+// reportPartialOriginalDelete handles a move whose original messages were
+// only partly deleted after they were copied to the target channel. The move
+// can no longer be rolled back, so the user is told which part of it is left.
+func (p *Plugin) reportPartialOriginalDelete(correlationID string, deleteErr error, newPostLink string, numDeleted, numPosts int, originalChannel *model.Channel, extra *model.CommandArgs) *model.CommandResponse {
+	p.API.LogError("Wrangler could only delete some of the original messages of a move",
+		"user_id", extra.UserId,
+		"deleted", numDeleted,
+		"total", numPosts,
+		"error", deleteErr.Error(),
+		"correlation_id", correlationID,
+	)
+
+	p.recordChannelLog(originalChannel.Id, extra.UserId, fmt.Sprintf("copied %d message(s) to %s but deleted only %d of the original messages", numPosts, newPostLink, numDeleted))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+		"Error: the thread was copied to %s, but only %d of the %d original messages could be deleted.\n\nThe remaining original messages were kept in ~%s and can be deleted manually.\n\nReason: %s\nReference: %s",
+		newPostLink, numDeleted, numPosts, originalChannel.Name, deleteErr.Error(), correlationID,
+	))
 }
 
 func (p *Plugin) postMoveThreadBotDM(userID, newPostLink string) error {
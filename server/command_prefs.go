@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	prefsUsage = `/wrangler prefs [show|set]
+  Show or change your personal Wrangler preferences
+    - '/wrangler prefs show' lists your current preferences
+    - '/wrangler prefs set default-action move|copy' sets the action run by '/wrangler thread'
+    - '/wrangler prefs set default-destination CHANNEL_ID|none' sets the channel that threads are moved or copied to when the destination is omitted
+    - '/wrangler prefs set success-verbosity minimal|normal|detailed' sets how much detail the summaries of moves and copies include`
+
+	threadUsage = `/wrangler thread [MESSAGE_ID] [CHANNEL_ID] [flags]
+  Move or copy a given message, along with the thread it belongs to, to a given channel depending on your default-action preference
+    - Threads are copied unless your default action is set to move
+    - Accepts the same flags as the move or copy thread commands`
+
+	prefDefaultAction      = "default-action"
+	prefDefaultDestination = "default-destination"
+	prefSuccessVerbosity   = "success-verbosity"
+
+	// prefNone clears the default destination preference.
+	prefNone = "none"
+)
+
+func getPrefsMessage() string {
+	return codeBlock(fmt.Sprintf("`Error: missing arguments\n\n%s", prefsUsage))
+}
+
+func (p *Plugin) runPrefsCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if len(args) < 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getPrefsMessage()), true, nil
+	}
+
+	prefs, err := p.getUserPreferences(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch args[0] {
+	case "show":
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+			"Your Wrangler preferences:\n\n| Preference | Value |\n| -- | -- |\n| %s | %s |\n| %s | %s |\n| %s | %s |",
+			prefDefaultAction, prefs.getDefaultAction(),
+			prefDefaultDestination, p.getDefaultDestinationDisplay(prefs.DefaultDestination),
+			prefSuccessVerbosity, prefs.getSuccessVerbosity(p.getConfiguration().SuccessMessageVerbosityValue()),
+		)), false, nil
+	case "set":
+		if len(args) < 3 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getPrefsMessage()), true, nil
+		}
+
+		var value string
+		switch args[1] {
+		case prefDefaultAction:
+			if args[2] != defaultActionMove && args[2] != defaultActionCopy {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: %s must be either %s or %s", prefDefaultAction, defaultActionMove, defaultActionCopy)), true, nil
+			}
+			prefs.DefaultAction = args[2]
+			value = prefs.DefaultAction
+		case prefDefaultDestination:
+			if args[2] == prefNone {
+				prefs.DefaultDestination = ""
+				value = prefNone
+				break
+			}
+			if !model.IsValidId(args[2]) {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: %s is not a valid channel ID; %s", args[2], channelIDArg.hint)), true, nil
+			}
+			err = p.checkDefaultDestination(args[2], extra.UserId)
+			if err != nil {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: %s", err.Error())), true, nil
+			}
+			prefs.DefaultDestination = args[2]
+			value = p.getDefaultDestinationDisplay(prefs.DefaultDestination)
+		case prefSuccessVerbosity:
+			if !isValidSuccessVerbosity(args[2]) {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: %s must be %s, %s or %s", prefSuccessVerbosity, successVerbosityMinimal, successVerbosityNormal, successVerbosityDetailed)), true, nil
+			}
+			prefs.SuccessVerbosity = args[2]
+			value = prefs.SuccessVerbosity
+		default:
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unknown preference %s", args[1])), true, nil
+		}
+
+		err = p.setUserPreferences(extra.UserId, prefs)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Your %s preference is now %s", args[1], value)), false, nil
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getPrefsMessage()), true, nil
+}
+
+// runThreadCommand moves or copies a thread depending on the default action
+// preference of the user.
+func (p *Plugin) runThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	prefs, err := p.getUserPreferences(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if prefs.getDefaultAction() == defaultActionMove {
+		return p.runMoveThreadCommand(args, extra)
+	}
+
+	return p.runCopyThreadCommand(args, extra)
+}
+
+// getDefaultDestinationDisplay returns the name and ID of the default
+// destination channel, or only its ID if the channel can't be found.
+func (p *Plugin) getDefaultDestinationDisplay(channelID string) string {
+	if len(channelID) == 0 {
+		return prefNone
+	}
+
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return channelID
+	}
+
+	return fmt.Sprintf("~%s (%s)", channel.Name, channelID)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefsCommand(t *testing.T) {
+	f := newThreadTestFixture(1)
+
+	var plugin Plugin
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{})
+
+	t.Run("missing arguments", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing arguments")
+	})
+
+	t.Run("show defaults", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"show"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "| default-action | copy |")
+	})
+
+	t.Run("invalid preference", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"set", "color", "blue"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: unknown preference color")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"set", "default-action", "delete"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: default-action must be either move or copy")
+	})
+
+	t.Run("invalid success verbosity", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"set", "success-verbosity", "loud"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: success-verbosity must be minimal, normal or detailed", resp.Text)
+	})
+
+	t.Run("thread is copied by default", func(t *testing.T) {
+		resp, isUserError, err := plugin.runThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Thread copy complete", resp.Text)
+		f.api.AssertNotCalled(t, "DeletePost", f.rootPost.Id)
+	})
+
+	t.Run("set move", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"set", "default-action", "move"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Your default-action preference is now move", resp.Text)
+
+		resp, _, err = plugin.runPrefsCommand([]string{"show"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, "| default-action | move |")
+
+		other := f.commandArgs()
+		other.UserId = model.NewId()
+		resp, _, err = plugin.runPrefsCommand([]string{"show"}, other)
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, "| default-action | copy |")
+	})
+
+	t.Run("thread is moved with the move preference", func(t *testing.T) {
+		resp, isUserError, err := plugin.runThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+	})
+}
+
+func TestDefaultDestinationPreference(t *testing.T) {
+	f := newThreadTestFixture(1)
+	missingChannelID := model.NewId()
+	f.api.On("GetChannel", missingChannelID).Return(nil, &model.AppError{Message: "not found"})
+
+	var plugin Plugin
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{})
+
+	t.Run("show unset", func(t *testing.T) {
+		resp, _, err := plugin.runPrefsCommand([]string{"show"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, "| default-destination | none |")
+	})
+
+	t.Run("destination is still required when unset", func(t *testing.T) {
+		resp, isUserError, err := plugin.runCopyThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing destination channel ID after the message ID")
+	})
+
+	t.Run("invalid channel ID", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"set", "default-destination", "town-square"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: town-square is not a valid channel ID")
+	})
+
+	t.Run("missing channel", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"set", "default-destination", missingChannelID}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, fmt.Sprintf("Error: channel with ID %s doesn't exist", missingChannelID), resp.Text)
+	})
+
+	t.Run("set and show", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"set", "default-destination", f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, fmt.Sprintf("Your default-destination preference is now ~target-channel (%s)", f.targetChannel.Id), resp.Text)
+
+		resp, _, err = plugin.runPrefsCommand([]string{"show"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, fmt.Sprintf("| default-destination | ~target-channel (%s) |", f.targetChannel.Id))
+	})
+
+	t.Run("copy uses the default destination", func(t *testing.T) {
+		resp, isUserError, err := plugin.runCopyThreadCommand([]string{f.rootPost.Id, "--limit", "1"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Thread copy complete", resp.Text)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == f.targetChannel.Id && post.Message == "This is reply 1"
+		}))
+	})
+
+	t.Run("destination can no longer be used", func(t *testing.T) {
+		f.targetChannel.DeleteAt = model.GetMillis()
+		defer func() { f.targetChannel.DeleteAt = 0 }()
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, fmt.Sprintf("Error: your default-destination preference can no longer be used: channel with ID %s doesn't exist. Pass a destination channel ID or change it with '/wrangler prefs set default-destination CHANNEL_ID'.", f.targetChannel.Id), resp.Text)
+		f.api.AssertNotCalled(t, "DeletePost", f.rootPost.Id)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		resp, isUserError, err := plugin.runPrefsCommand([]string{"set", "default-destination", "none"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Your default-destination preference is now none", resp.Text)
+
+		resp, _, err = plugin.runPrefsCommand([]string{"show"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, "| default-destination | none |")
+	})
+}
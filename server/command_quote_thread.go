@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const quoteThreadUsage = `/wrangler quote thread [MESSAGE_ID] [CHANNEL_ID]
+  Post a single message quoting a given message, along with the thread it belongs to, in a given channel
+    - The quote links to the original thread and is cut short when the thread is too long for a single message
+    - Obtain the channel ID by running '/wrangler list channels' or via the channel 'View Info' option`
+
+// maxQuotePostRunes is the longest quote that is posted.
+const maxQuotePostRunes = model.POST_MESSAGE_MAX_RUNES_V2
+
+func (p *Plugin) runQuoteThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	positional, err := parseCommandArgs(args, nil, messageIDArg, channelIDArg.withName("destination channel ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, quoteThreadUsage), true, nil
+	}
+	postID := positional[0]
+	channelID := positional[1]
+
+	postListResponse, appErr := p.API.GetPostThread(postID)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	wpl := buildWranglerPostList(postListResponse)
+
+	originalChannel, appErr := p.API.GetChannel(extra.ChannelId)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", extra.ChannelId)
+	}
+	_, appErr = p.API.GetChannelMember(channelID, extra.UserId)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", channelID)), true, nil
+	}
+	targetChannel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", channelID)
+	}
+
+	response, userErr, err := p.validateMoveOrCopy(wpl, originalChannel, targetChannel, extra)
+	if response != nil || err != nil {
+		return response, userErr, err
+	}
+
+	threadLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, p.getTeamName(extra.TeamId), wpl.RootPost().Id)
+	header := fmt.Sprintf("Quoted thread of %d message(s) from ~%s: %s", wpl.NumPosts(), originalChannel.Name, threadLink)
+	footer := fmt.Sprintf("The quote was cut short; see the full thread: %s", threadLink)
+	message, quotedCount := renderThreadQuote(header, footer, renderTranscriptEntries(wpl, p.getAuthorUsernames(wpl.Posts), p.getUserLocation(extra.UserId)), maxQuotePostRunes)
+
+	newPost, appErr := p.API.CreatePost(&model.Post{
+		UserId:    extra.UserId,
+		ChannelId: targetChannel.Id,
+		Message:   message,
+	})
+	if appErr != nil {
+		return nil, false, errors.Wrap(appErr, "unable to create quote post")
+	}
+
+	p.API.LogInfo("Wrangler quoted a thread",
+		"user_id", extra.UserId,
+		"original_post_id", wpl.RootPost().Id,
+		"new_post_id", newPost.Id,
+	)
+
+	msg := fmt.Sprintf("Thread quoted in ~%s", targetChannel.Name)
+	if quotedCount < wpl.NumPosts() {
+		msg += fmt.Sprintf("; the thread was too long for a single message, so only its first %d message(s) were quoted", quotedCount)
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// renderThreadQuote returns the header followed by the transcript entries as
+// blockquotes. When they don't fit in maxRunes runes, entries are left out
+// from the first one that doesn't fit and the footer is added instead. A root
+// entry that doesn't fit on its own is cut short. The number of quoted entries
+// is returned along with the message.
+func renderThreadQuote(header, footer string, entries []string, maxRunes int) (string, int) {
+	quotes := make([]string, len(entries))
+	for i, entry := range entries {
+		quotes[i] = "\n\n" + quoteText(entry)
+	}
+
+	message := header + strings.Join(quotes, "")
+	if utf8.RuneCountInString(message) <= maxRunes {
+		return message, len(entries)
+	}
+
+	message = header
+	room := maxRunes - utf8.RuneCountInString(header) - utf8.RuneCountInString("\n\n"+footer)
+	for i, quote := range quotes {
+		quoteRunes := utf8.RuneCountInString(quote)
+		if quoteRunes > room {
+			if i == 0 && room > 0 {
+				message += string([]rune(quote)[:room-1]) + "…"
+				i++
+			}
+			return message + "\n\n" + footer, i
+		}
+		message += quote
+		room -= quoteRunes
+	}
+
+	return message, len(entries)
+}
+
+// quoteText returns the text as a markdown blockquote.
+func quoteText(text string) string {
+	return "> " + strings.Replace(text, "\n", "\n> ", -1)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteThreadCommand(t *testing.T) {
+	setup := func(replyCount int) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(replyCount)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{})
+
+		return f, plugin
+	}
+
+	t.Run("no arguments", func(t *testing.T) {
+		_, plugin := setup(1)
+		resp, isUserError, err := plugin.runQuoteThreadCommand([]string{}, &model.CommandArgs{})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing arguments")
+	})
+
+	t.Run("thread is quoted", func(t *testing.T) {
+		f, plugin := setup(2)
+		resp, isUserError, err := plugin.runQuoteThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Thread quoted in ~target-channel", resp.Text)
+
+		f.api.AssertNumberOfCalls(t, "CreatePost", 1)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == f.targetChannel.Id && post.UserId == f.rootPost.UserId &&
+				strings.HasPrefix(post.Message, "Quoted thread of 3 message(s) from ~original-channel: test.sampledomain.com/team-1/pl/"+f.rootPost.Id) &&
+				strings.Contains(post.Message, "> This is the root message") &&
+				strings.Contains(post.Message, "> This is reply 2")
+		}))
+		f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+	})
+
+	t.Run("long thread is cut short", func(t *testing.T) {
+		f, plugin := setup(3)
+		for _, reply := range f.replies {
+			reply.Message = strings.Repeat("a", maxQuotePostRunes/3)
+		}
+
+		resp, isUserError, err := plugin.runQuoteThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Thread quoted in ~target-channel; the thread was too long for a single message, so only its first 3 message(s) were quoted", resp.Text)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return utf8.RuneCountInString(post.Message) <= maxQuotePostRunes &&
+				strings.HasSuffix(post.Message, "The quote was cut short; see the full thread: test.sampledomain.com/team-1/pl/"+f.rootPost.Id)
+		}))
+	})
+}
+
+func TestRenderThreadQuote(t *testing.T) {
+	t.Run("fits", func(t *testing.T) {
+		message, count := renderThreadQuote("header", "footer", []string{"one", "two\nlines"}, 100)
+		assert.Equal(t, "header\n\n> one\n\n> two\n> lines", message)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("later entries are left out", func(t *testing.T) {
+		message, count := renderThreadQuote("header", "footer", []string{"one", "two", "three"}, 28)
+		assert.Equal(t, "header\n\n> one\n\n> two\n\nfooter", message)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("long root entry is cut short", func(t *testing.T) {
+		message, count := renderThreadQuote("header", "footer", []string{strings.Repeat("a", 50)}, 30)
+		assert.Equal(t, "header\n\n> aaaaaaaaaaa…\n\nfooter", message)
+		assert.Equal(t, 30, utf8.RuneCountInString(message))
+		assert.Equal(t, 1, count)
+	})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const requestMoveUsage = `/wrangler request move [MESSAGE_ID] [CHANNEL_ID]
+  Ask the approvers to move a message and the thread it belongs to into another channel
+    - Available to everyone, including users who can't move threads themselves
+    - You get a DM with the outcome once the request is handled
+    - Requires the 'request-move' experimental feature`
+
+const moveRequestQuotaKeyPrefix = "move_request_quota_"
+
+func getMoveRequestQuotaKey(userID string) string {
+	return moveRequestQuotaKeyPrefix + userID
+}
+
+func (p *Plugin) runRequestMoveCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	config := p.getConfiguration()
+	if len(config.MoveApprovalChannelID) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: move requests are not enabled; a system administrator must set a move approval channel first"), true, nil
+	}
+	positional, err := parseCommandArgs(args, nil, messageIDArg, channelIDArg.withName("destination channel ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, requestMoveUsage), true, nil
+	}
+	postID := positional[0]
+	channelID := positional[1]
+
+	postListResponse, appErr := p.API.GetPostThread(postID)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	wpl := buildWranglerPostList(postListResponse)
+	if wpl.NumPosts() == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+
+	originalChannel, appErr := p.API.GetChannel(wpl.RootPost().ChannelId)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", wpl.RootPost().ChannelId)
+	}
+	if !p.API.HasPermissionToChannel(extra.UserId, originalChannel.Id, model.PERMISSION_READ_CHANNEL) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	_, appErr = p.API.GetChannelMember(channelID, extra.UserId)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", channelID)), true, nil
+	}
+	targetChannel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return nil, false, fmt.Errorf("unable to get channel with ID %s", channelID)
+	}
+	if targetChannel.Id == originalChannel.Id {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: the thread is already in that channel"), true, nil
+	}
+
+	limitReached, err := p.moveRequestLimitReached(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+	if limitReached {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: you already requested %d thread moves today; please try again tomorrow", config.MaxMoveRequestsPerUserPerDayInt())), true, nil
+	}
+
+	// The move runs from the channel of the thread once approved, as if the
+	// approver ran it there.
+	request := &moveApprovalRequest{
+		ID:            model.NewId(),
+		UserID:        extra.UserId,
+		ChannelID:     originalChannel.Id,
+		TeamID:        originalChannel.TeamId,
+		Args:          []string{wpl.RootPost().Id, targetChannel.Id},
+		RunAsApprover: true,
+	}
+	err = p.requestMoveApproval(request, wpl.RootPost(), wpl.NumPosts(), originalChannel, targetChannel)
+	if err != nil {
+		return nil, false, err
+	}
+	p.recordMoveRequest(extra.UserId)
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Your request to move the thread to ~%s was sent to the approvers. You will get a DM once it is handled.", targetChannel.Name)), false, nil
+}
+
+// moveRequestLimitReached returns true if the user filed the configured number
+// of move requests for the current day.
+func (p *Plugin) moveRequestLimitReached(userID string) (bool, error) {
+	max := p.getConfiguration().MaxMoveRequestsPerUserPerDayInt()
+	if max == 0 {
+		return false, nil
+	}
+
+	count, err := p.getMoveRequestCount(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return count >= max, nil
+}
+
+// getMoveRequestCount returns the number of move requests the user filed for
+// the current day.
+func (p *Plugin) getMoveRequestCount(userID string) (int, error) {
+	var quota moveQuota
+	_, err := p.kvGetJSON(getMoveRequestQuotaKey(userID), &quota)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to get move request quota")
+	}
+	if quota.Day != currentMoveQuotaDay() {
+		return 0, nil
+	}
+
+	return quota.Count, nil
+}
+
+// recordMoveRequest counts a filed move request towards the limit of the user
+// for the current day. Failures are logged as the request was already filed.
+func (p *Plugin) recordMoveRequest(userID string) {
+	if p.getConfiguration().MaxMoveRequestsPerUserPerDayInt() == 0 {
+		return
+	}
+
+	err := p.kvAtomicModify(getMoveRequestQuotaKey(userID), func(initial []byte) ([]byte, error) {
+		var quota moveQuota
+		if initial != nil {
+			err := json.Unmarshal(initial, &quota)
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to unmarshal move request quota")
+			}
+		}
+
+		day := currentMoveQuotaDay()
+		if quota.Day != day {
+			quota = moveQuota{Day: day}
+		}
+		quota.Count++
+
+		return json.Marshal(quota)
+	})
+	if err != nil {
+		p.API.LogError("Unable to record move request quota", "error", err.Error(), "user_id", userID)
+	}
+}
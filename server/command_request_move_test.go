@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMoveCommand(t *testing.T) {
+	approvalChannelID := model.NewId()
+	approverID := model.NewId()
+
+	isApprovalRequest := func(post *model.Post) bool {
+		return post.ChannelId == approvalChannelID && len(post.Attachments()) == 1
+	}
+
+	setup := func(config *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+		f.api.On("GetPostThread", f.replies[0].Id).Return(f.postList, nil)
+		f.unsetMock("GetUser")
+		f.api.On("GetUser", approverID).Return(&model.User{Id: approverID, Username: "approver", Roles: model.SYSTEM_USER_ROLE_ID + " " + model.SYSTEM_ADMIN_ROLE_ID}, nil)
+		f.api.On("GetUser", mock.AnythingOfType("string")).Return(&model.User{Id: model.NewId(), Username: "active.user", Roles: model.SYSTEM_USER_ROLE_ID}, nil)
+		f.api.On("HasPermissionTo", approverID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+		f.api.On("HasPermissionTo", mock.AnythingOfType("string"), model.PERMISSION_MANAGE_SYSTEM).Return(false)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+
+		return f, plugin
+	}
+
+	pendingRequests := func(t *testing.T, f *threadTestFixture, plugin *Plugin) []*moveApprovalRequest {
+		requests, err := plugin.getPendingMoveApprovals()
+		require.NoError(t, err)
+		return requests
+	}
+
+	// requestArgs are the arguments of a request run from another channel
+	// than the channel of the thread.
+	requestArgs := func(f *threadTestFixture) *model.CommandArgs {
+		extra := f.commandArgs()
+		extra.ChannelId = model.NewId()
+		return extra
+	}
+
+	t.Run("approval channel not set", func(t *testing.T) {
+		f, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runRequestMoveCommand([]string{f.rootPost.Id, f.targetChannel.Id}, requestArgs(f))
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: move requests are not enabled")
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("missing arguments", func(t *testing.T) {
+		f, plugin := setup(&configuration{MoveApprovalChannelID: approvalChannelID})
+
+		resp, isUserError, err := plugin.runRequestMoveCommand([]string{f.rootPost.Id}, requestArgs(f))
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing destination channel ID after the message ID")
+	})
+
+	t.Run("request and approve", func(t *testing.T) {
+		f, plugin := setup(&configuration{MoveApprovalChannelID: approvalChannelID, MovePermittedRoles: model.SYSTEM_ADMIN_ROLE_ID})
+
+		// The requester isn't allowed to move threads themselves.
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "your role doesn't permit you to move threads")
+
+		resp, isUserError, err = plugin.runRequestMoveCommand([]string{f.replies[0].Id, f.targetChannel.Id}, requestArgs(f))
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Your request to move the thread to ~"+f.targetChannel.Name+" was sent to the approvers. You will get a DM once it is handled.", resp.Text)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return isApprovalRequest(post) && strings.HasPrefix(post.Message, "@active.user requested to move 2 message(s) from ~"+f.originalChannel.Name+" to ~"+f.targetChannel.Name)
+		}))
+		f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+
+		requests := pendingRequests(t, f, plugin)
+		require.Len(t, requests, 1)
+		assert.Equal(t, f.originalChannel.Id, requests[0].ChannelID)
+		assert.Equal(t, []string{f.rootPost.Id, f.targetChannel.Id}, requests[0].Args)
+		assert.True(t, requests[0].RunAsApprover)
+
+		actionResp, err := plugin.handleMoveApprovalAction(approverID, requests[0].ID, moveApprovalActionApprove)
+		require.NoError(t, err)
+		require.NotNil(t, actionResp.Update)
+		assert.Contains(t, actionResp.Update.Message, "was approved by @approver")
+		assert.Contains(t, actionResp.Update.Message, "A thread has been moved")
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return strings.HasPrefix(post.Message, "Your request to move a thread was approved by @approver.")
+		}))
+	})
+
+	t.Run("daily limit", func(t *testing.T) {
+		f, plugin := setup(&configuration{MoveApprovalChannelID: approvalChannelID, MaxMoveRequestsPerUserPerDay: "2"})
+
+		for i := 0; i < 2; i++ {
+			_, isUserError, err := plugin.runRequestMoveCommand([]string{f.rootPost.Id, f.targetChannel.Id}, requestArgs(f))
+			require.NoError(t, err)
+			require.False(t, isUserError)
+		}
+
+		resp, isUserError, err := plugin.runRequestMoveCommand([]string{f.rootPost.Id, f.targetChannel.Id}, requestArgs(f))
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: you already requested 2 thread moves today; please try again tomorrow", resp.Text)
+		assert.Len(t, pendingRequests(t, f, plugin), 2)
+	})
+}
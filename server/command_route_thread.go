@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const routeThreadUsage = `/wrangler route thread [MESSAGE_ID] [flags]
+  Move a given message, along with the thread it belongs to, to the channel of the first routing rule matching the root message
+    - Routing rules are set in the plugin configuration
+    - When no rule matches, the configured routing endpoint is asked for the destination
+    - Accepts the same flags as '/wrangler move thread'`
+
+func (p *Plugin) runRouteThreadCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	// The remaining args are passed on to the move thread command.
+	positional, err := parseCommandArgs(args, getMoveThreadFlagSet(), messageIDArg)
+	if err != nil {
+		return getCommandArgsErrorResponse(err, routeThreadUsage), true, nil
+	}
+	postID := positional[0]
+
+	postListResponse, appErr := p.API.GetPostThread(postID)
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: unable to get post with ID %s; ensure this is correct", postID)), true, nil
+	}
+	wpl := buildWranglerPostList(postListResponse)
+	if wpl.NumPosts() == 0 {
+		return nil, false, fmt.Errorf("unable to get thread of post with ID %s", postID)
+	}
+
+	rules := p.getConfiguration().RoutingRulesList()
+	for _, rule := range rules {
+		if !rule.matches(wpl.RootPost().Message) {
+			continue
+		}
+
+		p.API.LogInfo("Wrangler routing rule matched",
+			"user_id", extra.UserId,
+			"original_post_id", wpl.RootPost().Id,
+			"target_channel_id", rule.ChannelID,
+		)
+
+		moveArgs := append([]string{postID, rule.ChannelID}, args[1:]...)
+		return p.runMoveThreadCommand(moveArgs, extra)
+	}
+
+	if len(p.getConfiguration().RoutingEndpointURL) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "No routing rule matches the root message of this thread; it was not moved"), false, nil
+	}
+
+	channelID, err := p.lookupRoutingEndpoint(wpl, extra)
+	if err != nil {
+		p.API.LogWarn("Wrangler routing endpoint lookup failed",
+			"error", err.Error(),
+			"user_id", extra.UserId,
+			"original_post_id", wpl.RootPost().Id,
+		)
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("The routing service could not pick a destination for this thread; it was not moved. Choose a destination and run `/wrangler move thread %s [CHANNEL_ID]` instead.", postID)), false, nil
+	}
+
+	p.API.LogInfo("Wrangler routing endpoint picked a destination",
+		"user_id", extra.UserId,
+		"original_post_id", wpl.RootPost().Id,
+		"target_channel_id", channelID,
+	)
+
+	moveArgs := append([]string{postID, channelID}, args[1:]...)
+	return p.runMoveThreadCommand(moveArgs, extra)
+}
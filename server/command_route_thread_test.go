@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteThreadCommand(t *testing.T) {
+	setup := func(rules func(f *threadTestFixture) string) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+		f.rootPost.Message = "Bug: the login page is broken"
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{RoutingRules: rules(f)})
+		require.NoError(t, plugin.configuration.IsValid())
+
+		return f, plugin
+	}
+
+	t.Run("no arguments", func(t *testing.T) {
+		_, plugin := setup(func(f *threadTestFixture) string { return "" })
+		resp, isUserError, err := plugin.runRouteThreadCommand([]string{}, &model.CommandArgs{})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: missing arguments")
+	})
+
+	t.Run("no matching rule", func(t *testing.T) {
+		f, plugin := setup(func(f *threadTestFixture) string {
+			return fmt.Sprintf(`[{"keyword": "outage", "channel_id": "%s"}]`, f.targetChannel.Id)
+		})
+		resp, isUserError, err := plugin.runRouteThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "No routing rule matches")
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("first matching rule", func(t *testing.T) {
+		otherChannelID := model.NewId()
+		f, plugin := setup(func(f *threadTestFixture) string {
+			return fmt.Sprintf(
+				`[{"keyword": "outage", "channel_id": "%s"}, {"regex": "(?i)^bug:", "channel_id": "%s"}, {"keyword": "LOGIN", "channel_id": "%s"}]`,
+				otherChannelID, f.targetChannel.Id, otherChannelID,
+			)
+		})
+		resp, isUserError, err := plugin.runRouteThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == f.targetChannel.Id
+		}))
+		f.api.AssertNotCalled(t, "GetChannel", otherChannelID)
+	})
+}
+
+func TestRouteThreadEndpoint(t *testing.T) {
+	setup := func(t *testing.T, handler http.HandlerFunc) (*threadTestFixture, *Plugin) {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		f := newThreadTestFixture(1)
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{
+			RoutingEndpointURL:        server.URL,
+			RoutingEndpointAuthHeader: "Bearer secret",
+		})
+		require.NoError(t, plugin.configuration.IsValid())
+
+		return f, plugin
+	}
+
+	t.Run("destination from the endpoint", func(t *testing.T) {
+		var lookup routingLookupRequest
+		var authHeader string
+		var f *threadTestFixture
+		f, plugin := setup(t, func(w http.ResponseWriter, r *http.Request) {
+			authHeader = r.Header.Get("Authorization")
+			_ = json.NewDecoder(r.Body).Decode(&lookup)
+			fmt.Fprintf(w, `{"channel_id": "%s"}`, f.targetChannel.Id)
+		})
+
+		resp, isUserError, err := plugin.runRouteThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		assert.Equal(t, "Bearer secret", authHeader)
+		assert.Equal(t, f.rootPost.Id, lookup.PostID)
+		assert.Equal(t, f.originalChannel.Id, lookup.ChannelID)
+		assert.Equal(t, f.rootPost.Message, lookup.Message)
+		assert.Equal(t, 1, lookup.ReplyCount)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == f.targetChannel.Id
+		}))
+	})
+
+	t.Run("invalid responses fall back to the user", func(t *testing.T) {
+		for name, handler := range map[string]http.HandlerFunc{
+			"error status": func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			"invalid JSON": func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "general")
+			},
+			"invalid channel ID": func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"channel_id": "general"}`)
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				f, plugin := setup(t, handler)
+
+				resp, isUserError, err := plugin.runRouteThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+				require.NoError(t, err)
+				assert.False(t, isUserError)
+				assert.Contains(t, resp.Text, "The routing service could not pick a destination for this thread")
+				assert.Contains(t, resp.Text, "/wrangler move thread "+f.rootPost.Id)
+				f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+			})
+		}
+	})
+
+	t.Run("timeout falls back to the user", func(t *testing.T) {
+		originalTimeout := routingEndpointTimeout
+		routingEndpointTimeout = 50 * time.Millisecond
+		defer func() { routingEndpointTimeout = originalTimeout }()
+
+		done := make(chan struct{})
+		f, plugin := setup(t, func(w http.ResponseWriter, r *http.Request) {
+			<-done
+		})
+		defer close(done)
+
+		resp, isUserError, err := plugin.runRouteThreadCommand([]string{f.rootPost.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "The routing service could not pick a destination for this thread")
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+}
+
+func TestValidateRoutingEndpoint(t *testing.T) {
+	assert.NoError(t, validateRoutingEndpoint("", ""))
+	assert.NoError(t, validateRoutingEndpoint("https://routing.example.com/lookup", ""))
+	assert.NoError(t, validateRoutingEndpoint("http://routing.internal:8080/lookup", "Bearer secret"))
+	assert.Error(t, validateRoutingEndpoint("", "Bearer secret"))
+	assert.Error(t, validateRoutingEndpoint("routing.example.com/lookup", ""))
+	assert.Error(t, validateRoutingEndpoint("ftp://routing.example.com", ""))
+	assert.Error(t, validateRoutingEndpoint("https://routing.example.com", "Bearer secret\r\nX-Other: 1"))
+}
+
+func TestRoutingRuleMatches(t *testing.T) {
+	rules, err := parseAndValidateRoutingRules(fmt.Sprintf(
+		`[{"keyword": "Outage", "channel_id": "%s"}, {"regex": "^bug:", "channel_id": "%s"}]`,
+		model.NewId(), model.NewId(),
+	))
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	assert.True(t, rules[0].matches("major outage in progress"))
+	assert.False(t, rules[0].matches("all systems operational"))
+	assert.True(t, rules[1].matches("bug: broken page"))
+	assert.False(t, rules[1].matches("Bug: broken page"))
+}
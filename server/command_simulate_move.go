@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/spf13/pflag"
+)
+
+const simulateMoveUsage = `/wrangler simulate move [MESSAGE_ID] [CHANNEL_ID] [flags]
+  Run the checks of a thread move without moving anything and report which of them pass or fail
+    - Only available to system admins
+    - Use it to try out changes to the permission and restriction settings
+	Flags:
+%s`
+
+const flagSimulateMoveAs = "as"
+
+// Results of a simulated check.
+const (
+	simulationPass = "✅"
+	simulationWarn = "⚠️"
+	simulationFail = "❌"
+)
+
+// simulationCheck is the result of one check of a simulated move.
+type simulationCheck struct {
+	name   string
+	result string
+	detail string
+}
+
+func getSimulateMoveFlagSet() *pflag.FlagSet {
+	flagSet := pflag.NewFlagSet("simulate move", pflag.ContinueOnError)
+	flagSet.String(flagSimulateMoveAs, "", "The @username or user ID of the user to run the checks as (defaults to you)")
+
+	return flagSet
+}
+
+func getSimulateMoveUsage() string {
+	return fmt.Sprintf(simulateMoveUsage, getSimulateMoveFlagSet().FlagUsages())
+}
+
+func (p *Plugin) runSimulateMoveCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: only system admins can simulate Wrangler moves"), true, nil
+	}
+
+	flagSet := getSimulateMoveFlagSet()
+	positional, err := parseCommandArgs(args, flagSet, messageIDArg, channelIDArg.withName("destination channel ID"))
+	if err != nil {
+		return getCommandArgsErrorResponse(err, getSimulateMoveUsage()), true, nil
+	}
+
+	userID := extra.UserId
+	as, err := flagSet.GetString(flagSimulateMoveAs)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(as) != 0 {
+		userID, err = p.resolveUserArg(as)
+		if err != nil {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, err.Error()), true, nil
+		}
+	}
+
+	checks, err := p.simulateMove(positional[0], positional[1], userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	msg := fmt.Sprintf("#### Simulated move of message %s to channel %s as %s\n\nNothing was changed.\n\n", positional[0], positional[1], p.getUserMention(userID))
+	var failed, warned int
+	for _, check := range checks {
+		msg += fmt.Sprintf("%s **%s**: %s\n", check.result, check.name, check.detail)
+		switch check.result {
+		case simulationFail:
+			failed++
+		case simulationWarn:
+			warned++
+		}
+	}
+
+	switch {
+	case failed != 0:
+		msg += fmt.Sprintf("\nResult: the move would be refused by %d check(s).", failed)
+	case warned != 0:
+		msg += fmt.Sprintf("\nResult: the move would be allowed once the %d warning(s) are handled.", warned)
+	default:
+		msg += "\nResult: the move would be allowed."
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+// simulateMove runs the checks of the move thread command for the given user
+// as if they ran it from the channel containing the post, without changing
+// anything. Checks that can't run without the thread or the destination
+// channel are skipped once those can't be found.
+func (p *Plugin) simulateMove(postID, channelID, userID string) ([]simulationCheck, error) {
+	var checks []simulationCheck
+	add := func(name, result, detail string) {
+		checks = append(checks, simulationCheck{name: name, result: result, detail: detail})
+	}
+	// checkResponse adds the result of a validation that returns the error
+	// response of the move command.
+	checkResponse := func(name string, response *model.CommandResponse, passed string) {
+		if response != nil {
+			add(name, simulationFail, strings.TrimPrefix(response.Text, "Error: "))
+			return
+		}
+		add(name, simulationPass, passed)
+	}
+	// skipRest adds a failed check that the remaining checks depend on.
+	skipRest := func(name, detail string) []simulationCheck {
+		add(name, simulationFail, detail+"; the remaining checks were skipped")
+		return checks
+	}
+
+	config := p.getConfiguration()
+
+	if p.authorizedPluginUser(userID) {
+		add("Wrangler access", simulationPass, "the user may use Wrangler")
+	} else {
+		add("Wrangler access", simulationFail, "the user is not permitted to use Wrangler")
+	}
+	if p.authorizedOperationUser(userID, config.MovePermittedRoleNames()) {
+		add("Move roles", simulationPass, "the user has a role permitted to move threads")
+	} else {
+		add("Move roles", simulationFail, "the user doesn't have any of the roles permitted to move threads")
+	}
+	if config.BlockGuestMoves {
+		if p.guestMovesBlocked(userID) {
+			add("Guest accounts", simulationFail, "guest accounts are not permitted to move threads")
+		} else {
+			add("Guest accounts", simulationPass, "the user is not a guest")
+		}
+	}
+	if p.blockedByMaintenanceMode(userID) {
+		add("Maintenance mode", simulationFail, maintenanceModeMessage)
+	} else {
+		add("Maintenance mode", simulationPass, "moves are not blocked by maintenance mode")
+	}
+
+	postListResponse, appErr := p.API.GetPostThread(postID)
+	if appErr != nil {
+		return skipRest("Thread", fmt.Sprintf("unable to get post with ID %s", postID)), nil
+	}
+	wpl := buildWranglerPostList(postListResponse)
+	if wpl.NumPosts() == 0 || wpl.RootPost().DeleteAt != 0 {
+		return skipRest("Thread", "that post no longer exists"), nil
+	}
+	add("Thread", simulationPass, fmt.Sprintf("the thread has %d message(s)", wpl.NumPosts()))
+
+	originalChannel, appErr := p.API.GetChannel(wpl.RootPost().ChannelId)
+	if appErr != nil {
+		return nil, fmt.Errorf("unable to get channel with ID %s", wpl.RootPost().ChannelId)
+	}
+	extra := &model.CommandArgs{
+		UserId:    userID,
+		ChannelId: originalChannel.Id,
+		TeamId:    originalChannel.TeamId,
+	}
+
+	if p.blockedByCommandChannel(extra) {
+		add("Command channel", simulationFail, p.getCommandChannelMessage())
+	} else {
+		add("Command channel", simulationPass, "the command may be run from the channel containing the thread")
+	}
+
+	override := p.canOverrideTargetChannel(userID)
+	if !override {
+		_, appErr = p.API.GetChannelMember(channelID, userID)
+		if appErr != nil {
+			return skipRest("Destination channel", fmt.Sprintf("channel with ID %s doesn't exist or the user is not a member", channelID)), nil
+		}
+	}
+	targetChannel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return skipRest("Destination channel", fmt.Sprintf("channel with ID %s doesn't exist", channelID)), nil
+	}
+	add("Destination channel", simulationPass, fmt.Sprintf("the thread would be moved to ~%s", targetChannel.Name))
+
+	response, _, err := p.validateMoveOrCopy(wpl, originalChannel, targetChannel, extra)
+	if err != nil {
+		return nil, err
+	}
+	checkResponse("Move restrictions", response, "the channel types, teams and thread size are allowed")
+	checkResponse("Removal from the original channel", p.validateRemovalFromChannel(originalChannel, extra), "the user may remove messages from ~"+originalChannel.Name)
+	response, err = p.validateThreadNotLocked(wpl, extra)
+	if err != nil {
+		return nil, err
+	}
+	checkResponse("Thread lock", response, "the thread is not locked")
+
+	if config.SkipDeactivatedUserPosts() && wpl.NumPosts() > 1 {
+		wpl.RemoveRepliesByAuthors(p.getDeactivatedAuthors(wpl.Posts[1:]))
+	}
+	maxAuthors := config.MaxAuthorsPerMoveInt()
+	if maxAuthors != 0 && wpl.NumAuthors() > maxAuthors {
+		add("Authors", simulationFail, fmt.Sprintf("the thread has %d distinct authors, but only threads with up to %d authors can be moved", wpl.NumAuthors(), maxAuthors))
+	} else {
+		add("Authors", simulationPass, fmt.Sprintf("the thread has %d distinct author(s)", wpl.NumAuthors()))
+	}
+
+	if config.NonMemberAuthorsPolicy() == nonMemberAuthorsAbort {
+		nonMemberAuthors := p.getNonMemberAuthors(wpl.Posts, targetChannel, p.getDeactivatedAuthors(wpl.Posts))
+		if len(nonMemberAuthors) != 0 {
+			add("Author memberships", simulationFail, fmt.Sprintf("some authors aren't members of ~%s: %s", targetChannel.Name, formatUsernames(nonMemberAuthors)))
+		} else {
+			add("Author memberships", simulationPass, fmt.Sprintf("all authors are members of ~%s", targetChannel.Name))
+		}
+	}
+
+	movedPosts := wpl.Posts
+	if config.MoveRepliesOnly && wpl.NumPosts() > 1 {
+		movedPosts = wpl.Posts[1:]
+	}
+
+	blockedPattern := findBlockedContentPattern(movedPosts, config.BlockedContentPatternsList())
+	if blockedPattern != nil {
+		add("Blocked content", simulationFail, fmt.Sprintf("a message matches the blocked content pattern `%s`; system admins can move it anyway with --%s", blockedPattern.String(), flagMoveThreadAllowBlocked))
+	} else {
+		add("Blocked content", simulationPass, "no message matches a blocked content pattern")
+	}
+
+	integrationPolicy := config.IntegrationPostsPolicy()
+	integrationCount := p.countIntegrationPosts(movedPosts)
+	switch {
+	case integrationPolicy == integrationPostsAllow || integrationCount == 0:
+		add("Integration posts", simulationPass, "integration posts don't prevent the move")
+	case integrationPolicy == integrationPostsBlock:
+		add("Integration posts", simulationFail, fmt.Sprintf("the thread contains %d message(s) posted by bots or integrations, and Wrangler is configured to not move them", integrationCount))
+	default:
+		add("Integration posts", simulationWarn, fmt.Sprintf("the thread contains %d message(s) posted by bots or integrations; the move must be confirmed with --%s", integrationCount, flagMoveThreadConfirmIntegration))
+	}
+
+	playbookPolicy := config.PlaybookPostsPolicy()
+	playbookCount := countPlaybookPosts(movedPosts)
+	switch {
+	case playbookPolicy == playbookPostsAllow || playbookCount == 0:
+		add("Playbook posts", simulationPass, "playbook posts don't prevent the move")
+	case playbookPolicy == playbookPostsBlock:
+		add("Playbook posts", simulationFail, fmt.Sprintf("the thread contains %d message(s) linked to playbook runs, and Wrangler is configured to not move them", playbookCount))
+	default:
+		add("Playbook posts", simulationWarn, fmt.Sprintf("the thread contains %d message(s) linked to playbook runs; the move must be confirmed with --%s", playbookCount, flagMoveThreadConfirmPlaybook))
+	}
+
+	warningCount := config.DestinationPostCountWarningInt()
+	if warningCount != 0 && targetChannel.TotalMsgCount+int64(len(movedPosts)) > int64(warningCount) {
+		add("Destination size", simulationWarn, fmt.Sprintf("~%s would go over %d messages; the move must be confirmed with --%s", targetChannel.Name, warningCount, flagMoveThreadConfirmLarge))
+	} else {
+		add("Destination size", simulationPass, fmt.Sprintf("~%s stays within the configured size", targetChannel.Name))
+	}
+
+	defaultChannelPolicy := config.DefaultChannelMovesPolicy()
+	switch {
+	case defaultChannelPolicy == defaultChannelMovesAllow || !p.isTeamDefaultChannel(targetChannel):
+		add("Default channel", simulationPass, "the target channel isn't guarded as a default channel")
+	case p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM):
+		add("Default channel", simulationPass, fmt.Sprintf("~%s is a default channel, but system admins may move threads into it", targetChannel.Name))
+	case defaultChannelPolicy == defaultChannelMovesBlock:
+		add("Default channel", simulationFail, fmt.Sprintf("~%s is a default channel, and Wrangler is configured to not move threads into default channels", targetChannel.Name))
+	default:
+		add("Default channel", simulationWarn, fmt.Sprintf("~%s is a default channel; the move must be confirmed with --%s", targetChannel.Name, flagMoveThreadConfirmDefault))
+	}
+
+	quotaReached, err := p.moveQuotaReached(originalChannel.Id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if quotaReached {
+		add("Daily move limit", simulationFail, fmt.Sprintf("the daily limit of %d thread moves out of ~%s was reached", config.MaxMovesPerChannelPerDayInt(), originalChannel.Name))
+	} else {
+		add("Daily move limit", simulationPass, "the daily move limit is not reached")
+	}
+
+	if p.requiresMoveApproval(originalChannel, userID) {
+		add("Approval", simulationWarn, "the move would be sent to the approval channel instead of running right away")
+	} else {
+		add("Approval", simulationPass, "the move doesn't need approval")
+	}
+
+	return checks, nil
+}
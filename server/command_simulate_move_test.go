@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateMoveCommand(t *testing.T) {
+	f := newThreadTestFixture(2)
+	adminID := model.NewId()
+	missingChannelID := model.NewId()
+	f.api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+	f.api.On("HasPermissionTo", f.rootPost.UserId, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+	f.api.On("GetUserByUsername", "author").Return(&model.User{Id: f.rootPost.UserId, Username: "author"}, nil)
+	f.api.On("GetChannel", missingChannelID).Return(nil, &model.AppError{Message: "not found"})
+
+	var plugin Plugin
+	plugin.SetAPI(f.api)
+
+	adminArgs := f.commandArgs()
+	adminArgs.UserId = adminID
+
+	t.Run("only system admins", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{})
+
+		resp, isUserError, err := plugin.runSimulateMoveCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: only system admins can simulate Wrangler moves", resp.Text)
+	})
+
+	t.Run("all checks pass", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{})
+
+		resp, isUserError, err := plugin.runSimulateMoveCommand([]string{f.rootPost.Id, f.targetChannel.Id}, adminArgs)
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "✅ **Wrangler access**: the user may use Wrangler\n")
+		assert.Contains(t, resp.Text, "✅ **Destination channel**: the thread would be moved to ~target-channel\n")
+		assert.Contains(t, resp.Text, "✅ **Approval**: the move doesn't need approval\n")
+		assert.NotContains(t, resp.Text, "❌")
+		assert.True(t, strings.HasSuffix(resp.Text, "Result: the move would be allowed."))
+	})
+
+	t.Run("checks fail as another user", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{
+			MaxAuthorsPerMove:           "1",
+			DestinationPostCountWarning: "2",
+		})
+		require.NoError(t, plugin.configuration.IsValid())
+
+		resp, isUserError, err := plugin.runSimulateMoveCommand([]string{f.rootPost.Id, f.targetChannel.Id, "--as", "@author"}, adminArgs)
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "❌ **Authors**: the thread has 3 distinct authors, but only threads with up to 1 authors can be moved\n")
+		assert.Contains(t, resp.Text, "⚠️ **Destination size**: ~target-channel would go over 2 messages; the move must be confirmed with --confirm-large-destination\n")
+		assert.Contains(t, resp.Text, "Result: the move would be refused by 1 check(s).")
+	})
+
+	t.Run("missing destination channel", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{AdminCrossTeamOverride: true})
+
+		resp, isUserError, err := plugin.runSimulateMoveCommand([]string{f.rootPost.Id, missingChannelID}, adminArgs)
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, fmt.Sprintf("❌ **Destination channel**: channel with ID %s doesn't exist; the remaining checks were skipped\n", missingChannelID))
+		assert.NotContains(t, resp.Text, "**Move restrictions**")
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		f.api.On("GetUserByUsername", "nobody").Return(nil, &model.AppError{Message: "not found"})
+
+		resp, isUserError, err := plugin.runSimulateMoveCommand([]string{f.rootPost.Id, f.targetChannel.Id, "--as", "@nobody"}, adminArgs)
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "Error: unable to find user @nobody")
+	})
+
+	f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+}
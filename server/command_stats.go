@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const statsUsage = `/wrangler stats
+  Show the channels that most threads were moved, copied or merged out of and into
+    - Only available to system admins
+    - Covers the stats window set in the plugin configuration`
+
+// statsLeaderboardSize is the number of channels shown in each stats table.
+const statsLeaderboardSize = 10
+
+// channelOperationCounts are the operations counted for a single channel.
+type channelOperationCounts struct {
+	channelID string
+	moves     int
+	copies    int
+	merges    int
+}
+
+func (c *channelOperationCounts) total() int {
+	return c.moves + c.copies + c.merges
+}
+
+func (c *channelOperationCounts) add(operationType string) {
+	switch operationType {
+	case operationMove:
+		c.moves++
+	case operationCopy:
+		c.copies++
+	case operationMerge:
+		c.merges++
+	}
+}
+
+func (p *Plugin) runStatsCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: only system admins can view Wrangler stats"), true, nil
+	}
+
+	config := p.getConfiguration()
+	records, err := p.getOperationHistory(now().Add(-config.StatsWindow()))
+	if err != nil {
+		return nil, false, err
+	}
+
+	days := config.StatsWindowDaysInt()
+	if len(records) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("No moves, copies or merges were recorded in the last %d day(s).", days)), false, nil
+	}
+
+	sources := make(map[string]*channelOperationCounts)
+	targets := make(map[string]*channelOperationCounts)
+	var totals channelOperationCounts
+	for _, record := range records {
+		totals.add(record.Type)
+		countOperation(sources, record.SourceChannelID, record.Type)
+		countOperation(targets, record.TargetChannelID, record.Type)
+	}
+
+	channelNames := make(map[string]string)
+	msg := fmt.Sprintf("#### Wrangler stats for the last %d day(s)\n\n", days)
+	msg += fmt.Sprintf("%d move(s), %d copy(ies) and %d merge(s) were recorded.\n\n", totals.moves, totals.copies, totals.merges)
+	msg += p.formatStatsLeaderboard("Source channel", sources, channelNames)
+	msg += "\n"
+	msg += p.formatStatsLeaderboard("Destination channel", targets, channelNames)
+
+	if config.EnableMoveFeedbackPoll {
+		tally, err := p.tallyFeedbackPolls(now().Add(-config.StatsWindow()).UnixNano())
+		if err != nil {
+			return nil, false, err
+		}
+		if tally.polls != 0 {
+			msg += fmt.Sprintf("\n%d feedback poll(s) were left, with %d :%s: vote(s) that the thread should have stayed and %d :%s: vote(s) that it belonged elsewhere.\n", tally.polls, tally.stayed, feedbackPollStayed, tally.moved, feedbackPollMoved)
+		}
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, msg), false, nil
+}
+
+func countOperation(counts map[string]*channelOperationCounts, channelID, operationType string) {
+	channelCounts, ok := counts[channelID]
+	if !ok {
+		channelCounts = &channelOperationCounts{channelID: channelID}
+		counts[channelID] = channelCounts
+	}
+	channelCounts.add(operationType)
+}
+
+// formatStatsLeaderboard returns a table of the channels with the most
+// operations, busiest first.
+func (p *Plugin) formatStatsLeaderboard(header string, counts map[string]*channelOperationCounts, channelNames map[string]string) string {
+	var leaderboard []*channelOperationCounts
+	for _, channelCounts := range counts {
+		leaderboard = append(leaderboard, channelCounts)
+	}
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].total() != leaderboard[j].total() {
+			return leaderboard[i].total() > leaderboard[j].total()
+		}
+		return leaderboard[i].channelID < leaderboard[j].channelID
+	})
+	if len(leaderboard) > statsLeaderboardSize {
+		leaderboard = leaderboard[:statsLeaderboardSize]
+	}
+
+	table := fmt.Sprintf("| %s | Moves | Copies | Merges | Total |\n| -- | -- | -- | -- | -- |\n", header)
+	for _, channelCounts := range leaderboard {
+		table += fmt.Sprintf("| %s | %d | %d | %d | %d |\n",
+			p.getStatsChannelName(channelCounts.channelID, channelNames),
+			channelCounts.moves, channelCounts.copies, channelCounts.merges, channelCounts.total(),
+		)
+	}
+
+	return table
+}
+
+// getStatsChannelName returns the name of the channel to show in the stats, or
+// its ID if the channel can't be found.
+func (p *Plugin) getStatsChannelName(channelID string, channelNames map[string]string) string {
+	name, ok := channelNames[channelID]
+	if ok {
+		return name
+	}
+
+	name = channelID
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr == nil {
+		name = "~" + channel.Name
+	}
+	channelNames[channelID] = name
+
+	return name
+}
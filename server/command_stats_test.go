@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCommand(t *testing.T) {
+	defer func() { now = time.Now }()
+
+	adminID := model.NewId()
+	userID := model.NewId()
+	channelA := &model.Channel{Id: model.NewId(), Name: "channel-a"}
+	channelB := &model.Channel{Id: model.NewId(), Name: "channel-b"}
+	deletedChannelID := model.NewId()
+
+	setup := func() (*plugintest.API, *Plugin) {
+		api := &plugintest.API{}
+		newMockKVStore(api)
+		mockLogs(api)
+		api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+		api.On("HasPermissionTo", userID, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		api.On("GetChannel", channelA.Id).Return(channelA, nil)
+		api.On("GetChannel", channelB.Id).Return(channelB, nil)
+		api.On("GetChannel", deletedChannelID).Return(nil, &model.AppError{Message: "not found"})
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(&configuration{StatsWindowDays: "7"})
+
+		return api, plugin
+	}
+
+	t.Run("admin only", func(t *testing.T) {
+		_, plugin := setup()
+
+		resp, isUserError, err := plugin.runStatsCommand([]string{}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: only system admins can view Wrangler stats", resp.Text)
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		_, plugin := setup()
+
+		resp, isUserError, err := plugin.runStatsCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "No moves, copies or merges were recorded in the last 7 day(s).", resp.Text)
+	})
+
+	t.Run("leaderboard", func(t *testing.T) {
+		_, plugin := setup()
+
+		// This operation is outside of the stats window.
+		now = func() time.Time { return time.Now().Add(-8 * 24 * time.Hour) }
+		plugin.recordOperation(operationMove, userID, channelB.Id, channelA.Id, 5)
+
+		now = time.Now
+		plugin.recordOperation(operationMove, userID, channelA.Id, channelB.Id, 3)
+		plugin.recordOperation(operationMove, userID, channelA.Id, deletedChannelID, 2)
+		plugin.recordOperation(operationCopy, userID, channelA.Id, channelB.Id, 1)
+		plugin.recordOperation(operationMerge, userID, channelB.Id, channelB.Id, 4)
+
+		resp, isUserError, err := plugin.runStatsCommand([]string{}, &model.CommandArgs{UserId: adminID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "#### Wrangler stats for the last 7 day(s)\n\n"+
+			"2 move(s), 1 copy(ies) and 1 merge(s) were recorded.\n\n"+
+			"| Source channel | Moves | Copies | Merges | Total |\n| -- | -- | -- | -- | -- |\n"+
+			"| ~channel-a | 2 | 1 | 0 | 3 |\n"+
+			"| ~channel-b | 0 | 0 | 1 | 1 |\n"+
+			"\n"+
+			"| Destination channel | Moves | Copies | Merges | Total |\n| -- | -- | -- | -- | -- |\n"+
+			"| ~channel-b | 1 | 1 | 1 | 3 |\n"+
+			"| "+deletedChannelID+" | 1 | 0 | 0 | 1 |\n",
+			resp.Text,
+		)
+	})
+
+	t.Run("recorded on copy", func(t *testing.T) {
+		f := newThreadTestFixture(1)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{})
+
+		_, _, err := plugin.runCopyThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+
+		records, err := plugin.getOperationHistory(time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, operationCopy, records[0].Type)
+		assert.Equal(t, f.originalChannel.Id, records[0].SourceChannelID)
+		assert.Equal(t, f.targetChannel.Id, records[0].TargetChannelID)
+		assert.Equal(t, 2, records[0].PostCount)
+		f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+	})
+}
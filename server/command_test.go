@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/mattermost/mattermost-server/v5/model"
@@ -53,7 +55,9 @@ func TestCommand(t *testing.T) {
 				args := &model.CommandArgs{Command: "wrangler move"}
 				resp, appErr := plugin.ExecuteCommand(context, args)
 				require.Nil(t, appErr)
-				require.Equal(t, resp.Text, getHelp())
+				noArgsHelp, ok := getNoArgsHelp("move", args)
+				require.True(t, ok)
+				require.Equal(t, resp.Text, noArgsHelp)
 			})
 
 			t.Run("invalid extra args", func(t *testing.T) {
@@ -69,7 +73,9 @@ func TestCommand(t *testing.T) {
 				args := &model.CommandArgs{Command: "wrangler copy"}
 				resp, appErr := plugin.ExecuteCommand(context, args)
 				require.Nil(t, appErr)
-				require.Equal(t, resp.Text, getHelp())
+				noArgsHelp, ok := getNoArgsHelp("copy", args)
+				require.True(t, ok)
+				require.Equal(t, resp.Text, noArgsHelp)
 			})
 
 			t.Run("invalid extra args", func(t *testing.T) {
@@ -80,12 +86,68 @@ func TestCommand(t *testing.T) {
 			})
 		})
 
+		t.Run("merge command", func(t *testing.T) {
+			t.Run("missing extra args", func(t *testing.T) {
+				args := &model.CommandArgs{Command: "wrangler merge"}
+				resp, appErr := plugin.ExecuteCommand(context, args)
+				require.Nil(t, appErr)
+				noArgsHelp, ok := getNoArgsHelp("merge", args)
+				require.True(t, ok)
+				require.Equal(t, resp.Text, noArgsHelp)
+			})
+
+			t.Run("invalid extra args", func(t *testing.T) {
+				args := &model.CommandArgs{Command: "wrangler merge invalid"}
+				resp, appErr := plugin.ExecuteCommand(context, args)
+				require.Nil(t, appErr)
+				require.Equal(t, resp.Text, getHelp())
+			})
+		})
+
+		t.Run("route command", func(t *testing.T) {
+			t.Run("missing extra args", func(t *testing.T) {
+				args := &model.CommandArgs{Command: "wrangler route"}
+				resp, appErr := plugin.ExecuteCommand(context, args)
+				require.Nil(t, appErr)
+				noArgsHelp, ok := getNoArgsHelp("route", args)
+				require.True(t, ok)
+				require.Equal(t, resp.Text, noArgsHelp)
+			})
+
+			t.Run("invalid extra args", func(t *testing.T) {
+				args := &model.CommandArgs{Command: "wrangler route invalid"}
+				resp, appErr := plugin.ExecuteCommand(context, args)
+				require.Nil(t, appErr)
+				require.Equal(t, resp.Text, getHelp())
+			})
+		})
+
+		t.Run("cancel command", func(t *testing.T) {
+			t.Run("missing extra args", func(t *testing.T) {
+				args := &model.CommandArgs{Command: "wrangler cancel"}
+				resp, appErr := plugin.ExecuteCommand(context, args)
+				require.Nil(t, appErr)
+				noArgsHelp, ok := getNoArgsHelp("cancel", args)
+				require.True(t, ok)
+				require.Equal(t, resp.Text, noArgsHelp)
+			})
+
+			t.Run("invalid extra args", func(t *testing.T) {
+				args := &model.CommandArgs{Command: "wrangler cancel invalid"}
+				resp, appErr := plugin.ExecuteCommand(context, args)
+				require.Nil(t, appErr)
+				require.Equal(t, resp.Text, getHelp())
+			})
+		})
+
 		t.Run("attach command", func(t *testing.T) {
 			t.Run("missing extra args", func(t *testing.T) {
 				args := &model.CommandArgs{Command: "wrangler attach"}
 				resp, appErr := plugin.ExecuteCommand(context, args)
 				require.Nil(t, appErr)
-				require.Equal(t, resp.Text, getHelp())
+				noArgsHelp, ok := getNoArgsHelp("attach", args)
+				require.True(t, ok)
+				require.Equal(t, resp.Text, noArgsHelp)
 			})
 
 			t.Run("invalid extra args", func(t *testing.T) {
@@ -101,7 +163,9 @@ func TestCommand(t *testing.T) {
 				args := &model.CommandArgs{Command: "wrangler list"}
 				resp, appErr := plugin.ExecuteCommand(context, args)
 				require.Nil(t, appErr)
-				require.Equal(t, resp.Text, getHelp())
+				noArgsHelp, ok := getNoArgsHelp("list", args)
+				require.True(t, ok)
+				require.Equal(t, resp.Text, noArgsHelp)
 			})
 
 			t.Run("invalid extra args", func(t *testing.T) {
@@ -233,3 +297,259 @@ func TestCommand(t *testing.T) {
 		})
 	})
 }
+
+func TestNoArgsHelp(t *testing.T) {
+	context := &plugin.Context{}
+	channelID := model.NewId()
+	rootID := model.NewId()
+
+	var plugin Plugin
+	plugin.SetAPI(&plugintest.API{})
+	plugin.setConfiguration(&configuration{})
+
+	t.Run("bare command shows the command list", func(t *testing.T) {
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "/wrangler", ChannelId: channelID})
+		require.Nil(t, appErr)
+		assert.Equal(t, getHelp(), resp.Text)
+	})
+
+	for _, command := range []string{"move", "move thread"} {
+		t.Run(command, func(t *testing.T) {
+			resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "/wrangler " + command, ChannelId: channelID})
+			require.Nil(t, appErr)
+			assert.True(t, strings.HasPrefix(resp.Text, "```\n/wrangler move thread [MESSAGE_ID] [CHANNEL_ID]\n"))
+			assert.Contains(t, resp.Text, "Examples for this channel:\n  /wrangler list messages\n")
+			assert.Contains(t, resp.Text, "  /wrangler move thread MESSAGE_ID CHANNEL_ID\n    Move a thread of this channel to another channel\n")
+			assert.Contains(t, resp.Text, "  /wrangler move thread MESSAGE_ID "+channelID+"\n    Move a thread of another channel to this channel\n")
+			assert.NotContains(t, resp.Text, "the thread you're viewing")
+			assert.NotContains(t, resp.Text, "/wrangler copy thread")
+		})
+	}
+
+	t.Run("move in a thread", func(t *testing.T) {
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "/wrangler move", ChannelId: channelID, RootId: rootID})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "  /wrangler move thread "+rootID+" CHANNEL_ID\n    Move the thread you're viewing to another channel\n")
+	})
+
+	t.Run("copy lists its subcommands", func(t *testing.T) {
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "/wrangler copy", ChannelId: channelID})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "/wrangler copy thread [MESSAGE_ID] [CHANNEL_ID]")
+		assert.Contains(t, resp.Text, "/wrangler copy pinned [CHANNEL_ID]")
+		assert.Contains(t, resp.Text, "  /wrangler copy thread MESSAGE_ID "+channelID+"\n")
+	})
+
+	t.Run("merge in a thread", func(t *testing.T) {
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "/wrangler merge thread", ChannelId: channelID, RootId: rootID})
+		require.Nil(t, appErr)
+		assert.True(t, strings.HasPrefix(resp.Text, "```\n/wrangler merge thread [MESSAGE_ID] [ROOT_MESSAGE_ID]\n"))
+		assert.Contains(t, resp.Text, "  /wrangler merge thread MESSAGE_ID "+rootID+"\n")
+	})
+
+	t.Run("subcommands without required arguments still run", func(t *testing.T) {
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "/wrangler info", ChannelId: channelID})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "Wrangler plugin version")
+	})
+}
+
+func TestSplitCommandArgs(t *testing.T) {
+	for command, expected := range map[string][]string{
+		"wrangler move thread id1 id2":                      {"wrangler", "move", "thread", "id1", "id2"},
+		"wrangler  move thread ":                            {"wrangler", "move", "thread"},
+		`wrangler move thread id1 id2 --changelog "a b  c"`: {"wrangler", "move", "thread", "id1", "id2", "--changelog", "a b  c"},
+		`wrangler move thread --changelog="a b"`:            {"wrangler", "move", "thread", "--changelog=a b"},
+		`wrangler move thread --changelog ""`:               {"wrangler", "move", "thread", "--changelog", ""},
+		`wrangler move thread "unterminated quote`:          {"wrangler", "move", "thread", "unterminated quote"},
+	} {
+		assert.Equal(t, expected, splitCommandArgs(command), command)
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	context := &plugin.Context{}
+	adminID := model.NewId()
+	userID := model.NewId()
+
+	api := &plugintest.API{}
+	api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+	api.On("HasPermissionTo", userID, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+
+	var plugin Plugin
+	plugin.SetAPI(api)
+	plugin.setConfiguration(&configuration{MaintenanceMode: true, MaintenanceModeAdminBypass: true})
+
+	t.Run("mutating commands are blocked", func(t *testing.T) {
+		for _, command := range []string{"move thread", "copy thread", "merge thread", "route thread", "archive thread", "import", "thread", "attach message", "admin import-config", "admin purge-user"} {
+			resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler " + command, UserId: userID})
+			require.Nil(t, appErr)
+			assert.Equal(t, maintenanceModeMessage, resp.Text, command)
+		}
+	})
+
+	t.Run("read-only commands still work", func(t *testing.T) {
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler info", UserId: userID})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "Wrangler plugin version")
+	})
+
+	t.Run("system admins can bypass", func(t *testing.T) {
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler move thread", UserId: adminID})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "/wrangler move thread [MESSAGE_ID] [CHANNEL_ID]")
+	})
+
+	t.Run("bypass disabled", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{MaintenanceMode: true})
+		for _, command := range []string{"move thread", "admin import-config", "admin purge-user"} {
+			resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler " + command, UserId: adminID})
+			require.Nil(t, appErr)
+			assert.Equal(t, maintenanceModeMessage, resp.Text, command)
+		}
+	})
+}
+
+func TestCommandChannel(t *testing.T) {
+	context := &plugin.Context{}
+	adminID := model.NewId()
+	commandChannel := &model.Channel{Id: model.NewId(), Name: "wrangler-ops"}
+
+	setup := func(config *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+		f.api.On("HasPermissionTo", adminID, model.PERMISSION_MANAGE_SYSTEM).Return(true)
+		f.api.On("HasPermissionTo", mock.AnythingOfType("string"), model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		f.api.On("GetChannel", commandChannel.Id).Return(commandChannel, nil)
+		f.api.On("GetPost", f.rootPost.Id).Return(f.rootPost, nil)
+
+		plugin := &Plugin{BotUserID: model.NewId()}
+		plugin.SetAPI(f.api)
+		config.CommandChannelID = commandChannel.Id
+		plugin.setConfiguration(config)
+
+		return f, plugin
+	}
+
+	t.Run("commands run elsewhere are blocked", func(t *testing.T) {
+		f, plugin := setup(&configuration{CommandChannelAdminBypass: true})
+
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler info", UserId: f.rootPost.UserId, ChannelId: f.originalChannel.Id})
+		require.Nil(t, appErr)
+		assert.Equal(t, "Wrangler commands can only be run in ~wrangler-ops.", resp.Text)
+	})
+
+	t.Run("system admins can bypass", func(t *testing.T) {
+		f, plugin := setup(&configuration{CommandChannelAdminBypass: true})
+
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler info", UserId: adminID, ChannelId: f.originalChannel.Id})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "Wrangler plugin version")
+	})
+
+	t.Run("bypass disabled", func(t *testing.T) {
+		f, plugin := setup(&configuration{})
+
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler info", UserId: adminID, ChannelId: f.originalChannel.Id})
+		require.Nil(t, appErr)
+		assert.Equal(t, "Wrangler commands can only be run in ~wrangler-ops.", resp.Text)
+	})
+
+	t.Run("messages of other channels are moved from the command channel", func(t *testing.T) {
+		f, plugin := setup(&configuration{})
+
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{
+			Command:   fmt.Sprintf("wrangler move thread %s %s", f.rootPost.Id, f.targetChannel.Id),
+			UserId:    f.rootPost.UserId,
+			ChannelId: commandChannel.Id,
+			TeamId:    f.team.Id,
+		})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+	})
+
+	t.Run("messages of channels the user isn't a member of are not retargeted", func(t *testing.T) {
+		f, plugin := setup(&configuration{})
+		f.unsetMock("GetChannelMember")
+		f.api.On("GetChannelMember", f.originalChannel.Id, f.rootPost.UserId).Return(nil, &model.AppError{})
+
+		args := &model.CommandArgs{UserId: f.rootPost.UserId, ChannelId: commandChannel.Id}
+		assert.Equal(t, args, plugin.retargetCommandArgs(args, []string{f.rootPost.Id}))
+	})
+}
+
+func TestMaxCommandLength(t *testing.T) {
+	context := &plugin.Context{}
+
+	var plugin Plugin
+	plugin.SetAPI(&plugintest.API{})
+	plugin.setConfiguration(&configuration{MaxCommandLength: "100"})
+
+	t.Run("long command is refused", func(t *testing.T) {
+		command := "wrangler move thread " + strings.Repeat("a", 80)
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: command, UserId: model.NewId()})
+		require.Nil(t, appErr)
+		assert.Equal(t, "Error: the command is 101 characters long, but Wrangler only accepts commands of up to 100 characters.", resp.Text)
+	})
+
+	t.Run("command within the limit", func(t *testing.T) {
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler move thread", UserId: model.NewId()})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "/wrangler move thread [MESSAGE_ID] [CHANNEL_ID]")
+	})
+}
+
+func TestExperimentalFeatures(t *testing.T) {
+	context := &plugin.Context{}
+	userID := model.NewId()
+
+	var plugin Plugin
+	plugin.SetAPI(&plugintest.API{})
+
+	t.Run("disabled commands are refused", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{})
+
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler import " + model.NewId(), UserId: userID})
+		require.Nil(t, appErr)
+		assert.Equal(t, "Error: /wrangler import is an experimental feature that isn't enabled on this server; please ask your system administrator to enable the import experimental feature", resp.Text)
+
+		resp, appErr = plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler request move", UserId: userID})
+		require.Nil(t, appErr)
+		assert.Equal(t, "Error: /wrangler request move is an experimental feature that isn't enabled on this server; please ask your system administrator to enable the request-move experimental feature", resp.Text)
+	})
+
+	t.Run("enabled commands run", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{ExperimentalFeatures: "import,request-move"})
+
+		resp, appErr := plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler import", UserId: userID})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "/wrangler import [CHANNEL_ID] [MESSAGE_ID]")
+
+		resp, appErr = plugin.ExecuteCommand(context, &model.CommandArgs{Command: "wrangler request move", UserId: userID})
+		require.Nil(t, appErr)
+		assert.Contains(t, resp.Text, "/wrangler request move [MESSAGE_ID] [CHANNEL_ID]")
+	})
+
+	t.Run("autocomplete", func(t *testing.T) {
+		hasSubCommand := func(command *model.Command, trigger string) bool {
+			for _, subCommand := range command.AutocompleteData.SubCommands {
+				if subCommand.Trigger == trigger {
+					return true
+				}
+			}
+			return false
+		}
+
+		command := getCommand(&configuration{})
+		assert.False(t, hasSubCommand(command, "import"))
+		assert.False(t, hasSubCommand(command, "request"))
+		assert.NotContains(t, command.AutoCompleteDesc, " import,")
+		assert.NotContains(t, command.AutocompleteData.HelpText, "request")
+
+		command = getCommand(&configuration{ExperimentalFeatures: "import"})
+		assert.True(t, hasSubCommand(command, "import"))
+		assert.False(t, hasSubCommand(command, "request"))
+		assert.Contains(t, command.AutoCompleteDesc, " import,")
+		assert.Contains(t, command.AutocompleteData.HelpText, " import,")
+	})
+}
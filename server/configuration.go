@@ -1,12 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/pkg/errors"
 )
 
@@ -22,15 +26,332 @@ import (
 // If you add non-reference types to your configuration struct, be sure to rewrite Clone as a deep
 // copy appropriate for your types.
 type configuration struct {
-	AllowedEmailDomain        string
-	EnableWebUI               bool
-	CommandAutoCompleteEnable bool
+	AllowedEmailDomain         string
+	EnableWebUI                bool
+	WebUIPermittedRoles        string
+	MovePermittedRoles         string
+	CopyPermittedRoles         string
+	CommandAutoCompleteEnable  bool
+	MaintenanceMode            bool
+	MaintenanceModeAdminBypass bool
 
 	MoveThreadMaxCount                       string
+	MaxCountBypassUsers                      string
 	MoveThreadToAnotherTeamEnable            bool
 	MoveThreadFromPrivateChannelEnable       bool
 	MoveThreadFromDirectMessageChannelEnable bool
 	MoveThreadFromGroupMessageChannelEnable  bool
+	MoveRepliesOnly                          bool
+	MaxNotificationDMsPerHour                string
+	MovedHashtag                             string
+	EnableChannelLogThread                   bool
+	ChannelStateActions                      string
+	RoutingRules                             string
+	MaxAuthorsPerMove                        string
+	MoveTextTransforms                       string
+	MovedPostProps                           string
+	CollapseGapSeconds                       string
+	CollapseInsertSeparator                  bool
+	OperationTimeoutSeconds                  string
+	PostCreationConcurrency                  string
+	DeactivatedUserPosts                     string
+	MoveApprovalChannelID                    string
+	MoveApprovalSourceChannels               string
+	SourceToDestinationMap                   string
+	DefaultArchiveChannelID                  string
+	OversizedTranscripts                     string
+	LockEmoji                                string
+	LockEmojiAdminBypass                     bool
+	StatsWindowDays                          string
+	NonMemberAuthors                         string
+	TemporaryResponseMinutes                 string
+	HistoryRetentionDays                     string
+	AdminCrossTeamOverride                   bool
+	IntegrationPosts                         string
+	ChangelogChannelID                       string
+	AllowedDestinationTeamIDs                string
+	MentionPolicy                            string
+	EnableMoveFeedbackPoll                   bool
+	MoveDeletionDelaySeconds                 string
+	AttachAsDirectReply                      bool
+	MaxMovesPerChannelPerDay                 string
+	DestinationPostCountWarning              string
+	CommandChannelID                         string
+	CommandChannelAdminBypass                bool
+	KnowledgeChannels                        string
+	ListMessagesTrimLength                   string
+	RoutingEndpointURL                       string
+	RoutingEndpointAuthHeader                string
+	BlockedContentPatterns                   string
+	MaxConcurrentOperations                  string
+	PlaybookPosts                            string
+	MaxCommandLength                         string
+	CompressArchivedImages                   bool
+	CompressedImageMaxDimension              string
+	CompressedImageQuality                   string
+	ResolutionAnnotations                    string
+	EnableMovedThreadSummary                 bool
+	HighImpactMoveChannelID                  string
+	HighImpactMovePostThreshold              string
+	HighImpactMoveAuthorThreshold            string
+	EnableMoveUndoButton                     bool
+	RootOnlyKeepReplies                      bool
+	AttributionAuthor                        string
+	MaxMergeSize                             string
+	TicketLinkRegex                          string
+	TicketLinkURLTemplate                    string
+	TicketLinkChannels                       string
+	MaxMoveRequestsPerUserPerDay             string
+	EnableOriginalTimestampHeader            bool
+	EnableMovedFromFooter                    bool
+	MovedFromFooterText                      string
+	HighImpactMoveDigestMinutes              string
+	MaxScheduledJobsPerUser                  string
+	AllowedSourceChannelPattern              string
+	EnableMoveRedirectStub                   bool
+	LinkBackToOriginal                       string
+	SuccessMessageVerbosity                  string
+	MentionMoverInMovedThreads               bool
+	BlockGuestMoves                          bool
+	EnabledOperations                        string
+	OriginalDeleteFailure                    string
+	MinAccountAgeDays                        string
+	DestinationTemplates                     string
+	DuplicateCopies                          string
+	DefaultTimezone                          string
+	MaxMoveFileSizeMB                        string
+	MaxMoveFileCount                         string
+	MoveFileLimitAction                      string
+	GroupMentions                            string
+	ReplyBroadcasts                          string
+	BoardsCardBoardID                        string
+	KeepMoverAsAuthor                        bool
+	ChannelAutocompleteIntervalMilliseconds  string
+	DefaultChannelMoves                      string
+	MoveSummaries                            bool
+	ExperimentalFeatures                     string
+}
+
+// channelStateAction describes how the resolution state of a thread root post
+// is reset when the thread is moved into a given channel.
+type channelStateAction struct {
+	RemoveReaction string `json:"remove_reaction"`
+	ResetProp      string `json:"reset_prop"`
+}
+
+// destinationTemplate frames the threads moved into a given channel. Both
+// templates may use the same placeholder as the MovedFromFooterText.
+type destinationTemplate struct {
+	Header string `json:"header"`
+	Footer string `json:"footer"`
+}
+
+// Values of the IntegrationPosts setting.
+const (
+	integrationPostsAllow   = "allow"
+	integrationPostsConfirm = "confirm"
+	integrationPostsBlock   = "block"
+)
+
+// Values of the PlaybookPosts setting.
+const (
+	playbookPostsAllow   = "allow"
+	playbookPostsConfirm = "confirm"
+	playbookPostsBlock   = "block"
+)
+
+// Values of the DefaultChannelMoves setting.
+const (
+	defaultChannelMovesAllow   = "allow"
+	defaultChannelMovesConfirm = "confirm"
+	defaultChannelMovesBlock   = "block"
+)
+
+// Values of the MentionPolicy setting.
+const (
+	mentionPolicyPreserveAll = "preserve-all"
+	mentionPolicySuppressAll = "suppress-all"
+	mentionPolicyChannelOnly = "channel-only"
+)
+
+// Values of the ReplyBroadcasts setting.
+const (
+	replyBroadcastsPreserve = "preserve"
+	replyBroadcastsRemove   = "remove"
+)
+
+// Values of the GroupMentions setting.
+const (
+	groupMentionsPreserve   = "preserve"
+	groupMentionsNeutralize = "neutralize"
+	groupMentionsWarn       = "warn"
+)
+
+// Values of the NonMemberAuthors setting.
+const (
+	nonMemberAuthorsAdd   = "add"
+	nonMemberAuthorsBot   = "bot"
+	nonMemberAuthorsAbort = "abort"
+)
+
+// Values of the OversizedTranscripts setting.
+const (
+	oversizedTranscriptsSplit = "split"
+	oversizedTranscriptsFile  = "file"
+)
+
+// Values of the DeactivatedUserPosts setting.
+const (
+	deactivatedUserPostsBot  = "bot"
+	deactivatedUserPostsSkip = "skip"
+)
+
+// Values of the LinkBackToOriginal setting.
+const (
+	linkBackCopies         = "copies"
+	linkBackCopiesAndMoves = "copies-and-moves"
+	linkBackOff            = "off"
+)
+
+// Values of the SuccessMessageVerbosity setting and the success-verbosity
+// preference.
+const (
+	successVerbosityMinimal  = "minimal"
+	successVerbosityNormal   = "normal"
+	successVerbosityDetailed = "detailed"
+)
+
+// Values of the EnabledOperations setting.
+const (
+	enabledOperationMove   = "move"
+	enabledOperationCopy   = "copy"
+	enabledOperationAttach = "attach"
+	enabledOperationMerge  = "merge"
+)
+
+// Keys of the ExperimentalFeatures setting. Each key enables an experimental
+// command or flag, which is otherwise left out of the autocomplete and refused
+// when run.
+const (
+	experimentalFeatureImport      = "import"
+	experimentalFeatureRequestMove = "request-move"
+	experimentalFeatureReminders   = "reminders"
+)
+
+// experimentalFeatures are the known keys of the ExperimentalFeatures setting.
+var experimentalFeatures = []string{
+	experimentalFeatureImport,
+	experimentalFeatureRequestMove,
+	experimentalFeatureReminders,
+}
+
+// Values of the OriginalDeleteFailure setting.
+const (
+	originalDeleteFailureRollback = "rollback"
+	originalDeleteFailureCopy     = "copy"
+)
+
+// Values of the MoveFileLimitAction setting.
+const (
+	moveFileLimitRefuse = "refuse"
+	moveFileLimitSkip   = "skip"
+)
+
+// Values of the DuplicateCopies setting.
+const (
+	duplicateCopiesAllow = "allow"
+	duplicateCopiesWarn  = "warn"
+	duplicateCopiesSkip  = "skip"
+)
+
+// Values of the AttributionAuthor setting.
+const (
+	attributionAuthorBot   = "bot"
+	attributionAuthorActor = "actor"
+)
+
+// Bounds and default of the StatsWindowDays setting.
+const (
+	defaultStatsWindowDays = 30
+	maxStatsWindowDays     = 365
+)
+
+// defaultMaxMoveRequestsPerUserPerDay is the number of move requests each
+// user may file per day when MaxMoveRequestsPerUserPerDay is not configured.
+const defaultMaxMoveRequestsPerUserPerDay = 5
+
+// movedFromFooterPlaceholder is replaced with the original channel in the
+// MovedFromFooterText.
+const movedFromFooterPlaceholder = "{channel}"
+
+// templatePlaceholderRegexp matches the placeholders of the moved from footer
+// and destination templates.
+var templatePlaceholderRegexp = regexp.MustCompile(`\{[A-Za-z_]+\}`)
+
+// defaultMovedFromFooterText is used when MovedFromFooterText is not
+// configured.
+const defaultMovedFromFooterText = "_Moved from {channel}_"
+
+// maxHighImpactMoveDigestMinutes is the highest allowed
+// HighImpactMoveDigestMinutes.
+const maxHighImpactMoveDigestMinutes = 7 * 24 * 60
+
+// maxHistoryRetentionDays is the highest allowed HistoryRetentionDays.
+const maxHistoryRetentionDays = 3650
+
+// maxTemporaryResponseMinutes is the highest allowed TemporaryResponseMinutes.
+const maxTemporaryResponseMinutes = 24 * 60
+
+// maxPostCreationConcurrency is the highest allowed PostCreationConcurrency.
+const maxPostCreationConcurrency = 20
+
+// defaultCollapseGap is used when CollapseGapSeconds is not configured.
+const defaultCollapseGap = 2 * time.Minute
+
+// defaultChannelAutocompleteInterval is used when
+// ChannelAutocompleteIntervalMilliseconds is not configured.
+const defaultChannelAutocompleteInterval = time.Second
+
+// maxChannelAutocompleteInterval is the highest allowed
+// ChannelAutocompleteIntervalMilliseconds, so that new channels show up in the
+// autocomplete soon after they are joined.
+const maxChannelAutocompleteInterval = time.Minute
+
+// routingRule maps root messages matching a keyword or a regular expression to
+// the channel that their threads should be routed to.
+type routingRule struct {
+	Keyword   string `json:"keyword"`
+	Regex     string `json:"regex"`
+	ChannelID string `json:"channel_id"`
+
+	regexp *regexp.Regexp
+}
+
+// matches returns true if the given message matches the rule. Keywords are
+// matched case-insensitively.
+func (r *routingRule) matches(message string) bool {
+	if r.regexp != nil {
+		return r.regexp.MatchString(message)
+	}
+
+	return strings.Contains(strings.ToLower(message), strings.ToLower(r.Keyword))
+}
+
+// textTransform is a find and replace applied to the message of every moved
+// post. When ChannelID is set, it only applies to posts moved out of that
+// channel.
+type textTransform struct {
+	Regex       string `json:"regex"`
+	Replacement string `json:"replacement"`
+	ChannelID   string `json:"channel_id"`
+
+	regexp *regexp.Regexp
+}
+
+// apply returns the message with all matches of the transform replaced.
+// Replacements may reference capture groups, such as $1.
+func (t *textTransform) apply(message string) string {
+	return t.regexp.ReplaceAllString(message, t.Replacement)
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
@@ -40,55 +361,2102 @@ func (c *configuration) Clone() *configuration {
 	return &clone
 }
 
-func (c *configuration) IsValid() error {
-	var err error
+func (c *configuration) IsValid() error {
+	var err error
+
+	if len(c.AllowedEmailDomain) != 0 {
+		emailDomains := strings.Split(c.AllowedEmailDomain, ",")
+		for _, emailDomain := range emailDomains {
+			if len(emailDomain) == 0 {
+				return errors.New("AllowedEmailDomain has a trailing comma")
+			}
+			_, err = url.Parse(emailDomain)
+			if err != nil {
+				return errors.Wrapf(err, "invalid AllowedEmailDomain value %s", emailDomain)
+			}
+		}
+	}
+
+	for _, role := range c.WebUIPermittedRoleNames() {
+		if !model.IsValidRoleName(role) {
+			return fmt.Errorf("WebUIPermittedRoles value %s is not a valid role name", role)
+		}
+	}
+	for _, role := range c.MovePermittedRoleNames() {
+		if !model.IsValidRoleName(role) {
+			return fmt.Errorf("MovePermittedRoles value %s is not a valid role name", role)
+		}
+	}
+	for _, role := range c.CopyPermittedRoleNames() {
+		if !model.IsValidRoleName(role) {
+			return fmt.Errorf("CopyPermittedRoles value %s is not a valid role name", role)
+		}
+	}
+
+	_, err = parseAndValidateMaxThreadCountMoveSize(c.MoveThreadMaxCount)
+	if err != nil {
+		return errors.Wrap(err, "invalid MoveThreadMaxSize")
+	}
+
+	for _, username := range strings.Split(c.MaxCountBypassUsers, ",") {
+		username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+		if len(username) != 0 && !model.IsValidUsername(strings.ToLower(username)) {
+			return fmt.Errorf("MaxCountBypassUsers value %s is not a valid username", username)
+		}
+	}
+
+	_, err = parseAndValidateMaxAuthorsPerMove(c.MaxAuthorsPerMove)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxAuthorsPerMove")
+	}
+
+	_, err = parseAndValidateMaxMergeSize(c.MaxMergeSize)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxMergeSize")
+	}
+
+	_, err = parseAndValidateCollapseGap(c.CollapseGapSeconds)
+	if err != nil {
+		return errors.Wrap(err, "invalid CollapseGapSeconds")
+	}
+
+	_, err = parseAndValidateMaxNotificationDMsPerHour(c.MaxNotificationDMsPerHour)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxNotificationDMsPerHour")
+	}
+
+	_, err = parseAndValidateMaxMovesPerChannelPerDay(c.MaxMovesPerChannelPerDay)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxMovesPerChannelPerDay")
+	}
+
+	_, err = parseAndValidateMaxMoveRequestsPerUserPerDay(c.MaxMoveRequestsPerUserPerDay)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxMoveRequestsPerUserPerDay")
+	}
+
+	_, err = parseAndValidateDestinationPostCountWarning(c.DestinationPostCountWarning)
+	if err != nil {
+		return errors.Wrap(err, "invalid DestinationPostCountWarning")
+	}
+
+	_, err = parseAndValidateListMessagesTrimLength(c.ListMessagesTrimLength)
+	if err != nil {
+		return errors.Wrap(err, "invalid ListMessagesTrimLength")
+	}
+
+	_, err = parseAndValidateMaxConcurrentOperations(c.MaxConcurrentOperations)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxConcurrentOperations")
+	}
+
+	_, err = parseAndValidateMaxCommandLength(c.MaxCommandLength)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxCommandLength")
+	}
+
+	_, err = parseAndValidateCompressedImageMaxDimension(c.CompressedImageMaxDimension)
+	if err != nil {
+		return errors.Wrap(err, "invalid CompressedImageMaxDimension")
+	}
+
+	_, err = parseAndValidateCompressedImageQuality(c.CompressedImageQuality)
+	if err != nil {
+		return errors.Wrap(err, "invalid CompressedImageQuality")
+	}
+
+	_, err = parseAndValidateOperationTimeout(c.OperationTimeoutSeconds)
+	if err != nil {
+		return errors.Wrap(err, "invalid OperationTimeoutSeconds")
+	}
+
+	_, err = parseAndValidateChannelAutocompleteInterval(c.ChannelAutocompleteIntervalMilliseconds)
+	if err != nil {
+		return errors.Wrap(err, "invalid ChannelAutocompleteIntervalMilliseconds")
+	}
+
+	_, err = parseAndValidateMoveDeletionDelay(c.MoveDeletionDelaySeconds)
+	if err != nil {
+		return errors.Wrap(err, "invalid MoveDeletionDelaySeconds")
+	}
+	if c.EnableMoveUndoButton && c.MoveDeletionDelay() == 0 {
+		return errors.New("EnableMoveUndoButton requires MoveDeletionDelaySeconds to be greater than 0")
+	}
+
+	_, err = parseAndValidatePostCreationConcurrency(c.PostCreationConcurrency)
+	if err != nil {
+		return errors.Wrap(err, "invalid PostCreationConcurrency")
+	}
+
+	_, err = parseAndValidateStatsWindowDays(c.StatsWindowDays)
+	if err != nil {
+		return errors.Wrap(err, "invalid StatsWindowDays")
+	}
+
+	retentionDays, err := parseAndValidateHistoryRetentionDays(c.HistoryRetentionDays)
+	if err != nil {
+		return errors.Wrap(err, "invalid HistoryRetentionDays")
+	}
+	if retentionDays != 0 && retentionDays < c.StatsWindowDaysInt() {
+		return fmt.Errorf("HistoryRetentionDays (%d) must be at least StatsWindowDays (%d)", retentionDays, c.StatsWindowDaysInt())
+	}
+
+	_, err = parseAndValidateTemporaryResponseMinutes(c.TemporaryResponseMinutes)
+	if err != nil {
+		return errors.Wrap(err, "invalid TemporaryResponseMinutes")
+	}
+
+	switch c.DeactivatedUserPosts {
+	case "", deactivatedUserPostsBot, deactivatedUserPostsSkip:
+	default:
+		return fmt.Errorf("DeactivatedUserPosts value %s must be %s or %s", c.DeactivatedUserPosts, deactivatedUserPostsBot, deactivatedUserPostsSkip)
+	}
+
+	for _, emojiName := range strings.Split(c.LockEmoji, ",") {
+		emojiName = strings.TrimSpace(emojiName)
+		if strings.ContainsAny(strings.Trim(emojiName, ":"), ": \t") {
+			return fmt.Errorf("LockEmoji value %s is not a valid emoji name", emojiName)
+		}
+	}
+
+	switch c.NonMemberAuthors {
+	case "", nonMemberAuthorsAdd, nonMemberAuthorsBot, nonMemberAuthorsAbort:
+	default:
+		return fmt.Errorf("NonMemberAuthors value %s must be %s, %s or %s", c.NonMemberAuthors, nonMemberAuthorsAdd, nonMemberAuthorsBot, nonMemberAuthorsAbort)
+	}
+
+	switch c.MentionPolicy {
+	case "", mentionPolicyPreserveAll, mentionPolicySuppressAll, mentionPolicyChannelOnly:
+	default:
+		return fmt.Errorf("MentionPolicy value %s must be %s, %s or %s", c.MentionPolicy, mentionPolicyPreserveAll, mentionPolicySuppressAll, mentionPolicyChannelOnly)
+	}
+
+	switch c.ReplyBroadcasts {
+	case "", replyBroadcastsPreserve, replyBroadcastsRemove:
+	default:
+		return fmt.Errorf("ReplyBroadcasts value %s must be %s or %s", c.ReplyBroadcasts, replyBroadcastsPreserve, replyBroadcastsRemove)
+	}
+
+	switch c.GroupMentions {
+	case "", groupMentionsPreserve, groupMentionsNeutralize, groupMentionsWarn:
+	default:
+		return fmt.Errorf("GroupMentions value %s must be %s, %s or %s", c.GroupMentions, groupMentionsPreserve, groupMentionsNeutralize, groupMentionsWarn)
+	}
+
+	switch c.AttributionAuthor {
+	case "", attributionAuthorBot, attributionAuthorActor:
+	default:
+		return fmt.Errorf("AttributionAuthor value %s must be %s or %s", c.AttributionAuthor, attributionAuthorBot, attributionAuthorActor)
+	}
+
+	switch c.LinkBackToOriginal {
+	case "", linkBackCopies, linkBackCopiesAndMoves, linkBackOff:
+	default:
+		return fmt.Errorf("LinkBackToOriginal value %s must be %s, %s or %s", c.LinkBackToOriginal, linkBackCopies, linkBackCopiesAndMoves, linkBackOff)
+	}
+
+	if len(c.SuccessMessageVerbosity) != 0 && !isValidSuccessVerbosity(c.SuccessMessageVerbosity) {
+		return fmt.Errorf("SuccessMessageVerbosity value %s must be %s, %s or %s", c.SuccessMessageVerbosity, successVerbosityMinimal, successVerbosityNormal, successVerbosityDetailed)
+	}
+
+	for _, operation := range c.EnabledOperationNames() {
+		switch operation {
+		case enabledOperationMove, enabledOperationCopy, enabledOperationAttach, enabledOperationMerge:
+		default:
+			return fmt.Errorf("EnabledOperations value %s must be %s, %s, %s or %s", operation, enabledOperationMove, enabledOperationCopy, enabledOperationAttach, enabledOperationMerge)
+		}
+	}
+
+	switch c.OriginalDeleteFailure {
+	case "", originalDeleteFailureRollback, originalDeleteFailureCopy:
+	default:
+		return fmt.Errorf("OriginalDeleteFailure value %s must be %s or %s", c.OriginalDeleteFailure, originalDeleteFailureRollback, originalDeleteFailureCopy)
+	}
+
+	if len(c.DefaultTimezone) != 0 {
+		if _, err := time.LoadLocation(c.DefaultTimezone); err != nil {
+			return fmt.Errorf("DefaultTimezone value %s is not a valid time zone", c.DefaultTimezone)
+		}
+	}
+
+	switch c.MoveFileLimitAction {
+	case "", moveFileLimitRefuse, moveFileLimitSkip:
+	default:
+		return fmt.Errorf("MoveFileLimitAction value %s must be %s or %s", c.MoveFileLimitAction, moveFileLimitRefuse, moveFileLimitSkip)
+	}
+
+	switch c.DuplicateCopies {
+	case "", duplicateCopiesAllow, duplicateCopiesWarn, duplicateCopiesSkip:
+	default:
+		return fmt.Errorf("DuplicateCopies value %s must be %s, %s or %s", c.DuplicateCopies, duplicateCopiesAllow, duplicateCopiesWarn, duplicateCopiesSkip)
+	}
+
+	switch c.IntegrationPosts {
+	case "", integrationPostsAllow, integrationPostsConfirm, integrationPostsBlock:
+	default:
+		return fmt.Errorf("IntegrationPosts value %s must be %s, %s or %s", c.IntegrationPosts, integrationPostsAllow, integrationPostsConfirm, integrationPostsBlock)
+	}
+
+	switch c.PlaybookPosts {
+	case "", playbookPostsAllow, playbookPostsConfirm, playbookPostsBlock:
+	default:
+		return fmt.Errorf("PlaybookPosts value %s must be %s, %s or %s", c.PlaybookPosts, playbookPostsAllow, playbookPostsConfirm, playbookPostsBlock)
+	}
+
+	switch c.DefaultChannelMoves {
+	case "", defaultChannelMovesAllow, defaultChannelMovesConfirm, defaultChannelMovesBlock:
+	default:
+		return fmt.Errorf("DefaultChannelMoves value %s must be %s, %s or %s", c.DefaultChannelMoves, defaultChannelMovesAllow, defaultChannelMovesConfirm, defaultChannelMovesBlock)
+	}
+
+	switch c.OversizedTranscripts {
+	case "", oversizedTranscriptsSplit, oversizedTranscriptsFile:
+	default:
+		return fmt.Errorf("OversizedTranscripts value %s must be %s or %s", c.OversizedTranscripts, oversizedTranscriptsSplit, oversizedTranscriptsFile)
+	}
+
+	if len(c.MoveApprovalChannelID) != 0 && !model.IsValidId(c.MoveApprovalChannelID) {
+		return fmt.Errorf("MoveApprovalChannelID value %s is not a valid channel ID", c.MoveApprovalChannelID)
+	}
+	for _, channelID := range strings.Split(c.MoveApprovalSourceChannels, ",") {
+		channelID = strings.TrimSpace(channelID)
+		if len(channelID) != 0 && !model.IsValidId(channelID) {
+			return fmt.Errorf("MoveApprovalSourceChannels value %s is not a valid channel ID", channelID)
+		}
+	}
+
+	_, err = parseAndValidateTicketLinker(c.TicketLinkRegex, c.TicketLinkURLTemplate)
+	if err != nil {
+		return errors.Wrap(err, "invalid ticket links")
+	}
+	for _, channelID := range strings.Split(c.TicketLinkChannels, ",") {
+		channelID = strings.TrimSpace(channelID)
+		if len(channelID) != 0 && !model.IsValidId(channelID) {
+			return fmt.Errorf("TicketLinkChannels value %s is not a valid channel ID", channelID)
+		}
+	}
+
+	for _, teamID := range strings.Split(c.AllowedDestinationTeamIDs, ",") {
+		teamID = strings.TrimSpace(teamID)
+		if len(teamID) != 0 && !model.IsValidId(teamID) {
+			return fmt.Errorf("AllowedDestinationTeamIDs value %s is not a valid team ID", teamID)
+		}
+	}
+
+	if len(c.MovedHashtag) != 0 {
+		hashtags, _ := model.ParseHashtags(c.MovedHashtag)
+		if hashtags != c.MovedHashtag || len(strings.Fields(hashtags)) != 1 {
+			return fmt.Errorf("MovedHashtag value %s is not a single valid hashtag", c.MovedHashtag)
+		}
+	}
+
+	_, err = parseAndValidateChannelStateActions(c.ChannelStateActions)
+	if err != nil {
+		return errors.Wrap(err, "invalid ChannelStateActions")
+	}
+
+	_, err = parseAndValidateDestinationTemplates(c.DestinationTemplates)
+	if err != nil {
+		return errors.Wrap(err, "invalid DestinationTemplates")
+	}
+
+	_, err = parseAndValidateResolutionAnnotations(c.ResolutionAnnotations)
+	if err != nil {
+		return errors.Wrap(err, "invalid ResolutionAnnotations")
+	}
+
+	_, err = parseAndValidateRoutingRules(c.RoutingRules)
+	if err != nil {
+		return errors.Wrap(err, "invalid RoutingRules")
+	}
+
+	err = validateRoutingEndpoint(c.RoutingEndpointURL, c.RoutingEndpointAuthHeader)
+	if err != nil {
+		return errors.Wrap(err, "invalid routing endpoint")
+	}
+
+	_, err = parseAndValidateMoveTextTransforms(c.MoveTextTransforms)
+	if err != nil {
+		return errors.Wrap(err, "invalid MoveTextTransforms")
+	}
+
+	_, err = parseAndValidateBlockedContentPatterns(c.BlockedContentPatterns)
+	if err != nil {
+		return errors.Wrap(err, "invalid BlockedContentPatterns")
+	}
+
+	_, err = parseAndValidateMovedPostProps(c.MovedPostProps)
+	if err != nil {
+		return errors.Wrap(err, "invalid MovedPostProps")
+	}
+
+	_, err = parseAndValidateSourceToDestinationMap(c.SourceToDestinationMap)
+	if err != nil {
+		return errors.Wrap(err, "invalid SourceToDestinationMap")
+	}
+
+	if len(c.DefaultArchiveChannelID) != 0 && !model.IsValidId(c.DefaultArchiveChannelID) {
+		return fmt.Errorf("DefaultArchiveChannelID value %s is not a valid channel ID", c.DefaultArchiveChannelID)
+	}
+
+	if len(c.ChangelogChannelID) != 0 && !model.IsValidId(c.ChangelogChannelID) {
+		return fmt.Errorf("ChangelogChannelID value %s is not a valid channel ID", c.ChangelogChannelID)
+	}
+	if len(c.BoardsCardBoardID) != 0 && !boardIDRegexp.MatchString(c.BoardsCardBoardID) {
+		return fmt.Errorf("BoardsCardBoardID value %s is not a valid board ID", c.BoardsCardBoardID)
+	}
+
+	if len(c.HighImpactMoveChannelID) != 0 && !model.IsValidId(c.HighImpactMoveChannelID) {
+		return fmt.Errorf("HighImpactMoveChannelID value %s is not a valid channel ID", c.HighImpactMoveChannelID)
+	}
+
+	_, err = parseAndValidateHighImpactMoveThreshold(c.HighImpactMovePostThreshold)
+	if err != nil {
+		return errors.Wrap(err, "invalid HighImpactMovePostThreshold")
+	}
+
+	_, err = parseAndValidateHighImpactMoveThreshold(c.HighImpactMoveAuthorThreshold)
+	if err != nil {
+		return errors.Wrap(err, "invalid HighImpactMoveAuthorThreshold")
+	}
+
+	_, err = parseAndValidateHighImpactMoveDigestMinutes(c.HighImpactMoveDigestMinutes)
+	if err != nil {
+		return errors.Wrap(err, "invalid HighImpactMoveDigestMinutes")
+	}
+
+	if c.MoveSummaries && len(c.HighImpactMoveChannelID) == 0 {
+		return errors.New("MoveSummaries requires HighImpactMoveChannelID to be set")
+	}
+
+	_, err = parseAndValidateMaxScheduledJobsPerUser(c.MaxScheduledJobsPerUser)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxScheduledJobsPerUser")
+	}
+
+	_, err = parseAndValidateMinAccountAgeDays(c.MinAccountAgeDays)
+	if err != nil {
+		return errors.Wrap(err, "invalid MinAccountAgeDays")
+	}
+
+	_, err = parseAndValidateMaxMoveFileSizeMB(c.MaxMoveFileSizeMB)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxMoveFileSizeMB")
+	}
+
+	_, err = parseAndValidateMaxMoveFileCount(c.MaxMoveFileCount)
+	if err != nil {
+		return errors.Wrap(err, "invalid MaxMoveFileCount")
+	}
+
+	_, err = parseAndValidateAllowedSourceChannelPattern(c.AllowedSourceChannelPattern)
+	if err != nil {
+		return errors.Wrap(err, "invalid AllowedSourceChannelPattern")
+	}
+
+	return nil
+}
+
+// capabilities returns the features supported by this build of the plugin
+// along with whether they are enabled by the configuration.
+func (c *configuration) capabilities() map[string]bool {
+	return map[string]bool{
+		"web_ui":                     c.EnableWebUI,
+		"maintenance_mode":           c.MaintenanceMode,
+		"move_roles":                 len(c.MovePermittedRoleNames()) != 0,
+		"copy_roles":                 len(c.CopyPermittedRoleNames()) != 0,
+		"cross_team_move":            c.MoveThreadToAnotherTeamEnable,
+		"admin_cross_team_override":  c.AdminCrossTeamOverride,
+		"destination_team_allowlist": len(c.AllowedDestinationTeamIDs) != 0,
+		"integration_post_check":     c.IntegrationPostsPolicy() != integrationPostsAllow,
+		"playbook_post_check":        c.PlaybookPostsPolicy() != playbookPostsAllow,
+		"mention_suppression":        c.MentionPolicyValue() != mentionPolicyPreserveAll,
+		"move_changelog":             len(c.ChangelogChannelID) != 0,
+		"move_feedback_poll":         c.EnableMoveFeedbackPoll,
+		"attach_as_direct_reply":     c.AttachAsDirectReply,
+		"move_from_private_channels": c.MoveThreadFromPrivateChannelEnable,
+		"move_from_direct_messages":  c.MoveThreadFromDirectMessageChannelEnable,
+		"move_from_group_messages":   c.MoveThreadFromGroupMessageChannelEnable,
+		"move_replies_only_default":  c.MoveRepliesOnly,
+		"moved_hashtag":              len(c.MovedHashtag) != 0,
+		"channel_log_thread":         c.EnableChannelLogThread,
+		"channel_state_actions":      len(c.ChannelStateActionsMap()) != 0,
+		"resolution_annotations":     len(c.ResolutionAnnotationsMap()) != 0,
+		"moved_thread_summary":       c.EnableMovedThreadSummary,
+		"original_timestamp_header":  c.EnableOriginalTimestampHeader,
+		"moved_from_footer":          c.EnableMovedFromFooter,
+		"high_impact_move_notices":   len(c.HighImpactMoveChannelID) != 0,
+		"high_impact_move_digest":    len(c.HighImpactMoveChannelID) != 0 && c.HighImpactMoveDigestInterval() != 0,
+		"move_undo_button":           c.EnableMoveUndoButton,
+		"move_root_only":             true,
+		"move_into_thread":           true,
+		"move_consolidate":           true,
+		"actor_attribution":          c.AttributionAuthorValue() == attributionAuthorActor,
+		"batch_api":                  c.EnableWebUI,
+		"history_csv_export":         true,
+		"merge_size_limit":           c.MaxMergeSizeInt() != 0,
+		"move_requests":              len(c.MoveApprovalChannelID) != 0 && c.ExperimentalFeatureEnabled(experimentalFeatureRequestMove),
+		"ticket_links":               len(c.TicketLinkRegex) != 0 && len(strings.TrimSpace(c.TicketLinkChannels)) != 0,
+		"routing_rules":              len(c.RoutingRulesList()) != 0,
+		"routing_endpoint":           len(c.RoutingEndpointURL) != 0,
+		"blocked_content":            len(c.BlockedContentPatternsList()) != 0,
+		"operation_limit":            c.MaxConcurrentOperationsInt() != 0,
+		"image_compression":          c.CompressArchivedImages,
+		"text_transforms":            len(strings.TrimSpace(c.MoveTextTransforms)) != 0,
+		"moved_post_props":           true,
+		"notification_dm_limit":      c.MaxNotificationDMsPerHourInt() != 0,
+		"channel_move_quota":         c.MaxMovesPerChannelPerDayInt() != 0,
+		"destination_size_warning":   c.DestinationPostCountWarningInt() != 0,
+		"command_channel":            len(c.CommandChannelID) != 0,
+		"knowledge_index":            len(strings.TrimSpace(c.KnowledgeChannels)) != 0,
+		"operation_timeout":          c.OperationTimeout() != 0,
+		"move_reminders":             c.ExperimentalFeatureEnabled(experimentalFeatureReminders),
+		"scheduled_job_limit":        c.MaxScheduledJobsPerUserInt() != 0,
+		"source_channel_pattern":     c.AllowedSourceChannelRegexp() != nil,
+		"move_redirect_stub":         true,
+		"link_back_on_copy":          c.LinkBackToOriginalValue() != linkBackOff,
+		"link_back_on_move":          c.LinkBackToOriginalValue() == linkBackCopiesAndMoves,
+		"success_message_verbosity":  true,
+		"mover_mention":              c.MentionMoverInMovedThreads,
+		"guest_moves_blocked":        c.BlockGuestMoves,
+		"enabled_operations":         len(c.EnabledOperationNames()) != 0,
+		"delete_failure_copy":        c.OriginalDeleteFailureValue() == originalDeleteFailureCopy,
+		"min_account_age":            c.MinAccountAgeDaysInt() != 0,
+		"destination_templates":      len(c.DestinationTemplatesMap()) != 0,
+		"duplicate_copy_detection":   c.DuplicateCopiesValue() != duplicateCopiesAllow,
+		"user_time_zones":            true,
+		"move_file_limits":           c.FileLimiter() != nil,
+		"group_mention_handling":     c.GroupMentionsValue() != groupMentionsPreserve,
+		"reply_broadcasts":           c.ReplyBroadcastsValue() == replyBroadcastsPreserve,
+		"boards_cards":               len(c.BoardsCardBoardID) != 0,
+		"keep_mover_as_author":       c.KeepMoverAsAuthor,
+		"channel_autocomplete_cache": c.ChannelAutocompleteInterval() != 0,
+		"default_channel_guard":      c.DefaultChannelMovesPolicy() != defaultChannelMovesAllow,
+		"move_summaries":             c.MoveSummaries,
+		"experimental_features":      len(c.ExperimentalFeatureNames()) != 0,
+		"reactions_preservation":     true,
+		"copy_thread_limit":          true,
+		"merge_thread":               true,
+		"check_permalinks":           true,
+		"move_approval":              len(c.MoveApprovalChannelID) != 0,
+		"export_thread":              true,
+		"quote_thread":               true,
+		"import_thread":              c.ExperimentalFeatureEnabled(experimentalFeatureImport),
+		"move_selection":             c.EnableWebUI,
+		"can_move_check":             c.EnableWebUI,
+		"move_provenance":            true,
+		"permalink_rewriting":        true,
+		"thread_lock":                len(c.LockEmojiNames()) != 0,
+		"stats":                      true,
+		"mine":                       true,
+		"temporary_responses":        c.TemporaryResponseTTL() != 0,
+		"archive_thread":             len(c.SourceToDestinationMapping()) != 0 || len(c.DefaultArchiveChannelID) != 0,
+	}
+}
+
+// NonMemberAuthorsPolicy returns how moves handle thread authors who aren't
+// members of the target channel. By default, their posts are recreated under
+// the Wrangler bot.
+func (c *configuration) NonMemberAuthorsPolicy() string {
+	if len(c.NonMemberAuthors) == 0 {
+		return nonMemberAuthorsBot
+	}
+
+	return c.NonMemberAuthors
+}
+
+// MentionPolicyValue returns how at-mentions of moved messages are handled.
+// By default, all mentions are preserved.
+func (c *configuration) MentionPolicyValue() string {
+	if len(c.MentionPolicy) == 0 {
+		return mentionPolicyPreserveAll
+	}
+
+	return c.MentionPolicy
+}
+
+// ReplyBroadcastsValue returns whether recreated replies that were also sent
+// to the channel are sent to the target channel too. By default, they are.
+func (c *configuration) ReplyBroadcastsValue() string {
+	if len(c.ReplyBroadcasts) == 0 {
+		return replyBroadcastsPreserve
+	}
+
+	return c.ReplyBroadcasts
+}
+
+// GroupMentionsValue returns how the at-mentions of user groups in moved
+// messages are handled. By default, they follow the mention policy.
+func (c *configuration) GroupMentionsValue() string {
+	if len(c.GroupMentions) == 0 {
+		return groupMentionsPreserve
+	}
+
+	return c.GroupMentions
+}
+
+// LinkBackToOriginalValue returns which operations link the new root message
+// back to the original thread. By default, only copies do.
+func (c *configuration) LinkBackToOriginalValue() string {
+	if len(c.LinkBackToOriginal) == 0 {
+		return linkBackCopies
+	}
+
+	return c.LinkBackToOriginal
+}
+
+// SuccessMessageVerbosityValue returns how much detail the summaries of
+// completed moves and copies include for users who haven't chosen a
+// verbosity of their own. By default, users get the normal summary.
+func (c *configuration) SuccessMessageVerbosityValue() string {
+	if len(c.SuccessMessageVerbosity) == 0 {
+		return successVerbosityNormal
+	}
+
+	return c.SuccessMessageVerbosity
+}
+
+func isValidSuccessVerbosity(verbosity string) bool {
+	switch verbosity {
+	case successVerbosityMinimal, successVerbosityNormal, successVerbosityDetailed:
+		return true
+	}
+
+	return false
+}
+
+// EnabledOperationNames returns the operations that are enabled, in both the
+// web UI and the commands. No operations means every operation is enabled.
+func (c *configuration) EnabledOperationNames() []string {
+	var operations []string
+	for _, operation := range strings.Split(c.EnabledOperations, ",") {
+		operation = strings.ToLower(strings.TrimSpace(operation))
+		if len(operation) != 0 {
+			operations = append(operations, operation)
+		}
+	}
+
+	return operations
+}
+
+// OperationEnabled returns true if the given operation may be run.
+func (c *configuration) OperationEnabled(operation string) bool {
+	operations := c.EnabledOperationNames()
+	if len(operations) == 0 {
+		return true
+	}
+	for _, enabled := range operations {
+		if enabled == operation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExperimentalFeatureNames returns the experimental features that are
+// enabled, including the unknown ones, which are ignored.
+func (c *configuration) ExperimentalFeatureNames() []string {
+	var features []string
+	for _, feature := range strings.Split(c.ExperimentalFeatures, ",") {
+		feature = strings.ToLower(strings.TrimSpace(feature))
+		if len(feature) != 0 {
+			features = append(features, feature)
+		}
+	}
+
+	return features
+}
+
+// ExperimentalFeatureEnabled returns true if the given experimental feature is
+// enabled. Unlike the enabled operations, no features means that every
+// experimental feature is disabled.
+func (c *configuration) ExperimentalFeatureEnabled(feature string) bool {
+	for _, enabled := range c.ExperimentalFeatureNames() {
+		if enabled == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnknownExperimentalFeatures returns the enabled experimental features that
+// Wrangler doesn't know, which are usually typos or features that were
+// promoted or removed.
+func (c *configuration) UnknownExperimentalFeatures() []string {
+	var unknown []string
+	for _, feature := range c.ExperimentalFeatureNames() {
+		known := false
+		for _, experimentalFeature := range experimentalFeatures {
+			if feature == experimentalFeature {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unknown = append(unknown, feature)
+		}
+	}
+
+	return unknown
+}
+
+// OriginalDeleteFailureValue returns what happens to a move whose original
+// messages can't be deleted once they were copied to the target channel. By
+// default, the move is rolled back.
+func (c *configuration) OriginalDeleteFailureValue() string {
+	if len(c.OriginalDeleteFailure) == 0 {
+		return originalDeleteFailureRollback
+	}
+
+	return c.OriginalDeleteFailure
+}
+
+// DefaultLocation returns the time zone that timestamps are shown in to users
+// without a known time zone. Defaults to UTC.
+func (c *configuration) DefaultLocation() *time.Location {
+	if len(c.DefaultTimezone) == 0 {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(c.DefaultTimezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// DuplicateCopiesValue returns what happens when a thread is copied to a
+// channel it was already copied to. By default, it is copied again.
+func (c *configuration) DuplicateCopiesValue() string {
+	if len(c.DuplicateCopies) == 0 {
+		return duplicateCopiesAllow
+	}
+
+	return c.DuplicateCopies
+}
+
+// AttributionAuthorValue returns who authors the notices that Wrangler leaves
+// in moved and copied threads. By default, the bot does.
+func (c *configuration) AttributionAuthorValue() string {
+	if len(c.AttributionAuthor) == 0 {
+		return attributionAuthorBot
+	}
+
+	return c.AttributionAuthor
+}
+
+// IntegrationPostsPolicy returns how moves and merges handle threads with messages posted
+// by bots or integrations. By default, they are moved without a warning.
+func (c *configuration) IntegrationPostsPolicy() string {
+	if len(c.IntegrationPosts) == 0 {
+		return integrationPostsAllow
+	}
+
+	return c.IntegrationPosts
+}
+
+// PlaybookPostsPolicy returns how moves and merges handle threads with messages
+// linked to playbook runs. By default, they are moved without a warning.
+func (c *configuration) PlaybookPostsPolicy() string {
+	if len(c.PlaybookPosts) == 0 {
+		return playbookPostsAllow
+	}
+
+	return c.PlaybookPosts
+}
+
+// DefaultChannelMovesPolicy returns how moves into the default channels of a
+// team are handled. By default, they are allowed.
+func (c *configuration) DefaultChannelMovesPolicy() string {
+	if len(c.DefaultChannelMoves) == 0 {
+		return defaultChannelMovesAllow
+	}
+
+	return c.DefaultChannelMoves
+}
+
+// ExportOversizedAsFile returns true if exported transcripts that are too long
+// for a single message are attached as a file instead of being split.
+func (c *configuration) ExportOversizedAsFile() bool {
+	return c.OversizedTranscripts == oversizedTranscriptsFile
+}
+
+// SkipDeactivatedUserPosts returns true if replies by deactivated users are
+// left out of moves. Otherwise, they are recreated under the Wrangler bot.
+func (c *configuration) SkipDeactivatedUserPosts() bool {
+	return c.DeactivatedUserPosts == deactivatedUserPostsSkip
+}
+
+// MoveFromChannelTypeEnabled returns true if moving messages out of channels of
+// the given type is permitted.
+func (c *configuration) MoveFromChannelTypeEnabled(channelType string) bool {
+	switch channelType {
+	case model.CHANNEL_PRIVATE:
+		return c.MoveThreadFromPrivateChannelEnable
+	case model.CHANNEL_DIRECT:
+		return c.MoveThreadFromDirectMessageChannelEnable
+	case model.CHANNEL_GROUP:
+		return c.MoveThreadFromGroupMessageChannelEnable
+	}
+
+	return true
+}
+
+func (c *configuration) MaxThreadCountMoveSizeInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxThreadCountMoveSize(c.MoveThreadMaxCount)
+
+	return i
+}
+
+// parseAndValidateMaxThreadCountMoveSize parses the max thread size config
+// value and returns an error if the value is invalid or cannot be parsed.
+// If MaxThreadCountMoveSize is not configured, set it to 0 which stands for
+// unlimited thread message count.
+func parseAndValidateMaxThreadCountMoveSize(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MoveThreadMaxSize value %s is not a valid integer", s)
+	}
+	if max < 1 {
+		return 0, fmt.Errorf("MoveThreadMaxSize (%d) must be greater than 0", max)
+	}
+
+	return max, nil
+}
+
+// MaxCountBypassUsernames returns the usernames of the users allowed to exceed
+// the max thread count move size.
+func (c *configuration) MaxCountBypassUsernames() []string {
+	var usernames []string
+	for _, username := range strings.Split(c.MaxCountBypassUsers, ",") {
+		username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+		if len(username) != 0 {
+			usernames = append(usernames, strings.ToLower(username))
+		}
+	}
+
+	return usernames
+}
+
+// LockEmojiNames returns the names of the emoji that lock a thread against
+// moving when reacted to its root post.
+func (c *configuration) LockEmojiNames() []string {
+	var emojiNames []string
+	for _, emojiName := range strings.Split(c.LockEmoji, ",") {
+		emojiName = strings.Trim(strings.TrimSpace(emojiName), ":")
+		if len(emojiName) != 0 {
+			emojiNames = append(emojiNames, emojiName)
+		}
+	}
+
+	return emojiNames
+}
+
+// WebUIPermittedRoleNames returns the roles that are permitted to use the
+// Wrangler web UI. No roles means the web UI follows the command
+// authorization.
+func (c *configuration) WebUIPermittedRoleNames() []string {
+	return parseRoleNames(c.WebUIPermittedRoles)
+}
+
+// MovePermittedRoleNames returns the roles that are permitted to move and
+// merge threads. No roles means any user permitted to run Wrangler commands
+// may move threads.
+func (c *configuration) MovePermittedRoleNames() []string {
+	return parseRoleNames(c.MovePermittedRoles)
+}
+
+// CopyPermittedRoleNames returns the roles that are permitted to copy threads
+// and pinned messages. No roles means any user permitted to run Wrangler
+// commands may copy threads.
+func (c *configuration) CopyPermittedRoleNames() []string {
+	return parseRoleNames(c.CopyPermittedRoles)
+}
+
+func parseRoleNames(s string) []string {
+	var roles []string
+	for _, role := range strings.Split(s, ",") {
+		role = strings.TrimSpace(role)
+		if len(role) != 0 {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// DestinationTeamAllowed returns true if threads may be moved or copied from
+// another team into the given team. No allowed destination team IDs means any
+// team is allowed.
+func (c *configuration) DestinationTeamAllowed(teamID string) bool {
+	var restricted bool
+	for _, allowedID := range strings.Split(c.AllowedDestinationTeamIDs, ",") {
+		allowedID = strings.TrimSpace(allowedID)
+		if len(allowedID) == 0 {
+			continue
+		}
+		if allowedID == teamID {
+			return true
+		}
+		restricted = true
+	}
+
+	return !restricted
+}
+
+// MoveApprovalSourceChannelIDs returns the IDs of the channels that moves need
+// approval for. No IDs means moves out of any channel need approval.
+func (c *configuration) MoveApprovalSourceChannelIDs() []string {
+	var channelIDs []string
+	for _, channelID := range strings.Split(c.MoveApprovalSourceChannels, ",") {
+		channelID = strings.TrimSpace(channelID)
+		if len(channelID) != 0 {
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+
+	return channelIDs
+}
+
+// IsKnowledgeChannel returns true if the channel is one of the configured
+// knowledge channels.
+func (c *configuration) IsKnowledgeChannel(channelID string) bool {
+	for _, knowledgeChannelID := range strings.Split(c.KnowledgeChannels, ",") {
+		if strings.TrimSpace(knowledgeChannelID) == channelID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *configuration) MaxAuthorsPerMoveInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxAuthorsPerMove(c.MaxAuthorsPerMove)
+
+	return i
+}
+
+// parseAndValidateMaxAuthorsPerMove parses the max authors per move config
+// value and returns an error if the value is invalid or cannot be parsed.
+// If MaxAuthorsPerMove is not configured or set to 0, any number of distinct
+// authors is allowed.
+func parseAndValidateMaxAuthorsPerMove(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxAuthorsPerMove value %s is not a valid integer", s)
+	}
+	if max < 0 {
+		return 0, fmt.Errorf("MaxAuthorsPerMove (%d) must not be negative", max)
+	}
+
+	return max, nil
+}
+
+func (c *configuration) MaxMergeSizeInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxMergeSize(c.MaxMergeSize)
+
+	return i
+}
+
+// parseAndValidateMaxMergeSize parses the max merge size config value and
+// returns an error if the value is invalid or cannot be parsed.
+// If MaxMergeSize is not configured or set to 0, merges of any size are
+// allowed.
+func parseAndValidateMaxMergeSize(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxMergeSize value %s is not a valid integer", s)
+	}
+	if max < 0 {
+		return 0, fmt.Errorf("MaxMergeSize (%d) must not be negative", max)
+	}
+
+	return max, nil
+}
+
+// CollapseGap returns the maximum time between consecutive posts by the same
+// author for them to be collapsed.
+func (c *configuration) CollapseGap() time.Duration {
+	// Use the parseAndValidate function, but ignore the error.
+	gap, _ := parseAndValidateCollapseGap(c.CollapseGapSeconds)
+
+	return gap
+}
+
+// CollapseSeparator returns the text inserted between the messages of
+// collapsed posts.
+func (c *configuration) CollapseSeparator() string {
+	if c.CollapseInsertSeparator {
+		return "\n\n---\n\n"
+	}
+
+	return "\n"
+}
+
+// parseAndValidateCollapseGap parses the collapse gap config value and returns
+// an error if the value is invalid or cannot be parsed. If CollapseGapSeconds
+// is not configured, the default gap is used.
+func parseAndValidateCollapseGap(s string) (time.Duration, error) {
+	if len(s) == 0 {
+		return defaultCollapseGap, nil
+	}
+
+	seconds, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "CollapseGapSeconds value %s is not a valid integer", s)
+	}
+	if seconds < 1 {
+		return 0, fmt.Errorf("CollapseGapSeconds (%d) must be greater than 0", seconds)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// OperationTimeout returns how long a move may run before it is aborted. A
+// value of 0 means moves never time out.
+func (c *configuration) OperationTimeout() time.Duration {
+	// Use the parseAndValidate function, but ignore the error.
+	timeout, _ := parseAndValidateOperationTimeout(c.OperationTimeoutSeconds)
+
+	return timeout
+}
+
+// parseAndValidateOperationTimeout returns the operation timeout or an error
+// if the value is invalid or cannot be parsed.
+func parseAndValidateOperationTimeout(s string) (time.Duration, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	seconds, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "OperationTimeoutSeconds value %s is not a valid integer", s)
+	}
+	if seconds < 0 {
+		return 0, fmt.Errorf("OperationTimeoutSeconds (%d) must not be negative", seconds)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// ChannelAutocompleteInterval returns the minimum interval between the channel
+// lists computed for the autocomplete of a user. A value of 0 means every
+// request computes the list.
+func (c *configuration) ChannelAutocompleteInterval() time.Duration {
+	// Use the parseAndValidate function, but ignore the error.
+	interval, _ := parseAndValidateChannelAutocompleteInterval(c.ChannelAutocompleteIntervalMilliseconds)
+
+	return interval
+}
+
+// parseAndValidateChannelAutocompleteInterval returns the channel autocomplete
+// interval or an error if the value is invalid or cannot be parsed. If
+// ChannelAutocompleteIntervalMilliseconds is empty the default interval is
+// returned.
+func parseAndValidateChannelAutocompleteInterval(s string) (time.Duration, error) {
+	if len(s) == 0 {
+		return defaultChannelAutocompleteInterval, nil
+	}
+
+	milliseconds, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "ChannelAutocompleteIntervalMilliseconds value %s is not a valid integer", s)
+	}
+	maxMilliseconds := int(maxChannelAutocompleteInterval / time.Millisecond)
+	if milliseconds < 0 || milliseconds > maxMilliseconds {
+		return 0, fmt.Errorf("ChannelAutocompleteIntervalMilliseconds (%d) must be between 0 and %d", milliseconds, maxMilliseconds)
+	}
+
+	return time.Duration(milliseconds) * time.Millisecond, nil
+}
+
+// maxMoveDeletionDelaySeconds bounds the delay before the original thread is
+// deleted so that moves don't keep the command waiting for too long.
+const maxMoveDeletionDelaySeconds = 30
+
+// MoveDeletionDelay returns how long a move waits after recreating the thread
+// before deleting the original thread.
+func (c *configuration) MoveDeletionDelay() time.Duration {
+	// Use the parseAndValidate function, but ignore the error.
+	delay, _ := parseAndValidateMoveDeletionDelay(c.MoveDeletionDelaySeconds)
+
+	return delay
+}
+
+// parseAndValidateMoveDeletionDelay returns the move deletion delay or an
+// error if the value is invalid or cannot be parsed.
+func parseAndValidateMoveDeletionDelay(s string) (time.Duration, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	seconds, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MoveDeletionDelaySeconds value %s is not a valid integer", s)
+	}
+	if seconds < 0 || seconds > maxMoveDeletionDelaySeconds {
+		return 0, fmt.Errorf("MoveDeletionDelaySeconds (%d) must be between 0 and %d", seconds, maxMoveDeletionDelaySeconds)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// StatsWindowDaysInt returns the number of days of operation history reported
+// by the stats command.
+func (c *configuration) StatsWindowDaysInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	days, _ := parseAndValidateStatsWindowDays(c.StatsWindowDays)
+
+	return days
+}
+
+// StatsWindow returns how long operations are kept in the operation history.
+func (c *configuration) StatsWindow() time.Duration {
+	return time.Duration(c.StatsWindowDaysInt()) * 24 * time.Hour
+}
+
+// HistoryRetention returns how long operations are kept in the operation
+// history. By default, they are kept for the stats window.
+func (c *configuration) HistoryRetention() time.Duration {
+	// Use the parseAndValidate function, but ignore the error.
+	days, _ := parseAndValidateHistoryRetentionDays(c.HistoryRetentionDays)
+	if days == 0 {
+		return c.StatsWindow()
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// parseAndValidateHistoryRetentionDays returns the history retention in days
+// or an error if the value is invalid. An empty value stands for 0, which
+// keeps the history for the stats window.
+func parseAndValidateHistoryRetentionDays(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "HistoryRetentionDays value %s is not a valid integer", s)
+	}
+	if days < 1 || days > maxHistoryRetentionDays {
+		return 0, fmt.Errorf("HistoryRetentionDays (%d) must be between 1 and %d", days, maxHistoryRetentionDays)
+	}
+
+	return days, nil
+}
+
+// parseAndValidateStatsWindowDays returns the stats window in days or an error
+// if the value is invalid or cannot be parsed.
+func parseAndValidateStatsWindowDays(s string) (int, error) {
+	if len(s) == 0 {
+		return defaultStatsWindowDays, nil
+	}
+
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultStatsWindowDays, errors.Wrapf(err, "StatsWindowDays value %s is not a valid integer", s)
+	}
+	if days < 1 || days > maxStatsWindowDays {
+		return defaultStatsWindowDays, fmt.Errorf("StatsWindowDays (%d) must be between 1 and %d", days, maxStatsWindowDays)
+	}
+
+	return days, nil
+}
+
+// PostCreationConcurrencyInt returns the number of replies recreated at the
+// same time when moving a thread.
+func (c *configuration) PostCreationConcurrencyInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidatePostCreationConcurrency(c.PostCreationConcurrency)
+
+	return i
+}
+
+// parseAndValidatePostCreationConcurrency returns the post creation
+// concurrency or an error if the value is invalid or cannot be parsed. If
+// PostCreationConcurrency is not configured, replies are recreated one after
+// the other.
+func parseAndValidatePostCreationConcurrency(s string) (int, error) {
+	if len(s) == 0 {
+		return 1, nil
+	}
+
+	concurrency, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "PostCreationConcurrency value %s is not a valid integer", s)
+	}
+	if concurrency < 1 || concurrency > maxPostCreationConcurrency {
+		return 0, fmt.Errorf("PostCreationConcurrency (%d) must be between 1 and %d", concurrency, maxPostCreationConcurrency)
+	}
+
+	return concurrency, nil
+}
+
+// TemporaryResponseTTL returns how long list and preview responses are kept
+// when they are posted as temporary bot messages. Zero means that they are
+// sent as ephemeral messages instead.
+func (c *configuration) TemporaryResponseTTL() time.Duration {
+	// Use the parseAndValidate function, but ignore the error.
+	minutes, _ := parseAndValidateTemporaryResponseMinutes(c.TemporaryResponseMinutes)
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// parseAndValidateTemporaryResponseMinutes returns the lifetime of temporary
+// responses in minutes or an error if the value is invalid. An empty value
+// stands for 0, which disables temporary responses.
+func parseAndValidateTemporaryResponseMinutes(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	minutes, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "TemporaryResponseMinutes value %s is not a valid integer", s)
+	}
+	if minutes < 0 || minutes > maxTemporaryResponseMinutes {
+		return 0, fmt.Errorf("TemporaryResponseMinutes (%d) must be between 0 and %d", minutes, maxTemporaryResponseMinutes)
+	}
+
+	return minutes, nil
+}
+
+func (c *configuration) MaxNotificationDMsPerHourInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxNotificationDMsPerHour(c.MaxNotificationDMsPerHour)
+
+	return i
+}
+
+// parseAndValidateMaxNotificationDMsPerHour parses the max notification DMs
+// per hour config value and returns an error if the value is invalid or cannot
+// be parsed. If MaxNotificationDMsPerHour is not configured, set it to 0 which
+// stands for no limit.
+func parseAndValidateMaxNotificationDMsPerHour(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxNotificationDMsPerHour value %s is not a valid integer", s)
+	}
+	if max < 1 {
+		return 0, fmt.Errorf("MaxNotificationDMsPerHour (%d) must be greater than 0", max)
+	}
+
+	return max, nil
+}
+
+func (c *configuration) MaxMovesPerChannelPerDayInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxMovesPerChannelPerDay(c.MaxMovesPerChannelPerDay)
+
+	return i
+}
+
+// parseAndValidateMaxMovesPerChannelPerDay parses the max moves per channel
+// per day config value and returns an error if the value is invalid or cannot
+// be parsed. If MaxMovesPerChannelPerDay is not configured, set it to 0 which
+// stands for no limit.
+func parseAndValidateMaxMovesPerChannelPerDay(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxMovesPerChannelPerDay value %s is not a valid integer", s)
+	}
+	if max < 1 {
+		return 0, fmt.Errorf("MaxMovesPerChannelPerDay (%d) must be greater than 0", max)
+	}
+
+	return max, nil
+}
+
+func (c *configuration) MaxScheduledJobsPerUserInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxScheduledJobsPerUser(c.MaxScheduledJobsPerUser)
+
+	return i
+}
+
+// parseAndValidateMaxScheduledJobsPerUser parses the max scheduled jobs per
+// user config value and returns an error if the value is invalid or cannot be
+// parsed. If MaxScheduledJobsPerUser is not configured, set it to 0 which
+// stands for no limit.
+func parseAndValidateMaxScheduledJobsPerUser(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxScheduledJobsPerUser value %s is not a valid integer", s)
+	}
+	if max < 1 {
+		return 0, fmt.Errorf("MaxScheduledJobsPerUser (%d) must be greater than 0", max)
+	}
+
+	return max, nil
+}
+
+func (c *configuration) MinAccountAgeDaysInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMinAccountAgeDays(c.MinAccountAgeDays)
+
+	return i
+}
+
+// parseAndValidateMinAccountAgeDays parses the min account age config value
+// and returns an error if the value is invalid or cannot be parsed. If
+// MinAccountAgeDays is not configured, set it to 0 which stands for no
+// minimum.
+func parseAndValidateMinAccountAgeDays(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MinAccountAgeDays value %s is not a valid integer", s)
+	}
+	if days < 1 {
+		return 0, fmt.Errorf("MinAccountAgeDays (%d) must be greater than 0", days)
+	}
+
+	return days, nil
+}
+
+func (c *configuration) MaxMoveFileSizeMBInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxMoveFileSizeMB(c.MaxMoveFileSizeMB)
+
+	return i
+}
+
+// parseAndValidateMaxMoveFileSizeMB parses the max move file size config
+// value and returns an error if the value is invalid or cannot be parsed. If
+// MaxMoveFileSizeMB is not configured, set it to 0 which stands for no limit.
+func parseAndValidateMaxMoveFileSizeMB(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	size, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxMoveFileSizeMB value %s is not a valid integer", s)
+	}
+	if size < 1 {
+		return 0, fmt.Errorf("MaxMoveFileSizeMB (%d) must be greater than 0", size)
+	}
+
+	return size, nil
+}
+
+func (c *configuration) MaxMoveFileCountInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxMoveFileCount(c.MaxMoveFileCount)
+
+	return i
+}
+
+// parseAndValidateMaxMoveFileCount parses the max move file count config
+// value and returns an error if the value is invalid or cannot be parsed. If
+// MaxMoveFileCount is not configured, set it to 0 which stands for no limit.
+func parseAndValidateMaxMoveFileCount(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxMoveFileCount value %s is not a valid integer", s)
+	}
+	if count < 1 {
+		return 0, fmt.Errorf("MaxMoveFileCount (%d) must be greater than 0", count)
+	}
+
+	return count, nil
+}
+
+// MoveFileLimitActionValue returns what happens to the file attachments of a
+// thread that exceed the file limits. By default, the thread isn't moved.
+func (c *configuration) MoveFileLimitActionValue() string {
+	if len(c.MoveFileLimitAction) == 0 {
+		return moveFileLimitRefuse
+	}
+
+	return c.MoveFileLimitAction
+}
+
+// FileLimiter returns a new file limiter enforcing the configured file
+// limits, or nil when no limit is configured.
+func (c *configuration) FileLimiter() *fileLimiter {
+	maxSizeMB := c.MaxMoveFileSizeMBInt()
+	maxCount := c.MaxMoveFileCountInt()
+	if maxSizeMB == 0 && maxCount == 0 {
+		return nil
+	}
+
+	return &fileLimiter{maxSize: int64(maxSizeMB) * 1024 * 1024, maxCount: maxCount}
+}
+
+func (c *configuration) MaxMoveRequestsPerUserPerDayInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxMoveRequestsPerUserPerDay(c.MaxMoveRequestsPerUserPerDay)
+
+	return i
+}
+
+// parseAndValidateMaxMoveRequestsPerUserPerDay parses the max move requests
+// per user per day config value and returns an error if the value is invalid
+// or cannot be parsed. If MaxMoveRequestsPerUserPerDay is not configured, the
+// default limit applies. A value of 0 stands for no limit.
+func parseAndValidateMaxMoveRequestsPerUserPerDay(s string) (int, error) {
+	if len(s) == 0 {
+		return defaultMaxMoveRequestsPerUserPerDay, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxMoveRequestsPerUserPerDay value %s is not a valid integer", s)
+	}
+	if max < 0 {
+		return 0, fmt.Errorf("MaxMoveRequestsPerUserPerDay (%d) must not be negative", max)
+	}
+
+	return max, nil
+}
+
+func (c *configuration) DestinationPostCountWarningInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateDestinationPostCountWarning(c.DestinationPostCountWarning)
+
+	return i
+}
+
+// parseAndValidateDestinationPostCountWarning parses the destination post
+// count warning config value and returns an error if the value is invalid or
+// cannot be parsed. If DestinationPostCountWarning is not configured, set it
+// to 0 which stands for no warning.
+func parseAndValidateDestinationPostCountWarning(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "DestinationPostCountWarning value %s is not a valid integer", s)
+	}
+	if count < 1 {
+		return 0, fmt.Errorf("DestinationPostCountWarning (%d) must be greater than 0", count)
+	}
+
+	return count, nil
+}
+
+// MaxConcurrentOperationsInt returns the number of move and copy operations
+// that may run at the same time, or 0 for no limit.
+func (c *configuration) MaxConcurrentOperationsInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxConcurrentOperations(c.MaxConcurrentOperations)
+
+	return i
+}
+
+// parseAndValidateMaxConcurrentOperations parses the max concurrent operations
+// config value and returns an error if the value is invalid or cannot be
+// parsed. If MaxConcurrentOperations is not configured, set it to 0 which
+// stands for no limit.
+func parseAndValidateMaxConcurrentOperations(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	max, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "MaxConcurrentOperations value %s is not a valid integer", s)
+	}
+	if max < 1 {
+		return 0, fmt.Errorf("MaxConcurrentOperations (%d) must be greater than 0", max)
+	}
+
+	return max, nil
+}
+
+// MaxCommandLengthInt returns the number of characters that Wrangler commands
+// may be long.
+func (c *configuration) MaxCommandLengthInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateMaxCommandLength(c.MaxCommandLength)
+
+	return i
+}
+
+// parseAndValidateMaxCommandLength parses the max command length config value
+// and returns an error if the value is invalid or cannot be parsed. If
+// MaxCommandLength is not configured, set it to the default.
+func parseAndValidateMaxCommandLength(s string) (int, error) {
+	if len(s) == 0 {
+		return defaultMaxCommandLength, nil
+	}
+
+	length, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultMaxCommandLength, errors.Wrapf(err, "MaxCommandLength value %s is not a valid integer", s)
+	}
+	if length < minMaxCommandLength || length > maxMaxCommandLength {
+		return defaultMaxCommandLength, fmt.Errorf("MaxCommandLength (%d) must be between %d and %d", length, minMaxCommandLength, maxMaxCommandLength)
+	}
+
+	return length, nil
+}
+
+// ListMessagesTrimLengthInt returns the character count that messages are
+// trimmed to by '/wrangler list messages' unless the command says otherwise.
+func (c *configuration) ListMessagesTrimLengthInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateListMessagesTrimLength(c.ListMessagesTrimLength)
+
+	return i
+}
+
+// parseAndValidateListMessagesTrimLength parses the list messages trim length
+// config value and returns an error if the value is invalid or cannot be
+// parsed. If ListMessagesTrimLength is not configured, set it to the default.
+func parseAndValidateListMessagesTrimLength(s string) (int, error) {
+	if len(s) == 0 {
+		return defaultListMessagesTrimLength, nil
+	}
+
+	length, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultListMessagesTrimLength, errors.Wrapf(err, "ListMessagesTrimLength value %s is not a valid integer", s)
+	}
+	if length < minListMessagesTrimLength || length > maxListMessagesTrimLength {
+		return defaultListMessagesTrimLength, fmt.Errorf("ListMessagesTrimLength (%d) must be between %d and %d", length, minListMessagesTrimLength, maxListMessagesTrimLength)
+	}
+
+	return length, nil
+}
+
+func (c *configuration) ChannelStateActionsMap() map[string]channelStateAction {
+	// Use the parseAndValidate function, but ignore the error.
+	actions, _ := parseAndValidateChannelStateActions(c.ChannelStateActions)
+
+	return actions
+}
+
+// parseAndValidateChannelStateActions parses the JSON mapping of channel IDs
+// to state actions and returns an error if it is invalid.
+func parseAndValidateChannelStateActions(s string) (map[string]channelStateAction, error) {
+	actions := make(map[string]channelStateAction)
+	if len(strings.TrimSpace(s)) == 0 {
+		return actions, nil
+	}
 
-	if len(c.AllowedEmailDomain) != 0 {
-		emailDomains := strings.Split(c.AllowedEmailDomain, ",")
-		for _, emailDomain := range emailDomains {
-			if len(emailDomain) == 0 {
-				return errors.New("AllowedEmailDomain has a trailing comma")
+	err := json.Unmarshal([]byte(s), &actions)
+	if err != nil {
+		return nil, errors.Wrap(err, "ChannelStateActions is not valid JSON")
+	}
+	for channelID, action := range actions {
+		if !model.IsValidId(channelID) {
+			return nil, fmt.Errorf("ChannelStateActions key %s is not a valid channel ID", channelID)
+		}
+		if len(action.RemoveReaction) == 0 && len(action.ResetProp) == 0 {
+			return nil, fmt.Errorf("ChannelStateActions entry for channel %s has no action", channelID)
+		}
+	}
+
+	return actions, nil
+}
+
+func (c *configuration) DestinationTemplatesMap() map[string]destinationTemplate {
+	// Use the parseAndValidate function, but ignore the error.
+	templates, _ := parseAndValidateDestinationTemplates(c.DestinationTemplates)
+
+	return templates
+}
+
+// parseAndValidateDestinationTemplates parses the JSON mapping of channel IDs
+// to destination templates and returns an error if it is invalid.
+func parseAndValidateDestinationTemplates(s string) (map[string]destinationTemplate, error) {
+	templates := make(map[string]destinationTemplate)
+	if len(strings.TrimSpace(s)) == 0 {
+		return templates, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &templates)
+	if err != nil {
+		return nil, errors.Wrap(err, "DestinationTemplates is not valid JSON")
+	}
+	for channelID, template := range templates {
+		if !model.IsValidId(channelID) {
+			return nil, fmt.Errorf("DestinationTemplates key %s is not a valid channel ID", channelID)
+		}
+		if len(strings.TrimSpace(template.Header)) == 0 && len(strings.TrimSpace(template.Footer)) == 0 {
+			return nil, fmt.Errorf("DestinationTemplates entry for channel %s has no header or footer", channelID)
+		}
+		for _, text := range []string{template.Header, template.Footer} {
+			for _, placeholder := range templatePlaceholderRegexp.FindAllString(text, -1) {
+				if placeholder != movedFromFooterPlaceholder {
+					return nil, fmt.Errorf("DestinationTemplates entry for channel %s has unknown placeholder %s; only %s is supported", channelID, placeholder, movedFromFooterPlaceholder)
+				}
 			}
-			_, err = url.Parse(emailDomain)
+		}
+	}
+
+	return templates, nil
+}
+
+func (c *configuration) ResolutionAnnotationsMap() map[string]resolutionAnnotation {
+	// Use the parseAndValidate function, but ignore the error.
+	annotations, _ := parseAndValidateResolutionAnnotations(c.ResolutionAnnotations)
+
+	return annotations
+}
+
+// parseAndValidateResolutionAnnotations parses the JSON mapping of channel IDs
+// to resolution annotations and returns an error if it is invalid.
+func parseAndValidateResolutionAnnotations(s string) (map[string]resolutionAnnotation, error) {
+	annotations := make(map[string]resolutionAnnotation)
+	if len(strings.TrimSpace(s)) == 0 {
+		return annotations, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &annotations)
+	if err != nil {
+		return nil, errors.Wrap(err, "ResolutionAnnotations is not valid JSON")
+	}
+	for channelID, annotation := range annotations {
+		if !model.IsValidId(channelID) {
+			return nil, fmt.Errorf("ResolutionAnnotations key %s is not a valid channel ID", channelID)
+		}
+		emoji := strings.Trim(annotation.Emoji, ":")
+		if len(emoji) != 0 && !emojiNameRegexp.MatchString(emoji) {
+			return nil, fmt.Errorf("ResolutionAnnotations entry for channel %s has an invalid emoji name %s", channelID, annotation.Emoji)
+		}
+	}
+
+	return annotations, nil
+}
+
+func (c *configuration) RoutingRulesList() []routingRule {
+	// Use the parseAndValidate function, but ignore the error.
+	rules, _ := parseAndValidateRoutingRules(c.RoutingRules)
+
+	return rules
+}
+
+// parseAndValidateRoutingRules parses the JSON list of routing rules, compiles
+// their regular expressions and returns an error if any rule is invalid.
+func parseAndValidateRoutingRules(s string) ([]routingRule, error) {
+	var rules []routingRule
+	if len(strings.TrimSpace(s)) == 0 {
+		return rules, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &rules)
+	if err != nil {
+		return nil, errors.Wrap(err, "RoutingRules is not valid JSON")
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if !model.IsValidId(rule.ChannelID) {
+			return nil, fmt.Errorf("RoutingRules entry %d has an invalid channel ID %s", i+1, rule.ChannelID)
+		}
+		if (len(rule.Keyword) == 0) == (len(rule.Regex) == 0) {
+			return nil, fmt.Errorf("RoutingRules entry %d must have either a keyword or a regex", i+1)
+		}
+		if len(rule.Regex) != 0 {
+			rule.regexp, err = regexp.Compile(rule.Regex)
 			if err != nil {
-				return errors.Wrapf(err, "invalid AllowedEmailDomain value %s", emailDomain)
+				return nil, errors.Wrapf(err, "RoutingRules entry %d has an invalid regex", i+1)
 			}
 		}
 	}
 
-	_, err = parseAndValidateMaxThreadCountMoveSize(c.MoveThreadMaxCount)
+	return rules, nil
+}
+
+// MoveTextTransformsForChannel returns the text transforms that apply to posts
+// moved out of the given channel, in the configured order.
+func (c *configuration) MoveTextTransformsForChannel(channelID string) []textTransform {
+	// Use the parseAndValidate function, but ignore the error.
+	transforms, _ := parseAndValidateMoveTextTransforms(c.MoveTextTransforms)
+
+	var channelTransforms []textTransform
+	for _, transform := range transforms {
+		if len(transform.ChannelID) == 0 || transform.ChannelID == channelID {
+			channelTransforms = append(channelTransforms, transform)
+		}
+	}
+
+	return channelTransforms
+}
+
+// parseAndValidateMoveTextTransforms parses the JSON list of text transforms,
+// compiles their regular expressions and returns an error if any transform is
+// invalid.
+func parseAndValidateMoveTextTransforms(s string) ([]textTransform, error) {
+	var transforms []textTransform
+	if len(strings.TrimSpace(s)) == 0 {
+		return transforms, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &transforms)
 	if err != nil {
-		return errors.Wrap(err, "invalid MoveThreadMaxSize")
+		return nil, errors.Wrap(err, "MoveTextTransforms is not valid JSON")
+	}
+	for i := range transforms {
+		transform := &transforms[i]
+		if len(transform.Regex) == 0 {
+			return nil, fmt.Errorf("MoveTextTransforms entry %d has no regex", i+1)
+		}
+		if len(transform.ChannelID) != 0 && !model.IsValidId(transform.ChannelID) {
+			return nil, fmt.Errorf("MoveTextTransforms entry %d has an invalid channel ID %s", i+1, transform.ChannelID)
+		}
+		transform.regexp, err = regexp.Compile(transform.Regex)
+		if err != nil {
+			return nil, errors.Wrapf(err, "MoveTextTransforms entry %d has an invalid regex", i+1)
+		}
+	}
+
+	return transforms, nil
+}
+
+// TicketLinkerForChannel returns the ticket linker of the posts moved into the
+// given channel, or nil if ticket references aren't linked in that channel.
+func (c *configuration) TicketLinkerForChannel(channelID string) *ticketLinker {
+	// Use the parseAndValidate function, but ignore the error.
+	linker, _ := parseAndValidateTicketLinker(c.TicketLinkRegex, c.TicketLinkURLTemplate)
+	if linker == nil {
+		return nil
+	}
+	for _, linkChannelID := range strings.Split(c.TicketLinkChannels, ",") {
+		if strings.TrimSpace(linkChannelID) == channelID {
+			return linker
+		}
 	}
 
 	return nil
 }
 
-func (c *configuration) MaxThreadCountMoveSizeInt() int {
+// parseAndValidateTicketLinker compiles the ticket reference regex and returns
+// an error if the regex or the URL template is invalid. No linker is returned
+// when the regex is not configured.
+func parseAndValidateTicketLinker(regex, urlTemplate string) (*ticketLinker, error) {
+	if len(regex) == 0 {
+		if len(urlTemplate) != 0 {
+			return nil, errors.New("TicketLinkURLTemplate is set, but TicketLinkRegex is not")
+		}
+		return nil, nil
+	}
+
+	compiled, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, errors.Wrap(err, "TicketLinkRegex is not a valid regex")
+	}
+	if !strings.Contains(urlTemplate, ticketLinkPlaceholder) {
+		return nil, fmt.Errorf("TicketLinkURLTemplate value %s must contain %s", urlTemplate, ticketLinkPlaceholder)
+	}
+	if !model.IsValidHttpUrl(strings.Replace(urlTemplate, ticketLinkPlaceholder, "TICKET", -1)) {
+		return nil, fmt.Errorf("TicketLinkURLTemplate value %s is not a valid HTTP or HTTPS URL", urlTemplate)
+	}
+
+	return &ticketLinker{regexp: compiled, urlTemplate: urlTemplate}, nil
+}
+
+// MovedFromFooter returns the footer appended to the messages moved out of
+// the given channel, or an empty string when footers are disabled. Team
+// channels are referenced with a channel link.
+func (c *configuration) MovedFromFooter(originalChannel *model.Channel) string {
+	if !c.EnableMovedFromFooter {
+		return ""
+	}
+
+	text := c.MovedFromFooterText
+	if len(strings.TrimSpace(text)) == 0 {
+		text = defaultMovedFromFooterText
+	}
+
+	return expandMovedFromPlaceholder(text, originalChannel)
+}
+
+// MovedFromFooterForChannel returns the footer appended to the messages moved
+// out of the original channel into the target channel. The footer template of
+// the target channel is used when it has one, regardless of whether footers
+// are enabled. Otherwise, the global footer is used.
+func (c *configuration) MovedFromFooterForChannel(originalChannel *model.Channel, targetChannelID string) string {
+	template := c.DestinationTemplatesMap()[targetChannelID]
+	if len(strings.TrimSpace(template.Footer)) == 0 {
+		return c.MovedFromFooter(originalChannel)
+	}
+
+	return expandMovedFromPlaceholder(template.Footer, originalChannel)
+}
+
+// DestinationHeader returns the header put at the top of the root message of
+// threads moved out of the original channel into the target channel, or an
+// empty string when the target channel has no header template.
+func (c *configuration) DestinationHeader(originalChannel *model.Channel, targetChannelID string) string {
+	template := c.DestinationTemplatesMap()[targetChannelID]
+	if len(strings.TrimSpace(template.Header)) == 0 {
+		return ""
+	}
+
+	return expandMovedFromPlaceholder(template.Header, originalChannel)
+}
+
+// expandMovedFromPlaceholder replaces the placeholder of the template with the
+// original channel. Team channels are referenced with a channel link.
+func expandMovedFromPlaceholder(text string, originalChannel *model.Channel) string {
+	var channel string
+	switch originalChannel.Type {
+	case model.CHANNEL_DIRECT:
+		channel = "a direct message"
+	case model.CHANNEL_GROUP:
+		channel = "a group message"
+	default:
+		channel = "~" + originalChannel.Name
+	}
+
+	return strings.Replace(text, movedFromFooterPlaceholder, channel, -1)
+}
+
+func (c *configuration) MovedPostPropsMap() map[string]string {
 	// Use the parseAndValidate function, but ignore the error.
-	i, _ := parseAndValidateMaxThreadCountMoveSize(c.MoveThreadMaxCount)
+	props, _ := parseAndValidateMovedPostProps(c.MovedPostProps)
+
+	return props
+}
+
+// reservedPostProps are post props that are set automatically on moved posts
+// or that change how posts are rendered, so they can't be configured.
+var reservedPostProps = []string{
+	propMovedFromChannel,
+	propMovedBy,
+	propMovedAt,
+	"attachments",
+	"card",
+	"from_webhook",
+	"from_bot",
+	"from_plugin",
+	"override_username",
+	"override_icon_url",
+	"override_icon_emoji",
+	"disable_group_highlight",
+	"mentionHighlightDisabled",
+	"channel_mentions",
+}
+
+// noChannelPattern matches no channel name. It allows no source channel when
+// the allowed source channel pattern can't be compiled, rather than allowing
+// all of them.
+var noChannelPattern = regexp.MustCompile(`[^\s\S]`)
+
+// AllowedSourceChannelRegexp returns the compiled pattern that the names of
+// the channels posts are moved out of must match, or nil if any channel is
+// allowed.
+func (c *configuration) AllowedSourceChannelRegexp() *regexp.Regexp {
+	pattern, err := parseAndValidateAllowedSourceChannelPattern(c.AllowedSourceChannelPattern)
+	if err != nil {
+		return noChannelPattern
+	}
+
+	return pattern
+}
+
+// parseAndValidateAllowedSourceChannelPattern compiles the allowed source
+// channel pattern and returns an error if it is not a valid regex. No pattern
+// is returned when it is not configured.
+func parseAndValidateAllowedSourceChannelPattern(s string) (*regexp.Regexp, error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil, nil
+	}
+
+	pattern, err := regexp.Compile(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "AllowedSourceChannelPattern is not a valid regex")
+	}
+
+	return pattern, nil
+}
+
+// blockAllContentPattern matches any message. It blocks all moves when the
+// blocked content patterns can't be parsed, rather than letting sensitive
+// content through.
+var blockAllContentPattern = regexp.MustCompile(`(?s).*`)
+
+// BlockedContentPatternsList returns the compiled patterns that block moving
+// the messages matching them.
+func (c *configuration) BlockedContentPatternsList() []*regexp.Regexp {
+	patterns, err := parseAndValidateBlockedContentPatterns(c.BlockedContentPatterns)
+	if err != nil {
+		return []*regexp.Regexp{blockAllContentPattern}
+	}
+
+	return patterns
+}
+
+// parseAndValidateBlockedContentPatterns parses the JSON list of blocked
+// content regular expressions, compiles them and returns an error if any of
+// them is invalid.
+func parseAndValidateBlockedContentPatterns(s string) ([]*regexp.Regexp, error) {
+	if len(strings.TrimSpace(s)) == 0 {
+		return nil, nil
+	}
+
+	var expressions []string
+	err := json.Unmarshal([]byte(s), &expressions)
+	if err != nil {
+		return nil, errors.Wrap(err, "BlockedContentPatterns is not a valid JSON list of strings")
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(expressions))
+	for i, expression := range expressions {
+		if len(expression) == 0 {
+			return nil, fmt.Errorf("BlockedContentPatterns entry %d is empty", i+1)
+		}
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, errors.Wrapf(err, "BlockedContentPatterns entry %d is not a valid regex", i+1)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// parseAndValidateMovedPostProps parses the JSON object of static props added
+// to moved posts and returns an error if it is invalid.
+func parseAndValidateMovedPostProps(s string) (map[string]string, error) {
+	props := make(map[string]string)
+	if len(strings.TrimSpace(s)) == 0 {
+		return props, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &props)
+	if err != nil {
+		return nil, errors.Wrap(err, "MovedPostProps is not a valid JSON object of string values")
+	}
+	for key := range props {
+		if len(strings.TrimSpace(key)) == 0 {
+			return nil, errors.New("MovedPostProps contains an empty key")
+		}
+		for _, reserved := range reservedPostProps {
+			if strings.EqualFold(key, reserved) {
+				return nil, fmt.Errorf("MovedPostProps key %s is reserved", key)
+			}
+		}
+	}
+
+	return props, nil
+}
+
+// IsArchiveChannel returns true if the channel is the archive destination of
+// any channel or the default archive channel.
+func (c *configuration) IsArchiveChannel(channelID string) bool {
+	if len(c.DefaultArchiveChannelID) != 0 && channelID == c.DefaultArchiveChannelID {
+		return true
+	}
+	for _, destination := range c.SourceToDestinationMapping() {
+		if destination == channelID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ImageCompressor returns the compressor of the images recreated in archive
+// channels, or nil if image compression is disabled.
+func (c *configuration) ImageCompressor() *imageCompressor {
+	if !c.CompressArchivedImages {
+		return nil
+	}
+
+	// Use the parseAndValidate functions, but ignore the errors.
+	maxDimension, _ := parseAndValidateCompressedImageMaxDimension(c.CompressedImageMaxDimension)
+	quality, _ := parseAndValidateCompressedImageQuality(c.CompressedImageQuality)
+
+	return &imageCompressor{maxDimension: maxDimension, quality: quality}
+}
+
+// parseAndValidateCompressedImageMaxDimension parses the compressed image max
+// dimension config value and returns an error if the value is invalid or
+// cannot be parsed. If CompressedImageMaxDimension is not configured, set it
+// to the default.
+func parseAndValidateCompressedImageMaxDimension(s string) (int, error) {
+	if len(s) == 0 {
+		return defaultCompressedImageMaxDimension, nil
+	}
+
+	dimension, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultCompressedImageMaxDimension, errors.Wrapf(err, "CompressedImageMaxDimension value %s is not a valid integer", s)
+	}
+	if dimension < minCompressedImageMaxDimension || dimension > maxCompressedImageMaxDimension {
+		return defaultCompressedImageMaxDimension, fmt.Errorf("CompressedImageMaxDimension (%d) must be between %d and %d", dimension, minCompressedImageMaxDimension, maxCompressedImageMaxDimension)
+	}
+
+	return dimension, nil
+}
+
+// parseAndValidateCompressedImageQuality parses the compressed image quality
+// config value and returns an error if the value is invalid or cannot be
+// parsed. If CompressedImageQuality is not configured, set it to the default.
+func parseAndValidateCompressedImageQuality(s string) (int, error) {
+	if len(s) == 0 {
+		return defaultCompressedImageQuality, nil
+	}
+
+	quality, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultCompressedImageQuality, errors.Wrapf(err, "CompressedImageQuality value %s is not a valid integer", s)
+	}
+	if quality < 1 || quality > 100 {
+		return defaultCompressedImageQuality, fmt.Errorf("CompressedImageQuality (%d) must be between 1 and 100", quality)
+	}
+
+	return quality, nil
+}
+
+// HighImpactMovePostThresholdInt returns the number of moved messages above
+// which a move is reported to the monitoring channel, or 0 for no threshold.
+func (c *configuration) HighImpactMovePostThresholdInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateHighImpactMoveThreshold(c.HighImpactMovePostThreshold)
 
 	return i
 }
 
-// parseAndValidateMaxThreadCountMoveSize parses the max thread size config
-// value and returns an error if the value is invalid or cannot be parsed.
-// If MaxThreadCountMoveSize is not configured, set it to 0 which stands for
-// unlimited thread message count.
-func parseAndValidateMaxThreadCountMoveSize(s string) (int, error) {
+// HighImpactMoveAuthorThresholdInt returns the number of distinct authors
+// above which a move is reported to the monitoring channel, or 0 for no
+// threshold.
+func (c *configuration) HighImpactMoveAuthorThresholdInt() int {
+	// Use the parseAndValidate function, but ignore the error.
+	i, _ := parseAndValidateHighImpactMoveThreshold(c.HighImpactMoveAuthorThreshold)
+
+	return i
+}
+
+// parseAndValidateHighImpactMoveThreshold parses a high-impact move threshold
+// config value and returns an error if the value is invalid or cannot be
+// parsed. If the threshold is not configured, set it to 0 which stands for no
+// threshold.
+func parseAndValidateHighImpactMoveThreshold(s string) (int, error) {
 	if len(s) == 0 {
 		return 0, nil
 	}
 
-	max, err := strconv.Atoi(s)
+	threshold, err := strconv.Atoi(s)
 	if err != nil {
-		return 0, errors.Wrapf(err, "MoveThreadMaxSize value %s is not a valid integer", s)
+		return 0, errors.Wrapf(err, "value %s is not a valid integer", s)
 	}
-	if max < 1 {
-		return 0, fmt.Errorf("MoveThreadMaxSize (%d) must be greater than 0", max)
+	if threshold < 1 {
+		return 0, fmt.Errorf("threshold (%d) must be greater than 0", threshold)
 	}
 
-	return max, nil
+	return threshold, nil
+}
+
+// HighImpactMoveDigestInterval returns how long high-impact move notices are
+// collected before they are posted together as a digest, or 0 to post each
+// notice right away.
+func (c *configuration) HighImpactMoveDigestInterval() time.Duration {
+	// Use the parseAndValidate function, but ignore the error.
+	minutes, _ := parseAndValidateHighImpactMoveDigestMinutes(c.HighImpactMoveDigestMinutes)
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// parseAndValidateHighImpactMoveDigestMinutes parses the digest interval in
+// minutes and returns an error if the value is invalid or cannot be parsed.
+// If the interval is not configured, set it to 0 which posts every notice
+// right away.
+func parseAndValidateHighImpactMoveDigestMinutes(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+
+	minutes, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "HighImpactMoveDigestMinutes value %s is not a valid integer", s)
+	}
+	if minutes < 0 || minutes > maxHighImpactMoveDigestMinutes {
+		return 0, fmt.Errorf("HighImpactMoveDigestMinutes (%d) must be between 0 and %d", minutes, maxHighImpactMoveDigestMinutes)
+	}
+
+	return minutes, nil
+}
+
+func (c *configuration) SourceToDestinationMapping() map[string]string {
+	// Use the parseAndValidate function, but ignore the error.
+	mapping, _ := parseAndValidateSourceToDestinationMap(c.SourceToDestinationMap)
+
+	return mapping
+}
+
+// parseAndValidateSourceToDestinationMap parses the JSON object mapping source
+// channel IDs to the channel IDs their threads are archived to and returns an
+// error if it is invalid.
+func parseAndValidateSourceToDestinationMap(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if len(strings.TrimSpace(s)) == 0 {
+		return mapping, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &mapping)
+	if err != nil {
+		return nil, errors.Wrap(err, "SourceToDestinationMap is not a valid JSON object of channel IDs")
+	}
+	for source, destination := range mapping {
+		if !model.IsValidId(source) {
+			return nil, fmt.Errorf("SourceToDestinationMap key %s is not a valid channel ID", source)
+		}
+		if !model.IsValidId(destination) {
+			return nil, fmt.Errorf("SourceToDestinationMap value %s is not a valid channel ID", destination)
+		}
+		if source == destination {
+			return nil, fmt.Errorf("SourceToDestinationMap maps channel %s to itself", source)
+		}
+	}
+
+	return mapping, nil
+}
+
+// checkRoutingRuleChannels returns an error if the destination channel of a
+// routing rule doesn't exist.
+func (p *Plugin) checkRoutingRuleChannels(rules []routingRule) error {
+	for i, rule := range rules {
+		_, appErr := p.API.GetChannel(rule.ChannelID)
+		if appErr != nil {
+			return errors.Wrapf(appErr, "RoutingRules entry %d has an unknown channel ID %s", i+1, rule.ChannelID)
+		}
+	}
+
+	return nil
 }
 
 // getConfiguration retrieves the active configuration under lock, making it safe to use
@@ -141,7 +2509,43 @@ func (p *Plugin) OnConfigurationChange() error {
 		return errors.Wrap(err, "failed to load plugin configuration")
 	}
 
+	rules, err := parseAndValidateRoutingRules(configuration.RoutingRules)
+	if err != nil {
+		return errors.Wrap(err, "invalid RoutingRules")
+	}
+	err = p.checkRoutingRuleChannels(rules)
+	if err != nil {
+		return errors.Wrap(err, "invalid RoutingRules")
+	}
+	_, err = parseAndValidateMoveTextTransforms(configuration.MoveTextTransforms)
+	if err != nil {
+		return errors.Wrap(err, "invalid MoveTextTransforms")
+	}
+	_, err = parseAndValidateTicketLinker(configuration.TicketLinkRegex, configuration.TicketLinkURLTemplate)
+	if err != nil {
+		return errors.Wrap(err, "invalid ticket links")
+	}
+	_, err = parseAndValidateBlockedContentPatterns(configuration.BlockedContentPatterns)
+	if err != nil {
+		return errors.Wrap(err, "invalid BlockedContentPatterns")
+	}
+	_, err = parseAndValidateAllowedSourceChannelPattern(configuration.AllowedSourceChannelPattern)
+	if err != nil {
+		return errors.Wrap(err, "invalid AllowedSourceChannelPattern")
+	}
+	_, err = parseAndValidateDestinationTemplates(configuration.DestinationTemplates)
+	if err != nil {
+		return errors.Wrap(err, "invalid DestinationTemplates")
+	}
+
+	if unknown := configuration.UnknownExperimentalFeatures(); len(unknown) != 0 {
+		p.API.LogWarn("Ignoring unknown experimental features",
+			"features", strings.Join(unknown, ","),
+			"known_features", strings.Join(experimentalFeatures, ","),
+		)
+	}
+
 	p.setConfiguration(configuration)
 
-	return p.API.RegisterCommand(getCommand(configuration.CommandAutoCompleteEnable))
+	return p.API.RegisterCommand(getCommand(configuration))
 }
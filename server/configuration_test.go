@@ -1,8 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,6 +44,392 @@ func TestConfigurationIsValid(t *testing.T) {
 		})
 	})
 
+	t.Run("WebUIPermittedRoles", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid roles", func(t *testing.T) {
+			config.WebUIPermittedRoles = "system_admin, system_user"
+			require.NoError(t, config.IsValid())
+		})
+		t.Run("invalid role", func(t *testing.T) {
+			config.WebUIPermittedRoles = "system admin"
+			require.Error(t, config.IsValid())
+		})
+	})
+
+	t.Run("HighImpactMove", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.HighImpactMoveChannelID = model.NewId()
+			config.HighImpactMovePostThreshold = "50"
+			config.HighImpactMoveAuthorThreshold = "10"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 50, config.HighImpactMovePostThresholdInt())
+			require.Equal(t, 10, config.HighImpactMoveAuthorThresholdInt())
+		})
+		t.Run("invalid channel ID", func(t *testing.T) {
+			config.HighImpactMoveChannelID = "monitoring"
+			require.Error(t, config.IsValid())
+		})
+		t.Run("invalid post threshold", func(t *testing.T) {
+			config.HighImpactMoveChannelID = ""
+			config.HighImpactMovePostThreshold = "0"
+			require.EqualError(t, config.IsValid(), "invalid HighImpactMovePostThreshold: threshold (0) must be greater than 0")
+		})
+		t.Run("invalid author threshold", func(t *testing.T) {
+			config.HighImpactMovePostThreshold = ""
+			config.HighImpactMoveAuthorThreshold = "many"
+			require.Error(t, config.IsValid())
+		})
+		t.Run("digest interval", func(t *testing.T) {
+			config.HighImpactMoveAuthorThreshold = ""
+			require.Equal(t, time.Duration(0), config.HighImpactMoveDigestInterval())
+			config.HighImpactMoveDigestMinutes = "60"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, time.Hour, config.HighImpactMoveDigestInterval())
+			config.HighImpactMoveDigestMinutes = "10081"
+			require.EqualError(t, config.IsValid(), "invalid HighImpactMoveDigestMinutes: HighImpactMoveDigestMinutes (10081) must be between 0 and 10080")
+		})
+	})
+
+	t.Run("MovePermittedRoles and CopyPermittedRoles", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid roles", func(t *testing.T) {
+			config.MovePermittedRoles = "system_admin"
+			config.CopyPermittedRoles = "system_admin, system_user"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, []string{"system_admin", "system_user"}, config.CopyPermittedRoleNames())
+		})
+		t.Run("invalid move role", func(t *testing.T) {
+			config.MovePermittedRoles = "system admin"
+			require.EqualError(t, config.IsValid(), "MovePermittedRoles value system admin is not a valid role name")
+		})
+		t.Run("invalid copy role", func(t *testing.T) {
+			config.MovePermittedRoles = ""
+			config.CopyPermittedRoles = "system_user,,system user"
+			require.EqualError(t, config.IsValid(), "CopyPermittedRoles value system user is not a valid role name")
+		})
+	})
+
+	t.Run("MoveDeletionDelaySeconds", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.MoveDeletionDelaySeconds = "5"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 5*time.Second, config.MoveDeletionDelay())
+		})
+		t.Run("too long", func(t *testing.T) {
+			config.MoveDeletionDelaySeconds = "31"
+			require.Error(t, config.IsValid())
+		})
+		t.Run("undo button without delay", func(t *testing.T) {
+			config.MoveDeletionDelaySeconds = ""
+			config.EnableMoveUndoButton = true
+			require.EqualError(t, config.IsValid(), "EnableMoveUndoButton requires MoveDeletionDelaySeconds to be greater than 0")
+		})
+	})
+
+	t.Run("MaxMergeSize", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.MaxMergeSize = "50"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 50, config.MaxMergeSizeInt())
+		})
+		t.Run("negative", func(t *testing.T) {
+			config.MaxMergeSize = "-1"
+			require.EqualError(t, config.IsValid(), "invalid MaxMergeSize: MaxMergeSize (-1) must not be negative")
+		})
+		t.Run("not an integer", func(t *testing.T) {
+			config.MaxMergeSize = "many"
+			require.Error(t, config.IsValid())
+		})
+	})
+
+	t.Run("MaxMoveRequestsPerUserPerDay", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, defaultMaxMoveRequestsPerUserPerDay, config.MaxMoveRequestsPerUserPerDayInt())
+		})
+		t.Run("no limit", func(t *testing.T) {
+			config.MaxMoveRequestsPerUserPerDay = "0"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 0, config.MaxMoveRequestsPerUserPerDayInt())
+		})
+		t.Run("negative", func(t *testing.T) {
+			config.MaxMoveRequestsPerUserPerDay = "-1"
+			require.EqualError(t, config.IsValid(), "invalid MaxMoveRequestsPerUserPerDay: MaxMoveRequestsPerUserPerDay (-1) must not be negative")
+		})
+	})
+
+	t.Run("ticket links", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.TicketLinkRegex = `PROJ-[0-9]+`
+			config.TicketLinkURLTemplate = "https://tracker.example.com/browse/{ticket}"
+			config.TicketLinkChannels = model.NewId()
+			require.NoError(t, config.IsValid())
+			require.NotNil(t, config.TicketLinkerForChannel(config.TicketLinkChannels))
+			require.Nil(t, config.TicketLinkerForChannel(model.NewId()))
+		})
+		t.Run("invalid regex", func(t *testing.T) {
+			config.TicketLinkRegex = `PROJ-[0-9`
+			require.Error(t, config.IsValid())
+		})
+		t.Run("template without placeholder", func(t *testing.T) {
+			config.TicketLinkRegex = `PROJ-[0-9]+`
+			config.TicketLinkURLTemplate = "https://tracker.example.com/browse/"
+			require.EqualError(t, config.IsValid(), "invalid ticket links: TicketLinkURLTemplate value https://tracker.example.com/browse/ must contain {ticket}")
+		})
+		t.Run("template not a URL", func(t *testing.T) {
+			config.TicketLinkURLTemplate = "tracker/{ticket}"
+			require.EqualError(t, config.IsValid(), "invalid ticket links: TicketLinkURLTemplate value tracker/{ticket} is not a valid HTTP or HTTPS URL")
+		})
+		t.Run("template without regex", func(t *testing.T) {
+			config.TicketLinkRegex = ""
+			config.TicketLinkURLTemplate = "https://tracker.example.com/browse/{ticket}"
+			require.EqualError(t, config.IsValid(), "invalid ticket links: TicketLinkURLTemplate is set, but TicketLinkRegex is not")
+		})
+		t.Run("invalid channel", func(t *testing.T) {
+			config.TicketLinkRegex = `PROJ-[0-9]+`
+			config.TicketLinkChannels = "town-square"
+			require.EqualError(t, config.IsValid(), "TicketLinkChannels value town-square is not a valid channel ID")
+		})
+	})
+
+	t.Run("AttributionAuthor", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, attributionAuthorBot, config.AttributionAuthorValue())
+		})
+		t.Run("actor", func(t *testing.T) {
+			config.AttributionAuthor = attributionAuthorActor
+			require.NoError(t, config.IsValid())
+			require.Equal(t, attributionAuthorActor, config.AttributionAuthorValue())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.AttributionAuthor = "user"
+			require.EqualError(t, config.IsValid(), "AttributionAuthor value user must be bot or actor")
+		})
+	})
+
+	t.Run("LinkBackToOriginal", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, linkBackCopies, config.LinkBackToOriginalValue())
+		})
+		t.Run("copies and moves", func(t *testing.T) {
+			config.LinkBackToOriginal = linkBackCopiesAndMoves
+			require.NoError(t, config.IsValid())
+			require.Equal(t, linkBackCopiesAndMoves, config.LinkBackToOriginalValue())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.LinkBackToOriginal = "moves"
+			require.EqualError(t, config.IsValid(), "LinkBackToOriginal value moves must be copies, copies-and-moves or off")
+		})
+	})
+
+	t.Run("SuccessMessageVerbosity", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, successVerbosityNormal, config.SuccessMessageVerbosityValue())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.SuccessMessageVerbosity = "verbose"
+			require.EqualError(t, config.IsValid(), "SuccessMessageVerbosity value verbose must be minimal, normal or detailed")
+		})
+	})
+
+	t.Run("EnabledOperations", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.True(t, config.OperationEnabled(enabledOperationMerge))
+		})
+		t.Run("valid", func(t *testing.T) {
+			config.EnabledOperations = "Move, copy,"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, []string{enabledOperationMove, enabledOperationCopy}, config.EnabledOperationNames())
+			require.True(t, config.OperationEnabled(enabledOperationCopy))
+			require.False(t, config.OperationEnabled(enabledOperationMerge))
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.EnabledOperations = "move,archive"
+			require.EqualError(t, config.IsValid(), "EnabledOperations value archive must be move, copy, attach or merge")
+		})
+	})
+
+	t.Run("MinAccountAgeDays", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 0, config.MinAccountAgeDaysInt())
+		})
+		t.Run("valid", func(t *testing.T) {
+			config.MinAccountAgeDays = "3"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 3, config.MinAccountAgeDaysInt())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.MinAccountAgeDays = "0"
+			require.EqualError(t, config.IsValid(), "invalid MinAccountAgeDays: MinAccountAgeDays (0) must be greater than 0")
+		})
+	})
+
+	t.Run("OriginalDeleteFailure", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, originalDeleteFailureRollback, config.OriginalDeleteFailureValue())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.OriginalDeleteFailure = "ignore"
+			require.EqualError(t, config.IsValid(), "OriginalDeleteFailure value ignore must be rollback or copy")
+		})
+	})
+
+	t.Run("DefaultTimezone", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, time.UTC, config.DefaultLocation())
+		})
+		t.Run("valid", func(t *testing.T) {
+			config.DefaultTimezone = "Europe/Paris"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, "Europe/Paris", config.DefaultLocation().String())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.DefaultTimezone = "Mars/Olympus_Mons"
+			require.EqualError(t, config.IsValid(), "DefaultTimezone value Mars/Olympus_Mons is not a valid time zone")
+		})
+	})
+
+	t.Run("MoveFileLimits", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("no limits by default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Nil(t, config.FileLimiter())
+			require.Equal(t, moveFileLimitRefuse, config.MoveFileLimitActionValue())
+		})
+		t.Run("valid", func(t *testing.T) {
+			config.MaxMoveFileSizeMB = "10"
+			config.MaxMoveFileCount = "5"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, &fileLimiter{maxSize: 10 * 1024 * 1024, maxCount: 5}, config.FileLimiter())
+		})
+		t.Run("invalid size", func(t *testing.T) {
+			config.MaxMoveFileSizeMB = "0"
+			require.EqualError(t, config.IsValid(), "invalid MaxMoveFileSizeMB: MaxMoveFileSizeMB (0) must be greater than 0")
+		})
+		t.Run("invalid count", func(t *testing.T) {
+			config.MaxMoveFileSizeMB = ""
+			config.MaxMoveFileCount = "many"
+			require.Error(t, config.IsValid())
+		})
+		t.Run("invalid action", func(t *testing.T) {
+			config.MaxMoveFileCount = ""
+			config.MoveFileLimitAction = "compress"
+			require.EqualError(t, config.IsValid(), "MoveFileLimitAction value compress must be refuse or skip")
+		})
+	})
+
+	t.Run("DuplicateCopies", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, duplicateCopiesAllow, config.DuplicateCopiesValue())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.DuplicateCopies = "block"
+			require.EqualError(t, config.IsValid(), "DuplicateCopies value block must be allow, warn or skip")
+		})
+	})
+
+	t.Run("BoardsCardBoardID", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.BoardsCardBoardID = "b" + model.NewId()
+			require.NoError(t, config.IsValid())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.BoardsCardBoardID = "../boards"
+			require.EqualError(t, config.IsValid(), "BoardsCardBoardID value ../boards is not a valid board ID")
+		})
+	})
+
+	t.Run("ReplyBroadcasts", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, replyBroadcastsPreserve, config.ReplyBroadcastsValue())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.ReplyBroadcasts = "drop"
+			require.EqualError(t, config.IsValid(), "ReplyBroadcasts value drop must be preserve or remove")
+		})
+	})
+
+	t.Run("GroupMentions", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.Equal(t, groupMentionsPreserve, config.GroupMentionsValue())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.GroupMentions = "block"
+			require.EqualError(t, config.IsValid(), "GroupMentions value block must be preserve, neutralize or warn")
+		})
+	})
+
+	t.Run("MentionPolicy", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.MentionPolicy = mentionPolicyChannelOnly
+			require.NoError(t, config.IsValid())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			config.MentionPolicy = "some"
+			require.Error(t, config.IsValid())
+		})
+	})
+
+	t.Run("AllowedDestinationTeamIDs", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid IDs", func(t *testing.T) {
+			config.AllowedDestinationTeamIDs = model.NewId() + ", " + model.NewId()
+			require.NoError(t, config.IsValid())
+		})
+		t.Run("invalid ID", func(t *testing.T) {
+			config.AllowedDestinationTeamIDs = model.NewId() + ",team-2"
+			require.Error(t, config.IsValid())
+		})
+	})
+
 	t.Run("MaxThreadCountMoveSize", func(t *testing.T) {
 		config := baseConfiguration
 
@@ -60,4 +453,883 @@ func TestConfigurationIsValid(t *testing.T) {
 			require.NoError(t, config.IsValid())
 		})
 	})
+
+	t.Run("MaxCountBypassUsers", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.MaxCountBypassUsers = "@alice, Bob"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, []string{"alice", "bob"}, config.MaxCountBypassUsernames())
+		})
+
+		t.Run("invalid username", func(t *testing.T) {
+			config.MaxCountBypassUsers = "alice,not a username"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MaxCountBypassUsers = ""
+			require.NoError(t, config.IsValid())
+			require.Empty(t, config.MaxCountBypassUsernames())
+		})
+	})
+
+	t.Run("MaxAuthorsPerMove", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.MaxAuthorsPerMove = "five"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("negative integer", func(t *testing.T) {
+			config.MaxAuthorsPerMove = "-1"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("zero", func(t *testing.T) {
+			config.MaxAuthorsPerMove = "0"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 0, config.MaxAuthorsPerMoveInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MaxAuthorsPerMove = ""
+			require.NoError(t, config.IsValid())
+		})
+	})
+
+	t.Run("CollapseGapSeconds", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.CollapseGapSeconds = "soon"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("zero", func(t *testing.T) {
+			config.CollapseGapSeconds = "0"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.CollapseGapSeconds = "30"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 30*time.Second, config.CollapseGap())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.CollapseGapSeconds = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, defaultCollapseGap, config.CollapseGap())
+		})
+	})
+
+	t.Run("OperationTimeoutSeconds", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.OperationTimeoutSeconds = "forever"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("negative", func(t *testing.T) {
+			config.OperationTimeoutSeconds = "-1"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.OperationTimeoutSeconds = "300"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 5*time.Minute, config.OperationTimeout())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.OperationTimeoutSeconds = ""
+			require.NoError(t, config.IsValid())
+			require.Zero(t, config.OperationTimeout())
+		})
+	})
+
+	t.Run("DefaultChannelMoves", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			config.DefaultChannelMoves = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, defaultChannelMovesAllow, config.DefaultChannelMovesPolicy())
+		})
+
+		t.Run("valid values", func(t *testing.T) {
+			for _, value := range []string{defaultChannelMovesAllow, defaultChannelMovesConfirm, defaultChannelMovesBlock} {
+				config.DefaultChannelMoves = value
+				require.NoError(t, config.IsValid())
+				require.Equal(t, value, config.DefaultChannelMovesPolicy())
+			}
+		})
+
+		t.Run("invalid value", func(t *testing.T) {
+			config.DefaultChannelMoves = "warn"
+			require.EqualError(t, config.IsValid(), "DefaultChannelMoves value warn must be allow, confirm or block")
+		})
+	})
+
+	t.Run("ExperimentalFeatures", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("default", func(t *testing.T) {
+			require.NoError(t, config.IsValid())
+			require.False(t, config.ExperimentalFeatureEnabled(experimentalFeatureImport))
+			require.Empty(t, config.UnknownExperimentalFeatures())
+		})
+		t.Run("valid", func(t *testing.T) {
+			config.ExperimentalFeatures = "Import, reminders,"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, []string{experimentalFeatureImport, experimentalFeatureReminders}, config.ExperimentalFeatureNames())
+			require.True(t, config.ExperimentalFeatureEnabled(experimentalFeatureReminders))
+			require.False(t, config.ExperimentalFeatureEnabled(experimentalFeatureRequestMove))
+		})
+		t.Run("unknown features are ignored", func(t *testing.T) {
+			config.ExperimentalFeatures = "import,scheduling"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, []string{"scheduling"}, config.UnknownExperimentalFeatures())
+			require.True(t, config.ExperimentalFeatureEnabled(experimentalFeatureImport))
+		})
+	})
+
+	t.Run("ChannelAutocompleteIntervalMilliseconds", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.ChannelAutocompleteIntervalMilliseconds = "fast"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("negative", func(t *testing.T) {
+			config.ChannelAutocompleteIntervalMilliseconds = "-1"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("too long", func(t *testing.T) {
+			config.ChannelAutocompleteIntervalMilliseconds = "60001"
+			require.EqualError(t, config.IsValid(), "invalid ChannelAutocompleteIntervalMilliseconds: ChannelAutocompleteIntervalMilliseconds (60001) must be between 0 and 60000")
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.ChannelAutocompleteIntervalMilliseconds = "250"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 250*time.Millisecond, config.ChannelAutocompleteInterval())
+		})
+
+		t.Run("disabled", func(t *testing.T) {
+			config.ChannelAutocompleteIntervalMilliseconds = "0"
+			require.NoError(t, config.IsValid())
+			require.Zero(t, config.ChannelAutocompleteInterval())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.ChannelAutocompleteIntervalMilliseconds = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, defaultChannelAutocompleteInterval, config.ChannelAutocompleteInterval())
+		})
+	})
+
+	t.Run("StatsWindowDays", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.StatsWindowDays = "a month"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("out of range", func(t *testing.T) {
+			config.StatsWindowDays = "0"
+			require.Error(t, config.IsValid())
+			config.StatsWindowDays = "366"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.StatsWindowDays = "90"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 90*24*time.Hour, config.StatsWindow())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.StatsWindowDays = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, defaultStatsWindowDays, config.StatsWindowDaysInt())
+		})
+	})
+
+	t.Run("HistoryRetentionDays", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.HistoryRetentionDays = "forever"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("shorter than the stats window", func(t *testing.T) {
+			config.StatsWindowDays = "30"
+			config.HistoryRetentionDays = "7"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.HistoryRetentionDays = "90"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 90*24*time.Hour, config.HistoryRetention())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.HistoryRetentionDays = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, config.StatsWindow(), config.HistoryRetention())
+		})
+	})
+
+	t.Run("PostCreationConcurrency", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.PostCreationConcurrency = "many"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("out of range", func(t *testing.T) {
+			config.PostCreationConcurrency = "0"
+			require.Error(t, config.IsValid())
+			config.PostCreationConcurrency = "21"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.PostCreationConcurrency = "5"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 5, config.PostCreationConcurrencyInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.PostCreationConcurrency = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 1, config.PostCreationConcurrencyInt())
+		})
+	})
+
+	t.Run("MaxNotificationDMsPerHour", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.MaxNotificationDMsPerHour = "twenty"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("zero", func(t *testing.T) {
+			config.MaxNotificationDMsPerHour = "0"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.MaxNotificationDMsPerHour = "100"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 100, config.MaxNotificationDMsPerHourInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MaxNotificationDMsPerHour = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 0, config.MaxNotificationDMsPerHourInt())
+		})
+	})
+
+	t.Run("MaxMovesPerChannelPerDay", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.MaxMovesPerChannelPerDay = "ten"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("zero", func(t *testing.T) {
+			config.MaxMovesPerChannelPerDay = "0"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.MaxMovesPerChannelPerDay = "10"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 10, config.MaxMovesPerChannelPerDayInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MaxMovesPerChannelPerDay = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 0, config.MaxMovesPerChannelPerDayInt())
+		})
+	})
+
+	t.Run("AllowedSourceChannelPattern", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid regex", func(t *testing.T) {
+			config.AllowedSourceChannelPattern = "^(support"
+			require.Error(t, config.IsValid())
+			// No channel is allowed rather than all of them.
+			pattern := config.AllowedSourceChannelRegexp()
+			require.NotNil(t, pattern)
+			require.False(t, pattern.MatchString("town-square"))
+		})
+
+		t.Run("valid regex", func(t *testing.T) {
+			config.AllowedSourceChannelPattern = "^(support|triage)-"
+			require.NoError(t, config.IsValid())
+			pattern := config.AllowedSourceChannelRegexp()
+			require.NotNil(t, pattern)
+			require.True(t, pattern.MatchString("support-emea"))
+			require.False(t, pattern.MatchString("town-square"))
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.AllowedSourceChannelPattern = ""
+			require.NoError(t, config.IsValid())
+			require.Nil(t, config.AllowedSourceChannelRegexp())
+		})
+	})
+
+	t.Run("MaxScheduledJobsPerUser", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.MaxScheduledJobsPerUser = "ten"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("zero", func(t *testing.T) {
+			config.MaxScheduledJobsPerUser = "0"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.MaxScheduledJobsPerUser = "20"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 20, config.MaxScheduledJobsPerUserInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MaxScheduledJobsPerUser = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 0, config.MaxScheduledJobsPerUserInt())
+		})
+	})
+
+	t.Run("DestinationPostCountWarning", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.DestinationPostCountWarning = "many"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("zero", func(t *testing.T) {
+			config.DestinationPostCountWarning = "0"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.DestinationPostCountWarning = "50000"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 50000, config.DestinationPostCountWarningInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.DestinationPostCountWarning = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 0, config.DestinationPostCountWarningInt())
+		})
+	})
+
+	t.Run("MaxConcurrentOperations", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.MaxConcurrentOperations = "few"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("zero", func(t *testing.T) {
+			config.MaxConcurrentOperations = "0"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.MaxConcurrentOperations = "4"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 4, config.MaxConcurrentOperationsInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MaxConcurrentOperations = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 0, config.MaxConcurrentOperationsInt())
+		})
+	})
+
+	t.Run("MaxCommandLength", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.MaxCommandLength = "long"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("too low", func(t *testing.T) {
+			config.MaxCommandLength = "99"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("too high", func(t *testing.T) {
+			config.MaxCommandLength = "16384"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.MaxCommandLength = "2000"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 2000, config.MaxCommandLengthInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MaxCommandLength = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 1000, config.MaxCommandLengthInt())
+		})
+	})
+
+	t.Run("ResolutionAnnotations", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid JSON", func(t *testing.T) {
+			config.ResolutionAnnotations = "resolved"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("invalid channel ID", func(t *testing.T) {
+			config.ResolutionAnnotations = `{"resolved": {"text": "Resolved"}}`
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("invalid emoji", func(t *testing.T) {
+			config.ResolutionAnnotations = fmt.Sprintf(`{"%s": {"emoji": "white check mark"}}`, model.NewId())
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid annotation", func(t *testing.T) {
+			channelID := model.NewId()
+			config.ResolutionAnnotations = fmt.Sprintf(`{"%s": {"text": "Resolved", "emoji": ":white_check_mark:"}}`, channelID)
+			require.NoError(t, config.IsValid())
+			require.Equal(t, map[string]resolutionAnnotation{channelID: {Text: "Resolved", Emoji: ":white_check_mark:"}}, config.ResolutionAnnotationsMap())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.ResolutionAnnotations = ""
+			require.NoError(t, config.IsValid())
+			require.Empty(t, config.ResolutionAnnotationsMap())
+		})
+	})
+
+	t.Run("CompressedImageMaxDimension", func(t *testing.T) {
+		config := baseConfiguration
+		config.CompressArchivedImages = true
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.CompressedImageMaxDimension = "big"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("too low", func(t *testing.T) {
+			config.CompressedImageMaxDimension = "99"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("too high", func(t *testing.T) {
+			config.CompressedImageMaxDimension = "10001"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.CompressedImageMaxDimension = "800"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 800, config.ImageCompressor().maxDimension)
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.CompressedImageMaxDimension = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 1600, config.ImageCompressor().maxDimension)
+		})
+	})
+
+	t.Run("CompressedImageQuality", func(t *testing.T) {
+		config := baseConfiguration
+		config.CompressArchivedImages = true
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.CompressedImageQuality = "high"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("zero", func(t *testing.T) {
+			config.CompressedImageQuality = "0"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("too high", func(t *testing.T) {
+			config.CompressedImageQuality = "101"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.CompressedImageQuality = "90"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 90, config.ImageCompressor().quality)
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.CompressedImageQuality = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 75, config.ImageCompressor().quality)
+		})
+
+		t.Run("disabled", func(t *testing.T) {
+			config.CompressArchivedImages = false
+			require.Nil(t, config.ImageCompressor())
+		})
+	})
+
+	t.Run("ListMessagesTrimLength", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid integer", func(t *testing.T) {
+			config.ListMessagesTrimLength = "long"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("too low", func(t *testing.T) {
+			config.ListMessagesTrimLength = "9"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("too high", func(t *testing.T) {
+			config.ListMessagesTrimLength = "501"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid integer", func(t *testing.T) {
+			config.ListMessagesTrimLength = "120"
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 120, config.ListMessagesTrimLengthInt())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.ListMessagesTrimLength = ""
+			require.NoError(t, config.IsValid())
+			require.Equal(t, 80, config.ListMessagesTrimLengthInt())
+		})
+	})
+
+	t.Run("MovedHashtag", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.MovedHashtag = "#moved"
+			require.NoError(t, config.IsValid())
+		})
+
+		t.Run("missing hash", func(t *testing.T) {
+			config.MovedHashtag = "moved"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("multiple hashtags", func(t *testing.T) {
+			config.MovedHashtag = "#moved #again"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MovedHashtag = ""
+			require.NoError(t, config.IsValid())
+		})
+	})
+
+	t.Run("MoveTextTransforms", func(t *testing.T) {
+		config := baseConfiguration
+		channelID := model.NewId()
+
+		t.Run("valid", func(t *testing.T) {
+			config.MoveTextTransforms = fmt.Sprintf(`[{"regex": "INT-[0-9]+", "replacement": ""}, {"regex": "foo", "replacement": "bar", "channel_id": "%s"}]`, channelID)
+			require.NoError(t, config.IsValid())
+			require.Len(t, config.MoveTextTransformsForChannel(channelID), 2)
+			require.Len(t, config.MoveTextTransformsForChannel(model.NewId()), 1)
+		})
+
+		t.Run("invalid regex", func(t *testing.T) {
+			config.MoveTextTransforms = `[{"regex": "(INT", "replacement": ""}]`
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("missing regex", func(t *testing.T) {
+			config.MoveTextTransforms = `[{"replacement": "bar"}]`
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("invalid channel ID", func(t *testing.T) {
+			config.MoveTextTransforms = `[{"regex": "foo", "replacement": "bar", "channel_id": "town-square"}]`
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MoveTextTransforms = ""
+			require.NoError(t, config.IsValid())
+		})
+	})
+
+	t.Run("BlockedContentPatterns", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("invalid JSON", func(t *testing.T) {
+			config.BlockedContentPatterns = "AKIA[0-9A-Z]{16}"
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("invalid regex", func(t *testing.T) {
+			config.BlockedContentPatterns = `["AKIA[0-9A-Z"]`
+			require.Error(t, config.IsValid())
+			// Moves are blocked rather than let through.
+			require.Equal(t, []*regexp.Regexp{blockAllContentPattern}, config.BlockedContentPatternsList())
+			require.NotNil(t, findBlockedContentPattern([]*model.Post{{Message: "hello"}}, config.BlockedContentPatternsList()))
+		})
+
+		t.Run("empty pattern", func(t *testing.T) {
+			config.BlockedContentPatterns = `[""]`
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("valid patterns", func(t *testing.T) {
+			config.BlockedContentPatterns = `["AKIA[0-9A-Z]{16}", "(?i)password:"]`
+			require.NoError(t, config.IsValid())
+			require.Len(t, config.BlockedContentPatternsList(), 2)
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.BlockedContentPatterns = ""
+			require.NoError(t, config.IsValid())
+			require.Empty(t, config.BlockedContentPatternsList())
+		})
+	})
+
+	t.Run("MovedFromFooter", func(t *testing.T) {
+		config := baseConfiguration
+		channel := &model.Channel{Name: "town-square", Type: model.CHANNEL_OPEN}
+		require.Empty(t, config.MovedFromFooter(channel))
+
+		config.EnableMovedFromFooter = true
+		require.Equal(t, "_Moved from ~town-square_", config.MovedFromFooter(channel))
+		require.Equal(t, "_Moved from a direct message_", config.MovedFromFooter(&model.Channel{Name: "user1__user2", Type: model.CHANNEL_DIRECT}))
+
+		config.MovedFromFooterText = "From {channel}"
+		require.Equal(t, "From ~town-square", config.MovedFromFooter(channel))
+	})
+
+	t.Run("DestinationTemplates", func(t *testing.T) {
+		config := baseConfiguration
+		channelID := model.NewId()
+
+		t.Run("valid", func(t *testing.T) {
+			config.DestinationTemplates = fmt.Sprintf(`{"%s": {"header": "#### Reported bug", "footer": "_From {channel}_"}}`, channelID)
+			require.NoError(t, config.IsValid())
+			channel := &model.Channel{Name: "town-square", Type: model.CHANNEL_OPEN}
+			require.Equal(t, "#### Reported bug", config.DestinationHeader(channel, channelID))
+			require.Equal(t, "_From ~town-square_", config.MovedFromFooterForChannel(channel, channelID))
+			require.Empty(t, config.MovedFromFooterForChannel(channel, model.NewId()))
+		})
+		t.Run("invalid channel ID", func(t *testing.T) {
+			config.DestinationTemplates = `{"bugs": {"header": "Bug"}}`
+			require.EqualError(t, config.IsValid(), "invalid DestinationTemplates: DestinationTemplates key bugs is not a valid channel ID")
+		})
+		t.Run("empty template", func(t *testing.T) {
+			config.DestinationTemplates = fmt.Sprintf(`{"%s": {}}`, channelID)
+			require.EqualError(t, config.IsValid(), fmt.Sprintf("invalid DestinationTemplates: DestinationTemplates entry for channel %s has no header or footer", channelID))
+		})
+		t.Run("unknown placeholder", func(t *testing.T) {
+			config.DestinationTemplates = fmt.Sprintf(`{"%s": {"footer": "Moved by {user}"}}`, channelID)
+			require.EqualError(t, config.IsValid(), fmt.Sprintf("invalid DestinationTemplates: DestinationTemplates entry for channel %s has unknown placeholder {user}; only {channel} is supported", channelID))
+		})
+	})
+
+	t.Run("MovedPostProps", func(t *testing.T) {
+		config := baseConfiguration
+
+		t.Run("valid", func(t *testing.T) {
+			config.MovedPostProps = `{"report_category": "support", "source": "wrangler"}`
+			require.NoError(t, config.IsValid())
+			require.Equal(t, map[string]string{"report_category": "support", "source": "wrangler"}, config.MovedPostPropsMap())
+		})
+
+		t.Run("non-string value", func(t *testing.T) {
+			config.MovedPostProps = `{"report_category": 1}`
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("reserved key", func(t *testing.T) {
+			config.MovedPostProps = `{"moved_by": "someone"}`
+			require.Error(t, config.IsValid())
+			config.MovedPostProps = `{"attachments": "none"}`
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.MovedPostProps = ""
+			require.NoError(t, config.IsValid())
+		})
+	})
+
+	t.Run("SourceToDestinationMap", func(t *testing.T) {
+		config := baseConfiguration
+		sourceID := model.NewId()
+		destinationID := model.NewId()
+
+		t.Run("valid", func(t *testing.T) {
+			config.SourceToDestinationMap = fmt.Sprintf(`{"%s": "%s"}`, sourceID, destinationID)
+			require.NoError(t, config.IsValid())
+			require.Equal(t, map[string]string{sourceID: destinationID}, config.SourceToDestinationMapping())
+		})
+
+		t.Run("invalid channel ID", func(t *testing.T) {
+			config.SourceToDestinationMap = fmt.Sprintf(`{"%s": "archive"}`, sourceID)
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("same channel", func(t *testing.T) {
+			config.SourceToDestinationMap = fmt.Sprintf(`{"%s": "%s"}`, sourceID, sourceID)
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.SourceToDestinationMap = ""
+			require.NoError(t, config.IsValid())
+		})
+	})
+
+	t.Run("RoutingRules", func(t *testing.T) {
+		config := baseConfiguration
+		channelID := model.NewId()
+
+		t.Run("valid", func(t *testing.T) {
+			config.RoutingRules = fmt.Sprintf(`[{"keyword": "outage", "channel_id": "%s"}, {"regex": "^bug:", "channel_id": "%s"}]`, channelID, channelID)
+			require.NoError(t, config.IsValid())
+			require.Len(t, config.RoutingRulesList(), 2)
+		})
+
+		t.Run("invalid regex", func(t *testing.T) {
+			config.RoutingRules = fmt.Sprintf(`[{"regex": "(bug", "channel_id": "%s"}]`, channelID)
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("keyword and regex", func(t *testing.T) {
+			config.RoutingRules = fmt.Sprintf(`[{"keyword": "bug", "regex": "bug", "channel_id": "%s"}]`, channelID)
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("invalid channel ID", func(t *testing.T) {
+			config.RoutingRules = `[{"keyword": "bug", "channel_id": "town-square"}]`
+			require.Error(t, config.IsValid())
+		})
+
+		t.Run("unset value", func(t *testing.T) {
+			config.RoutingRules = ""
+			require.NoError(t, config.IsValid())
+			require.Empty(t, config.RoutingRulesList())
+		})
+	})
+}
+
+func TestOnConfigurationChangeRoutingRules(t *testing.T) {
+	channelID := model.NewId()
+
+	setup := func(channelExists bool) *Plugin {
+		api := &plugintest.API{}
+		api.On("LoadPluginConfiguration", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			config := args.Get(0).(*configuration)
+			config.RoutingRules = fmt.Sprintf(`[{"keyword": "bug", "channel_id": "%s"}]`, channelID)
+		})
+		if channelExists {
+			api.On("GetChannel", channelID).Return(&model.Channel{Id: channelID}, nil)
+		} else {
+			api.On("GetChannel", channelID).Return(nil, &model.AppError{Message: "not found"})
+		}
+		api.On("RegisterCommand", mock.Anything).Return(nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+
+		return plugin
+	}
+
+	t.Run("existing channel", func(t *testing.T) {
+		plugin := setup(true)
+		require.NoError(t, plugin.OnConfigurationChange())
+		require.Len(t, plugin.getConfiguration().RoutingRulesList(), 1)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		plugin := setup(false)
+		require.Error(t, plugin.OnConfigurationChange())
+		require.Empty(t, plugin.getConfiguration().RoutingRulesList())
+	})
+}
+
+func TestOnConfigurationChangeInvalidSettings(t *testing.T) {
+	for name, set := range map[string]func(config *configuration){
+		"BlockedContentPatterns":      func(config *configuration) { config.BlockedContentPatterns = `["AKIA[0-9A-Z"]` },
+		"AllowedSourceChannelPattern": func(config *configuration) { config.AllowedSourceChannelPattern = "^(support" },
+		"DestinationTemplates":        func(config *configuration) { config.DestinationTemplates = `{"bugs": {"header": "Bug"}}` },
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := &plugintest.API{}
+			api.On("LoadPluginConfiguration", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				set(args.Get(0).(*configuration))
+			})
+
+			plugin := &Plugin{}
+			plugin.SetAPI(api)
+			plugin.setConfiguration(&configuration{})
+
+			err := plugin.OnConfigurationChange()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid "+name)
+			// The previous configuration is kept.
+			require.Equal(t, &configuration{}, plugin.getConfiguration())
+			api.AssertNotCalled(t, "RegisterCommand", mock.Anything)
+		})
+	}
+}
+
+func TestOnConfigurationChangeExperimentalFeatures(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("LoadPluginConfiguration", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		config := args.Get(0).(*configuration)
+		config.ExperimentalFeatures = "import,scheduling"
+	})
+	api.On("LogWarn", "Ignoring unknown experimental features", "features", "scheduling", "known_features", "import,request-move,reminders").Return()
+	api.On("RegisterCommand", mock.MatchedBy(func(command *model.Command) bool {
+		return strings.Contains(command.AutoCompleteDesc, " import,") && !strings.Contains(command.AutoCompleteDesc, "request move")
+	})).Return(nil)
+
+	plugin := &Plugin{}
+	plugin.SetAPI(api)
+
+	require.NoError(t, plugin.OnConfigurationChange())
+	api.AssertExpectations(t)
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const (
+	flagAfter  = "after"
+	flagBefore = "before"
+
+	dateRangeDateFormat = "2006-01-02"
+)
+
+// dateRange restricts the replies of a thread to those created within it. The
+// start is inclusive and the end is exclusive so that consecutive ranges
+// don't overlap. Either bound may be unset.
+type dateRange struct {
+	after  time.Time
+	before time.Time
+}
+
+func addDateRangeFlags(flagSet *pflag.FlagSet, verb string) {
+	flagSet.String(flagAfter, "", fmt.Sprintf("Only %s the replies posted at or after the given time, as a date like 2020-06-01 or an RFC 3339 time like 2020-06-01T15:04:05Z", verb))
+	flagSet.String(flagBefore, "", fmt.Sprintf("Only %s the replies posted before the given time, as a date like 2020-06-01 or an RFC 3339 time like 2020-06-01T15:04:05Z", verb))
+}
+
+func parseDateRangeFlags(flagSet *pflag.FlagSet) (dateRange, error) {
+	var r dateRange
+
+	after, err := flagSet.GetString(flagAfter)
+	if err != nil {
+		return r, err
+	}
+	r.after, err = parseDateRangeTime(flagAfter, after)
+	if err != nil {
+		return r, err
+	}
+
+	before, err := flagSet.GetString(flagBefore)
+	if err != nil {
+		return r, err
+	}
+	r.before, err = parseDateRangeTime(flagBefore, before)
+	if err != nil {
+		return r, err
+	}
+
+	if !r.after.IsZero() && !r.before.IsZero() && !r.before.After(r.after) {
+		return r, errors.Errorf("--%s must be later than --%s", flagBefore, flagAfter)
+	}
+
+	return r, nil
+}
+
+// parseDateRangeTime parses a date, which stands for midnight UTC, or an RFC
+// 3339 time. An empty value is returned as the zero time.
+func parseDateRangeTime(flag, s string) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(dateRangeDateFormat, s)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.Errorf("--%s value %s must be a date like 2020-06-01 or an RFC 3339 time like 2020-06-01T15:04:05Z", flag, s)
+	}
+
+	return t, nil
+}
+
+// isSet returns true if either bound of the range is set.
+func (r dateRange) isSet() bool {
+	return !r.after.IsZero() || !r.before.IsZero()
+}
+
+// contains returns true if the given creation timestamp in milliseconds is
+// within the range.
+func (r dateRange) contains(createAt int64) bool {
+	return r.includes(time.Unix(0, createAt*int64(time.Millisecond)))
+}
+
+// includes returns true if the given time is within the range.
+func (r dateRange) includes(t time.Time) bool {
+	if !r.after.IsZero() && t.Before(r.after) {
+		return false
+	}
+	if !r.before.IsZero() && !t.Before(r.before) {
+		return false
+	}
+
+	return true
+}
+
+// String describes the range for messages to users.
+func (r dateRange) String() string {
+	switch {
+	case r.after.IsZero():
+		return "before " + r.before.UTC().Format(transcriptTimeFormat)
+	case r.before.IsZero():
+		return "since " + r.after.UTC().Format(transcriptTimeFormat)
+	default:
+		return fmt.Sprintf("between %s and %s", r.after.UTC().Format(transcriptTimeFormat), r.before.UTC().Format(transcriptTimeFormat))
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDateRangeFlags(t *testing.T) {
+	parse := func(args ...string) (dateRange, error) {
+		flagSet := getCopyThreadFlagSet()
+		require.NoError(t, flagSet.Parse(args))
+		return parseDateRangeFlags(flagSet)
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		r, err := parse()
+		require.NoError(t, err)
+		assert.False(t, r.isSet())
+	})
+
+	t.Run("date and time", func(t *testing.T) {
+		r, err := parse("--after", "2020-06-01", "--before", "2020-06-02T12:30:00+02:00")
+		require.NoError(t, err)
+		assert.True(t, r.isSet())
+		assert.Equal(t, time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC), r.after.UTC())
+		assert.Equal(t, time.Date(2020, time.June, 2, 10, 30, 0, 0, time.UTC), r.before.UTC())
+		assert.Equal(t, "between 2020-06-01 00:00 UTC and 2020-06-02 10:30 UTC", r.String())
+	})
+
+	t.Run("invalid time", func(t *testing.T) {
+		_, err := parse("--after", "yesterday")
+		require.EqualError(t, err, "--after value yesterday must be a date like 2020-06-01 or an RFC 3339 time like 2020-06-01T15:04:05Z")
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		_, err := parse("--after", "2020-06-02", "--before", "2020-06-02")
+		require.EqualError(t, err, "--before must be later than --after")
+
+		_, err = parse("--after", "2020-06-02", "--before", "2020-06-01")
+		require.EqualError(t, err, "--before must be later than --after")
+	})
+}
+
+func TestDateRangeContains(t *testing.T) {
+	after := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2020, time.June, 2, 0, 0, 0, 0, time.UTC)
+	millis := func(t time.Time) int64 {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+
+	r := dateRange{after: after, before: before}
+	assert.False(t, r.contains(millis(after)-1))
+	assert.True(t, r.contains(millis(after)))
+	assert.True(t, r.contains(millis(before)-1))
+	assert.False(t, r.contains(millis(before)))
+
+	assert.True(t, dateRange{after: after}.contains(millis(before)))
+	assert.False(t, dateRange{after: after}.contains(millis(after)-1))
+	assert.True(t, dateRange{before: before}.contains(millis(after)-1))
+	assert.False(t, dateRange{before: before}.contains(millis(before)))
+}
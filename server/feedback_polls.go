@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const feedbackPollsKey = "feedback_polls"
+
+// Reactions used to answer a move feedback poll.
+const (
+	feedbackPollStayed = "+1"
+	feedbackPollMoved  = "-1"
+)
+
+// feedbackPoll is a bot post left in the original channel of a move asking
+// whether the thread belonged there.
+type feedbackPoll struct {
+	PostID    string `json:"post_id"`
+	ChannelID string `json:"channel_id"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// feedbackPollTally is the combined result of the feedback polls.
+type feedbackPollTally struct {
+	polls  int
+	stayed int
+	moved  int
+}
+
+func unmarshalFeedbackPolls(data []byte) ([]feedbackPoll, error) {
+	var polls []feedbackPoll
+	if data == nil {
+		return polls, nil
+	}
+
+	err := json.Unmarshal(data, &polls)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal feedback polls")
+	}
+
+	return polls, nil
+}
+
+// postFeedbackPoll posts a poll in the original channel of a move asking
+// whether the moved thread should have stayed there. The poll is tracked so
+// that its answers can be shown in the stats. Polls older than the history
+// retention are no longer tracked.
+func (p *Plugin) postFeedbackPoll(channelID, newPostLink string) error {
+	post, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		Message:   fmt.Sprintf("A thread was moved out of this channel: %s\nShould it have stayed here? React with :%s: if it should have stayed or :%s: if it belonged elsewhere.", newPostLink, feedbackPollStayed, feedbackPollMoved),
+	})
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to create feedback poll post")
+	}
+
+	for _, emojiName := range []string{feedbackPollStayed, feedbackPollMoved} {
+		_, appErr = p.API.AddReaction(&model.Reaction{
+			UserId:    p.BotUserID,
+			PostId:    post.Id,
+			EmojiName: emojiName,
+		})
+		if appErr != nil {
+			p.API.LogError("Unable to add feedback poll reaction", "error", appErr.Error(), "post_id", post.Id)
+		}
+	}
+
+	poll := feedbackPoll{
+		PostID:    post.Id,
+		ChannelID: channelID,
+		CreatedAt: now().UnixNano(),
+	}
+	cutoff := now().Add(-p.getConfiguration().HistoryRetention()).UnixNano()
+	err := p.kvAtomicModify(feedbackPollsKey, func(initial []byte) ([]byte, error) {
+		polls, err := unmarshalFeedbackPolls(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		var kept []feedbackPoll
+		for _, existing := range polls {
+			if existing.CreatedAt >= cutoff {
+				kept = append(kept, existing)
+			}
+		}
+
+		return json.Marshal(append(kept, poll))
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to track feedback poll")
+	}
+
+	return nil
+}
+
+// tallyFeedbackPolls counts the answers to the feedback polls posted since the
+// given time. The reactions of the bot itself and polls that were deleted are
+// left out.
+func (p *Plugin) tallyFeedbackPolls(since int64) (feedbackPollTally, error) {
+	var tally feedbackPollTally
+
+	data, appErr := p.API.KVGet(feedbackPollsKey)
+	if appErr != nil {
+		return tally, errors.Wrap(appErr, "unable to get feedback polls")
+	}
+	polls, err := unmarshalFeedbackPolls(data)
+	if err != nil {
+		return tally, err
+	}
+
+	for _, poll := range polls {
+		if poll.CreatedAt < since {
+			continue
+		}
+
+		reactions, appErr := p.API.GetReactions(poll.PostID)
+		if appErr != nil {
+			continue
+		}
+		tally.polls++
+		for _, reaction := range reactions {
+			if reaction.UserId == p.BotUserID {
+				continue
+			}
+			switch reaction.EmojiName {
+			case feedbackPollStayed:
+				tally.stayed++
+			case feedbackPollMoved:
+				tally.moved++
+			}
+		}
+	}
+
+	return tally, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// fileLimiter enforces the configured maximum size and number of the file
+// attachments that are re-uploaded when a thread is moved or copied, which
+// keeps large moves from exhausting storage.
+type fileLimiter struct {
+	// maxSize is the maximum size of a file in bytes, or 0 for no limit.
+	maxSize int64
+	// maxCount is the maximum number of files, or 0 for no limit.
+	maxCount int
+	// allowed is the number of files that were within the limits.
+	allowed int
+	// skipped describes each file that was over the limits.
+	skipped []string
+}
+
+// allow returns true if the file is within the limits, counting it towards
+// the maximum number of files. Files over the limits are recorded as skipped.
+func (l *fileLimiter) allow(fileInfo *model.FileInfo) bool {
+	if l.maxSize != 0 && fileInfo.Size > l.maxSize {
+		l.skipped = append(l.skipped, fmt.Sprintf("%s (%s, over the %s limit)", fileInfo.Name, formatFileSize(fileInfo.Size), formatFileSize(l.maxSize)))
+		return false
+	}
+	if l.maxCount != 0 && l.allowed >= l.maxCount {
+		l.skipped = append(l.skipped, fmt.Sprintf("%s (over the limit of %d files)", fileInfo.Name, l.maxCount))
+		return false
+	}
+	l.allowed++
+
+	return true
+}
+
+func formatFileSize(size int64) string {
+	return fmt.Sprintf("%.1f MB", float64(size)/1024/1024)
+}
+
+// validateFileLimits returns a response refusing the operation when the file
+// attachments of the thread are over the configured limits and Wrangler is
+// configured to refuse such threads. The outcome, such as "moved", completes
+// the response. When the files over the limits are skipped instead, they are
+// left out while the thread is recreated.
+func (p *Plugin) validateFileLimits(wpl *WranglerPostList, outcome string) (*model.CommandResponse, error) {
+	config := p.getConfiguration()
+	limiter := config.FileLimiter()
+	if limiter == nil || !wpl.ContainsFileAttachments() || config.MoveFileLimitActionValue() != moveFileLimitRefuse {
+		return nil, nil
+	}
+
+	for _, post := range wpl.Posts {
+		for _, fileID := range post.FileIds {
+			fileInfo, appErr := p.API.GetFileInfo(fileID)
+			if appErr != nil {
+				return nil, errors.Wrap(appErr, "unable to lookup file info")
+			}
+			limiter.allow(fileInfo)
+		}
+	}
+	if len(limiter.skipped) == 0 {
+		return nil, nil
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+		"Error: %d file attachment(s) are over the file limits Wrangler is configured with, so nothing was %s:\n- %s",
+		len(limiter.skipped), outcome, strings.Join(limiter.skipped, "\n- "),
+	)), nil
+}
+
+// getSkippedFilesNote returns a note for the bot message of a moved or copied
+// thread listing the files that were left out for being over the file limits,
+// or an empty string when none were.
+func getSkippedFilesNote(limiter *fileLimiter) string {
+	if limiter == nil || len(limiter.skipped) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\n\n%d file attachment(s) were over the file limits and were left out: %s", len(limiter.skipped), strings.Join(limiter.skipped, ", "))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveThreadFileLimits(t *testing.T) {
+	setup := func(config *configuration) (*threadTestFixture, *Plugin, []*model.FileInfo) {
+		f := newThreadTestFixture(2)
+		fileInfos := []*model.FileInfo{
+			{Id: model.NewId(), Name: "notes.txt", Size: 1024},
+			{Id: model.NewId(), Name: "video.mp4", Size: 30 * 1024 * 1024},
+			{Id: model.NewId(), Name: "slides.pdf", Size: 2048},
+		}
+		f.replies[0].FileIds = model.StringArray{fileInfos[0].Id, fileInfos[1].Id}
+		f.replies[1].Message = ""
+		f.replies[1].FileIds = model.StringArray{fileInfos[2].Id}
+		for _, fileInfo := range fileInfos {
+			f.api.On("GetFileInfo", fileInfo.Id).Return(fileInfo, nil)
+			f.api.On("GetFile", fileInfo.Id).Return([]byte("file"), nil)
+			f.api.On("UploadFile", mock.Anything, f.targetChannel.Id, fileInfo.Name).Return(&model.FileInfo{Id: model.NewId(), Name: fileInfo.Name}, nil)
+		}
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+		require.NoError(t, plugin.configuration.IsValid())
+
+		return f, plugin, fileInfos
+	}
+
+	t.Run("within the limits", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{MaxMoveFileSizeMB: "50", MaxMoveFileCount: "3"})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		f.api.AssertNumberOfCalls(t, "UploadFile", 3)
+	})
+
+	t.Run("oversized file refuses the move", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{MaxMoveFileSizeMB: "25"})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: 1 file attachment(s) are over the file limits Wrangler is configured with, so nothing was moved:\n- video.mp4 (30.0 MB, over the 25.0 MB limit)", resp.Text)
+		f.api.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+		f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+	})
+
+	t.Run("too many files refuses the copy", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{MaxMoveFileCount: "2"})
+
+		resp, isUserError, err := plugin.runCopyThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: 1 file attachment(s) are over the file limits Wrangler is configured with, so nothing was copied:\n- slides.pdf (over the limit of 2 files)", resp.Text)
+		f.api.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("files over the limits are skipped", func(t *testing.T) {
+		f, plugin, fileInfos := setup(&configuration{MaxMoveFileSizeMB: "25", MaxMoveFileCount: "1", MoveFileLimitAction: moveFileLimitSkip})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+
+		f.api.AssertCalled(t, "UploadFile", mock.Anything, f.targetChannel.Id, fileInfos[0].Name)
+		f.api.AssertNumberOfCalls(t, "UploadFile", 1)
+		f.api.AssertNotCalled(t, "GetFile", fileInfos[1].Id)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "This is reply 1\n\n_1 file attachment(s) of this message were left out for being over the file limits_" && len(post.FileIds) == 1
+		}))
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "_1 file attachment(s) of this message were left out for being over the file limits_" && len(post.FileIds) == 0
+		}))
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return strings.HasPrefix(post.Message, "This thread was moved from another channel") &&
+				strings.HasSuffix(post.Message, "2 file attachment(s) were over the file limits and were left out: video.mp4 (30.0 MB, over the 25.0 MB limit), slides.pdf (over the limit of 1 files)")
+		}))
+	})
+
+	t.Run("skipping files leaves the original posts unchanged", func(t *testing.T) {
+		f, plugin, fileInfos := setup(&configuration{MaxMoveFileSizeMB: "25", MaxMoveFileCount: "1", MoveFileLimitAction: moveFileLimitSkip})
+
+		resp, isUserError, err := plugin.runCopyThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Thread copy complete", resp.Text)
+
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "This is reply 1\n\n_1 file attachment(s) of this message were left out for being over the file limits_"
+		}))
+		assert.Equal(t, "This is reply 1", f.replies[0].Message)
+		assert.Equal(t, model.StringArray{fileInfos[0].Id, fileInfos[1].Id}, f.replies[0].FileIds)
+		assert.Empty(t, f.replies[1].Message)
+		assert.Equal(t, model.StringArray{fileInfos[2].Id}, f.replies[1].FileIds)
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// groupMentionFilter applies the GroupMentions setting to the at-mentions of
+// user groups in moved messages, which would otherwise notify every member of
+// the groups again.
+type groupMentionFilter struct {
+	// neutralize is set to neutralize the mentions of every group.
+	neutralize bool
+	// groups are the mentioned groups, keyed by their lowercase name.
+	groups map[string]*model.Group
+}
+
+// apply returns the message with the group mentions neutralized when the
+// filter neutralizes them.
+func (f *groupMentionFilter) apply(message string) string {
+	if f == nil || !f.neutralize || len(f.groups) == 0 {
+		return message
+	}
+
+	return neutralizeMentionsFunc(message, func(name string) bool {
+		_, ok := f.groups[strings.ToLower(name)]
+		return ok
+	})
+}
+
+// getGroupMentionFilter returns the filter applying the group mention policy
+// to the posts, or nil when group mentions follow the mention policy. Groups
+// are only looked up when the mention policy preserves all mentions, since
+// group mentions are neutralized along with the mentions of users otherwise.
+func (p *Plugin) getGroupMentionFilter(posts []*model.Post) *groupMentionFilter {
+	config := p.getConfiguration()
+	if config.GroupMentionsValue() == groupMentionsPreserve || config.MentionPolicyValue() != mentionPolicyPreserveAll {
+		return nil
+	}
+
+	return &groupMentionFilter{
+		neutralize: config.GroupMentionsValue() == groupMentionsNeutralize,
+		groups:     p.findGroupMentions(posts),
+	}
+}
+
+// findGroupMentions returns the user groups mentioned in the posts, keyed by
+// their lowercase name. Mentions that don't name a group, usually mentions of
+// users, are left out, as are all mentions on servers without user groups.
+func (p *Plugin) findGroupMentions(posts []*model.Post) map[string]*model.Group {
+	names := make(map[string]bool)
+	for _, post := range posts {
+		for _, name := range findMentionNames(post.Message) {
+			name = strings.ToLower(name)
+			if !channelMentions[name] {
+				names[name] = true
+			}
+		}
+	}
+
+	groups := make(map[string]*model.Group)
+	for name := range names {
+		group, appErr := p.API.GetGroupByName(name)
+		if appErr != nil {
+			continue
+		}
+		groups[name] = group
+	}
+
+	return groups
+}
+
+// findMentionNames returns the names of the at-mentions of the message. Code
+// is skipped since mentions in code don't notify.
+func findMentionNames(message string) []string {
+	var names []string
+	mapOutsideCode(message, func(text string) string {
+		for _, submatches := range mentionRegexp.FindAllStringSubmatch(text, -1) {
+			names = append(names, strings.TrimRight(submatches[2], ".-_"))
+		}
+		return text
+	})
+
+	return names
+}
+
+// getGroupMentionsNote returns a warning for the move summary listing the
+// mentioned groups that were notified again and the group mentions that
+// don't resolve in the target channel, or an empty string when group mentions
+// aren't warned about. The mentions are quoted as code so that the summary
+// doesn't notify the groups itself.
+func getGroupMentionsNote(f *groupMentionFilter) string {
+	if f == nil || f.neutralize || len(f.groups) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(f.groups))
+	for name := range f.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var notified, unresolved []string
+	for _, name := range names {
+		group := f.groups[name]
+		switch {
+		case group.DeleteAt != 0:
+			unresolved = append(unresolved, fmt.Sprintf("`@%s` (the group was deleted)", name))
+		case !group.AllowReference:
+			unresolved = append(unresolved, fmt.Sprintf("`@%s` (the group can't be mentioned)", name))
+		default:
+			notified = append(notified, fmt.Sprintf("`@%s`", name))
+		}
+	}
+
+	var note string
+	if len(notified) != 0 {
+		note += fmt.Sprintf("\nWarning: the moved messages mention user groups whose members may have been notified again: %s\n", strings.Join(notified, ", "))
+	}
+	if len(unresolved) != 0 {
+		note += fmt.Sprintf("\nWarning: the moved messages mention user groups that won't resolve in the target channel: %s\n", strings.Join(unresolved, ", "))
+	}
+
+	return note
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveThreadGroupMentions(t *testing.T) {
+	setup := func(config *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+		f.replies[0].Message = "@devs @Legacy and @bob, please review. `@ops` @channel"
+		f.api.On("GetGroupByName", "devs").Return(&model.Group{Id: model.NewId(), AllowReference: true}, nil)
+		f.api.On("GetGroupByName", "legacy").Return(&model.Group{Id: model.NewId()}, nil)
+		f.api.On("GetGroupByName", mock.AnythingOfType("string")).Return(nil, model.NewAppError("GetGroupByName", "app.group.no_rows", nil, "", http.StatusNotFound))
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+		require.NoError(t, plugin.configuration.IsValid())
+
+		return f, plugin
+	}
+
+	t.Run("preserved by default", func(t *testing.T) {
+		f, plugin := setup(&configuration{})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.NotContains(t, resp.Text, "Warning")
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == f.replies[0].Message
+		}))
+		f.api.AssertNotCalled(t, "GetGroupByName", mock.Anything)
+	})
+
+	t.Run("neutralized", func(t *testing.T) {
+		f, plugin := setup(&configuration{GroupMentions: groupMentionsNeutralize})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.NotContains(t, resp.Text, "Warning")
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "@\u200bdevs @\u200bLegacy and @bob, please review. `@ops` @channel"
+		}))
+		f.api.AssertNotCalled(t, "GetGroupByName", "ops")
+		f.api.AssertNotCalled(t, "GetGroupByName", "channel")
+	})
+
+	t.Run("warned about", func(t *testing.T) {
+		f, plugin := setup(&configuration{GroupMentions: groupMentionsWarn})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "\nWarning: the moved messages mention user groups whose members may have been notified again: `@devs`\n")
+		assert.Contains(t, resp.Text, "\nWarning: the moved messages mention user groups that won't resolve in the target channel: `@legacy` (the group can't be mentioned)\n")
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == f.replies[0].Message
+		}))
+	})
+
+	t.Run("neutralized along with the other mentions", func(t *testing.T) {
+		f, plugin := setup(&configuration{MentionPolicy: mentionPolicySuppressAll, GroupMentions: groupMentionsWarn})
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.NotContains(t, resp.Text, "Warning")
+		f.api.AssertNotCalled(t, "GetGroupByName", mock.Anything)
+	})
+}
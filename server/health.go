@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const profileImagePath = "assets/profile.png"
+
+// checkProfileImage verifies that the bundled bot profile image is readable.
+func (p *Plugin) checkProfileImage() error {
+	bundlePath, err := p.API.GetBundlePath()
+	if err != nil {
+		return errors.Wrap(err, "unable to get bundle path")
+	}
+
+	img, err := os.Open(filepath.Join(bundlePath, profileImagePath))
+	if err != nil {
+		return errors.Wrap(err, "unable to open profile image")
+	}
+	defer img.Close()
+
+	return nil
+}
+
+// checkBot verifies that the Wrangler bot account exists and is active.
+func (p *Plugin) checkBot() error {
+	bot, appErr := p.API.GetBot(p.BotUserID, false)
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to get Wrangler bot")
+	}
+	if bot.DeleteAt != 0 {
+		return errors.New("the Wrangler bot account is deactivated")
+	}
+
+	return nil
+}
+
+// pluginHealth describes the problems found when the plugin was activated.
+type pluginHealth struct {
+	// issues lists the problems found. The plugin runs in a degraded state
+	// when it isn't empty.
+	issues []string
+
+	// profileImageAvailable is set when the bot profile image asset could be
+	// read.
+	profileImageAvailable bool
+}
+
+// setHealth replaces the health state of the plugin.
+func (p *Plugin) setHealth(health pluginHealth) {
+	p.healthLock.Lock()
+	defer p.healthLock.Unlock()
+
+	p.health = health
+}
+
+// getHealth returns the health state found when the plugin was activated.
+func (p *Plugin) getHealth() pluginHealth {
+	p.healthLock.RLock()
+	defer p.healthLock.RUnlock()
+
+	return p.health
+}
+
+// isDegraded returns true if problems were found when the plugin was
+// activated.
+func (p *Plugin) isDegraded() bool {
+	return len(p.getHealth().issues) != 0
+}
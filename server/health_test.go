@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivationHealthChecks(t *testing.T) {
+	setup := func(t *testing.T, withAsset bool) (*Plugin, *plugintest.Helpers) {
+		bundlePath, err := ioutil.TempDir("", "wrangler")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(bundlePath) })
+
+		if withAsset {
+			require.NoError(t, os.MkdirAll(filepath.Join(bundlePath, "assets"), 0700))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(bundlePath, profileImagePath), []byte("png"), 0600))
+		}
+
+		botID := model.NewId()
+		api := &plugintest.API{}
+		mockLogs(api)
+		api.On("GetBundlePath").Return(bundlePath, nil)
+		api.On("GetBot", botID, false).Return(&model.Bot{UserId: botID}, nil)
+		api.On("RegisterCommand", mock.Anything).Return(nil)
+
+		helpers := &plugintest.Helpers{}
+		helpers.On("EnsureBot", mock.Anything).Return(botID, nil)
+		helpers.On("EnsureBot", mock.Anything, mock.Anything).Return(botID, nil)
+
+		p := &Plugin{}
+		p.SetAPI(api)
+		p.SetHelpers(helpers)
+		t.Cleanup(func() { require.NoError(t, p.OnDeactivate()) })
+
+		return p, helpers
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		p, helpers := setup(t, true)
+		require.NoError(t, p.OnActivate())
+
+		assert.False(t, p.isDegraded())
+		assert.True(t, p.getHealth().profileImageAvailable)
+		require.Len(t, helpers.Calls, 1)
+		assert.Len(t, helpers.Calls[0].Arguments, 2)
+	})
+
+	t.Run("missing profile image", func(t *testing.T) {
+		p, helpers := setup(t, false)
+		require.NoError(t, p.OnActivate())
+
+		assert.True(t, p.isDegraded())
+		require.Len(t, p.getHealth().issues, 1)
+		assert.Contains(t, p.getHealth().issues[0], "bot profile image is unavailable")
+		require.Len(t, helpers.Calls, 1)
+		assert.Len(t, helpers.Calls[0].Arguments, 1)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeProfileImage, nil)
+		status, err := p.handleProfileImage(w, r)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+	})
+}
+
+func TestCheckBot(t *testing.T) {
+	botID := model.NewId()
+	api := &plugintest.API{}
+	api.On("GetBot", botID, false).Return(&model.Bot{UserId: botID, DeleteAt: 1}, nil)
+
+	p := &Plugin{BotUserID: botID}
+	p.SetAPI(api)
+
+	require.Error(t, p.checkBot())
+}
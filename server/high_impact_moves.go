@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const highImpactMoveDigestKey = "high_impact_move_digest"
+
+// highImpactMoveDigestCheckInterval is how often the buffered high-impact
+// moves are checked for a digest that is due.
+const highImpactMoveDigestCheckInterval = time.Minute
+
+// highImpactMoveEvent is a high-impact move waiting to be posted in the next
+// digest. Channels are stored as they are displayed in the notice.
+type highImpactMoveEvent struct {
+	UserID     string `json:"user_id"`
+	PostLink   string `json:"post_link"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	NumPosts   int    `json:"num_posts"`
+	NumAuthors int    `json:"num_authors"`
+	Reasons    string `json:"reasons"`
+	MovedAt    int64  `json:"moved_at"`
+}
+
+// highImpactMoveReasons returns why a move of the given number of messages and
+// authors between the two channels is high-impact. No reasons means the move
+// is below the configured thresholds.
+func highImpactMoveReasons(config *configuration, originalChannel, targetChannel *model.Channel, numPosts, numAuthors int) []string {
+	var reasons []string
+	if len(originalChannel.TeamId) != 0 && len(targetChannel.TeamId) != 0 && originalChannel.TeamId != targetChannel.TeamId {
+		reasons = append(reasons, "moved to another team")
+	}
+	postThreshold := config.HighImpactMovePostThresholdInt()
+	if postThreshold != 0 && numPosts > postThreshold {
+		reasons = append(reasons, fmt.Sprintf("more than %d messages", postThreshold))
+	}
+	authorThreshold := config.HighImpactMoveAuthorThresholdInt()
+	if authorThreshold != 0 && numAuthors > authorThreshold {
+		reasons = append(reasons, fmt.Sprintf("more than %d authors", authorThreshold))
+	}
+
+	return reasons
+}
+
+// notifyHighImpactMove posts a notice of the move to the configured monitoring
+// channel when the move is high-impact. Failing to post the notice doesn't
+// fail the move, so errors are only logged.
+func (p *Plugin) notifyHighImpactMove(originalChannel, targetChannel *model.Channel, numPosts, numAuthors int, userID, newPostLink string) {
+	config := p.getConfiguration()
+	if len(config.HighImpactMoveChannelID) == 0 {
+		return
+	}
+	reasons := highImpactMoveReasons(config, originalChannel, targetChannel, numPosts, numAuthors)
+	if len(reasons) == 0 {
+		return
+	}
+
+	event := highImpactMoveEvent{
+		UserID:     userID,
+		PostLink:   newPostLink,
+		From:       p.getChannelDisplay(originalChannel),
+		To:         p.getChannelDisplay(targetChannel),
+		NumPosts:   numPosts,
+		NumAuthors: numAuthors,
+		Reasons:    strings.Join(reasons, ", "),
+		MovedAt:    now().UnixNano(),
+	}
+	if config.HighImpactMoveDigestInterval() != 0 {
+		p.bufferHighImpactMove(event)
+		return
+	}
+
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: config.HighImpactMoveChannelID,
+		Message: fmt.Sprintf(
+			"#### High-impact thread move\n\n%s moved a thread: %s\n\n| From | To | Messages | Authors | Reason |\n| -- | -- | -- | -- | -- |\n| %s | %s | %d | %d | %s |",
+			p.getUserMention(userID), newPostLink,
+			event.From, event.To, numPosts, numAuthors, event.Reasons,
+		),
+	})
+	if appErr != nil {
+		p.API.LogError("Unable to post high-impact move notice",
+			"error", appErr.Error(),
+			"user_id", userID,
+			"channel_id", config.HighImpactMoveChannelID,
+		)
+	}
+}
+
+func unmarshalHighImpactMoveEvents(data []byte) ([]highImpactMoveEvent, error) {
+	var events []highImpactMoveEvent
+	if data == nil {
+		return events, nil
+	}
+
+	err := json.Unmarshal(data, &events)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal high-impact move digest")
+	}
+
+	return events, nil
+}
+
+// bufferHighImpactMove stores a high-impact move for the next digest. Errors
+// are logged as they must not fail the move itself.
+func (p *Plugin) bufferHighImpactMove(event highImpactMoveEvent) {
+	err := p.kvAtomicModify(highImpactMoveDigestKey, func(initial []byte) ([]byte, error) {
+		events, err := unmarshalHighImpactMoveEvents(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(append(events, event))
+	})
+	if err != nil {
+		p.API.LogError("Unable to buffer high-impact move for the digest",
+			"error", err.Error(),
+			"user_id", event.UserID,
+		)
+	}
+}
+
+// takeDueHighImpactMoves removes the buffered high-impact moves from the store
+// and returns them once the digest interval has passed since the oldest of
+// them. When digests are disabled, any buffered moves are due right away.
+// Taking them atomically ensures that each move is only reported once when
+// several servers post digests.
+func (p *Plugin) takeDueHighImpactMoves() ([]highImpactMoveEvent, error) {
+	// Nothing is stored before the first buffered move.
+	data, appErr := p.API.KVGet(highImpactMoveDigestKey)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "unable to get high-impact move digest")
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	interval := p.getConfiguration().HighImpactMoveDigestInterval()
+	var due []highImpactMoveEvent
+	err := p.kvAtomicModify(highImpactMoveDigestKey, func(initial []byte) ([]byte, error) {
+		events, err := unmarshalHighImpactMoveEvents(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		due = nil
+		if len(events) == 0 || now().Before(time.Unix(0, events[0].MovedAt).Add(interval)) {
+			return json.Marshal(events)
+		}
+		due = events
+
+		return json.Marshal([]highImpactMoveEvent{})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to take high-impact move digest")
+	}
+
+	return due, nil
+}
+
+// postHighImpactMoveDigest posts a single notice of the buffered high-impact
+// moves to the monitoring channel once the digest is due.
+func (p *Plugin) postHighImpactMoveDigest() {
+	events, err := p.takeDueHighImpactMoves()
+	if err != nil {
+		p.API.LogError("Unable to process high-impact move digest", "error", err.Error())
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	channelID := p.getConfiguration().HighImpactMoveChannelID
+	if len(channelID) == 0 {
+		p.API.LogInfo("Dropping high-impact move digest since no monitoring channel is configured", "move_count", len(events))
+		return
+	}
+
+	msg := fmt.Sprintf(
+		"#### High-impact thread moves\n\n%d high-impact thread move(s) since %s:\n\n| Moved by | Thread | From | To | Messages | Authors | Reason |\n| -- | -- | -- | -- | -- | -- | -- |\n",
+		len(events), time.Unix(0, events[0].MovedAt).UTC().Format(time.RFC1123),
+	)
+	for _, event := range events {
+		msg += fmt.Sprintf("| %s | %s | %s | %s | %d | %d | %s |\n",
+			p.getUserMention(event.UserID), event.PostLink, event.From, event.To,
+			event.NumPosts, event.NumAuthors, event.Reasons,
+		)
+	}
+
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		Message:   msg,
+	})
+	if appErr != nil {
+		p.API.LogError("Unable to post high-impact move digest",
+			"error", appErr.Error(),
+			"channel_id", channelID,
+			"move_count", len(events),
+		)
+	}
+}
+
+// startHighImpactMoveDigests periodically posts the high-impact move digest
+// once it is due until stopHighImpactMoveDigests is called.
+func (p *Plugin) startHighImpactMoveDigests() {
+	p.highImpactDigestStop = make(chan struct{})
+	p.highImpactDigestDone = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(highImpactMoveDigestCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.postHighImpactMoveDigest()
+			case <-stop:
+				return
+			}
+		}
+	}(p.highImpactDigestStop, p.highImpactDigestDone)
+}
+
+// stopHighImpactMoveDigests stops the digests and waits for them to exit.
+func (p *Plugin) stopHighImpactMoveDigests() {
+	if p.highImpactDigestStop == nil {
+		return
+	}
+
+	close(p.highImpactDigestStop)
+	<-p.highImpactDigestDone
+	p.highImpactDigestStop = nil
+}
+
+// getChannelDisplay returns the channel name prefixed with the name of its
+// team, which keeps channels of different teams apart in the notice.
+func (p *Plugin) getChannelDisplay(channel *model.Channel) string {
+	if len(channel.TeamId) == 0 {
+		return channel.Name
+	}
+	team, appErr := p.API.GetTeam(channel.TeamId)
+	if appErr != nil {
+		return channel.Name
+	}
+
+	return team.Name + "/" + channel.Name
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighImpactMoveReasons(t *testing.T) {
+	teamID := model.NewId()
+	channel := &model.Channel{Id: model.NewId(), TeamId: teamID}
+	sameTeamChannel := &model.Channel{Id: model.NewId(), TeamId: teamID}
+	otherTeamChannel := &model.Channel{Id: model.NewId(), TeamId: model.NewId()}
+	directChannel := &model.Channel{Id: model.NewId(), Type: model.CHANNEL_DIRECT}
+	config := &configuration{HighImpactMovePostThreshold: "10", HighImpactMoveAuthorThreshold: "3"}
+
+	t.Run("below thresholds", func(t *testing.T) {
+		assert.Empty(t, highImpactMoveReasons(config, channel, sameTeamChannel, 10, 3))
+	})
+
+	t.Run("no thresholds", func(t *testing.T) {
+		assert.Empty(t, highImpactMoveReasons(&configuration{}, channel, sameTeamChannel, 1000, 100))
+	})
+
+	t.Run("direct message to a team channel", func(t *testing.T) {
+		assert.Empty(t, highImpactMoveReasons(config, directChannel, channel, 2, 2))
+	})
+
+	t.Run("every reason", func(t *testing.T) {
+		assert.Equal(t, []string{"moved to another team", "more than 10 messages", "more than 3 authors"},
+			highImpactMoveReasons(config, channel, otherTeamChannel, 11, 4))
+	})
+}
+
+func TestMoveThreadHighImpactNotice(t *testing.T) {
+	f := newThreadTestFixture(2)
+	monitoringChannelID := model.NewId()
+	isNotice := func(post *model.Post) bool {
+		return post.ChannelId == monitoringChannelID
+	}
+
+	var plugin Plugin
+	plugin.SetAPI(f.api)
+
+	t.Run("below threshold", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{HighImpactMoveChannelID: monitoringChannelID, HighImpactMovePostThreshold: "3"})
+
+		_, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		f.api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(isNotice))
+	})
+
+	t.Run("above threshold", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{HighImpactMoveChannelID: monitoringChannelID, HighImpactMovePostThreshold: "2"})
+
+		_, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return isNotice(post) &&
+				strings.Contains(post.Message, "@active.user moved a thread: test.sampledomain.com/team-1/pl/") &&
+				strings.Contains(post.Message, "| team-1/original-channel | team-1/target-channel | 3 | 3 | more than 2 messages |")
+		}))
+	})
+}
+
+func TestHighImpactMoveDigest(t *testing.T) {
+	currentTime := time.Now()
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	f := newThreadTestFixture(2)
+	monitoringChannelID := model.NewId()
+	isNotice := func(post *model.Post) bool {
+		return post.ChannelId == monitoringChannelID
+	}
+
+	var plugin Plugin
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{
+		HighImpactMoveChannelID:     monitoringChannelID,
+		HighImpactMovePostThreshold: "2",
+		HighImpactMoveDigestMinutes: "60",
+	})
+
+	for i := 0; i < 2; i++ {
+		_, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+	}
+	f.api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(isNotice))
+
+	t.Run("not due yet", func(t *testing.T) {
+		currentTime = currentTime.Add(59 * time.Minute)
+		plugin.postHighImpactMoveDigest()
+		f.api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(isNotice))
+	})
+
+	t.Run("due", func(t *testing.T) {
+		currentTime = currentTime.Add(time.Minute)
+		plugin.postHighImpactMoveDigest()
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return isNotice(post) &&
+				strings.Contains(post.Message, "2 high-impact thread move(s) since") &&
+				strings.Count(post.Message, "| @active.user | test.sampledomain.com/team-1/pl/") == 2 &&
+				strings.Contains(post.Message, "| team-1/original-channel | team-1/target-channel | 3 | 3 | more than 2 messages |")
+		}))
+		var notices int
+		for _, call := range f.api.Calls {
+			if call.Method == "CreatePost" && isNotice(call.Arguments.Get(0).(*model.Post)) {
+				notices++
+			}
+		}
+		assert.Equal(t, 1, notices)
+
+		events, err := plugin.takeDueHighImpactMoves()
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// historyCSVFlushRows is how many rows of the history export are written
+// between flushes of the response.
+const historyCSVFlushRows = 100
+
+// historyCSVHeader are the columns of the operation history export.
+var historyCSVHeader = []string{"timestamp", "actor_user_id", "operation", "source_channel_id", "target_channel_id", "post_count", "outcome"}
+
+// historyOutcomeCompleted is the outcome of every exported operation, since
+// only completed operations are recorded in the history.
+const historyOutcomeCompleted = "completed"
+
+// parseHistoryExportRange returns the date range of the after and before
+// query parameters, which take the same values as the --after and --before
+// flags.
+func parseHistoryExportRange(query url.Values) (dateRange, error) {
+	var r dateRange
+	var err error
+
+	r.after, err = parseDateRangeTime(flagAfter, query.Get(flagAfter))
+	if err != nil {
+		return r, errors.Errorf("%s query parameter %s must be a date like 2020-06-01 or an RFC 3339 time like 2020-06-01T15:04:05Z", flagAfter, query.Get(flagAfter))
+	}
+	r.before, err = parseDateRangeTime(flagBefore, query.Get(flagBefore))
+	if err != nil {
+		return r, errors.Errorf("%s query parameter %s must be a date like 2020-06-01 or an RFC 3339 time like 2020-06-01T15:04:05Z", flagBefore, query.Get(flagBefore))
+	}
+	if !r.after.IsZero() && !r.before.IsZero() && !r.before.After(r.after) {
+		return r, errors.Errorf("%s query parameter must be later than %s", flagBefore, flagAfter)
+	}
+
+	return r, nil
+}
+
+// writeOperationHistoryCSV writes the records within the date range as CSV,
+// oldest first. Rows are flushed as they are written so that large histories
+// reach the client without being buffered.
+func writeOperationHistoryCSV(w io.Writer, records []operationRecord, r dateRange) error {
+	flusher, _ := w.(http.Flusher)
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(historyCSVHeader)
+	if err != nil {
+		return errors.Wrap(err, "unable to write CSV header")
+	}
+
+	var written int
+	for _, record := range records {
+		timestamp := time.Unix(0, record.Timestamp).UTC()
+		if !r.includes(timestamp) {
+			continue
+		}
+
+		err = writer.Write([]string{
+			timestamp.Format(time.RFC3339),
+			record.UserID,
+			record.Type,
+			record.SourceChannelID,
+			record.TargetChannelID,
+			strconv.Itoa(record.PostCount),
+			historyOutcomeCompleted,
+		})
+		if err != nil {
+			return errors.Wrap(err, "unable to write CSV row")
+		}
+
+		written++
+		if written%historyCSVFlushRows == 0 {
+			writer.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return errors.Wrap(writer.Error(), "unable to write CSV")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryCSVAPI(t *testing.T) {
+	currentTime := time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	api := &plugintest.API{}
+	newMockKVStore(api)
+	mockLogs(api)
+	api.On("HasPermissionTo", "admin", model.PERMISSION_MANAGE_SYSTEM).Return(true)
+	api.On("HasPermissionTo", "user1", model.PERMISSION_MANAGE_SYSTEM).Return(false)
+
+	plugin := &Plugin{}
+	plugin.SetAPI(api)
+	plugin.setConfiguration(&configuration{StatsWindowDays: "30"})
+
+	require.NoError(t, plugin.kvSetJSON(operationHistoryKey, []operationRecord{
+		{Type: operationMove, UserID: "user1", SourceChannelID: "source1", TargetChannelID: "target1", PostCount: 3, Timestamp: time.Date(2020, 6, 1, 9, 30, 0, 0, time.UTC).UnixNano()},
+		{Type: operationCopy, UserID: "user2", SourceChannelID: "source2", TargetChannelID: "target2", PostCount: 1, Timestamp: time.Date(2020, 6, 10, 8, 0, 0, 0, time.UTC).UnixNano()},
+	}))
+
+	get := func(userID, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAPIHistoryCSV+query, nil)
+		r.Header.Set("Mattermost-User-Id", userID)
+		plugin.serveHTTP(nil, w, r)
+		return w
+	}
+
+	t.Run("admins only", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, get("user1", "").Code)
+	})
+
+	t.Run("whole history", func(t *testing.T) {
+		w := get("admin", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Equal(t, strings.Join([]string{
+			"timestamp,actor_user_id,operation,source_channel_id,target_channel_id,post_count,outcome",
+			"2020-06-01T09:30:00Z,user1,move,source1,target1,3,completed",
+			"2020-06-10T08:00:00Z,user2,copy,source2,target2,1,completed",
+			"",
+		}, "\n"), w.Body.String())
+	})
+
+	t.Run("date range", func(t *testing.T) {
+		w := get("admin", "?after=2020-06-05&before=2020-06-11")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, strings.Join([]string{
+			"timestamp,actor_user_id,operation,source_channel_id,target_channel_id,post_count,outcome",
+			"2020-06-10T08:00:00Z,user2,copy,source2,target2,1,completed",
+			"",
+		}, "\n"), w.Body.String())
+	})
+
+	t.Run("invalid date range", func(t *testing.T) {
+		w := get("admin", "?after=yesterday")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "after query parameter yesterday must be a date")
+
+		w = get("admin", "?after=2020-06-11&before=2020-06-05")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
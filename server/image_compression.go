@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCompressedImageMaxDimension = 1600
+	minCompressedImageMaxDimension     = 100
+	maxCompressedImageMaxDimension     = 10000
+
+	defaultCompressedImageQuality = 75
+)
+
+// imageCompressor downscales and re-encodes the image attachments of posts
+// that are recreated in archive channels so that they take less storage.
+type imageCompressor struct {
+	maxDimension int
+	quality      int
+	// compressedCount is the number of images that were compressed.
+	compressedCount int
+}
+
+// compress returns the compressed image and true if the file is a JPEG or PNG
+// image that could be made smaller. Other files are returned unchanged along
+// with false. An error is returned if an image can't be decoded or encoded.
+func (c *imageCompressor) compress(data []byte, fileInfo *model.FileInfo) ([]byte, bool, error) {
+	mimeType := strings.ToLower(fileInfo.MimeType)
+	if mimeType != "image/jpeg" && mimeType != "image/png" {
+		return data, false, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return data, false, errors.Wrap(err, "unable to decode image")
+	}
+	img = downscaleImage(img, c.maxDimension)
+
+	var out bytes.Buffer
+	if mimeType == "image/jpeg" {
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: c.quality})
+	} else {
+		// PNG is lossless, so only downscaling makes it smaller.
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		err = encoder.Encode(&out, img)
+	}
+	if err != nil {
+		return data, false, errors.Wrap(err, "unable to encode image")
+	}
+	if out.Len() >= len(data) {
+		return data, false, nil
+	}
+
+	return out.Bytes(), true, nil
+}
+
+// downscaleImage returns the image scaled down with a box filter so that
+// neither side is longer than maxDimension, keeping the aspect ratio. Images
+// that are small enough are returned unchanged.
+func downscaleImage(src image.Image, maxDimension int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxDimension && height <= maxDimension {
+		return src
+	}
+
+	newWidth, newHeight := maxDimension, maxDimension
+	if width > height {
+		newHeight = height * maxDimension / width
+	} else {
+		newWidth = width * maxDimension / height
+	}
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	if newHeight < 1 {
+		newHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := 0; y < newHeight; y++ {
+		srcMinY := bounds.Min.Y + y*height/newHeight
+		srcMaxY := bounds.Min.Y + (y+1)*height/newHeight
+		for x := 0; x < newWidth; x++ {
+			srcMinX := bounds.Min.X + x*width/newWidth
+			srcMaxX := bounds.Min.X + (x+1)*width/newWidth
+
+			var r, g, b, a, count uint64
+			for sy := srcMinY; sy < srcMaxY; sy++ {
+				for sx := srcMinX; sx < srcMaxX; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					count++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / count >> 8),
+				G: uint8(g / count >> 8),
+				B: uint8(b / count >> 8),
+				A: uint8(a / count >> 8),
+			})
+		}
+	}
+
+	return dst
+}
+
+// getCompressedImagesNote returns a note for the bot message of a moved thread
+// when some of its images were compressed.
+func getCompressedImagesNote(compression *imageCompressor) string {
+	if compression == nil || compression.compressedCount == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\n\n%d image(s) were compressed to save storage; the original files were removed along with the original messages.", compression.compressedCount)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: 255})
+		}
+	}
+
+	return img
+}
+
+func encodeTestPNG(t *testing.T, img image.Image, level png.CompressionLevel) []byte {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: level}
+	require.NoError(t, encoder.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestImageCompressorCompress(t *testing.T) {
+	compressor := &imageCompressor{maxDimension: 100, quality: 50}
+
+	t.Run("large png is downscaled", func(t *testing.T) {
+		data := encodeTestPNG(t, newTestImage(400, 200), png.NoCompression)
+
+		compressed, ok, err := compressor.compress(data, &model.FileInfo{MimeType: "image/png"})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Less(t, len(compressed), len(data))
+
+		config, err := png.DecodeConfig(bytes.NewReader(compressed))
+		require.NoError(t, err)
+		assert.Equal(t, 100, config.Width)
+		assert.Equal(t, 50, config.Height)
+	})
+
+	t.Run("jpeg is re-encoded", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, jpeg.Encode(&buf, newTestImage(50, 200), &jpeg.Options{Quality: 100}))
+		data := buf.Bytes()
+
+		compressed, ok, err := compressor.compress(data, &model.FileInfo{MimeType: "image/jpeg"})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Less(t, len(compressed), len(data))
+
+		config, err := jpeg.DecodeConfig(bytes.NewReader(compressed))
+		require.NoError(t, err)
+		assert.Equal(t, 25, config.Width)
+		assert.Equal(t, 100, config.Height)
+	})
+
+	t.Run("small image is kept", func(t *testing.T) {
+		data := encodeTestPNG(t, newTestImage(10, 10), png.BestCompression)
+
+		compressed, ok, err := compressor.compress(data, &model.FileInfo{MimeType: "image/png"})
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, data, compressed)
+	})
+
+	t.Run("other files are kept", func(t *testing.T) {
+		data := []byte("not an image")
+
+		compressed, ok, err := compressor.compress(data, &model.FileInfo{MimeType: "text/plain"})
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, data, compressed)
+	})
+
+	t.Run("invalid image", func(t *testing.T) {
+		data := []byte("not an image")
+
+		compressed, ok, err := compressor.compress(data, &model.FileInfo{MimeType: "image/png"})
+		require.Error(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, data, compressed)
+	})
+}
+
+func TestMoveThreadCompressesArchivedImages(t *testing.T) {
+	f := newThreadTestFixture(1)
+	f.replies[0].FileIds = model.StringArray{model.NewId()}
+	data := encodeTestPNG(t, newTestImage(400, 200), png.NoCompression)
+
+	f.api.On("GetFileInfo", f.replies[0].FileIds[0]).Return(&model.FileInfo{Name: "image.png", MimeType: "image/png"}, nil)
+	f.api.On("GetFile", f.replies[0].FileIds[0]).Return(data, nil)
+	f.api.On("UploadFile", mock.Anything, f.targetChannel.Id, "image.png").Return(&model.FileInfo{Id: model.NewId()}, nil)
+
+	plugin := &Plugin{}
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{
+		DefaultArchiveChannelID:     f.targetChannel.Id,
+		CompressArchivedImages:      true,
+		CompressedImageMaxDimension: "100",
+	})
+	require.NoError(t, plugin.configuration.IsValid())
+
+	resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+	assert.Contains(t, resp.Text, "A thread has been moved")
+
+	f.api.AssertCalled(t, "UploadFile", mock.MatchedBy(func(uploaded []byte) bool {
+		return len(uploaded) < len(data)
+	}), f.targetChannel.Id, "image.png")
+	f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.Message == "This thread was moved from another channel\n\n1 image(s) were compressed to save storage; the original files were removed along with the original messages."
+	}))
+}
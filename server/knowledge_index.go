@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const knowledgeIndexKeyPrefix = "knowledge_index_"
+
+// knowledgeIndexHeader starts every knowledge index post.
+const knowledgeIndexHeader = "#### Index\n"
+
+// maxKnowledgeIndexTitleRunes bounds the length of the title of an entry.
+const maxKnowledgeIndexTitleRunes = 100
+
+// knowledgeIndex is the pinned post of a knowledge channel that lists the
+// threads moved into it.
+type knowledgeIndex struct {
+	PostID  string                `json:"post_id"`
+	Entries []knowledgeIndexEntry `json:"entries"`
+}
+
+type knowledgeIndexEntry struct {
+	Title string `json:"title"`
+	Link  string `json:"link"`
+}
+
+func getKnowledgeIndexKey(channelID string) string {
+	return knowledgeIndexKeyPrefix + channelID
+}
+
+// firstMessageLine returns the first non-empty line of a message without the
+// markdown heading markers, or an empty string when there is none.
+func firstMessageLine(message string) string {
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "# "))
+		if len(line) != 0 {
+			return line
+		}
+	}
+
+	return ""
+}
+
+// knowledgeIndexTitle returns the title of a thread in the index, which is
+// the first non-empty line of its root message.
+func knowledgeIndexTitle(message string) string {
+	title := firstMessageLine(message)
+	if len(title) == 0 {
+		return "Untitled thread"
+	}
+	if utf8.RuneCountInString(title) > maxKnowledgeIndexTitleRunes {
+		title = string([]rune(title)[:maxKnowledgeIndexTitleRunes]) + "..."
+	}
+
+	// Brackets would end the link text early.
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(title)
+}
+
+// renderKnowledgeIndex returns the message of the index post with the entries
+// sorted by title. Entries that don't fit in a single message are left out
+// and counted instead.
+func renderKnowledgeIndex(entries []knowledgeIndexEntry) string {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Title) < strings.ToLower(entries[j].Title)
+	})
+
+	message := knowledgeIndexHeader
+	for i, entry := range entries {
+		line := fmt.Sprintf("\n- [%s](%s)", entry.Title, entry.Link)
+		omitted := fmt.Sprintf("\n\n...and %d more thread(s)", len(entries)-i)
+		if utf8.RuneCountInString(message+line+omitted) > model.POST_MESSAGE_MAX_RUNES_V2 {
+			return message + omitted
+		}
+		message += line
+	}
+
+	return message
+}
+
+// addKnowledgeIndexEntry adds the moved thread to the index post of the
+// knowledge channel. The index post is created and pinned when there is none
+// yet or when it was deleted.
+func (p *Plugin) addKnowledgeIndexEntry(channelID, rootMessage, threadLink string) error {
+	var index knowledgeIndex
+	_, err := p.kvGetJSON(getKnowledgeIndexKey(channelID), &index)
+	if err != nil {
+		return errors.Wrap(err, "unable to get knowledge index")
+	}
+	index.Entries = append(index.Entries, knowledgeIndexEntry{
+		Title: knowledgeIndexTitle(rootMessage),
+		Link:  threadLink,
+	})
+	message := renderKnowledgeIndex(index.Entries)
+
+	var indexPost *model.Post
+	if len(index.PostID) != 0 {
+		post, appErr := p.API.GetPost(index.PostID)
+		if appErr == nil && post.DeleteAt == 0 && post.ChannelId == channelID {
+			indexPost = post
+		}
+	}
+
+	if indexPost != nil {
+		indexPost.Message = message
+		_, appErr := p.API.UpdatePost(indexPost)
+		if appErr != nil {
+			return errors.Wrap(appErr, "unable to update knowledge index post")
+		}
+	} else {
+		newPost, appErr := p.API.CreatePost(&model.Post{
+			UserId:    p.BotUserID,
+			ChannelId: channelID,
+			Message:   message,
+			IsPinned:  true,
+		})
+		if appErr != nil {
+			return errors.Wrap(appErr, "unable to create knowledge index post")
+		}
+		index.PostID = newPost.Id
+	}
+
+	return p.kvSetJSON(getKnowledgeIndexKey(channelID), index)
+}
+
+// updateMoveKnowledgeIndex adds the moved thread to the index of the target
+// channel if it is a knowledge channel and returns a note for the move
+// summary.
+func (p *Plugin) updateMoveKnowledgeIndex(targetChannel *model.Channel, rootMessage, userID, newPostLink string) string {
+	if !p.getConfiguration().IsKnowledgeChannel(targetChannel.Id) {
+		return ""
+	}
+
+	err := p.addKnowledgeIndexEntry(targetChannel.Id, rootMessage, newPostLink)
+	if err != nil {
+		p.API.LogError("Unable to update knowledge index",
+			"error", err.Error(),
+			"user_id", userID,
+			"channel_id", targetChannel.Id,
+		)
+		return "\nThe thread was moved, but it could not be added to the index of the channel.\n"
+	}
+
+	return "\nThe thread was added to the index of the channel.\n"
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnowledgeIndexTitle(t *testing.T) {
+	assert.Equal(t, "How do I reset my password?", knowledgeIndexTitle("\n## How do I reset my password?\nI'm locked out"))
+	assert.Equal(t, "Use \\[brackets\\]", knowledgeIndexTitle("Use [brackets]"))
+	assert.Equal(t, "Untitled thread", knowledgeIndexTitle("  \n"))
+	assert.Equal(t, strings.Repeat("a", maxKnowledgeIndexTitleRunes)+"...", knowledgeIndexTitle(strings.Repeat("a", maxKnowledgeIndexTitleRunes+1)))
+}
+
+func TestRenderKnowledgeIndex(t *testing.T) {
+	t.Run("sorted by title", func(t *testing.T) {
+		message := renderKnowledgeIndex([]knowledgeIndexEntry{
+			{Title: "beta", Link: "link-b"},
+			{Title: "Alpha", Link: "link-a"},
+			{Title: "gamma", Link: "link-c"},
+		})
+		assert.Equal(t, "#### Index\n\n- [Alpha](link-a)\n- [beta](link-b)\n- [gamma](link-c)", message)
+	})
+
+	t.Run("too many entries", func(t *testing.T) {
+		var entries []knowledgeIndexEntry
+		for i := 0; i < 200; i++ {
+			entries = append(entries, knowledgeIndexEntry{Title: strings.Repeat("a", maxKnowledgeIndexTitleRunes), Link: "link"})
+		}
+		message := renderKnowledgeIndex(entries)
+		assert.LessOrEqual(t, len(message), model.POST_MESSAGE_MAX_RUNES_V2)
+		assert.Contains(t, message, "more thread(s)")
+	})
+}
+
+func TestMoveThreadKnowledgeIndex(t *testing.T) {
+	f := newThreadTestFixture(1)
+	f.rootPost.Message = "Why is the build failing?\nIt fails on CI"
+	var updatedIndex *model.Post
+	f.api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(func(post *model.Post) *model.Post {
+		updatedIndex = post.Clone()
+		return post
+	}, nil)
+
+	plugin := &Plugin{BotUserID: model.NewId()}
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{KnowledgeChannels: model.NewId() + ", " + f.targetChannel.Id})
+	newPostLink := makePostLink(*f.config.ServiceSettings.SiteURL, f.team.Name, f.newPost.Id)
+
+	t.Run("index post is created and pinned", func(t *testing.T) {
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "The thread was added to the index of the channel.")
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == f.targetChannel.Id && post.UserId == plugin.BotUserID && post.IsPinned &&
+				post.Message == "#### Index\n\n- [Why is the build failing?]("+newPostLink+")"
+		}))
+
+		var index knowledgeIndex
+		found, err := plugin.kvGetJSON(getKnowledgeIndexKey(f.targetChannel.Id), &index)
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, f.newPost.Id, index.PostID)
+	})
+
+	t.Run("index post is updated", func(t *testing.T) {
+		indexPost := &model.Post{Id: f.newPost.Id, ChannelId: f.targetChannel.Id}
+		f.api.On("GetPost", f.newPost.Id).Return(indexPost, nil)
+		f.rootPost.Message = "Deploying to staging"
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "The thread was added to the index of the channel.")
+		require.NotNil(t, updatedIndex)
+		assert.Equal(t, "#### Index\n\n- [Deploying to staging]("+newPostLink+")\n- [Why is the build failing?]("+newPostLink+")", updatedIndex.Message)
+	})
+
+	t.Run("moves into an existing thread and consolidated moves are indexed", func(t *testing.T) {
+		intoRootPost := &model.Post{Id: model.NewId(), ChannelId: f.targetChannel.Id, Message: "Existing discussion"}
+		f.api.On("GetPost", intoRootPost.Id).Return(intoRootPost, nil)
+
+		for _, args := range [][]string{{"--into-thread", intoRootPost.Id}, {"--consolidate"}} {
+			resp, isUserError, err := plugin.runMoveThreadCommand(append([]string{f.rootPost.Id, f.targetChannel.Id}, args...), f.commandArgs())
+			require.NoError(t, err)
+			assert.False(t, isUserError)
+			assert.Contains(t, resp.Text, "The thread was added to the index of the channel.", args[0])
+		}
+	})
+
+	t.Run("other channels have no index", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{})
+
+		resp, _, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.NotContains(t, resp.Text, "index")
+	})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// kvAtomicModifyAttempts is the number of times an atomic KV update is
+// retried when the stored value changes underneath it.
+const kvAtomicModifyAttempts = 5
+
+// kvListPageSize is the number of keys fetched per page when listing keys.
+const kvListPageSize = 100
+
+// kvListKeysWithPrefix returns all stored keys that start with the prefix.
+func (p *Plugin) kvListKeysWithPrefix(prefix string) ([]string, error) {
+	var keys []string
+	for page := 0; ; page++ {
+		pageKeys, appErr := p.API.KVList(page, kvListPageSize)
+		if appErr != nil {
+			return nil, errors.Wrap(appErr, "unable to list KV keys")
+		}
+		for _, key := range pageKeys {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		if len(pageKeys) < kvListPageSize {
+			return keys, nil
+		}
+	}
+}
+
+// kvGetJSON loads the value stored under the given key into v. It returns
+// false if no value is stored under the key.
+func (p *Plugin) kvGetJSON(key string, v interface{}) (bool, error) {
+	data, appErr := p.API.KVGet(key)
+	if appErr != nil {
+		return false, errors.Wrapf(appErr, "unable to get KV value for key %s", key)
+	}
+	if data == nil {
+		return false, nil
+	}
+
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to unmarshal KV value for key %s", key)
+	}
+
+	return true, nil
+}
+
+// kvSetJSON stores v under the given key.
+func (p *Plugin) kvSetJSON(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal KV value for key %s", key)
+	}
+
+	appErr := p.API.KVSet(key, data)
+	if appErr != nil {
+		return errors.Wrapf(appErr, "unable to set KV value for key %s", key)
+	}
+
+	return nil
+}
+
+// kvAtomicModify updates the value stored under the given key with the result
+// of modify. The update only succeeds if the stored value was not changed by
+// someone else in the meantime, which is retried a few times before giving up.
+// Any error returned by modify aborts the update and is returned unchanged.
+func (p *Plugin) kvAtomicModify(key string, modify func(initial []byte) ([]byte, error)) error {
+	for i := 0; i < kvAtomicModifyAttempts; i++ {
+		initial, appErr := p.API.KVGet(key)
+		if appErr != nil {
+			return errors.Wrapf(appErr, "unable to get KV value for key %s", key)
+		}
+
+		updated, err := modify(initial)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(key, initial, updated)
+		if appErr != nil {
+			return errors.Wrapf(appErr, "unable to set KV value for key %s", key)
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.Errorf("unable to update KV value for key %s after %d attempts", key, kvAtomicModifyAttempts)
+}
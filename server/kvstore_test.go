@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockKVStore is an in-memory KV store backing the KV methods of a mocked
+// plugin API.
+type mockKVStore struct {
+	lock sync.Mutex
+	data map[string][]byte
+}
+
+func newMockKVStore(api *plugintest.API) *mockKVStore {
+	store := &mockKVStore{data: make(map[string][]byte)}
+
+	api.On("KVGet", mock.AnythingOfType("string")).Return(
+		func(key string) []byte {
+			store.lock.Lock()
+			defer store.lock.Unlock()
+			return store.data[key]
+		},
+		nil,
+	)
+	api.On("KVSet", mock.AnythingOfType("string"), mock.Anything).Return(
+		func(key string, value []byte) *model.AppError {
+			store.lock.Lock()
+			defer store.lock.Unlock()
+			store.data[key] = value
+			return nil
+		},
+	)
+	api.On("KVSetWithExpiry", mock.AnythingOfType("string"), mock.Anything, mock.AnythingOfType("int64")).Return(
+		func(key string, value []byte, expireInSeconds int64) *model.AppError {
+			store.lock.Lock()
+			defer store.lock.Unlock()
+			store.data[key] = value
+			return nil
+		},
+	)
+	api.On("KVCompareAndSet", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(
+		func(key string, oldValue, newValue []byte) bool {
+			store.lock.Lock()
+			defer store.lock.Unlock()
+			if !bytes.Equal(store.data[key], oldValue) {
+				return false
+			}
+			store.data[key] = newValue
+			return true
+		},
+		nil,
+	)
+	api.On("KVCompareAndDelete", mock.AnythingOfType("string"), mock.Anything).Return(
+		func(key string, oldValue []byte) bool {
+			store.lock.Lock()
+			defer store.lock.Unlock()
+			value, ok := store.data[key]
+			if !ok || !bytes.Equal(value, oldValue) {
+				return false
+			}
+			delete(store.data, key)
+			return true
+		},
+		nil,
+	)
+	api.On("KVDelete", mock.AnythingOfType("string")).Return(
+		func(key string) *model.AppError {
+			store.lock.Lock()
+			defer store.lock.Unlock()
+			delete(store.data, key)
+			return nil
+		},
+	)
+	api.On("KVList", mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(
+		func(page, perPage int) []string {
+			store.lock.Lock()
+			defer store.lock.Unlock()
+			keys := []string{}
+			for key := range store.data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if page*perPage >= len(keys) {
+				return []string{}
+			}
+			keys = keys[page*perPage:]
+			if len(keys) > perPage {
+				keys = keys[:perPage]
+			}
+			return keys
+		},
+		nil,
+	)
+
+	return store
+}
+
+func TestKVStoreHelpers(t *testing.T) {
+	api := &plugintest.API{}
+	newMockKVStore(api)
+
+	var plugin Plugin
+	plugin.SetAPI(api)
+
+	t.Run("get missing key", func(t *testing.T) {
+		var value []string
+		found, err := plugin.kvGetJSON("missing", &value)
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		require.NoError(t, plugin.kvSetJSON("key", []string{"a", "b"}))
+
+		var value []string
+		found, err := plugin.kvGetJSON("key", &value)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []string{"a", "b"}, value)
+	})
+
+	t.Run("atomic modify", func(t *testing.T) {
+		err := plugin.kvAtomicModify("counter", func(initial []byte) ([]byte, error) {
+			assert.Nil(t, initial)
+			return []byte("1"), nil
+		})
+		require.NoError(t, err)
+
+		err = plugin.kvAtomicModify("counter", func(initial []byte) ([]byte, error) {
+			assert.Equal(t, []byte("1"), initial)
+			return []byte("2"), nil
+		})
+		require.NoError(t, err)
+	})
+}
@@ -48,6 +48,30 @@ const manifestStr = `
         "placeholder": "",
         "default": false
       },
+      {
+        "key": "WebUIPermittedRoles",
+        "display_name": "Web UI Permitted Roles",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of roles, such as system_admin or system_user, that are permitted to see the Wrangler webapp functionality. When empty, the webapp functionality is shown to the same users that can run Wrangler commands.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MovePermittedRoles",
+        "display_name": "Move Permitted Roles",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of roles, such as system_admin or system_user, that are permitted to move and merge threads. When empty, any user that can run Wrangler commands can move threads.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "CopyPermittedRoles",
+        "display_name": "Copy Permitted Roles",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of roles, such as system_admin or system_user, that are permitted to copy threads and pinned messages. When empty, any user that can run Wrangler commands can copy threads.",
+        "placeholder": "",
+        "default": null
+      },
       {
         "key": "CommandAutoCompleteEnable",
         "display_name": "Enable Wrangler Command AutoComplete",
@@ -56,6 +80,38 @@ const manifestStr = `
         "placeholder": "",
         "default": false
       },
+      {
+        "key": "MaintenanceMode",
+        "display_name": "Enable Maintenance Mode",
+        "type": "bool",
+        "help_text": "When enabled, all commands that move, copy, merge, route or attach messages, as well as the admin commands that import the configuration or purge the data of a user, are disabled and respond with a maintenance notice. Read-only commands such as info and list keep working.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "MaintenanceModeAdminBypass",
+        "display_name": "Allow System Admins To Bypass Maintenance Mode",
+        "type": "bool",
+        "help_text": "When enabled, system admins can keep using all Wrangler commands while maintenance mode is enabled.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "CommandChannelID",
+        "display_name": "Command Channel ID",
+        "type": "text",
+        "help_text": "(Optional) The ID of the only channel where Wrangler commands can be run, to centralize and audit their usage. Commands run elsewhere respond with a pointer to this channel. Messages from any channel can still be moved or copied by giving their ID from this channel.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "CommandChannelAdminBypass",
+        "display_name": "Allow System Admins To Bypass The Command Channel",
+        "type": "bool",
+        "help_text": "When enabled, system admins can run Wrangler commands in any channel even when a command channel is set.",
+        "placeholder": "",
+        "default": false
+      },
       {
         "key": "MoveThreadMaxCount",
         "display_name": "Max Thread Count Move Size",
@@ -64,6 +120,30 @@ const manifestStr = `
         "placeholder": "",
         "default": null
       },
+      {
+        "key": "MaxCountBypassUsers",
+        "display_name": "Max Thread Count Bypass Users",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of usernames allowed to move or copy threads larger than the max thread count move size. Every bypass is logged as a warning.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MaxAuthorsPerMove",
+        "display_name": "Max Authors Per Move",
+        "type": "text",
+        "help_text": "The maximum number of distinct authors in a thread that the plugin is allowed to move or merge into another thread. Leave empty or set to 0 for unlimited authors.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MaxMergeSize",
+        "display_name": "Max Merge Size",
+        "type": "text",
+        "help_text": "The maximum number of messages that a thread can have once another thread is merged into it, counting the messages of both threads. This is independent of the Max Thread Count Move Size. Leave empty or set to 0 for unlimited merges.",
+        "placeholder": "",
+        "default": null
+      },
       {
         "key": "MoveThreadToAnotherTeamEnable",
         "display_name": "Enable Moving Threads To Different Teams",
@@ -72,6 +152,22 @@ const manifestStr = `
         "placeholder": "",
         "default": false
       },
+      {
+        "key": "AllowedDestinationTeamIDs",
+        "display_name": "Allowed Destination Teams",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of team IDs that threads may be moved or copied into from other teams. When empty, threads may be moved into any team. Only used when moving threads to different teams is enabled.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "AdminCrossTeamOverride",
+        "display_name": "Enable Admin Cross-Team Override",
+        "type": "bool",
+        "help_text": "When true, system admins can move threads into any existing channel of any team, even when they aren't members of the channel or team and moving threads to different teams is disabled. Every use of the override is logged.",
+        "placeholder": "",
+        "default": false
+      },
       {
         "key": "MoveThreadFromPrivateChannelEnable",
         "display_name": "Enable Moving Threads From Private Channels",
@@ -95,6 +191,856 @@ const manifestStr = `
         "help_text": "Control whether Wrangler is permitted to move message threads from group message channels or not.",
         "placeholder": "",
         "default": false
+      },
+      {
+        "key": "AllowedSourceChannelPattern",
+        "display_name": "Allowed Source Channel Pattern",
+        "type": "text",
+        "help_text": "(Optional) A regex matched against channel names, such as ^(support|triage)-. When set, posts can only be moved out of public and private channels whose name matches. Direct and group message channels are controlled by the settings above. Leave empty to allow every channel.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MoveRepliesOnly",
+        "display_name": "Move Only Thread Replies By Default",
+        "type": "bool",
+        "help_text": "When enabled, moving a thread leaves the root message in the original channel and moves only its replies. A note linking to the new location is left in the original thread. This can be overridden with the --replies-only flag.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "RootOnlyKeepReplies",
+        "display_name": "Keep Replies Of Root-Only Moves",
+        "type": "bool",
+        "help_text": "When enabled, moving a thread with the --root-only flag leaves its replies in the original thread, along with the original root message that they depend on and a note linking to the moved root message. Otherwise, the replies are deleted once the move is confirmed with --confirm-discard-replies.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "OperationTimeoutSeconds",
+        "display_name": "Operation Timeout Seconds",
+        "type": "text",
+        "help_text": "The maximum number of seconds a thread move, copy or merge may run before it is aborted and rolled back. Leave empty or set to 0 for no timeout.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "ChannelAutocompleteIntervalMilliseconds",
+        "display_name": "Channel Autocomplete Interval Milliseconds",
+        "type": "text",
+        "help_text": "The minimum number of milliseconds between the channel lists loaded for the autocomplete of the slash command for each user, up to 60000. The autocomplete is requested on every keystroke, so requests made sooner get the last list again. Set to 0 to load the list on every request.",
+        "placeholder": "",
+        "default": "1000"
+      },
+      {
+        "key": "MaxConcurrentOperations",
+        "display_name": "Max Concurrent Operations",
+        "type": "text",
+        "help_text": "(Optional) The maximum number of thread moves and copies that run at the same time across the server. Further operations are queued and the user is told to wait. Queued operations that can't start within the operation timeout are abandoned without changes. Leave empty for no limit.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MoveDeletionDelaySeconds",
+        "display_name": "Move Deletion Delay Seconds",
+        "type": "text",
+        "help_text": "The number of seconds, up to 30, that a thread move waits after recreating the thread before deleting the original messages, giving clients time to sync the new messages. The wait counts towards the operation timeout. Leave empty or set to 0 for no delay.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "EnableMoveUndoButton",
+        "display_name": "Enable Move Undo Button",
+        "type": "bool",
+        "help_text": "Control whether the user moving a thread is shown an Undo button while the move waits to delete the original messages. The undo window is the Move Deletion Delay Seconds setting, which must be greater than 0. Undoing a move removes the recreated thread and keeps the original messages.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "StatsWindowDays",
+        "display_name": "Stats Window Days",
+        "type": "text",
+        "help_text": "The number of days of moves, copies and merges reported by the stats command, between 1 and 365.",
+        "placeholder": "",
+        "default": "30"
+      },
+      {
+        "key": "HistoryRetentionDays",
+        "display_name": "History Retention Days",
+        "type": "text",
+        "help_text": "The number of days moves, copies and merges are kept in the operation history, between 1 and 3650. Older operations are pruned in the background. It must be at least the stats window. Leave empty to keep operations for the stats window.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "TemporaryResponseMinutes",
+        "display_name": "Temporary Response Minutes",
+        "type": "text",
+        "help_text": "When set, list and move preview responses are sent as messages from the Wrangler bot in your direct message channel with it and deleted after this many minutes, up to 1440. This helps on clients that handle ephemeral messages poorly. Leave empty or set to 0 to send them as ephemeral messages.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "PostCreationConcurrency",
+        "display_name": "Post Creation Concurrency",
+        "type": "text",
+        "help_text": "The number of replies recreated at the same time when moving a thread, between 1 and 20. Higher values speed up large moves at the cost of more load on the server. The root message is always recreated first and replies keep their order.",
+        "placeholder": "",
+        "default": "1"
+      },
+      {
+        "key": "MaxNotificationDMsPerHour",
+        "display_name": "Max Notification DMs Per Hour",
+        "type": "text",
+        "help_text": "The maximum number of notification DMs the Wrangler bot sends across the whole server in any one-hour window. Notifications beyond this limit are skipped and logged, which protects users from notification storms during bulk operations. Leave empty for no limit.",
+        "placeholder": "",
+        "default": "100"
+      },
+      {
+        "key": "MaxMovesPerChannelPerDay",
+        "display_name": "Max Moves Per Channel Per Day",
+        "type": "text",
+        "help_text": "(Optional) The maximum number of threads that can be moved out of any one channel per day, counted in UTC. Further moves out of the channel are refused until the next day, which protects active channels from being emptied by aggressive reorganization. System admins are not limited. Leave empty for no limit.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MaxScheduledJobsPerUser",
+        "display_name": "Max Scheduled Jobs Per User",
+        "type": "text",
+        "help_text": "(Optional) The maximum number of move reminders each user can have scheduled at once. Moves with a reminder are refused once a user reaches the limit. System admins are not limited. Leave empty for no limit.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MinAccountAgeDays",
+        "display_name": "Minimum Account Age In Days",
+        "type": "text",
+        "help_text": "(Optional) The number of days an account must exist before it can move, merge or copy threads, which limits abuse by new accounts in open communities. System admins are exempt. Leave empty for no minimum.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "DestinationPostCountWarning",
+        "display_name": "Destination Post Count Warning",
+        "type": "text",
+        "help_text": "(Optional) Warn before a thread move that would bring the destination channel over this many messages, so that large moves don't flood already busy channels by accident. The move can be confirmed with the --confirm-large-destination flag. Leave empty for no warning.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "DefaultChannelMoves",
+        "display_name": "Moves Into Default Channels",
+        "type": "dropdown",
+        "help_text": "Control how thread moves and merges into the default channels of a team are handled, which every member of the team is in: Town Square, and Off-Topic or the configured experimental default channels. Moves and merges can require confirmation with the --confirm-default-channel flag, be blocked, or be allowed without a warning. System admins are never stopped.",
+        "placeholder": "",
+        "default": "allow",
+        "options": [
+          {
+            "display_name": "Allow without a warning",
+            "value": "allow"
+          },
+          {
+            "display_name": "Warn and require confirmation",
+            "value": "confirm"
+          },
+          {
+            "display_name": "Block the move",
+            "value": "block"
+          }
+        ]
+      },
+      {
+        "key": "BlockedContentPatterns",
+        "display_name": "Blocked Content Patterns",
+        "type": "longtext",
+        "help_text": "(Optional) A JSON list of regular expressions, such as [\"AKIA[0-9A-Z]{16}\"]. Moving a thread with a message matching any of them is blocked with a warning naming the matched pattern, which guards against accidentally relocating secrets. System admins can move such threads anyway with the --allow-blocked-content flag.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MaxCommandLength",
+        "display_name": "Max Command Length",
+        "type": "text",
+        "help_text": "The maximum number of characters of a Wrangler command, between 100 and 16383. Longer commands are refused before they are parsed.",
+        "placeholder": "",
+        "default": "1000"
+      },
+      {
+        "key": "CompressArchivedImages",
+        "display_name": "Compress Archived Images",
+        "type": "bool",
+        "help_text": "Downscale and re-encode the JPEG and PNG attachments of threads moved to an archive channel or copied to another team so that they take less storage. Images that can't be made smaller are kept as is.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "CompressedImageMaxDimension",
+        "display_name": "Compressed Image Max Dimension",
+        "type": "text",
+        "help_text": "The maximum width and height in pixels of compressed images, between 100 and 10000. Larger images are downscaled, keeping their aspect ratio.",
+        "placeholder": "",
+        "default": "1600"
+      },
+      {
+        "key": "CompressedImageQuality",
+        "display_name": "Compressed Image Quality",
+        "type": "text",
+        "help_text": "The JPEG quality of compressed images, between 1 and 100. PNG images are lossless and are only downscaled.",
+        "placeholder": "",
+        "default": "75"
+      },
+      {
+        "key": "MaxMoveFileSizeMB",
+        "display_name": "Max Moved File Size (MB)",
+        "type": "text",
+        "help_text": "(Optional) The maximum size in megabytes of each file attachment that is re-uploaded when a thread is moved, copied or merged. Leave empty for no limit.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MaxMoveFileCount",
+        "display_name": "Max Moved File Count",
+        "type": "text",
+        "help_text": "(Optional) The maximum number of file attachments that are re-uploaded when a thread is moved, copied or merged. Leave empty for no limit.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MoveFileLimitAction",
+        "display_name": "Files Over The Limits",
+        "type": "dropdown",
+        "help_text": "Control what happens when file attachments are over the max moved file size or count: refuse the whole operation, or leave those files out and list them in the moved thread. Files that are left out of a move are deleted along with the original messages.",
+        "placeholder": "",
+        "default": "refuse",
+        "options": [
+          {
+            "display_name": "Refuse the operation",
+            "value": "refuse"
+          },
+          {
+            "display_name": "Leave the files out",
+            "value": "skip"
+          }
+        ]
+      },
+      {
+        "key": "ListMessagesTrimLength",
+        "display_name": "List Messages Trim Length",
+        "type": "text",
+        "help_text": "The number of characters that messages are trimmed to by '/wrangler list messages', between 10 and 500. Trimmed messages end with an ellipsis and can be read in full through their permalinks. Users can override it with the --trim-length flag.",
+        "placeholder": "",
+        "default": "80"
+      },
+      {
+        "key": "DeactivatedUserPosts",
+        "display_name": "Moved Messages By Deactivated Users",
+        "type": "dropdown",
+        "help_text": "Control how messages by deactivated users are handled when moving or merging a thread. They can either be recreated under the Wrangler bot with a note naming the original author, or be left out of the moved thread. The root message of a thread is always recreated under the Wrangler bot.",
+        "placeholder": "",
+        "default": "bot",
+        "options": [
+          {
+            "display_name": "Recreate under the Wrangler bot",
+            "value": "bot"
+          },
+          {
+            "display_name": "Skip",
+            "value": "skip"
+          }
+        ]
+      },
+      {
+        "key": "MoveApprovalChannelID",
+        "display_name": "Move Approval Channel ID",
+        "type": "text",
+        "help_text": "(Optional) When set, thread moves by users who aren't system or channel admins are sent for approval to this channel instead of running right away. Members of the channel approve or reject the moves with buttons on the request, and the requesting user is notified by DM. The Wrangler bot must be a member of the channel.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MoveApprovalSourceChannels",
+        "display_name": "Move Approval Source Channels",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of channel IDs that thread moves need approval for. When empty, moves out of any channel need approval. Only used when a move approval channel is set.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MaxMoveRequestsPerUserPerDay",
+        "display_name": "Max Move Requests Per User Per Day",
+        "type": "text",
+        "help_text": "The maximum number of move requests that each user can file with '/wrangler request move' per day, counted in UTC. Set to 0 for no limit.",
+        "placeholder": "",
+        "default": "5"
+      },
+      {
+        "key": "NonMemberAuthors",
+        "display_name": "Moved Messages By Non-Members Of The Target Channel",
+        "type": "dropdown",
+        "help_text": "Control how thread moves and merges handle authors who aren't members of the target channel. Their messages can be recreated under the Wrangler bot with a note naming the original author, the authors can be added to the target channel, or the move or merge can be aborted.",
+        "placeholder": "",
+        "default": "bot",
+        "options": [
+          {
+            "display_name": "Recreate under the Wrangler bot",
+            "value": "bot"
+          },
+          {
+            "display_name": "Add the authors to the target channel",
+            "value": "add"
+          },
+          {
+            "display_name": "Abort the move",
+            "value": "abort"
+          }
+        ]
+      },
+      {
+        "key": "KeepMoverAsAuthor",
+        "display_name": "Keep The Moving User As The Author Of Their Messages",
+        "type": "bool",
+        "help_text": "When enabled, the messages that the user moving a thread posted themselves are recreated under their own account without an \"Originally posted by\" note, even when they aren't a member of the target channel, such as system admins moving threads with the cross-team override. The messages of other non-members are still recreated under the Wrangler bot.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "IntegrationPosts",
+        "display_name": "Moved Messages By Bots And Integrations",
+        "type": "dropdown",
+        "help_text": "Control how a thread move handles messages posted by bots, webhooks and other integrations, which may stop working correctly when their messages are moved. Moves and merges can require confirmation with the --confirm-integration-posts flag, be blocked, or be allowed without a warning.",
+        "placeholder": "",
+        "default": "allow",
+        "options": [
+          {
+            "display_name": "Warn and require confirmation",
+            "value": "confirm"
+          },
+          {
+            "display_name": "Block the move",
+            "value": "block"
+          },
+          {
+            "display_name": "Allow without a warning",
+            "value": "allow"
+          }
+        ]
+      },
+      {
+        "key": "PlaybookPosts",
+        "display_name": "Moved Messages Linked To Playbook Runs",
+        "type": "dropdown",
+        "help_text": "Control how a thread move handles messages linked to runs of the Playbooks plugin, such as status updates. Moved messages keep their playbook props, but the runs may still point to the original messages. Moves and merges can require confirmation with the --confirm-playbook-posts flag, be blocked, or be allowed without a warning.",
+        "placeholder": "",
+        "default": "allow",
+        "options": [
+          {
+            "display_name": "Warn and require confirmation",
+            "value": "confirm"
+          },
+          {
+            "display_name": "Block the move",
+            "value": "block"
+          },
+          {
+            "display_name": "Allow without a warning",
+            "value": "allow"
+          }
+        ]
+      },
+      {
+        "key": "MentionPolicy",
+        "display_name": "Mentions In Moved Messages",
+        "type": "dropdown",
+        "help_text": "Control whether the at-mentions of moved messages notify again in the target channel. Neutralized mentions read the same but no longer notify anyone.",
+        "placeholder": "",
+        "default": "preserve-all",
+        "options": [
+          {
+            "display_name": "Preserve all mentions",
+            "value": "preserve-all"
+          },
+          {
+            "display_name": "Only preserve @channel, @all and @here",
+            "value": "channel-only"
+          },
+          {
+            "display_name": "Neutralize all mentions",
+            "value": "suppress-all"
+          }
+        ]
+      },
+      {
+        "key": "GroupMentions",
+        "display_name": "Group Mentions In Moved Messages",
+        "type": "dropdown",
+        "help_text": "Control the at-mentions of user groups, such as @devs, in moved messages. Group mentions can follow the setting of mentions in moved messages, always be neutralized so that moves don't notify whole groups again, or be kept with a warning in the move summary listing the groups that will be notified again and those that won't resolve since they were deleted or can't be mentioned. Only applies when all mentions are preserved.",
+        "placeholder": "",
+        "default": "preserve",
+        "options": [
+          {
+            "display_name": "Follow the setting of mentions in moved messages",
+            "value": "preserve"
+          },
+          {
+            "display_name": "Neutralize group mentions",
+            "value": "neutralize"
+          },
+          {
+            "display_name": "Warn about group mentions",
+            "value": "warn"
+          }
+        ]
+      },
+      {
+        "key": "ReplyBroadcasts",
+        "display_name": "Replies Also Sent To The Channel",
+        "type": "dropdown",
+        "help_text": "Control whether the moved, copied and merged replies that were also sent to the channel show in the feed of the target channel too. Replies can instead be recreated as plain replies that only show in their thread.",
+        "placeholder": "",
+        "default": "preserve",
+        "options": [
+          {
+            "display_name": "Also send them to the target channel",
+            "value": "preserve"
+          },
+          {
+            "display_name": "Recreate them as plain replies",
+            "value": "remove"
+          }
+        ]
+      },
+      {
+        "key": "AttributionAuthor",
+        "display_name": "Author Of Move And Copy Notices",
+        "type": "dropdown",
+        "help_text": "Control who authors the notices that Wrangler leaves in moved and copied threads, such as \"This thread was moved from another channel\". The user who ran the command only authors a notice when they can post in its channel; otherwise the bot does.",
+        "placeholder": "",
+        "default": "bot",
+        "options": [
+          {
+            "display_name": "The Wrangler bot",
+            "value": "bot"
+          },
+          {
+            "display_name": "The user who ran the command",
+            "value": "actor"
+          }
+        ]
+      },
+      {
+        "key": "MentionMoverInMovedThreads",
+        "display_name": "Mention The Mover In Moved Threads",
+        "type": "bool",
+        "help_text": "Control whether the \"This thread was moved from another channel\" notice in moved threads @mentions the user who moved the thread, so that it is clear who handled the move and owns the follow-up.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "BlockGuestMoves",
+        "display_name": "Forbid Guests From Moving Threads",
+        "type": "bool",
+        "help_text": "Control whether guest accounts are forbidden from moving and merging threads, regardless of the roles permitted to move threads. Guests can still copy threads they can read.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "EnabledOperations",
+        "display_name": "Enabled Operations",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of the operations that can be run, out of move, copy, attach and merge. Disabled operations are hidden from the web UI and refused by the slash commands and the API. Leave empty to enable every operation.",
+        "placeholder": "",
+        "default": ""
+      },
+      {
+        "key": "ExperimentalFeatures",
+        "display_name": "Experimental Features",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of the experimental features to enable, out of import (the import command), request-move (the request move command) and reminders (the remind flag of the move thread command). Experimental commands are hidden from the autocomplete and refused unless their feature is enabled. Unknown features are ignored with a warning. Leave empty to disable every experimental feature.",
+        "placeholder": "",
+        "default": ""
+      },
+      {
+        "key": "OriginalDeleteFailure",
+        "display_name": "When Original Messages Can't Be Deleted",
+        "type": "dropdown",
+        "help_text": "Control what happens to a move when its original messages can't be deleted once they were copied to the destination. The move is either rolled back, which removes the copied messages, or kept as a copy with a warning that the original messages are still in place.",
+        "placeholder": "",
+        "default": "rollback",
+        "options": [
+          {
+            "display_name": "Roll back the move",
+            "value": "rollback"
+          },
+          {
+            "display_name": "Keep the move as a copy",
+            "value": "copy"
+          }
+        ]
+      },
+      {
+        "key": "OversizedTranscripts",
+        "display_name": "Oversized Thread Transcripts",
+        "type": "dropdown",
+        "help_text": "Control how thread transcripts that are too long for a single message are sent by the export thread command. They can either be split across several messages in a thread, or be attached as a markdown file.",
+        "placeholder": "",
+        "default": "split",
+        "options": [
+          {
+            "display_name": "Split across messages",
+            "value": "split"
+          },
+          {
+            "display_name": "Attach as a file",
+            "value": "file"
+          }
+        ]
+      },
+      {
+        "key": "LockEmoji",
+        "display_name": "Thread Lock Emoji",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of emoji names. Threads whose root message has a reaction with one of these emoji can't be moved. Example: lock,no_entry",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "LockEmojiAdminBypass",
+        "display_name": "Allow System Admins To Move Locked Threads",
+        "type": "bool",
+        "help_text": "When enabled, system admins can move threads that are locked with a thread lock emoji.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "MovedHashtag",
+        "display_name": "Moved Thread Hashtag",
+        "type": "text",
+        "help_text": "(Optional) When set, this hashtag is appended to the root message of every moved thread so that relocated content can be found with search. Example: #moved",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "EnableChannelLogThread",
+        "display_name": "Enable Channel Log Threads",
+        "type": "bool",
+        "help_text": "When enabled, every thread moved or merged out of a channel is recorded as a reply in a Wrangler log thread in that same channel. The log thread is created by the Wrangler bot the first time it is needed.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "ChannelStateActions",
+        "display_name": "Channel State Actions",
+        "type": "longtext",
+        "help_text": "(Optional) A JSON object mapping destination channel IDs to actions that reset the resolution state of threads moved into them. Each value is an object with an optional remove_reaction field (the emoji name to remove from the root message) and an optional reset_prop field (the root message prop to clear).",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "DestinationTemplates",
+        "display_name": "Destination Templates",
+        "type": "longtext",
+        "help_text": "(Optional) A JSON object mapping destination channel IDs to templates framing the threads moved into them. Each value is an object with an optional header field, put at the top of the moved root message, and an optional footer field, which replaces the moved from footer. {channel} is replaced with a link to the original channel. Destinations without a footer template use the moved from footer.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "DuplicateCopies",
+        "display_name": "Repeated Copies",
+        "type": "dropdown",
+        "help_text": "Control what happens when a thread is copied to a channel it was already copied to: copy it again, refuse the copy with a link to the earlier one unless the copy is run with --allow-duplicate, or skip the copy. Copies are remembered for the history retention.",
+        "placeholder": "",
+        "default": "allow",
+        "options": [
+          {
+            "display_name": "Copy again",
+            "value": "allow"
+          },
+          {
+            "display_name": "Warn",
+            "value": "warn"
+          },
+          {
+            "display_name": "Skip",
+            "value": "skip"
+          }
+        ]
+      },
+      {
+        "key": "ResolutionAnnotations",
+        "display_name": "Resolution Annotations",
+        "type": "longtext",
+        "help_text": "(Optional) A JSON object mapping destination channel IDs, such as a resolved channel, to annotations that mark threads moved into them as resolved. Each value is an object with an optional text field appended to the root message, where {date} and {user} are replaced with the date of the move and the user who moved the thread (defaults to '✅ Resolved on {date} by {user}'), and an optional emoji field (the emoji name of a reaction the Wrangler bot adds to the root message).",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "RoutingRules",
+        "display_name": "Routing Rules",
+        "type": "longtext",
+        "help_text": "(Optional) A JSON list of rules used by the route thread command. Each rule is an object with a channel_id field and either a keyword field (matched case-insensitively) or a regex field matched against the root message. Threads are moved to the channel of the first matching rule.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "RoutingEndpointURL",
+        "display_name": "Routing Endpoint URL",
+        "type": "text",
+        "help_text": "(Optional) An HTTP or HTTPS URL that the route thread command asks for the destination of threads that no routing rule matches. The thread metadata is posted as JSON and the endpoint must answer with a JSON object with a channel_id field. When the endpoint fails, times out or answers with an invalid channel ID, the thread is left in place and the user is asked to pick a destination.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "RoutingEndpointAuthHeader",
+        "display_name": "Routing Endpoint Authorization Header",
+        "type": "text",
+        "help_text": "(Optional) The value of the Authorization header sent to the routing endpoint, such as \"Bearer \u003ctoken\u003e\".",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "SourceToDestinationMap",
+        "display_name": "Archive Destinations",
+        "type": "longtext",
+        "help_text": "(Optional) A JSON object mapping source channel IDs to the channel IDs that the archive thread command moves their threads to. Example: {\"\u003csource_channel_id\u003e\": \"\u003cdestination_channel_id\u003e\"}",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "DefaultArchiveChannelID",
+        "display_name": "Default Archive Channel ID",
+        "type": "text",
+        "help_text": "(Optional) The channel ID that the archive thread command moves threads to when the current channel has no archive destination.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "ChangelogChannelID",
+        "display_name": "Changelog Channel ID",
+        "type": "text",
+        "help_text": "(Optional) The channel ID of the running changelog post that the move thread command appends entries to with the --changelog flag. The Wrangler bot creates the changelog post when there is none.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "BoardsCardBoardID",
+        "display_name": "Boards Board ID For Cards",
+        "type": "text",
+        "help_text": "(Optional) The ID of the board of the Boards plugin that the move thread command creates cards on with the --create-card flag. Each card is titled with the first line of the root message of the moved thread and links to it.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "KnowledgeChannels",
+        "display_name": "Knowledge Channel IDs",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of channel IDs, such as knowledge base channels, that keep an index of the threads moved into them. The Wrangler bot maintains a pinned post in each of these channels listing the first line of every moved thread with a link to it, sorted by title.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "EnableMoveFeedbackPoll",
+        "display_name": "Enable Move Feedback Polls",
+        "type": "bool",
+        "help_text": "Control whether the move thread command can leave a poll in the original channel with the --feedback-poll flag, asking whether the moved thread should have stayed there. The votes are shown in the Wrangler stats.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "EnableOriginalTimestampHeader",
+        "display_name": "Enable Original Timestamp Headers",
+        "type": "bool",
+        "help_text": "Control whether the root message of every moved thread starts with a bold \"Originally posted on\" header with its original date and time in the time zone of the user who moved it. Moved messages are timestamped when they are moved, so the header tells where the thread belongs chronologically.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "DefaultTimezone",
+        "display_name": "Default Time Zone",
+        "type": "text",
+        "help_text": "(Optional) The time zone, such as Europe/Paris, of the timestamps shown to users whose time zone isn't set in their profile, in thread transcripts, original timestamp headers and '/wrangler mine'. Defaults to UTC.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "EnableMovedFromFooter",
+        "display_name": "Enable Moved From Footers",
+        "type": "bool",
+        "help_text": "Control whether every moved message ends with a footer naming the channel it was moved from. The footer is part of the message, so it is also found by searches.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "MovedFromFooterText",
+        "display_name": "Moved From Footer Text",
+        "type": "text",
+        "help_text": "(Optional) The text of the footer of moved messages. {channel} is replaced with a link to the original channel. Leave empty to use \"_Moved from {channel}_\".",
+        "placeholder": "",
+        "default": ""
+      },
+      {
+        "key": "EnableMoveRedirectStub",
+        "display_name": "Keep Moved Root Messages As Redirects",
+        "type": "bool",
+        "help_text": "Control whether moves keep the original root message as a permanent redirect to the moved thread instead of deleting it, so that links to it, such as from external documentation, keep working. Can be overridden per move with --leave-redirect.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "LinkBackToOriginal",
+        "display_name": "Link Back To Original Thread",
+        "type": "dropdown",
+        "help_text": "Control which operations end the new root message with a \"↩ Originally in\" link to the original thread. Moves only add the link when the original root message is kept, such as with --replies-only, --root-only or --leave-redirect, since the link would lead nowhere otherwise.",
+        "placeholder": "",
+        "default": "copies",
+        "options": [
+          {
+            "display_name": "Copies only",
+            "value": "copies"
+          },
+          {
+            "display_name": "Copies and moves",
+            "value": "copies-and-moves"
+          },
+          {
+            "display_name": "Never",
+            "value": "off"
+          }
+        ]
+      },
+      {
+        "key": "SuccessMessageVerbosity",
+        "display_name": "Success Message Verbosity",
+        "type": "dropdown",
+        "help_text": "Control how much detail the summaries of completed moves and copies include: only the outcome and permalink, the normal summary with the message and author counts, or a detailed summary that also quotes the root message and gives a reference for support. Users can choose their own with '/wrangler prefs set success-verbosity'.",
+        "placeholder": "",
+        "default": "normal",
+        "options": [
+          {
+            "display_name": "Minimal",
+            "value": "minimal"
+          },
+          {
+            "display_name": "Normal",
+            "value": "normal"
+          },
+          {
+            "display_name": "Detailed",
+            "value": "detailed"
+          }
+        ]
+      },
+      {
+        "key": "EnableMovedThreadSummary",
+        "display_name": "Enable Moved Thread Summaries",
+        "type": "bool",
+        "help_text": "Control whether every thread move posts a pinned summary of the activity of the thread right above the moved thread: the number of replies, the number of participants, the time span of the discussion and its busiest day.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "HighImpactMoveChannelID",
+        "display_name": "High-Impact Move Monitoring Channel ID",
+        "type": "text",
+        "help_text": "(Optional) The ID of a channel where Wrangler posts a notice with the key details and a permalink of every high-impact thread move: moves to another team and moves above the post or author thresholds below. Leave empty to post no notices.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "HighImpactMovePostThreshold",
+        "display_name": "High-Impact Move Post Threshold",
+        "type": "text",
+        "help_text": "(Optional) Moves of more than this many messages are reported to the high-impact move monitoring channel. Leave empty to not report moves by their number of messages.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "HighImpactMoveAuthorThreshold",
+        "display_name": "High-Impact Move Author Threshold",
+        "type": "text",
+        "help_text": "(Optional) Moves of threads with more than this many distinct authors are reported to the high-impact move monitoring channel. Leave empty to not report moves by their number of authors.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "HighImpactMoveDigestMinutes",
+        "display_name": "High-Impact Move Digest Minutes",
+        "type": "text",
+        "help_text": "(Optional) Collect high-impact moves for this many minutes, up to 10080, and post them to the monitoring channel as a single digest instead of one notice per move. Leave empty or set to 0 to post a notice for every move.",
+        "placeholder": "",
+        "default": ""
+      },
+      {
+        "key": "MoveSummaries",
+        "display_name": "Post Move Summaries To The Monitoring Channel",
+        "type": "bool",
+        "help_text": "When enabled, a summary of every thread move is posted to the high-impact move monitoring channel, showing how many messages left the source channel and arrived in the destination channel, with links to both channels and to the moved thread. Requires a monitoring channel.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "AttachAsDirectReply",
+        "display_name": "Attach Messages As Direct Replies",
+        "type": "bool",
+        "help_text": "Control where the attach message command attaches a message when the given message is a reply. By default the message is attached under the root of the thread; when true, it is attached as a direct reply to the given message, still within the same thread.",
+        "placeholder": "",
+        "default": false
+      },
+      {
+        "key": "MoveTextTransforms",
+        "display_name": "Moved Message Text Transforms",
+        "type": "longtext",
+        "help_text": "(Optional) A JSON list of find and replace transforms applied in order to the text of every moved message. Each transform is an object with a regex field, a replacement field that may reference capture groups such as $1, and an optional channel_id field restricting it to messages moved out of that channel.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "TicketLinkRegex",
+        "display_name": "Ticket Reference Regex",
+        "type": "text",
+        "help_text": "(Optional) A regex matching ticket references, such as [A-Z]+-[0-9]+, that are rewritten as links to the issue tracker in messages moved into the ticket link channels.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "TicketLinkURLTemplate",
+        "display_name": "Ticket Link URL Template",
+        "type": "text",
+        "help_text": "The URL of a ticket in the issue tracker, with {ticket} standing for the matched ticket reference. For example: https://tracker.example.com/browse/{ticket}. Required when a ticket reference regex is set.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "TicketLinkChannels",
+        "display_name": "Ticket Link Channels",
+        "type": "text",
+        "help_text": "(Optional) A comma-separated list of channel IDs. Ticket references are only linked in messages moved into these channels.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "MovedPostProps",
+        "display_name": "Moved Message Props",
+        "type": "longtext",
+        "help_text": "(Optional) A JSON object of extra static props added to every moved message, for use by other plugins and integrations. Values must be strings. Moved messages always get the moved_from_channel, moved_by and moved_at props.",
+        "placeholder": "",
+        "default": null
+      },
+      {
+        "key": "CollapseGapSeconds",
+        "display_name": "Collapse Gap Seconds",
+        "type": "text",
+        "help_text": "The maximum number of seconds between consecutive messages by the same author for them to be merged when copying a thread with the --collapse flag.",
+        "placeholder": "",
+        "default": "120"
+      },
+      {
+        "key": "CollapseInsertSeparator",
+        "display_name": "Insert Separators Between Collapsed Messages",
+        "type": "bool",
+        "help_text": "When enabled, a horizontal rule is inserted between the messages merged by the --collapse flag. Otherwise they are separated by a line break.",
+        "placeholder": "",
+        "default": false
       }
     ]
   }
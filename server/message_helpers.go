@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/pkg/errors"
@@ -17,31 +23,42 @@ func (p *Plugin) validateMoveOrCopy(wpl *WranglerPostList, originalChannel *mode
 
 	config := p.getConfiguration()
 
-	switch originalChannel.Type {
-	case model.CHANNEL_PRIVATE:
-		if !config.MoveThreadFromPrivateChannelEnable {
-			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving posts from private channels"), false, nil
-		}
-	case model.CHANNEL_DIRECT:
-		if !config.MoveThreadFromDirectMessageChannelEnable {
-			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving posts from direct message channels"), false, nil
-		}
-	case model.CHANNEL_GROUP:
-		if !config.MoveThreadFromGroupMessageChannelEnable {
-			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving posts from group message channels"), false, nil
-		}
+	response := validateSourceChannelType(originalChannel, config)
+	if response != nil {
+		return response, false, nil
 	}
 
+	override := p.canOverrideTargetChannel(extra.UserId)
+	var overridden bool
+
 	if !originalChannel.IsGroupOrDirect() {
 		// DM and GM channels are "teamless" so it doesn't make sense to check
 		// the MoveThreadToAnotherTeamEnable config when dealing with those.
 		if !config.MoveThreadToAnotherTeamEnable && targetChannel.TeamId != originalChannel.TeamId {
-			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving messages to different teams"), false, nil
+			if !override {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving messages to different teams"), false, nil
+			}
+			overridden = true
+		}
+		if config.MoveThreadToAnotherTeamEnable && targetChannel.TeamId != originalChannel.TeamId && !config.DestinationTeamAllowed(targetChannel.TeamId) {
+			if !override {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving messages into the team of the target channel"), false, nil
+			}
+			overridden = true
 		}
 	}
 
 	if config.MaxThreadCountMoveSizeInt() != 0 && config.MaxThreadCountMoveSizeInt() < wpl.NumPosts() {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread is %d posts long, but this command is configured to only move threads of up to %d posts", wpl.NumPosts(), config.MaxThreadCountMoveSizeInt())), true, nil
+		if !p.canBypassMaxCount(extra.UserId, config) {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the thread is %d posts long, but this command is configured to only move threads of up to %d posts", wpl.NumPosts(), config.MaxThreadCountMoveSizeInt())), true, nil
+		}
+
+		p.API.LogWarn("Wrangler max thread count bypassed",
+			"user_id", extra.UserId,
+			"original_post_id", wpl.RootPost().Id,
+			"thread_count", wpl.NumPosts(),
+			"max_thread_count", config.MaxThreadCountMoveSizeInt(),
+		)
 	}
 
 	if wpl.RootPost().ChannelId != extra.ChannelId {
@@ -50,7 +67,19 @@ func (p *Plugin) validateMoveOrCopy(wpl *WranglerPostList, originalChannel *mode
 
 	_, appErr := p.API.GetChannelMember(targetChannel.Id, extra.UserId)
 	if appErr != nil {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", targetChannel.Id)), true, nil
+		if !override {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: channel with ID %s doesn't exist or you are not a member", targetChannel.Id)), true, nil
+		}
+		overridden = true
+	}
+
+	if overridden {
+		p.API.LogWarn("Wrangler admin cross-team override used",
+			"user_id", extra.UserId,
+			"original_post_id", wpl.RootPost().Id,
+			"target_channel_id", targetChannel.Id,
+			"target_team_id", targetChannel.TeamId,
+		)
 	}
 
 	if extra.RootId == wpl.RootPost().Id || extra.ParentId == wpl.RootPost().Id {
@@ -60,7 +89,440 @@ func (p *Plugin) validateMoveOrCopy(wpl *WranglerPostList, originalChannel *mode
 	return nil, false, nil
 }
 
-func (p *Plugin) copyWranglerPostlist(wpl *WranglerPostList, targetChannel *model.Channel) (*model.Post, error) {
+// validateSourceChannelType checks that the configuration allows moving posts
+// out of channels of the type of the original channel. Team channels must also
+// match the allowed source channel pattern when one is configured; DM and GM
+// channel names are made of user IDs, so only their type setting applies.
+func validateSourceChannelType(originalChannel *model.Channel, config *configuration) *model.CommandResponse {
+	switch originalChannel.Type {
+	case model.CHANNEL_PRIVATE:
+		if !config.MoveThreadFromPrivateChannelEnable {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving posts from private channels")
+		}
+	case model.CHANNEL_DIRECT:
+		if !config.MoveThreadFromDirectMessageChannelEnable {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving posts from direct message channels")
+		}
+	case model.CHANNEL_GROUP:
+		if !config.MoveThreadFromGroupMessageChannelEnable {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Wrangler is currently configured to not allow moving posts from group message channels")
+		}
+	}
+
+	pattern := config.AllowedSourceChannelRegexp()
+	if pattern != nil && !originalChannel.IsGroupOrDirect() && !pattern.MatchString(originalChannel.Name) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Wrangler is currently configured to only allow moving posts from channels whose name matches `%s`", pattern.String()))
+	}
+
+	return nil
+}
+
+// validateRemovalFromChannel checks that the user is allowed to remove messages
+// from the original channel as part of a move. Moving is a deletion in the
+// original channel rather than a new post, so users who can't post in a
+// read-only channel, such as an announcement channel, may still move messages
+// out of it as long as they are allowed to delete other users' messages there.
+func (p *Plugin) validateRemovalFromChannel(originalChannel *model.Channel, extra *model.CommandArgs) *model.CommandResponse {
+	if p.API.HasPermissionToChannel(extra.UserId, originalChannel.Id, model.PERMISSION_CREATE_POST) {
+		return nil
+	}
+	if p.API.HasPermissionToChannel(extra.UserId, originalChannel.Id, model.PERMISSION_DELETE_OTHERS_POSTS) {
+		return nil
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Error: this channel is read-only for you; moving messages out of it requires permission to delete other users' messages")
+}
+
+// validateThreadNotLocked checks that the root post of the thread doesn't carry
+// one of the configured lock reactions. System admins may still move locked
+// threads when the bypass is enabled.
+func (p *Plugin) validateThreadNotLocked(wpl *WranglerPostList, extra *model.CommandArgs) (*model.CommandResponse, error) {
+	config := p.getConfiguration()
+	lockEmojiNames := config.LockEmojiNames()
+	if len(lockEmojiNames) == 0 {
+		return nil, nil
+	}
+
+	reactions, appErr := p.API.GetReactions(wpl.RootPost().Id)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "unable to get reactions to check thread lock")
+	}
+
+	for _, reaction := range reactions {
+		for _, emojiName := range lockEmojiNames {
+			if reaction.EmojiName != emojiName {
+				continue
+			}
+
+			if config.LockEmojiAdminBypass && p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+				p.API.LogWarn("Wrangler thread lock bypassed",
+					"user_id", extra.UserId,
+					"original_post_id", wpl.RootPost().Id,
+				)
+				return nil, nil
+			}
+
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: this thread is locked with the :%s: reaction and can't be moved; remove the reaction to move it", emojiName)), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// findBlockedContentPattern returns the first of the patterns that the
+// message of any of the posts matches, or nil if none does.
+func findBlockedContentPattern(posts []*model.Post, patterns []*regexp.Regexp) *regexp.Regexp {
+	for _, pattern := range patterns {
+		for _, post := range posts {
+			if pattern.MatchString(post.Message) {
+				return pattern
+			}
+		}
+	}
+
+	return nil
+}
+
+// playbookPostTypes are the types of the posts that the Playbooks plugin
+// creates for its runs, such as status updates and retrospectives.
+var playbookPostTypes = []string{"custom_run_update", "custom_retro", "custom_update_status"}
+
+// playbookPostProps are the props that tie a post to a playbook run.
+var playbookPostProps = []string{"playbookRunId", "playbook_run_id"}
+
+// countPlaybookPosts returns the number of posts linked to playbook runs.
+// Moved posts keep their props, but the run may still point to the original
+// posts.
+func countPlaybookPosts(posts []*model.Post) int {
+	var count int
+	for _, post := range posts {
+		if isPlaybookPost(post) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func isPlaybookPost(post *model.Post) bool {
+	for _, postType := range playbookPostTypes {
+		if post.Type == postType {
+			return true
+		}
+	}
+	for _, prop := range playbookPostProps {
+		if post.GetProp(prop) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// countIntegrationPosts returns the number of posts made by bots, webhooks or
+// other integrations, leaving out the posts of the Wrangler bot itself.
+func (p *Plugin) countIntegrationPosts(posts []*model.Post) int {
+	bots := make(map[string]bool)
+	var count int
+	for _, post := range posts {
+		if post.UserId == p.BotUserID {
+			continue
+		}
+		if post.GetProp("from_webhook") == "true" || post.GetProp("from_bot") == "true" {
+			count++
+			continue
+		}
+
+		isBot, ok := bots[post.UserId]
+		if !ok {
+			user, appErr := p.API.GetUser(post.UserId)
+			isBot = appErr == nil && user.IsBot
+			bots[post.UserId] = isBot
+		}
+		if isBot {
+			count++
+		}
+	}
+
+	return count
+}
+
+// canOverrideTargetChannel returns true if the user is a system admin allowed
+// by the AdminCrossTeamOverride setting to move threads into channels and
+// teams they aren't a member of.
+func (p *Plugin) canOverrideTargetChannel(userID string) bool {
+	if !p.getConfiguration().AdminCrossTeamOverride {
+		return false
+	}
+
+	return p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM)
+}
+
+// defaultOffTopicChannel is the name of the Off-Topic channel, which is a
+// default channel unless other default channels are configured.
+const defaultOffTopicChannel = "off-topic"
+
+// isTeamDefaultChannel returns true if the channel is one of the default
+// channels that every member of its team joins, Town Square and the channels
+// configured as experimental default channels, by default Off-Topic.
+func (p *Plugin) isTeamDefaultChannel(channel *model.Channel) bool {
+	if channel.IsGroupOrDirect() {
+		return false
+	}
+	if channel.Name == model.DEFAULT_CHANNEL {
+		return true
+	}
+
+	defaultChannels := p.API.GetConfig().TeamSettings.ExperimentalDefaultChannels
+	if len(defaultChannels) == 0 {
+		defaultChannels = []string{defaultOffTopicChannel}
+	}
+	for _, name := range defaultChannels {
+		if channel.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canBypassMaxCount returns true if the user is allowed to exceed the max
+// thread count move size.
+func (p *Plugin) canBypassMaxCount(userID string, config *configuration) bool {
+	usernames := config.MaxCountBypassUsernames()
+	if len(usernames) == 0 {
+		return false
+	}
+
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		p.API.LogError("Unable to get user to check max count bypass", "user_id", userID, "error", appErr.Error())
+		return false
+	}
+
+	for _, username := range usernames {
+		if username == strings.ToLower(user.Username) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyOptions controls how posts are recreated by copyWranglerPostlist.
+type copyOptions struct {
+	// rootHashtag is appended to the new root post when set.
+	rootHashtag string
+	// rootStateAction resets the resolution state of the new root post.
+	rootStateAction channelStateAction
+	// rootAnnotation is appended to the new root post when set.
+	rootAnnotation string
+	// rootReaction is added to the new root post by the Wrangler bot when set.
+	rootReaction string
+	// rootTimestampHeader, when set, puts the original creation time of the
+	// root post at the top of the new root post.
+	rootTimestampHeader bool
+	// timestampLocation is the time zone of the original creation time put
+	// at the top of the new root post. Defaults to UTC.
+	timestampLocation *time.Location
+	// rootHeader, when set, is put at the top of the new root post.
+	rootHeader string
+	// rootLinkBack, when set, is the permalink of the original thread that
+	// the new root post links back to.
+	rootLinkBack string
+	// rootID, when set, is the ID of an existing thread root post in the
+	// target channel that all posts are recreated as replies to.
+	rootID string
+	// textTransforms are applied in order to the message of every post.
+	textTransforms []textTransform
+	// ticketLinks, when set, links the ticket references of every post after
+	// the text transforms are applied.
+	ticketLinks *ticketLinker
+	// props are added to the props of every post.
+	props map[string]interface{}
+	// footer, when set, is appended to the message of every post.
+	footer string
+	// concurrency is the number of replies that are recreated at the same
+	// time. Replies are recreated one after the other when it is at most 1.
+	concurrency int
+	// botAttributedAuthors maps the IDs of authors, such as deactivated users,
+	// to their usernames. Their posts are recreated under the Wrangler bot.
+	botAttributedAuthors map[string]string
+	// mentionPolicy controls which at-mentions of every post are neutralized
+	// so that they don't notify again. Mentions are preserved when empty.
+	mentionPolicy string
+	// groupMentions, when set, applies the group mention policy to every post.
+	groupMentions *groupMentionFilter
+	// removeBroadcasts is set to recreate replies that were also sent to
+	// the channel as plain replies.
+	removeBroadcasts bool
+	// provenance, when set, collects the original and new IDs of every
+	// recreated post.
+	provenance *provenanceTracker
+	// compression, when set, compresses the image attachments of every post.
+	compression *imageCompressor
+	// fileLimits, when set, leaves out the file attachments that are over the
+	// file limits.
+	fileLimits *fileLimiter
+}
+
+// getDeactivatedAuthors returns the usernames of the deactivated authors of
+// the given posts, keyed by user ID. Authors that can't be looked up are
+// treated as active.
+func (p *Plugin) getDeactivatedAuthors(posts []*model.Post) map[string]string {
+	deactivated := make(map[string]string)
+	checked := make(map[string]bool)
+	for _, post := range posts {
+		if checked[post.UserId] {
+			continue
+		}
+		checked[post.UserId] = true
+
+		user, appErr := p.API.GetUser(post.UserId)
+		if appErr != nil {
+			p.API.LogError("Unable to get post author to check if they are deactivated", "user_id", post.UserId, "error", appErr.Error())
+			continue
+		}
+		if user.DeleteAt != 0 {
+			deactivated[user.Id] = user.Username
+		}
+	}
+
+	return deactivated
+}
+
+// getNonMemberAuthors returns the usernames of the authors of the given posts
+// who aren't members of the target channel, keyed by user ID. The Wrangler bot
+// and the authors in skip are left out.
+func (p *Plugin) getNonMemberAuthors(posts []*model.Post, targetChannel *model.Channel, skip map[string]string) map[string]string {
+	nonMembers := make(map[string]string)
+	checked := make(map[string]bool)
+	for _, post := range posts {
+		if checked[post.UserId] || post.UserId == p.BotUserID {
+			continue
+		}
+		checked[post.UserId] = true
+		if _, ok := skip[post.UserId]; ok {
+			continue
+		}
+
+		_, appErr := p.API.GetChannelMember(targetChannel.Id, post.UserId)
+		if appErr == nil {
+			continue
+		}
+
+		username := post.UserId
+		user, appErr := p.API.GetUser(post.UserId)
+		if appErr == nil {
+			username = user.Username
+		}
+		nonMembers[post.UserId] = username
+	}
+
+	return nonMembers
+}
+
+// attributeToBot changes the author of the post to the Wrangler bot and notes
+// the original author in the message.
+func (p *Plugin) attributeToBot(post *model.Post, username string) {
+	post.UserId = p.BotUserID
+	attribution := fmt.Sprintf("_Originally posted by @%s_", username)
+	if len(strings.TrimSpace(post.Message)) == 0 {
+		post.Message = attribution
+	} else {
+		post.Message = fmt.Sprintf("%s\n\n%s", attribution, post.Message)
+	}
+}
+
+// prependOriginalTimestampHeader puts the original creation time of the post,
+// in the given time zone or UTC when none is given, in bold at the top of its
+// message. Recreated posts are timestamped when they are recreated, so the
+// header tells when the discussion started.
+func prependOriginalTimestampHeader(post *model.Post, createAt int64, location *time.Location) {
+	if location == nil {
+		location = time.UTC
+	}
+	prependHeader(post, fmt.Sprintf("**Originally posted on %s**", time.Unix(0, createAt*int64(time.Millisecond)).In(location).Format("Jan 2, 2006 at 15:04 MST")))
+}
+
+// prependHeader puts the header as the first paragraph of the message of the
+// post.
+func prependHeader(post *model.Post, header string) {
+	if len(strings.TrimSpace(post.Message)) == 0 {
+		post.Message = header
+	} else {
+		post.Message = fmt.Sprintf("%s\n\n%s", header, post.Message)
+	}
+}
+
+// appendFooter adds the footer as the last paragraph of the message of the
+// post, where it is shown and searchable along with the message.
+func appendFooter(post *model.Post, footer string) {
+	if len(strings.TrimSpace(post.Message)) == 0 {
+		post.Message = footer
+	} else {
+		post.Message = fmt.Sprintf("%s\n\n%s", strings.TrimRight(post.Message, "\n"), footer)
+	}
+}
+
+// Props added to every post recreated by a move so that other plugins and
+// integrations can tell that the post was moved.
+const (
+	propMovedFromChannel = "moved_from_channel"
+	propMovedBy          = "moved_by"
+	propMovedAt          = "moved_at"
+)
+
+// movedPostProps returns the props to add to the posts moved out of the
+// original channel by the given user, including the configured static props.
+func movedPostProps(originalChannel *model.Channel, userID string, config *configuration) map[string]interface{} {
+	props := make(map[string]interface{})
+	for key, value := range config.MovedPostPropsMap() {
+		props[key] = value
+	}
+	props[propMovedFromChannel] = originalChannel.Id
+	props[propMovedBy] = userID
+	props[propMovedAt] = model.GetMillis()
+
+	return props
+}
+
+// newOperationContext returns the context bounding a move operation. It is
+// canceled once the configured operation timeout has passed.
+func (p *Plugin) newOperationContext() (context.Context, context.CancelFunc) {
+	timeout := p.getConfiguration().OperationTimeout()
+	if timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// sleepContext waits for the given duration, returning early with the context
+// error if the context is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// copyWranglerPostlist recreates the posts of the post list in the target
+// channel and returns the new root post. When an existing root ID is provided
+// in the options, all posts are recreated as replies to it and the first
+// recreated post is returned instead. If an error occurs after the first post
+// was recreated, that post is returned along with the error so that callers
+// can clean up. The copy stops with an error once the context is done.
+func (p *Plugin) copyWranglerPostlist(ctx context.Context, wpl *WranglerPostList, targetChannel *model.Channel, options copyOptions) (*model.Post, error) {
 	var appErr *model.AppError
 	var newRootPost *model.Post
 
@@ -68,76 +530,434 @@ func (p *Plugin) copyWranglerPostlist(wpl *WranglerPostList, targetChannel *mode
 		// The thread contains at least one attachment. To properly move the
 		// thread, the files will have to be re-uploaded. This is completed
 		// before any messages are moved.
-		// TODO: check number of files that need to be re-uploaded or file size?
 		p.API.LogInfo("Wrangler is re-uploading file attachments",
 			"file_count", wpl.FileAttachmentCount,
 		)
 
-		for _, post := range wpl.Posts {
+		// The re-uploaded files and the notes about skipped files are set on
+		// clones, so that the post list of the caller keeps its original posts.
+		clonedWPL := *wpl
+		clonedWPL.Posts = make([]*model.Post, len(wpl.Posts))
+		for i, original := range wpl.Posts {
+			post := original.Clone()
+			clonedWPL.Posts[i] = post
+
 			var newFileIDs []string
+			var newFileInfos []*model.FileInfo
 			var fileBytes []byte
 			var oldFileInfo, newFileInfo *model.FileInfo
+			var skippedCount int
 			for _, fileID := range post.FileIds {
 				oldFileInfo, appErr = p.API.GetFileInfo(fileID)
 				if appErr != nil {
 					return nil, errors.Wrap(appErr, "unable to lookup file info to re-upload")
 				}
+				if options.fileLimits != nil && !options.fileLimits.allow(oldFileInfo) {
+					skippedCount++
+					continue
+				}
 				fileBytes, appErr = p.API.GetFile(fileID)
 				if appErr != nil {
 					return nil, errors.Wrap(appErr, "unable to get file bytes to re-upload")
 				}
+				if options.compression != nil {
+					compressed, ok, err := options.compression.compress(fileBytes, oldFileInfo)
+					if err != nil {
+						p.API.LogWarn("Unable to compress image; it is re-uploaded unchanged",
+							"file_id", fileID,
+							"error", err.Error(),
+						)
+					} else if ok {
+						fileBytes = compressed
+						options.compression.compressedCount++
+					}
+				}
 				newFileInfo, appErr = p.API.UploadFile(fileBytes, targetChannel.Id, oldFileInfo.Name)
 				if appErr != nil {
 					return nil, errors.Wrap(appErr, "unable to re-upload file")
 				}
 
 				newFileIDs = append(newFileIDs, newFileInfo.Id)
+				newFileInfos = append(newFileInfos, newFileInfo)
 			}
 
 			post.FileIds = newFileIDs
+			if skippedCount != 0 {
+				note := fmt.Sprintf("_%d file attachment(s) of this message were left out for being over the file limits_", skippedCount)
+				if len(strings.TrimSpace(post.Message)) == 0 {
+					post.Message = note
+				} else {
+					post.Message += "\n\n" + note
+				}
+			}
+			if post.Metadata != nil && (len(post.FileIds) != 0 || skippedCount != 0) {
+				// Keep the embed and image previews of the post, but point its
+				// file previews to the re-uploaded files that remain once the
+				// originals are deleted, leaving out the skipped files.
+				metadata := *post.Metadata
+				metadata.Files = newFileInfos
+				post.Metadata = &metadata
+			}
 		}
+		wpl = &clonedWPL
 	}
 
-	for i, post := range wpl.Posts {
-		var reactions []*model.Reaction
+	replies := wpl.Posts
+	rootID := options.rootID
+	if len(rootID) == 0 && wpl.NumPosts() != 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrap(err, "copy stopped")
+		}
 
-		// Store reactions to be reapplied later.
+		newPost, err := p.copyPost(wpl.RootPost(), targetChannel, "", 0, options)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create new root post")
+		}
+		newRootPost = newPost.Clone()
+		rootID = newRootPost.Id
+		replies = wpl.Posts[1:]
+	}
+
+	if options.concurrency <= 1 {
+		for _, post := range replies {
+			if err := ctx.Err(); err != nil {
+				return newRootPost, errors.Wrap(err, "copy stopped")
+			}
+
+			newPost, err := p.copyPost(post, targetChannel, rootID, 0, options)
+			if err != nil {
+				return newRootPost, errors.Wrap(err, "unable to create new post")
+			}
+			if newRootPost == nil {
+				newRootPost = newPost.Clone()
+			}
+		}
+
+		return newRootPost, nil
+	}
+
+	// The replies are timestamped after the root post so that it stays first.
+	createAt := model.GetMillis()
+	if newRootPost != nil && createAt <= newRootPost.CreateAt {
+		createAt = newRootPost.CreateAt + 1
+	}
+	newPosts, err := p.copyRepliesConcurrently(ctx, replies, targetChannel, rootID, createAt, options)
+	if newRootPost == nil && len(newPosts) != 0 && newPosts[0] != nil {
+		newRootPost = newPosts[0].Clone()
+	}
+	if err != nil {
+		return newRootPost, err
+	}
+
+	return newRootPost, nil
+}
+
+// copyRepliesConcurrently recreates the replies as replies to the given root
+// post using up to the configured number of concurrent post creations. The
+// replies are given increasing creation timestamps starting at createAt so
+// that they keep their order regardless of which creation finishes first. The
+// recreated posts are returned in order; posts that were not recreated are
+// nil.
+func (p *Plugin) copyRepliesConcurrently(ctx context.Context, replies []*model.Post, targetChannel *model.Channel, rootID string, createAt int64, options copyOptions) ([]*model.Post, error) {
+	newPosts := make([]*model.Post, len(replies))
+	errs := make([]error, len(replies))
+
+	var wg sync.WaitGroup
+	var failed int32
+	var stopErr error
+	sem := make(chan struct{}, options.concurrency)
+	for i, post := range replies {
+		if err := ctx.Err(); err != nil {
+			stopErr = errors.Wrap(err, "copy stopped")
+			break
+		}
+		if atomic.LoadInt32(&failed) != 0 {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, post *model.Post) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			newPosts[i], errs[i] = p.copyPost(post, targetChannel, rootID, createAt+int64(i), options)
+			if errs[i] != nil {
+				atomic.StoreInt32(&failed, 1)
+			}
+		}(i, post)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return newPosts, errors.Wrap(err, "unable to create new post")
+		}
+	}
+	if stopErr != nil {
+		return newPosts, stopErr
+	}
+
+	return newPosts, nil
+}
+
+// copyPost recreates a single post in the target channel along with its
+// reactions. The post is recreated as a reply when a root ID is given, or as
+// the new thread root otherwise. The server sets the creation timestamp unless
+// one is given.
+func (p *Plugin) copyPost(post *model.Post, targetChannel *model.Channel, rootID string, createAt int64, options copyOptions) (*model.Post, error) {
+	// Store reactions to be reapplied later. Posts that don't exist yet, such
+	// as imported posts, have no reactions.
+	var reactions []*model.Reaction
+	var appErr *model.AppError
+	if len(post.Id) != 0 {
 		reactions, appErr = p.API.GetReactions(post.Id)
 		if appErr != nil {
 			// Reaction-based errors are logged, but do not cause the plugin to
 			// abort the move thread process.
 			p.API.LogError("Failed to get reactions on original post", "err", appErr)
 		}
+	}
 
-		newPost := post.Clone()
-		cleanPost(newPost)
-		newPost.ChannelId = targetChannel.Id
+	newPost := post.Clone()
+	cleanPost(newPost)
+	newPost.ChannelId = targetChannel.Id
+	newPost.CreateAt = createAt
+	for _, transform := range options.textTransforms {
+		newPost.Message = transform.apply(newPost.Message)
+	}
+	if options.ticketLinks != nil {
+		newPost.Message = options.ticketLinks.link(newPost.Message)
+	}
+	newPost.Message = neutralizeMentions(newPost.Message, options.mentionPolicy)
+	newPost.Message = options.groupMentions.apply(newPost.Message)
+	for key, value := range options.props {
+		newPost.AddProp(key, value)
+	}
+	if username, ok := options.botAttributedAuthors[post.UserId]; ok {
+		p.attributeToBot(newPost, username)
+	}
+
+	if len(rootID) == 0 {
+		if options.rootTimestampHeader && post.CreateAt != 0 {
+			prependOriginalTimestampHeader(newPost, post.CreateAt, options.timestampLocation)
+		}
+		if len(options.rootHeader) != 0 {
+			prependHeader(newPost, options.rootHeader)
+		}
+		if len(options.rootHashtag) != 0 {
+			appendHashtag(newPost, options.rootHashtag)
+		}
+		if len(options.rootStateAction.ResetProp) != 0 {
+			newPost.DelProp(options.rootStateAction.ResetProp)
+		}
+		reactions = filterReactions(reactions, options.rootStateAction.RemoveReaction)
+		if len(options.rootAnnotation) != 0 {
+			newPost.Message = strings.TrimRight(newPost.Message, "\n") + "\n\n" + options.rootAnnotation
+		}
+		if len(options.rootLinkBack) != 0 {
+			appendFooter(newPost, "↩ Originally in "+options.rootLinkBack)
+		}
+	} else {
+		newPost.RootId = rootID
+		newPost.ParentId = rootID
+		if options.removeBroadcasts && newPost.GetProp(commentTypeProp) == commentTypeBroadcast {
+			newPost.DelProp(commentTypeProp)
+		}
+	}
+	if len(options.footer) != 0 {
+		appendFooter(newPost, options.footer)
+	}
+
+	newPost, appErr = p.API.CreatePost(newPost)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if options.provenance != nil {
+		options.provenance.track(post, newPost)
+	}
+
+	for _, reaction := range reactions {
+		reaction.PostId = newPost.Id
+		_, appErr = p.API.AddReaction(reaction)
+		if appErr != nil {
+			// Reaction-based errors are logged, but do not cause the plugin to
+			// abort the move thread process.
+			p.API.LogError("Failed to reapply reactions to post", "err", appErr)
+		}
+	}
+	if len(rootID) == 0 && len(options.rootReaction) != 0 {
+		_, appErr = p.API.AddReaction(&model.Reaction{
+			UserId:    p.BotUserID,
+			PostId:    newPost.Id,
+			EmojiName: options.rootReaction,
+		})
+		if appErr != nil {
+			p.API.LogError("Failed to add resolution reaction to post", "err", appErr)
+		}
+	}
+
+	return newPost, nil
+}
+
+// commentTypeProp is the post prop marking a reply that was also sent to the
+// channel, with the commentTypeBroadcast value, so that it shows in the
+// channel feed as well as in its thread.
+const (
+	commentTypeProp      = "comment_type"
+	commentTypeBroadcast = "also_sent_to_channel"
+)
+
+// mentionRegexp matches an at-mention that isn't part of a word or email
+// address, capturing what precedes it and the mentioned name.
+var mentionRegexp = regexp.MustCompile(`(^|[^\w@.\-])@([a-zA-Z0-9][a-zA-Z0-9._\-]*)`)
+
+// channelMentions are the mentions that notify a whole channel.
+var channelMentions = map[string]bool{
+	"channel": true,
+	"all":     true,
+	"here":    true,
+}
+
+// neutralizeMentions returns the message with at-mentions neutralized
+// according to the mention policy. A zero-width space is inserted after the
+// @ of neutralized mentions so that they read the same but no longer notify.
+// Code is left byte for byte unchanged since mentions in code don't notify.
+func neutralizeMentions(message, policy string) string {
+	if policy != mentionPolicySuppressAll && policy != mentionPolicyChannelOnly {
+		return message
+	}
+
+	return neutralizeMentionsFunc(message, func(name string) bool {
+		return policy != mentionPolicyChannelOnly || !channelMentions[strings.ToLower(name)]
+	})
+}
 
-		if i == 0 {
-			newPost, appErr = p.API.CreatePost(newPost)
-			if appErr != nil {
-				return nil, errors.Wrap(appErr, "unable to create new root post")
+// neutralizeMentionsFunc returns the message with the at-mentions for which
+// neutralize returns true neutralized. Code is left unchanged.
+func neutralizeMentionsFunc(message string, neutralize func(name string) bool) string {
+	return mapOutsideCode(message, func(text string) string {
+		return mentionRegexp.ReplaceAllStringFunc(text, func(match string) string {
+			submatches := mentionRegexp.FindStringSubmatch(match)
+			if !neutralize(strings.TrimRight(submatches[2], ".-_")) {
+				return match
 			}
-			newRootPost = newPost.Clone()
-		} else {
-			newPost.RootId = newRootPost.Id
-			newPost.ParentId = newRootPost.Id
-			newPost, appErr = p.API.CreatePost(newPost)
-			if appErr != nil {
-				return nil, errors.Wrap(appErr, "unable to create new post")
+
+			return submatches[1] + "@\u200b" + submatches[2]
+		})
+	})
+}
+
+// mapOutsideCode returns the message with the text outside of fenced code
+// blocks and inline code spans replaced by the result of fn. Code is copied
+// unchanged, and fn is called with one line of text at a time.
+func mapOutsideCode(message string, fn func(text string) string) string {
+	var b strings.Builder
+	var fence string
+	for _, line := range strings.SplitAfter(message, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		switch {
+		case len(fence) != 0:
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
 			}
+			b.WriteString(line)
+		case strings.HasPrefix(trimmed, "```"):
+			fence = "```"
+			b.WriteString(line)
+		case strings.HasPrefix(trimmed, "~~~"):
+			fence = "~~~"
+			b.WriteString(line)
+		default:
+			b.WriteString(mapOutsideInlineCode(line, fn))
 		}
+	}
 
-		for _, reaction := range reactions {
-			reaction.PostId = newPost.Id
-			_, appErr = p.API.AddReaction(reaction)
-			if appErr != nil {
-				// Reaction-based errors are logged, but do not cause the plugin to
-				// abort the move thread process.
-				p.API.LogError("Failed to reapply reactions to post", "err", appErr)
+	return b.String()
+}
+
+// mapOutsideInlineCode returns the line with the text outside of inline code
+// spans replaced by the result of fn. A code span starts with a run of
+// backticks and ends with the next run of the same length; unmatched runs are
+// treated as text.
+func mapOutsideInlineCode(line string, fn func(text string) string) string {
+	var b strings.Builder
+	var last int
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		n := backtickRunLength(line[i:])
+		end := -1
+		for j := i + n; j < len(line); {
+			k := strings.IndexByte(line[j:], '`')
+			if k == -1 {
+				break
+			}
+			m := backtickRunLength(line[j+k:])
+			if m == n {
+				end = j + k + m
+				break
 			}
+			j += k + m
 		}
+		if end == -1 {
+			i += n
+			continue
+		}
+
+		b.WriteString(fn(line[last:i]))
+		b.WriteString(line[i:end])
+		last = end
+		i = end
 	}
+	b.WriteString(fn(line[last:]))
 
-	return newRootPost, nil
+	return b.String()
+}
+
+func backtickRunLength(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+
+	return n
+}
+
+// appendHashtag appends the given hashtag on its own line at the end of the
+// post message and registers it as a post hashtag so that it is indexed by
+// search. Posts already containing the hashtag are left untouched.
+func appendHashtag(post *model.Post, hashtag string) {
+	hashtags, _ := model.ParseHashtags(post.Message)
+	for _, existing := range strings.Fields(hashtags) {
+		if strings.EqualFold(existing, hashtag) {
+			return
+		}
+	}
+
+	if len(strings.TrimSpace(post.Message)) == 0 {
+		post.Message = hashtag
+	} else {
+		post.Message = fmt.Sprintf("%s\n\n%s", strings.TrimRight(post.Message, "\n"), hashtag)
+	}
+	post.Hashtags, _ = model.ParseHashtags(post.Message)
+}
+
+// filterReactions returns the reactions that don't use the given emoji.
+func filterReactions(reactions []*model.Reaction, emojiName string) []*model.Reaction {
+	emojiName = strings.Trim(emojiName, ":")
+	if len(emojiName) == 0 {
+		return reactions
+	}
+
+	var filtered []*model.Reaction
+	for _, reaction := range reactions {
+		if reaction.EmojiName != emojiName {
+			filtered = append(filtered, reaction)
+		}
+	}
+
+	return filtered
 }
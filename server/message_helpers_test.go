@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendHashtag(t *testing.T) {
+	tests := []struct {
+		name             string
+		message          string
+		expectedMessage  string
+		expectedHashtags string
+	}{
+		{
+			name:             "empty message",
+			message:          "",
+			expectedMessage:  "#moved",
+			expectedHashtags: "#moved",
+		},
+		{
+			name:             "plain message",
+			message:          "This is a message",
+			expectedMessage:  "This is a message\n\n#moved",
+			expectedHashtags: "#moved",
+		},
+		{
+			name:             "message with code block",
+			message:          "```\ncode\n```\n",
+			expectedMessage:  "```\ncode\n```\n\n#moved",
+			expectedHashtags: "#moved",
+		},
+		{
+			name:             "message with other hashtag",
+			message:          "This is #important",
+			expectedMessage:  "This is #important\n\n#moved",
+			expectedHashtags: "#important #moved",
+		},
+		{
+			name:             "message already tagged",
+			message:          "This was #Moved before",
+			expectedMessage:  "This was #Moved before",
+			expectedHashtags: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &model.Post{Message: tt.message}
+			appendHashtag(post, "#moved")
+			assert.Equal(t, tt.expectedMessage, post.Message)
+			assert.Equal(t, tt.expectedHashtags, post.Hashtags)
+		})
+	}
+}
+
+// newCopyTestAPI returns a mocked plugin API that records the posts created
+// by copyWranglerPostlist. Post creation takes the given latency, and fails
+// for posts with the given message.
+func newCopyTestAPI(latency func() time.Duration, failingMessage string) (*plugintest.API, func() []*model.Post) {
+	var lock sync.Mutex
+	var created []*model.Post
+
+	api := &plugintest.API{}
+	api.On("GetReactions", mock.AnythingOfType("string")).Return(nil, nil)
+	api.On("CreatePost", mock.Anything).Return(
+		func(post *model.Post) *model.Post {
+			time.Sleep(latency())
+			if post.Message == failingMessage {
+				return nil
+			}
+
+			newPost := post.Clone()
+			newPost.Id = model.NewId()
+			if newPost.CreateAt == 0 {
+				newPost.CreateAt = model.GetMillis()
+			}
+			lock.Lock()
+			created = append(created, newPost)
+			lock.Unlock()
+
+			return newPost
+		},
+		func(post *model.Post) *model.AppError {
+			if post.Message == failingMessage {
+				return model.NewAppError("test", "failed", nil, "", http.StatusInternalServerError)
+			}
+			return nil
+		},
+	)
+
+	return api, func() []*model.Post {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]*model.Post{}, created...)
+	}
+}
+
+func newCopyTestPostList(replyCount int) *WranglerPostList {
+	rootPost := &model.Post{Id: model.NewId(), UserId: model.NewId(), Message: "root", CreateAt: 1}
+	wpl := &WranglerPostList{Posts: []*model.Post{rootPost}}
+	for i := 0; i < replyCount; i++ {
+		wpl.Posts = append(wpl.Posts, &model.Post{
+			Id:       model.NewId(),
+			UserId:   model.NewId(),
+			RootId:   rootPost.Id,
+			ParentId: rootPost.Id,
+			Message:  fmt.Sprintf("reply %d", i+1),
+			CreateAt: int64(i + 2),
+		})
+	}
+	wpl.updateMetadata()
+
+	return wpl
+}
+
+func TestNeutralizeMentions(t *testing.T) {
+	message := "@channel please review, @alice and @bob.smith. Mail alice@example.com or ping @HERE."
+
+	tests := []struct {
+		policy          string
+		expectedMessage string
+	}{
+		{
+			policy:          mentionPolicyPreserveAll,
+			expectedMessage: message,
+		},
+		{
+			policy:          mentionPolicySuppressAll,
+			expectedMessage: "@\u200bchannel please review, @\u200balice and @\u200bbob.smith. Mail alice@example.com or ping @\u200bHERE.",
+		},
+		{
+			policy:          mentionPolicyChannelOnly,
+			expectedMessage: "@channel please review, @\u200balice and @\u200bbob.smith. Mail alice@example.com or ping @HERE.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			assert.Equal(t, tt.expectedMessage, neutralizeMentions(message, tt.policy))
+		})
+	}
+}
+
+func TestNeutralizeMentionsSkipsCode(t *testing.T) {
+	message := "@alice see `@Override` and ``a ` @b``\n```java\n@Override\n```\n  ~~~\n@Deprecated\n  ~~~\nthanks @bob, `unclosed @carol"
+	expected := "@\u200balice see `@Override` and ``a ` @b``\n```java\n@Override\n```\n  ~~~\n@Deprecated\n  ~~~\nthanks @\u200bbob, `unclosed @\u200bcarol"
+
+	assert.Equal(t, expected, neutralizeMentions(message, mentionPolicySuppressAll))
+}
+
+func TestSleepContext(t *testing.T) {
+	t.Run("no delay", func(t *testing.T) {
+		require.NoError(t, sleepContext(context.Background(), 0))
+	})
+
+	t.Run("delay elapses", func(t *testing.T) {
+		start := time.Now()
+		require.NoError(t, sleepContext(context.Background(), 20*time.Millisecond))
+		assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	})
+
+	t.Run("context done first", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := sleepContext(ctx, time.Minute)
+		require.Equal(t, context.DeadlineExceeded, err)
+		assert.True(t, time.Since(start) < time.Minute)
+	})
+}
+
+func TestCopyWranglerPostlistConcurrency(t *testing.T) {
+	targetChannel := &model.Channel{Id: model.NewId()}
+	randomLatency := func() time.Duration {
+		return time.Duration(rand.Intn(3)) * time.Millisecond
+	}
+
+	// Sequentially created posts can share a server timestamp, in which case
+	// they are ordered by creation.
+	for _, concurrency := range []int{1, 5} {
+		t.Run(fmt.Sprintf("concurrency %d keeps the thread order", concurrency), func(t *testing.T) {
+			api, created := newCopyTestAPI(randomLatency, "")
+			plugin := &Plugin{}
+			plugin.SetAPI(api)
+
+			wpl := newCopyTestPostList(20)
+			newRootPost, err := plugin.copyWranglerPostlist(context.Background(), wpl, targetChannel, copyOptions{concurrency: concurrency})
+			require.NoError(t, err)
+
+			posts := created()
+			require.Len(t, posts, 21)
+			// The root post is created before any reply references it.
+			assert.Equal(t, newRootPost.Id, posts[0].Id)
+			assert.Empty(t, posts[0].RootId)
+
+			sort.SliceStable(posts, func(i, j int) bool {
+				return posts[i].CreateAt < posts[j].CreateAt
+			})
+			for i, post := range posts {
+				assert.Equal(t, wpl.Posts[i].Message, post.Message)
+				if i != 0 {
+					assert.Equal(t, newRootPost.Id, post.RootId)
+					assert.GreaterOrEqual(t, post.CreateAt, posts[i-1].CreateAt)
+					if concurrency > 1 {
+						assert.Greater(t, post.CreateAt, posts[i-1].CreateAt)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("failed reply returns the new root post", func(t *testing.T) {
+		api, _ := newCopyTestAPI(randomLatency, "reply 7")
+		mockLogs(api)
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+
+		newRootPost, err := plugin.copyWranglerPostlist(context.Background(), newCopyTestPostList(20), targetChannel, copyOptions{concurrency: 5})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to create new post")
+		require.NotNil(t, newRootPost)
+		assert.Equal(t, "root", newRootPost.Message)
+	})
+}
+
+func BenchmarkCopyWranglerPostlist(b *testing.B) {
+	targetChannel := &model.Channel{Id: model.NewId()}
+	latency := func() time.Duration { return time.Millisecond }
+
+	for _, concurrency := range []int{1, 5, 10} {
+		b.Run(fmt.Sprintf("concurrency %d", concurrency), func(b *testing.B) {
+			api, _ := newCopyTestAPI(latency, "")
+			plugin := &Plugin{}
+			plugin.SetAPI(api)
+			wpl := newCopyTestPostList(50)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := plugin.copyWranglerPostlist(context.Background(), wpl, targetChannel, copyOptions{concurrency: concurrency})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
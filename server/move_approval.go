@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	moveApprovalKeyPrefix = "move_approval_"
+
+	moveApprovalActionApprove = "approve"
+	moveApprovalActionReject  = "reject"
+)
+
+// moveApprovalRequest is a move that is waiting for the approval of a user in
+// the approval channel.
+type moveApprovalRequest struct {
+	ID        string   `json:"id"`
+	UserID    string   `json:"user_id"`
+	ChannelID string   `json:"channel_id"`
+	TeamID    string   `json:"team_id"`
+	Args      []string `json:"args"`
+	// RunAsApprover is set for requests filed with the request move command.
+	// The move runs as the approver since the requester may not be allowed
+	// to move threads.
+	RunAsApprover bool `json:"run_as_approver,omitempty"`
+}
+
+func getMoveApprovalKey(requestID string) string {
+	return moveApprovalKeyPrefix + requestID
+}
+
+// requiresMoveApproval returns true if moves out of the channel by the user
+// must be approved first. System admins and channel admins never need
+// approval.
+func (p *Plugin) requiresMoveApproval(channel *model.Channel, userID string) bool {
+	config := p.getConfiguration()
+	if len(config.MoveApprovalChannelID) == 0 {
+		return false
+	}
+
+	sourceChannelIDs := config.MoveApprovalSourceChannelIDs()
+	if len(sourceChannelIDs) != 0 {
+		var found bool
+		for _, channelID := range sourceChannelIDs {
+			if channelID == channel.Id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		return false
+	}
+	if p.API.HasPermissionToChannel(userID, channel.Id, model.PERMISSION_MANAGE_CHANNEL_ROLES) {
+		return false
+	}
+
+	return true
+}
+
+// requestMoveApproval stores the move as a pending request and posts a message
+// with approve and reject buttons to the approval channel.
+func (p *Plugin) requestMoveApproval(request *moveApprovalRequest, rootPost *model.Post, movedCount int, originalChannel, targetChannel *model.Channel) error {
+	err := p.kvSetJSON(getMoveApprovalKey(request.ID), request)
+	if err != nil {
+		return errors.Wrap(err, "unable to store move approval request")
+	}
+
+	username := "Someone"
+	user, appErr := p.API.GetUser(request.UserID)
+	if appErr == nil {
+		username = "@" + user.Username
+	}
+
+	rootPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, p.getTeamName(request.TeamID), rootPost.Id)
+	post := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: p.getConfiguration().MoveApprovalChannelID,
+		Message: fmt.Sprintf(
+			"%s requested to move %d message(s) from ~%s to ~%s: %s",
+			username, movedCount, originalChannel.Name, targetChannel.Name, rootPostLink,
+		),
+	}
+	actionURL := fmt.Sprintf("/plugins/%s%s", manifest.Id, routeAPIMoveApproval)
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{{
+		Actions: []*model.PostAction{
+			{
+				Name: "Approve",
+				Type: model.POST_ACTION_TYPE_BUTTON,
+				Integration: &model.PostActionIntegration{
+					URL:     actionURL,
+					Context: map[string]interface{}{"request_id": request.ID, "action": moveApprovalActionApprove},
+				},
+			},
+			{
+				Name: "Reject",
+				Type: model.POST_ACTION_TYPE_BUTTON,
+				Integration: &model.PostActionIntegration{
+					URL:     actionURL,
+					Context: map[string]interface{}{"request_id": request.ID, "action": moveApprovalActionReject},
+				},
+			},
+		},
+	}})
+
+	_, appErr = p.API.CreatePost(post)
+	if appErr != nil {
+		// Remove the request as nobody will be able to handle it.
+		p.API.KVDelete(getMoveApprovalKey(request.ID))
+		return errors.Wrap(appErr, "unable to post move approval request")
+	}
+
+	p.API.LogInfo("Wrangler move approval requested",
+		"user_id", request.UserID,
+		"original_post_id", rootPost.Id,
+		"request_id", request.ID,
+	)
+
+	return nil
+}
+
+// getPendingMoveApprovals returns all move requests that are waiting for
+// approval.
+func (p *Plugin) getPendingMoveApprovals() ([]*moveApprovalRequest, error) {
+	keys, err := p.kvListKeysWithPrefix(moveApprovalKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*moveApprovalRequest
+	for _, key := range keys {
+		var request moveApprovalRequest
+		found, err := p.kvGetJSON(key, &request)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			requests = append(requests, &request)
+		}
+	}
+
+	return requests, nil
+}
+
+// removeUserMoveApprovals removes the pending move requests filed by the user
+// and returns how many were removed. Requests handled in the meantime are not
+// counted.
+func (p *Plugin) removeUserMoveApprovals(userID string) (int, error) {
+	requests, err := p.getPendingMoveApprovals()
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int
+	for _, request := range requests {
+		if request.UserID != userID {
+			continue
+		}
+		taken, err := p.takeMoveApproval(request.ID)
+		if err != nil {
+			return removed, err
+		}
+		if taken != nil {
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// takeMoveApproval removes the pending request with the given ID and returns
+// it, or nil if there is no such request. Each request can only be taken once.
+func (p *Plugin) takeMoveApproval(requestID string) (*moveApprovalRequest, error) {
+	key := getMoveApprovalKey(requestID)
+	data, appErr := p.API.KVGet(key)
+	if appErr != nil {
+		return nil, errors.Wrapf(appErr, "unable to get KV value for key %s", key)
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	deleted, appErr := p.API.KVCompareAndDelete(key, data)
+	if appErr != nil {
+		return nil, errors.Wrapf(appErr, "unable to delete KV value for key %s", key)
+	}
+	if !deleted {
+		// Someone else handled the request in the meantime.
+		return nil, nil
+	}
+
+	var request moveApprovalRequest
+	err := json.Unmarshal(data, &request)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to unmarshal KV value for key %s", key)
+	}
+
+	return &request, nil
+}
+
+// handleMoveApprovalAction approves or rejects a pending move request on behalf
+// of the given user and returns the response to the button action.
+func (p *Plugin) handleMoveApprovalAction(userID, requestID, action string) (*model.PostActionIntegrationResponse, error) {
+	config := p.getConfiguration()
+	if action != moveApprovalActionApprove && action != moveApprovalActionReject {
+		return nil, fmt.Errorf("unknown move approval action %s", action)
+	}
+
+	// System admins may also handle requests from the admin queue.
+	_, appErr := p.API.GetChannelMember(config.MoveApprovalChannelID, userID)
+	if appErr != nil && !p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		return &model.PostActionIntegrationResponse{EphemeralText: "Only members of the approval channel can handle move requests."}, nil
+	}
+	if action == moveApprovalActionApprove && config.MaintenanceMode {
+		return &model.PostActionIntegrationResponse{EphemeralText: maintenanceModeMessage}, nil
+	}
+
+	request, err := p.takeMoveApproval(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return &model.PostActionIntegrationResponse{EphemeralText: "This move request was already handled."}, nil
+	}
+
+	approver := "someone"
+	user, appErr := p.API.GetUser(userID)
+	if appErr == nil {
+		approver = "@" + user.Username
+	}
+
+	p.API.LogInfo("Wrangler move approval handled",
+		"user_id", userID,
+		"request_id", request.ID,
+		"action", action,
+	)
+
+	if action == moveApprovalActionReject {
+		err = p.PostBotDM(request.UserID, fmt.Sprintf("Your request to move a thread was rejected by %s.", approver))
+		if err != nil {
+			p.API.LogError("Unable to send move rejection DM", "error", err.Error(), "user_id", request.UserID)
+		}
+
+		return &model.PostActionIntegrationResponse{
+			Update: &model.Post{Message: fmt.Sprintf("The move request by %s was rejected by %s.", p.getUserMention(request.UserID), approver), Props: model.StringInterface{}},
+		}, nil
+	}
+
+	// The move is run as the requesting user so that all permission checks
+	// apply to them, unless the request was filed by a user who can't move
+	// threads themselves.
+	extra := &model.CommandArgs{
+		UserId:    request.UserID,
+		ChannelId: request.ChannelID,
+		TeamId:    request.TeamID,
+	}
+	if request.RunAsApprover {
+		extra.UserId = userID
+	}
+	resp, _, err := p.moveThreadCommand(request.Args, extra, true)
+	var result string
+	switch {
+	case err != nil:
+		result = fmt.Sprintf("Error: %s", err.Error())
+	case resp != nil:
+		result = resp.Text
+	}
+
+	err = p.PostBotDM(request.UserID, fmt.Sprintf("Your request to move a thread was approved by %s.\n\n%s", approver, result))
+	if err != nil {
+		p.API.LogError("Unable to send move approval DM", "error", err.Error(), "user_id", request.UserID)
+	}
+
+	return &model.PostActionIntegrationResponse{
+		Update: &model.Post{Message: fmt.Sprintf("The move request by %s was approved by %s.\n\n%s", p.getUserMention(request.UserID), approver, result), Props: model.StringInterface{}},
+	}, nil
+}
+
+// getUserMention returns the @-mention of the user, or a placeholder if the
+// user can't be found.
+func (p *Plugin) getUserMention(userID string) string {
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return "someone"
+	}
+
+	return "@" + user.Username
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveApproval(t *testing.T) {
+	approvalChannelID := model.NewId()
+	approverID := model.NewId()
+
+	isApprovalRequest := func(post *model.Post) bool {
+		return post.ChannelId == approvalChannelID && len(post.Attachments()) == 1
+	}
+
+	setup := func(config *configuration) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+		f.unsetMock("HasPermissionToChannel")
+		f.api.On("HasPermissionTo", mock.AnythingOfType("string"), model.PERMISSION_MANAGE_SYSTEM).Return(false)
+		f.api.On("HasPermissionToChannel", mock.AnythingOfType("string"), f.originalChannel.Id, model.PERMISSION_MANAGE_CHANNEL_ROLES).Return(false)
+		f.api.On("HasPermissionToChannel", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).Return(true)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+
+		return f, plugin
+	}
+
+	pendingRequestIDs := func(f *threadTestFixture) []string {
+		var requestIDs []string
+		for key := range f.kvStore.data {
+			if strings.HasPrefix(key, moveApprovalKeyPrefix) {
+				requestIDs = append(requestIDs, strings.TrimPrefix(key, moveApprovalKeyPrefix))
+			}
+		}
+		return requestIDs
+	}
+
+	// requestMove runs a move that needs approval and returns the ID of the
+	// stored request.
+	requestMove := func(t *testing.T, f *threadTestFixture, plugin *Plugin) string {
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "requires approval")
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(isApprovalRequest))
+		f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+
+		requestIDs := pendingRequestIDs(f)
+		require.Len(t, requestIDs, 1)
+		return requestIDs[0]
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		f, plugin := setup(&configuration{})
+
+		_, _, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		f.api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(isApprovalRequest))
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+	})
+
+	t.Run("other source channel", func(t *testing.T) {
+		f, plugin := setup(&configuration{MoveApprovalChannelID: approvalChannelID, MoveApprovalSourceChannels: model.NewId()})
+
+		_, _, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		f.api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(isApprovalRequest))
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+	})
+
+	t.Run("system admins don't need approval", func(t *testing.T) {
+		f, plugin := setup(&configuration{MoveApprovalChannelID: approvalChannelID})
+		f.unsetMock("HasPermissionTo")
+		f.api.On("HasPermissionTo", mock.AnythingOfType("string"), model.PERMISSION_MANAGE_SYSTEM).Return(true)
+
+		_, _, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		f.api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(isApprovalRequest))
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		f, plugin := setup(&configuration{MoveApprovalChannelID: approvalChannelID})
+		requestID := requestMove(t, f, plugin)
+
+		resp, err := plugin.handleMoveApprovalAction(approverID, requestID, moveApprovalActionApprove)
+		require.NoError(t, err)
+		require.NotNil(t, resp.Update)
+		assert.Contains(t, resp.Update.Message, "was approved by @active.user")
+		assert.Empty(t, resp.Update.Attachments())
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return strings.HasPrefix(post.Message, "Your request to move a thread was approved by @active.user.")
+		}))
+		assert.Empty(t, pendingRequestIDs(f))
+
+		resp, err = plugin.handleMoveApprovalAction(approverID, requestID, moveApprovalActionApprove)
+		require.NoError(t, err)
+		assert.Equal(t, "This move request was already handled.", resp.EphemeralText)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		f, plugin := setup(&configuration{MoveApprovalChannelID: approvalChannelID})
+		requestID := requestMove(t, f, plugin)
+
+		resp, err := plugin.handleMoveApprovalAction(approverID, requestID, moveApprovalActionReject)
+		require.NoError(t, err)
+		require.NotNil(t, resp.Update)
+		assert.Contains(t, resp.Update.Message, "was rejected by @active.user")
+		f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "Your request to move a thread was rejected by @active.user."
+		}))
+		assert.Empty(t, pendingRequestIDs(f))
+	})
+
+	t.Run("approver not in the approval channel", func(t *testing.T) {
+		f, plugin := setup(&configuration{MoveApprovalChannelID: approvalChannelID})
+		requestID := requestMove(t, f, plugin)
+		f.unsetMock("GetChannelMember")
+		f.api.On("GetChannelMember", approvalChannelID, approverID).Return(nil, &model.AppError{Message: "not found"})
+
+		resp, err := plugin.handleMoveApprovalAction(approverID, requestID, moveApprovalActionApprove)
+		require.NoError(t, err)
+		assert.Equal(t, "Only members of the approval channel can handle move requests.", resp.EphemeralText)
+		f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+		assert.Len(t, pendingRequestIDs(f), 1)
+	})
+}
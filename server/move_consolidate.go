@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// renderConsolidatedThread returns the combined message of a consolidated
+// move, split into parts that each fit in a post. The transcript entries name
+// the author and the time of each post, in the time zone of the given user,
+// and follow the configured mention policies, as the combined message is
+// posted anew. An error meant for the user is returned when the thread can't
+// be consolidated without losing content.
+func (p *Plugin) renderConsolidatedThread(wpl *WranglerPostList, originalChannel, targetChannel *model.Channel, userID string, groupMentions *groupMentionFilter) ([]string, error) {
+	if wpl.ContainsFileAttachments() {
+		return nil, errors.Errorf("the thread has %d file attachment(s), which can't be kept in a consolidated message; move the thread without --%s", wpl.FileAttachmentCount, flagMoveThreadConsolidate)
+	}
+
+	config := p.getConfiguration()
+	header := fmt.Sprintf("#### Consolidated thread of %d message(s)", wpl.NumPosts())
+	if destinationHeader := config.DestinationHeader(originalChannel, targetChannel.Id); len(destinationHeader) != 0 {
+		header = destinationHeader + "\n\n" + header
+	}
+	if footer := config.MovedFromFooterForChannel(originalChannel, targetChannel.Id); len(footer) != 0 {
+		header += "\n\n" + footer
+	}
+	entries := []string{header}
+	for _, entry := range renderTranscriptEntries(wpl, p.getAuthorUsernames(wpl.Posts), p.getUserLocation(userID)) {
+		entries = append(entries, groupMentions.apply(neutralizeMentions(entry, config.MentionPolicyValue())))
+	}
+
+	chunks, splittable := splitTranscript(entries, maxTranscriptPostRunes)
+	if !splittable {
+		return nil, errors.Errorf("the thread has a code block or line that is too long for a single message; move the thread without --%s", flagMoveThreadConsolidate)
+	}
+
+	return chunks, nil
+}
+
+// moveThreadConsolidated moves a whole thread to the target channel as a
+// single combined post by the Wrangler bot instead of recreating each post,
+// which reduces the number of posts in archives. Combined messages that are
+// too long for a single post are continued in replies to it. Reactions aren't
+// kept, and only the original root post is linked to the combined post.
+func (p *Plugin) moveThreadConsolidated(wpl *WranglerPostList, originalChannel, targetChannel *model.Channel, targetTeam *model.Team, extra *model.CommandArgs, options moveThreadOptions) (*model.CommandResponse, bool, error) {
+	groupMentions := p.getGroupMentionFilter(wpl.Posts)
+	chunks, err := p.renderConsolidatedThread(wpl, originalChannel, targetChannel, extra.UserId, groupMentions)
+	if err != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: %s", err.Error())), true, nil
+	}
+
+	correlationID := model.NewId()
+
+	ctx, cancel := p.newOperationContext()
+	defer cancel()
+
+	release, response := p.acquireOperationSlot(ctx, "move", extra)
+	if response != nil {
+		return response, false, nil
+	}
+	defer release()
+
+	p.API.LogInfo("Wrangler is moving a thread as a consolidated message",
+		"user_id", extra.UserId,
+		"original_post_id", wpl.RootPost().Id,
+		"original_channel_id", originalChannel.Id,
+		"part_count", len(chunks),
+		"correlation_id", correlationID,
+	)
+
+	props := movedPostProps(originalChannel, extra.UserId, p.getConfiguration())
+	// The combined message stands for the whole thread, so it is recorded as
+	// the recreated root message.
+	provenance := newProvenanceTracker()
+	var newRootPost *model.Post
+	for i, chunk := range chunks {
+		post := &model.Post{
+			UserId:    p.BotUserID,
+			ChannelId: targetChannel.Id,
+			Message:   chunk,
+		}
+		if newRootPost != nil {
+			post.RootId = newRootPost.Id
+			post.ParentId = newRootPost.Id
+		}
+		for key, value := range props {
+			post.AddProp(key, value)
+		}
+
+		newPost, appErr := p.API.CreatePost(post)
+		if appErr != nil {
+			return p.rollbackMove(correlationID, "creating the consolidated message", errors.Wrapf(appErr, "unable to create part %d of the consolidated message", i+1), newRootPost, extra), false, nil
+		}
+		if newRootPost == nil {
+			newRootPost = newPost.Clone()
+			provenance.track(wpl.RootPost(), newRootPost)
+		}
+	}
+
+	undone, err := p.waitForMoveUndo(ctx, p.getConfiguration().MoveDeletionDelay(), extra)
+	if err != nil {
+		return p.rollbackMove(correlationID, "waiting for the undo window", err, newRootPost, extra), false, nil
+	}
+	if undone {
+		return p.undoMove(correlationID, newRootPost, extra), false, nil
+	}
+	if err = p.checkMoveChannels(originalChannel.Id, targetChannel.Id); err != nil {
+		return p.rollbackMove(correlationID, "checking the channels before deleting the original messages", err, newRootPost, extra), false, nil
+	}
+
+	newPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, targetTeam.Name, newRootPost.Id)
+	var redirectNote string
+	if options.leaveRedirect {
+		var numDeleted int
+		redirectNote, numDeleted, err = p.replaceWithRedirectStub(wpl, newRootPost.Id, newPostLink)
+		if err != nil {
+			return p.handleRedirectStubFailure(correlationID, err, numDeleted, []string{newRootPost.Id}, newPostLink, wpl, originalChannel, targetChannel, extra), false, nil
+		}
+		provenance.keep(wpl.RootPost().Id)
+	} else {
+		// Cleanup is handled by simply deleting the root post. Any
+		// comments/replies are automatically marked as deleted for us.
+		appErr := p.API.DeletePost(wpl.RootPost().Id)
+		if appErr != nil {
+			return p.handleOriginalDeleteFailure(correlationID, errors.Wrap(appErr, "unable to delete post"), []string{newRootPost.Id}, newPostLink, wpl.NumPosts(), originalChannel, targetChannel, extra), false, nil
+		}
+	}
+
+	p.API.LogInfo("Wrangler consolidated thread move complete",
+		"user_id", extra.UserId,
+		"new_post_id", newRootPost.Id,
+		"new_channel_id", targetChannel.Id,
+		"correlation_id", correlationID,
+	)
+
+	var notes string
+	if len(chunks) > 1 {
+		notes = fmt.Sprintf("The consolidated message was too long for a single post, so it was split into %d parts continued in replies.\n", len(chunks))
+	}
+
+	return p.completeMove(completedMove{
+		correlationID:  correlationID,
+		summary:        "A thread has been moved as a consolidated message",
+		channelLogText: fmt.Sprintf("moved a thread of %d message(s) as a consolidated message to %s", wpl.NumPosts(), newPostLink),
+		wpl:            wpl,
+		newRootPost:    newRootPost,
+		newPostLink:    newPostLink,
+		numPosts:       wpl.NumPosts(),
+		numAuthors:     wpl.NumAuthors(),
+		rootMoved:      true,
+		newThread:      true,
+		notes:          notes + redirectNote,
+		groupMentions:  groupMentions,
+		provenance:     provenance,
+	}, originalChannel, targetChannel, targetTeam, extra, options), false, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// getIntoThreadRootPost returns the root post of the existing thread given by
+// permalink or post ID that a thread is moved into, or an error response if
+// it isn't a thread root in the target channel.
+func (p *Plugin) getIntoThreadRootPost(intoThread string, wpl *WranglerPostList, targetChannel *model.Channel) (*model.Post, *model.CommandResponse) {
+	postID := intoThread
+	if match := permalinkRegexp.FindStringSubmatch(intoThread); match != nil {
+		postID = match[1]
+	}
+	if !model.IsValidId(postID) {
+		return nil, getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: --%s value %s must be the permalink or ID of a root message", flagMoveThreadIntoThread, intoThread))
+	}
+
+	rootPost, appErr := p.API.GetPost(postID)
+	if appErr != nil || rootPost.DeleteAt != 0 {
+		return nil, getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the --%s message %s doesn't exist", flagMoveThreadIntoThread, postID))
+	}
+	if rootPost.ChannelId != targetChannel.Id {
+		return nil, getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the --%s message %s is not in ~%s", flagMoveThreadIntoThread, postID, targetChannel.Name))
+	}
+	if len(rootPost.RootId) != 0 {
+		return nil, getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: the --%s message %s is a reply; use the root message of the thread instead", flagMoveThreadIntoThread, postID))
+	}
+	if rootPost.Id == wpl.RootPost().Id {
+		return nil, getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("Error: a thread can't be moved into itself with --%s", flagMoveThreadIntoThread))
+	}
+
+	return rootPost, nil
+}
+
+// moveThreadIntoThread moves a whole thread to the target channel as replies
+// to an existing thread there. The moved root post becomes the first of the
+// new replies. Since there is no new root post to remove, the recreated posts
+// are removed one by one when the move is rolled back or undone.
+func (p *Plugin) moveThreadIntoThread(wpl *WranglerPostList, originalChannel, targetChannel *model.Channel, targetTeam *model.Team, intoRootPost *model.Post, extra *model.CommandArgs, options moveThreadOptions) (*model.CommandResponse, bool, error) {
+	correlationID := model.NewId()
+
+	ctx, cancel := p.newOperationContext()
+	defer cancel()
+
+	release, response := p.acquireOperationSlot(ctx, "move", extra)
+	if response != nil {
+		return response, false, nil
+	}
+	defer release()
+
+	p.API.LogInfo("Wrangler is moving a thread into an existing thread",
+		"user_id", extra.UserId,
+		"original_post_id", wpl.RootPost().Id,
+		"original_channel_id", originalChannel.Id,
+		"target_root_id", intoRootPost.Id,
+		"correlation_id", correlationID,
+	)
+
+	postOptions := p.getMoveCopyOptions(wpl, originalChannel, targetChannel, extra.UserId)
+	postOptions.rootID = intoRootPost.Id
+	postOptions.provenance = newProvenanceTracker()
+	_, err := p.copyWranglerPostlist(ctx, wpl, targetChannel, postOptions)
+	if err != nil {
+		return p.rollbackMovePosts(correlationID, "copying the messages to the target thread", err, postOptions.provenance.newPostIDs(), extra), false, nil
+	}
+	p.rewriteMovedPermalinks(postOptions.provenance)
+	newPostIDs := postOptions.provenance.newPostIDs()
+
+	notePost, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.getAttributionAuthor(extra.UserId, targetChannel.Id),
+		RootId:    intoRootPost.Id,
+		ParentId:  intoRootPost.Id,
+		ChannelId: targetChannel.Id,
+		Message:   fmt.Sprintf("%d message(s) were moved into this thread from another channel", wpl.NumPosts()) + p.getMoverMention(extra.UserId) + getCompressedImagesNote(postOptions.compression) + getSkippedFilesNote(postOptions.fileLimits),
+	})
+	if appErr != nil {
+		return p.rollbackMovePosts(correlationID, "creating the moved thread note", errors.Wrap(appErr, "unable to create new bot post"), newPostIDs, extra), false, nil
+	}
+	newPostIDs = append(newPostIDs, notePost.Id)
+
+	undone, err := p.waitForMoveUndo(ctx, p.getConfiguration().MoveDeletionDelay(), extra)
+	if err != nil {
+		return p.rollbackMovePosts(correlationID, "waiting for the undo window", err, newPostIDs, extra), false, nil
+	}
+	if undone {
+		return p.undoMovePosts(correlationID, newPostIDs, extra), false, nil
+	}
+	if err = p.checkMoveChannels(originalChannel.Id, targetChannel.Id); err != nil {
+		return p.rollbackMovePosts(correlationID, "checking the channels before deleting the original messages", err, newPostIDs, extra), false, nil
+	}
+
+	newPostLink := makePostLink(*p.API.GetConfig().ServiceSettings.SiteURL, targetTeam.Name, intoRootPost.Id)
+	var redirectNote string
+	if options.leaveRedirect {
+		var numDeleted int
+		redirectNote, numDeleted, err = p.replaceWithRedirectStub(wpl, intoRootPost.Id, newPostLink)
+		if err != nil {
+			return p.handleRedirectStubFailure(correlationID, err, numDeleted, newPostIDs, newPostLink, wpl, originalChannel, targetChannel, extra), false, nil
+		}
+		postOptions.provenance.keep(wpl.RootPost().Id)
+	} else {
+		// Cleanup is handled by simply deleting the root post. Any
+		// comments/replies are automatically marked as deleted for us.
+		appErr = p.API.DeletePost(wpl.RootPost().Id)
+		if appErr != nil {
+			return p.handleOriginalDeleteFailure(correlationID, errors.Wrap(appErr, "unable to delete post"), newPostIDs, newPostLink, wpl.NumPosts(), originalChannel, targetChannel, extra), false, nil
+		}
+	}
+
+	p.API.LogInfo("Wrangler thread move into an existing thread complete",
+		"user_id", extra.UserId,
+		"target_root_id", intoRootPost.Id,
+		"new_channel_id", targetChannel.Id,
+		"correlation_id", correlationID,
+	)
+
+	return p.completeMove(completedMove{
+		correlationID:  correlationID,
+		summary:        "A thread has been moved into an existing thread",
+		channelLogText: fmt.Sprintf("moved a thread of %d message(s) into %s", wpl.NumPosts(), newPostLink),
+		wpl:            wpl,
+		newRootPost:    intoRootPost,
+		newPostLink:    newPostLink,
+		numPosts:       wpl.NumPosts(),
+		numAuthors:     wpl.NumAuthors(),
+		rootMoved:      true,
+		notes:          redirectNote,
+		groupMentions:  postOptions.groupMentions,
+		provenance:     postOptions.provenance,
+	}, originalChannel, targetChannel, targetTeam, extra, options), false, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const moveQuotaKeyPrefix = "move_quota_"
+
+// moveQuotaDayFormat identifies the UTC day that moves are counted for.
+const moveQuotaDayFormat = "2006-01-02"
+
+// moveQuota counts the moves out of a channel during a single day.
+type moveQuota struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+func getMoveQuotaKey(channelID string) string {
+	return moveQuotaKeyPrefix + channelID
+}
+
+func currentMoveQuotaDay() string {
+	return now().UTC().Format(moveQuotaDayFormat)
+}
+
+// moveQuotaReached returns true if the configured number of moves out of the
+// channel for the current day was reached. System admins are never limited.
+func (p *Plugin) moveQuotaReached(channelID, userID string) (bool, error) {
+	max := p.getConfiguration().MaxMovesPerChannelPerDayInt()
+	if max == 0 {
+		return false, nil
+	}
+	if p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		return false, nil
+	}
+
+	var quota moveQuota
+	_, err := p.kvGetJSON(getMoveQuotaKey(channelID), &quota)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to get move quota")
+	}
+	if quota.Day != currentMoveQuotaDay() {
+		return false, nil
+	}
+
+	return quota.Count >= max, nil
+}
+
+// recordChannelMove counts a completed move out of the channel towards the
+// quota of the current day. Counts of earlier days are reset. Failures are
+// logged as the move itself already succeeded.
+func (p *Plugin) recordChannelMove(channelID string) {
+	if p.getConfiguration().MaxMovesPerChannelPerDayInt() == 0 {
+		return
+	}
+
+	err := p.kvAtomicModify(getMoveQuotaKey(channelID), func(initial []byte) ([]byte, error) {
+		var quota moveQuota
+		if initial != nil {
+			err := json.Unmarshal(initial, &quota)
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to unmarshal move quota")
+			}
+		}
+
+		day := currentMoveQuotaDay()
+		if quota.Day != day {
+			quota = moveQuota{Day: day}
+		}
+		quota.Count++
+
+		return json.Marshal(quota)
+	})
+	if err != nil {
+		p.API.LogError("Unable to record move quota", "error", err.Error(), "channel_id", channelID)
+	}
+}
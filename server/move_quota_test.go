@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveThreadDailyQuota(t *testing.T) {
+	currentTime := time.Date(2020, time.June, 1, 23, 50, 0, 0, time.UTC)
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	setup := func(isAdmin bool) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+		f.api.On("HasPermissionTo", mock.AnythingOfType("string"), model.PERMISSION_MANAGE_SYSTEM).Return(isAdmin)
+
+		plugin := &Plugin{BotUserID: model.NewId()}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{MaxMovesPerChannelPerDay: "2"})
+
+		return f, plugin
+	}
+
+	t.Run("quota reached and reset the next day", func(t *testing.T) {
+		f, plugin := setup(false)
+
+		for i := 0; i < 2; i++ {
+			resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+			require.NoError(t, err)
+			assert.False(t, isUserError)
+			assert.Contains(t, resp.Text, "A thread has been moved")
+		}
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: the daily limit of 2 thread moves out of ~original-channel was reached; try again tomorrow", resp.Text)
+
+		t.Run("still blocked at the end of the day", func(t *testing.T) {
+			currentTime = time.Date(2020, time.June, 1, 23, 59, 59, 0, time.UTC)
+
+			reached, err := plugin.moveQuotaReached(f.originalChannel.Id, f.rootPost.UserId)
+			require.NoError(t, err)
+			assert.True(t, reached)
+		})
+
+		t.Run("reset after midnight", func(t *testing.T) {
+			currentTime = time.Date(2020, time.June, 2, 0, 0, 1, 0, time.UTC)
+
+			resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+			require.NoError(t, err)
+			assert.False(t, isUserError)
+			assert.Contains(t, resp.Text, "A thread has been moved")
+
+			var quota moveQuota
+			found, err := plugin.kvGetJSON(getMoveQuotaKey(f.originalChannel.Id), &quota)
+			require.NoError(t, err)
+			require.True(t, found)
+			assert.Equal(t, moveQuota{Day: "2020-06-02", Count: 1}, quota)
+		})
+	})
+
+	t.Run("merges count towards the quota", func(t *testing.T) {
+		f, plugin := setup(false)
+		targetRoot := &model.Post{Id: model.NewId(), UserId: f.rootPost.UserId, ChannelId: f.targetChannel.Id, Message: "target"}
+		targetPostList := model.NewPostList()
+		targetPostList.AddPost(targetRoot)
+		targetPostList.AddOrder(targetRoot.Id)
+		f.api.On("GetPostThread", targetRoot.Id).Return(targetPostList, nil)
+
+		resp, isUserError, err := plugin.runMergeThreadCommand([]string{f.rootPost.Id, targetRoot.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been merged")
+
+		resp, isUserError, err = plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+
+		resp, isUserError, err = plugin.runMergeThreadCommand([]string{f.rootPost.Id, targetRoot.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Equal(t, "Error: the daily limit of 2 thread moves out of ~original-channel was reached; try again tomorrow", resp.Text)
+	})
+
+	t.Run("other channels are not limited", func(t *testing.T) {
+		f, plugin := setup(false)
+		require.NoError(t, plugin.kvSetJSON(getMoveQuotaKey(model.NewId()), moveQuota{Day: currentMoveQuotaDay(), Count: 2}))
+
+		reached, err := plugin.moveQuotaReached(f.originalChannel.Id, f.rootPost.UserId)
+		require.NoError(t, err)
+		assert.False(t, reached)
+	})
+
+	t.Run("system admins bypass the quota", func(t *testing.T) {
+		f, plugin := setup(true)
+		require.NoError(t, plugin.kvSetJSON(getMoveQuotaKey(f.originalChannel.Id), moveQuota{Day: currentMoveQuotaDay(), Count: 2}))
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		f, plugin := setup(false)
+		plugin.setConfiguration(&configuration{})
+
+		_, _, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		f.api.AssertNotCalled(t, "KVGet", getMoveQuotaKey(f.originalChannel.Id))
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// movedRedirectProp marks an original root post that was kept as a redirect
+// to its moved thread. The value is the ID of the post it redirects to.
+const movedRedirectProp = "wrangler_moved_to"
+
+// replaceWithRedirectStub removes the original thread of a move while keeping
+// its root post as a permanent redirect to the new location, so that links to
+// the original root post keep resolving. The replies are deleted and the root
+// post is edited in place, as the server doesn't allow creating a post with a
+// given ID. It returns a note for the move summary and the number of original
+// messages that were deleted, which is also set when it fails. When the root
+// post can't be edited, for example because of the post edit time limit, it is
+// deleted as in any other move.
+func (p *Plugin) replaceWithRedirectStub(wpl *WranglerPostList, newPostID, newPostLink string) (string, int, error) {
+	for i, post := range wpl.Posts[1:] {
+		appErr := p.API.DeletePost(post.Id)
+		if appErr != nil {
+			return "", i, errors.Wrap(appErr, "unable to delete post")
+		}
+	}
+	numDeleted := wpl.NumPosts() - 1
+
+	stub := wpl.RootPost().Clone()
+	stub.Message = fmt.Sprintf("This thread was moved to %s. This message was kept by Wrangler so that links to it keep working.", newPostLink)
+	stub.FileIds = nil
+	stub.IsPinned = false
+	stub.Props = model.StringInterface{movedRedirectProp: newPostID}
+
+	_, appErr := p.API.UpdatePost(stub)
+	if appErr != nil {
+		p.API.LogError("Unable to keep the original root post as a redirect",
+			"error", appErr.Error(),
+			"post_id", stub.Id,
+		)
+
+		appErr = p.API.DeletePost(stub.Id)
+		if appErr != nil {
+			return "", numDeleted, errors.Wrap(appErr, "unable to delete post")
+		}
+		return "\nThe original root message could not be kept as a redirect, so it was deleted.\n", numDeleted + 1, nil
+	}
+
+	return "\nThe original root message was kept as a redirect to the moved thread so that links to it keep working.\n", numDeleted, nil
+}
+
+// handleRedirectStubFailure handles a move whose original thread couldn't be
+// replaced with a redirect. It is handled as any other deletion failure when
+// nothing was deleted yet, and reported as a partial move otherwise.
+func (p *Plugin) handleRedirectStubFailure(correlationID string, stubErr error, numDeleted int, newPostIDs []string, newPostLink string, wpl *WranglerPostList, originalChannel, targetChannel *model.Channel, extra *model.CommandArgs) *model.CommandResponse {
+	if numDeleted == 0 {
+		return p.handleOriginalDeleteFailure(correlationID, stubErr, newPostIDs, newPostLink, wpl.NumPosts(), originalChannel, targetChannel, extra)
+	}
+
+	return p.reportPartialOriginalDelete(correlationID, stubErr, newPostLink, numDeleted, wpl.NumPosts(), originalChannel, extra)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// maxMoveSelectionPosts is the largest number of posts that can be selected
+// for a single move.
+const maxMoveSelectionPosts = 200
+
+// moveSelectionRequest is the body of a move selection API request.
+type moveSelectionRequest struct {
+	PostIDs                 []string `json:"post_ids"`
+	ChannelID               string   `json:"channel_id"`
+	ConfirmIntegrationPosts bool     `json:"confirm_integration_posts"`
+	ConfirmPlaybookPosts    bool     `json:"confirm_playbook_posts"`
+}
+
+// moveSelectionResult reports the outcome of a move selection.
+type moveSelectionResult struct {
+	MovedThreadCount int                         `json:"moved_thread_count"`
+	FailedCount      int                         `json:"failed_count"`
+	Threads          []moveSelectionThreadResult `json:"threads"`
+}
+
+// moveSelectionThreadResult reports the outcome of moving one of the threads
+// of a move selection.
+type moveSelectionThreadResult struct {
+	RootID          string   `json:"root_id,omitempty"`
+	SelectedPostIDs []string `json:"selected_post_ids"`
+	PostCount       int      `json:"post_count"`
+	Success         bool     `json:"success"`
+	Message         string   `json:"message"`
+}
+
+// selectedThread is a distinct thread of a move selection.
+type selectedThread struct {
+	wpl             *WranglerPostList
+	channel         *model.Channel
+	selectedPostIDs []string
+}
+
+// moveSelection moves the threads that the selected posts belong to into the
+// target channel. Posts of the same thread are moved together once. Each
+// thread is moved with the same checks as the move thread command, and the
+// combined size of the threads must be within the max thread count.
+func (p *Plugin) moveSelection(userID string, request *moveSelectionRequest) (*moveSelectionResult, string) {
+	if len(request.PostIDs) == 0 {
+		return nil, "no posts were selected"
+	}
+	if len(request.PostIDs) > maxMoveSelectionPosts {
+		return nil, fmt.Sprintf("%d posts were selected, but at most %d posts can be moved at once", len(request.PostIDs), maxMoveSelectionPosts)
+	}
+	if len(request.ChannelID) == 0 {
+		return nil, "no target channel was given"
+	}
+
+	threads, failed := p.collectSelectedThreads(userID, request.PostIDs)
+	combinedCount := countSelectedPosts(threads)
+
+	config := p.getConfiguration()
+	if config.MaxThreadCountMoveSizeInt() != 0 && config.MaxThreadCountMoveSizeInt() < combinedCount {
+		if !p.canBypassMaxCount(userID, config) {
+			return nil, fmt.Sprintf("the selected threads are %d posts long combined, but only up to %d posts can be moved", combinedCount, config.MaxThreadCountMoveSizeInt())
+		}
+	}
+
+	var flags []string
+	if request.ConfirmIntegrationPosts {
+		flags = append(flags, "--"+flagMoveThreadConfirmIntegration)
+	}
+	if request.ConfirmPlaybookPosts {
+		flags = append(flags, "--"+flagMoveThreadConfirmPlaybook)
+	}
+	threadResults := p.runSelectedThreads(userID, threads, request.ChannelID, "move", func(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+		return p.moveThreadCommand(append(args, flags...), extra, false)
+	})
+
+	result := &moveSelectionResult{
+		FailedCount: len(failed),
+		Threads:     append(failed, threadResults...),
+	}
+	for _, threadResult := range threadResults {
+		if threadResult.Success {
+			result.MovedThreadCount++
+		} else {
+			result.FailedCount++
+		}
+	}
+
+	return result, ""
+}
+
+// collectSelectedThreads returns the distinct threads that the selected posts
+// belong to, oldest first, along with the results of the selected posts whose
+// thread can't be found.
+func (p *Plugin) collectSelectedThreads(userID string, postIDs []string) ([]*selectedThread, []moveSelectionThreadResult) {
+	var failed []moveSelectionThreadResult
+	threads := make(map[string]*selectedThread)
+	seen := make(map[string]bool)
+	for _, postID := range postIDs {
+		if seen[postID] {
+			continue
+		}
+		seen[postID] = true
+
+		thread, message := p.getSelectedThread(userID, postID)
+		if thread == nil {
+			failed = append(failed, moveSelectionThreadResult{
+				SelectedPostIDs: []string{postID},
+				Message:         message,
+			})
+			continue
+		}
+
+		rootID := thread.wpl.RootPost().Id
+		if existing, ok := threads[rootID]; ok {
+			existing.selectedPostIDs = append(existing.selectedPostIDs, postID)
+			continue
+		}
+		thread.selectedPostIDs = []string{postID}
+		threads[rootID] = thread
+	}
+
+	var ordered []*selectedThread
+	for _, thread := range threads {
+		ordered = append(ordered, thread)
+	}
+	// Handle older threads first so that they keep their order in the target
+	// channel.
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].wpl.RootPost().CreateAt < ordered[j].wpl.RootPost().CreateAt
+	})
+
+	return ordered, failed
+}
+
+// countSelectedPosts returns the combined number of posts of the threads.
+func countSelectedPosts(threads []*selectedThread) int {
+	var count int
+	for _, thread := range threads {
+		count += thread.wpl.NumPosts()
+	}
+
+	return count
+}
+
+// runSelectedThreads runs the command on each thread with the thread root ID
+// and the target channel ID as arguments, as if the user ran it from the
+// channel of the thread, and returns the result of each thread.
+func (p *Plugin) runSelectedThreads(userID string, threads []*selectedThread, channelID, verb string, run func(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error)) []moveSelectionThreadResult {
+	var results []moveSelectionThreadResult
+	for _, thread := range threads {
+		rootID := thread.wpl.RootPost().Id
+		threadResult := moveSelectionThreadResult{
+			RootID:          rootID,
+			SelectedPostIDs: thread.selectedPostIDs,
+			PostCount:       thread.wpl.NumPosts(),
+		}
+
+		extra := &model.CommandArgs{
+			UserId:    userID,
+			ChannelId: thread.channel.Id,
+			TeamId:    thread.channel.TeamId,
+		}
+		resp, userErr, err := run([]string{rootID, channelID}, extra)
+		switch {
+		case err != nil:
+			p.API.LogError("Unable to handle selected thread",
+				"error", err.Error(),
+				"user_id", userID,
+				"root_id", rootID,
+				"operation", verb,
+			)
+			threadResult.Message = fmt.Sprintf("unable to %s the thread; check the plugin logs", verb)
+		case resp == nil:
+			threadResult.Message = fmt.Sprintf("unable to %s the thread", verb)
+		default:
+			threadResult.Success = !userErr
+			threadResult.Message = resp.Text
+		}
+
+		results = append(results, threadResult)
+	}
+
+	return results
+}
+
+// getSelectedThread returns the thread of the selected post if the user can
+// read it, or a message explaining why it can't be moved.
+func (p *Plugin) getSelectedThread(userID, postID string) (*selectedThread, string) {
+	notFound := fmt.Sprintf("unable to get post with ID %s; ensure this is correct", postID)
+
+	postListResponse, appErr := p.API.GetPostThread(postID)
+	if appErr != nil {
+		return nil, notFound
+	}
+	wpl := buildWranglerPostList(postListResponse)
+	if wpl.NumPosts() == 0 {
+		return nil, notFound
+	}
+
+	// Only members of the channel of the thread may move it.
+	_, appErr = p.API.GetChannelMember(wpl.RootPost().ChannelId, userID)
+	if appErr != nil {
+		return nil, notFound
+	}
+	channel, appErr := p.API.GetChannel(wpl.RootPost().ChannelId)
+	if appErr != nil {
+		return nil, notFound
+	}
+
+	return &selectedThread{wpl: wpl, channel: channel}, ""
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveSelectionAPI(t *testing.T) {
+	setup := func(config *configuration) (*threadTestFixture, *Plugin, *model.Post) {
+		f := newThreadTestFixture(2)
+		for _, reply := range f.replies {
+			f.api.On("GetPostThread", reply.Id).Return(f.postList, nil)
+		}
+
+		otherRoot := &model.Post{
+			Id:        model.NewId(),
+			UserId:    model.NewId(),
+			ChannelId: f.originalChannel.Id,
+			Message:   "This is another root message",
+			CreateAt:  0,
+		}
+		otherList := model.NewPostList()
+		otherList.AddPost(otherRoot)
+		otherList.AddOrder(otherRoot.Id)
+		f.api.On("GetPostThread", otherRoot.Id).Return(otherList, nil)
+
+		plugin := &Plugin{BotUserID: model.NewId()}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(config)
+
+		return f, plugin, otherRoot
+	}
+
+	post := func(plugin *Plugin, userID string, request moveSelectionRequest) (*httptest.ResponseRecorder, int, error) {
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, routeAPIMoveSelection, bytes.NewReader(body))
+		if len(userID) != 0 {
+			r.Header.Set("Mattermost-User-Id", userID)
+		}
+		status, err := plugin.serveHTTP(nil, w, r)
+
+		return w, status, err
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{EnableWebUI: true})
+		_, status, err := post(plugin, "", moveSelectionRequest{PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("web UI disabled", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{})
+		_, status, err := post(plugin, f.rootPost.UserId, moveSelectionRequest{PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("role not permitted to use the web UI", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{EnableWebUI: true, WebUIPermittedRoles: model.SYSTEM_ADMIN_ROLE_ID})
+		_, status, err := post(plugin, f.rootPost.UserId, moveSelectionRequest{PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("role permitted without command authorization", func(t *testing.T) {
+		f, plugin, _ := setup(&configuration{EnableWebUI: true, AllowedEmailDomain: "mattermost.com", WebUIPermittedRoles: model.SYSTEM_USER_ROLE_ID})
+		f.unsetMock("GetUser")
+		f.api.On("GetUser", f.rootPost.UserId).Return(&model.User{Id: f.rootPost.UserId, Email: "user@example.com", Roles: model.SYSTEM_USER_ROLE_ID}, nil)
+
+		_, status, err := post(plugin, f.rootPost.UserId, moveSelectionRequest{PostIDs: []string{f.rootPost.Id}, ChannelID: f.targetChannel.Id})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("combined size over the limit", func(t *testing.T) {
+		f, plugin, otherRoot := setup(&configuration{EnableWebUI: true, MoveThreadMaxCount: "3"})
+		_, status, err := post(plugin, f.rootPost.UserId, moveSelectionRequest{PostIDs: []string{f.rootPost.Id, otherRoot.Id}, ChannelID: f.targetChannel.Id})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the selected threads are 4 posts long combined")
+		assert.Equal(t, http.StatusBadRequest, status)
+		f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("overlapping threads are moved once", func(t *testing.T) {
+		f, plugin, otherRoot := setup(&configuration{EnableWebUI: true, MoveThreadMaxCount: "4"})
+		missingID := model.NewId()
+		f.api.On("GetPostThread", missingID).Return(nil, &model.AppError{Message: "not found"})
+
+		w, status, err := post(plugin, f.rootPost.UserId, moveSelectionRequest{
+			PostIDs:   []string{f.replies[0].Id, f.rootPost.Id, otherRoot.Id, f.replies[1].Id, missingID},
+			ChannelID: f.targetChannel.Id,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+
+		var result moveSelectionResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, 2, result.MovedThreadCount)
+		assert.Equal(t, 1, result.FailedCount)
+		require.Len(t, result.Threads, 3)
+
+		assert.Equal(t, []string{missingID}, result.Threads[0].SelectedPostIDs)
+		assert.False(t, result.Threads[0].Success)
+
+		// The older thread is moved first.
+		assert.Equal(t, otherRoot.Id, result.Threads[1].RootID)
+		assert.True(t, result.Threads[1].Success)
+		assert.Equal(t, 1, result.Threads[1].PostCount)
+
+		assert.Equal(t, f.rootPost.Id, result.Threads[2].RootID)
+		assert.True(t, result.Threads[2].Success)
+		assert.Equal(t, 3, result.Threads[2].PostCount)
+		assert.Equal(t, []string{f.replies[0].Id, f.rootPost.Id, f.replies[1].Id}, result.Threads[2].SelectedPostIDs)
+		assert.Contains(t, result.Threads[2].Message, "A thread has been moved")
+
+		var movedCount int
+		for _, call := range f.api.Calls {
+			if call.Method == "CreatePost" && call.Arguments.Get(0).(*model.Post).ChannelId == f.targetChannel.Id &&
+				call.Arguments.Get(0).(*model.Post).UserId != plugin.BotUserID {
+				movedCount++
+			}
+		}
+		assert.Equal(t, 4, movedCount)
+	})
+}
+
+func TestMoveSelectionKeepsOriginalOrder(t *testing.T) {
+	f := newThreadTestFixture(1)
+	f.rootPost.CreateAt = time.Date(2021, time.March, 4, 12, 30, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	f.replies[0].CreateAt = f.rootPost.CreateAt + 1
+
+	var newestRoot, oldestRoot *model.Post
+	for _, root := range []**model.Post{&newestRoot, &oldestRoot} {
+		*root = &model.Post{
+			Id:        model.NewId(),
+			UserId:    model.NewId(),
+			ChannelId: f.originalChannel.Id,
+		}
+		postList := model.NewPostList()
+		postList.AddPost(*root)
+		postList.AddOrder((*root).Id)
+		f.api.On("GetPostThread", (*root).Id).Return(postList, nil)
+	}
+	newestRoot.Message = "This is the newest root message"
+	newestRoot.CreateAt = time.Date(2021, time.March, 10, 8, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	oldestRoot.Message = "This is the oldest root message"
+	oldestRoot.CreateAt = time.Date(2021, time.March, 1, 17, 5, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+
+	plugin := &Plugin{BotUserID: model.NewId()}
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{EnableWebUI: true, EnableOriginalTimestampHeader: true})
+
+	result, message := plugin.moveSelection(f.rootPost.UserId, &moveSelectionRequest{
+		PostIDs:   []string{newestRoot.Id, f.rootPost.Id, oldestRoot.Id},
+		ChannelID: f.targetChannel.Id,
+	})
+	require.NotNil(t, result, message)
+	assert.Equal(t, 3, result.MovedThreadCount)
+
+	var rootMessages []string
+	for _, call := range f.api.Calls {
+		if call.Method != "CreatePost" {
+			continue
+		}
+		post := call.Arguments.Get(0).(*model.Post)
+		if post.ChannelId == f.targetChannel.Id && post.RootId == "" && post.UserId != plugin.BotUserID {
+			rootMessages = append(rootMessages, post.Message)
+		}
+	}
+	assert.Equal(t, []string{
+		"**Originally posted on Mar 1, 2021 at 17:05 UTC**\n\nThis is the oldest root message",
+		"**Originally posted on Mar 4, 2021 at 12:30 UTC**\n\nThis is the root message",
+		"**Originally posted on Mar 10, 2021 at 08:00 UTC**\n\nThis is the newest root message",
+	}, rootMessages)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// notifyMoveSummary posts a summary of the recorded move to the monitoring
+// channel when move summaries are enabled, so that every move can be audited
+// at a glance. Failing to post the summary doesn't fail the move, so errors
+// are only logged.
+func (p *Plugin) notifyMoveSummary(record operationRecord, originalChannel, targetChannel *model.Channel, newPostLink string) {
+	config := p.getConfiguration()
+	if !config.MoveSummaries || len(config.HighImpactMoveChannelID) == 0 {
+		return
+	}
+
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: config.HighImpactMoveChannelID,
+		Message:   p.renderMoveSummary(record, originalChannel, targetChannel, newPostLink),
+	})
+	if appErr != nil {
+		p.API.LogError("Unable to post move summary",
+			"error", appErr.Error(),
+			"user_id", record.UserID,
+			"channel_id", config.HighImpactMoveChannelID,
+		)
+	}
+}
+
+// renderMoveSummary returns the summary of a move, which shows how the move
+// changed the source and destination channels.
+func (p *Plugin) renderMoveSummary(record operationRecord, originalChannel, targetChannel *model.Channel, newPostLink string) string {
+	return fmt.Sprintf(
+		"#### Thread move summary\n\n%s moved a thread on %s.\n\n| | Channel | Change |\n| -- | -- | -- |\n| Source | %s | %d message(s) moved out |\n| Destination | %s | %d new message(s): %s |",
+		p.getUserMention(record.UserID), time.Unix(0, record.Timestamp).UTC().Format(time.RFC1123),
+		p.getChannelSummaryLink(originalChannel), record.PostCount,
+		p.getChannelSummaryLink(targetChannel), record.PostCount, newPostLink,
+	)
+}
+
+// getChannelSummaryLink returns the channel prefixed with the name of its team
+// and linked to the channel. Direct and group message channels have no team to
+// link them with, so they are only described.
+func (p *Plugin) getChannelSummaryLink(channel *model.Channel) string {
+	switch channel.Type {
+	case model.CHANNEL_DIRECT:
+		return "a direct message channel"
+	case model.CHANNEL_GROUP:
+		return "a group message channel"
+	}
+
+	teamName := p.getTeamName(channel.TeamId)
+	if len(teamName) == 0 {
+		return channel.Name
+	}
+	link := makeChannelLink(*p.API.GetConfig().ServiceSettings.SiteURL, teamName, channel.Name)
+
+	return fmt.Sprintf("[%s/%s](%s)", teamName, channel.Name, link)
+}
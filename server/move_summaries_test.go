@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveThreadMonitoringSummary(t *testing.T) {
+	currentTime := time.Date(2021, time.March, 4, 15, 30, 0, 0, time.UTC)
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	monitoringChannelID := model.NewId()
+	isSummary := func(post *model.Post) bool {
+		return post.ChannelId == monitoringChannelID
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		f := newThreadTestFixture(2)
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{HighImpactMoveChannelID: monitoringChannelID})
+
+		_, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		f.api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(isSummary))
+	})
+
+	t.Run("summary of the move", func(t *testing.T) {
+		f := newThreadTestFixture(2)
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{HighImpactMoveChannelID: monitoringChannelID, MoveSummaries: true})
+		require.NoError(t, plugin.configuration.IsValid())
+
+		_, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+
+		expected := fmt.Sprintf("#### Thread move summary\n\n"+
+			"@active.user moved a thread on Thu, 04 Mar 2021 15:30:00 UTC.\n\n"+
+			"| | Channel | Change |\n| -- | -- | -- |\n"+
+			"| Source | [team-1/original-channel](test.sampledomain.com/team-1/channels/original-channel) | 3 message(s) moved out |\n"+
+			"| Destination | [team-1/target-channel](test.sampledomain.com/team-1/channels/target-channel) | 3 new message(s): test.sampledomain.com/team-1/pl/%s |",
+			f.newPost.Id)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return isSummary(post) && post.Message == expected
+		}))
+	})
+
+	t.Run("replies-only move", func(t *testing.T) {
+		f := newThreadTestFixture(2)
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{HighImpactMoveChannelID: monitoringChannelID, MoveSummaries: true})
+
+		_, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id, "--replies-only"}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return isSummary(post) &&
+				strings.Contains(post.Message, "| 2 message(s) moved out |") &&
+				strings.Contains(post.Message, "| 2 new message(s): ")
+		}))
+	})
+
+	t.Run("requires a monitoring channel", func(t *testing.T) {
+		config := &configuration{MoveSummaries: true}
+		require.EqualError(t, config.IsValid(), "MoveSummaries requires HighImpactMoveChannelID to be set")
+	})
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// pendingMoveUndo is a move that is waiting to delete its original messages
+// and may still be undone by the user who ran it.
+type pendingMoveUndo struct {
+	userID string
+	cancel context.CancelFunc
+}
+
+// moveUndoRegistry tracks the moves that may still be undone. Undos are only
+// tracked in memory since the undo window is bounded by the move deletion
+// delay. The zero value is ready to use.
+type moveUndoRegistry struct {
+	lock    sync.Mutex
+	pending map[string]pendingMoveUndo
+}
+
+func (r *moveUndoRegistry) add(undoID string, undo pendingMoveUndo) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.pending == nil {
+		r.pending = make(map[string]pendingMoveUndo)
+	}
+	r.pending[undoID] = undo
+}
+
+// take removes the pending undo with the given ID and returns true if it was
+// still pending. An empty user ID takes the undo regardless of who ran the
+// move. Each undo can only be taken once, either by the user undoing the move
+// or by the move once the undo window is over.
+func (r *moveUndoRegistry) take(undoID, userID string) (pendingMoveUndo, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	undo, ok := r.pending[undoID]
+	if !ok || (len(userID) != 0 && undo.userID != userID) {
+		return pendingMoveUndo{}, false
+	}
+	delete(r.pending, undoID)
+
+	return undo, true
+}
+
+// countUser returns the number of moves run by the user that may still be
+// undone.
+func (r *moveUndoRegistry) countUser(userID string) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var count int
+	for _, undo := range r.pending {
+		if undo.userID == userID {
+			count++
+		}
+	}
+
+	return count
+}
+
+// waitForMoveUndo waits for the move deletion delay and returns true if the
+// user undid the move in the meantime. When the undo button is enabled, the
+// user is shown an ephemeral Undo button for the duration of the delay.
+func (p *Plugin) waitForMoveUndo(ctx context.Context, delay time.Duration, extra *model.CommandArgs) (bool, error) {
+	if !p.getConfiguration().EnableMoveUndoButton || delay <= 0 {
+		return false, sleepContext(ctx, delay)
+	}
+
+	undoCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	undoID := model.NewId()
+	p.undos.add(undoID, pendingMoveUndo{userID: extra.UserId, cancel: cancel})
+
+	undoPost := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: extra.ChannelId,
+		Message:   fmt.Sprintf("The thread was moved. The original messages will be deleted in %s; until then, you can undo the move.", delay),
+	}
+	model.ParseSlackAttachment(undoPost, []*model.SlackAttachment{{
+		Actions: []*model.PostAction{{
+			Name: "Undo",
+			Type: model.POST_ACTION_TYPE_BUTTON,
+			Integration: &model.PostActionIntegration{
+				URL:     fmt.Sprintf("/plugins/%s%s", manifest.Id, routeAPIMoveUndo),
+				Context: map[string]interface{}{"undo_id": undoID},
+			},
+		}},
+	}})
+	undoPost = p.API.SendEphemeralPost(extra.UserId, undoPost)
+
+	err := sleepContext(undoCtx, delay)
+	_, stillPending := p.undos.take(undoID, "")
+	if stillPending && undoPost != nil {
+		undoPost.Message = "The thread was moved. The undo window is over."
+		if err != nil {
+			undoPost.Message = "The thread move could not be completed, so there is nothing to undo."
+		}
+		undoPost.Props = model.StringInterface{}
+		p.API.UpdateEphemeralPost(extra.UserId, undoPost)
+	}
+	if !stillPending {
+		// The move was undone, which may have happened right as the delay was
+		// over.
+		return true, nil
+	}
+
+	return false, err
+}
+
+// undoMove removes the recreated thread of a move that was undone and returns
+// the response for the user.
+func (p *Plugin) undoMove(correlationID string, newRootPost *model.Post, extra *model.CommandArgs) *model.CommandResponse {
+	// Deleting the new root post also deletes any copied replies.
+	return p.undoMovePosts(correlationID, []string{newRootPost.Id}, extra)
+}
+
+// undoMovePosts removes the given posts created by a move that was undone and
+// returns the response for the user.
+func (p *Plugin) undoMovePosts(correlationID string, newPostIDs []string, extra *model.CommandArgs) *model.CommandResponse {
+	p.API.LogInfo("Wrangler thread move undone",
+		"user_id", extra.UserId,
+		"correlation_id", correlationID,
+	)
+
+	var failed bool
+	for _, newPostID := range newPostIDs {
+		appErr := p.API.DeletePost(newPostID)
+		if appErr != nil {
+			p.API.LogError("Unable to remove the recreated thread of an undone move",
+				"new_post_id", newPostID,
+				"error", appErr.Error(),
+				"correlation_id", correlationID,
+			)
+			failed = true
+		}
+	}
+	if failed {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "The thread move was undone and the original messages were kept, but some copied messages could not be removed from the target channel.")
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "The thread move was undone. Nothing was changed.")
+}
+
+// handleMoveUndoAction undoes a pending move on behalf of the given user and
+// returns the response to the button action.
+func (p *Plugin) handleMoveUndoAction(userID, undoID string) *model.PostActionIntegrationResponse {
+	undo, ok := p.undos.take(undoID, userID)
+	if !ok {
+		return &model.PostActionIntegrationResponse{
+			Update: &model.Post{Message: "Undo is no longer possible since the move is already complete.", Props: model.StringInterface{}},
+		}
+	}
+	undo.cancel()
+
+	return &model.PostActionIntegrationResponse{
+		Update: &model.Post{Message: "The thread move was undone. The original messages were kept.", Props: model.StringInterface{}},
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveUndoRegistry(t *testing.T) {
+	var registry moveUndoRegistry
+	registry.add("undo1", pendingMoveUndo{userID: "user1", cancel: func() {}})
+
+	_, ok := registry.take("undo1", "user2")
+	assert.False(t, ok, "only the user who ran the move can undo it")
+
+	undo, ok := registry.take("undo1", "user1")
+	assert.True(t, ok)
+	assert.Equal(t, "user1", undo.userID)
+
+	_, ok = registry.take("undo1", "")
+	assert.False(t, ok, "an undo can only be taken once")
+}
+
+func TestMoveThreadUndoButton(t *testing.T) {
+	setup := func(undo bool) (*threadTestFixture, *Plugin) {
+		f := newThreadTestFixture(1)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(f.api)
+		plugin.setConfiguration(&configuration{MoveDeletionDelaySeconds: "1", EnableMoveUndoButton: true})
+		require.NoError(t, plugin.getConfiguration().IsValid())
+
+		f.api.On("SendEphemeralPost", f.rootPost.UserId, mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: model.NewId()}).Run(func(args mock.Arguments) {
+			if !undo {
+				return
+			}
+			undoID := args.Get(1).(*model.Post).Attachments()[0].Actions[0].Integration.Context["undo_id"].(string)
+			resp := plugin.handleMoveUndoAction(f.rootPost.UserId, undoID)
+			assert.Equal(t, "The thread move was undone. The original messages were kept.", resp.Update.Message)
+		})
+		f.api.On("UpdateEphemeralPost", f.rootPost.UserId, mock.AnythingOfType("*model.Post")).Return(&model.Post{})
+
+		return f, plugin
+	}
+
+	t.Run("undone", func(t *testing.T) {
+		f, plugin := setup(true)
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "The thread move was undone. Nothing was changed.", resp.Text)
+		f.api.AssertCalled(t, "DeletePost", f.newPost.Id)
+		f.api.AssertNotCalled(t, "DeletePost", f.rootPost.Id)
+		f.api.AssertNotCalled(t, "UpdateEphemeralPost", mock.Anything, mock.Anything)
+	})
+
+	t.Run("undo window over", func(t *testing.T) {
+		f, plugin := setup(false)
+
+		resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Contains(t, resp.Text, "A thread has been moved")
+		f.api.AssertCalled(t, "DeletePost", f.rootPost.Id)
+		f.api.AssertCalled(t, "UpdateEphemeralPost", f.rootPost.UserId, mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "The thread was moved. The undo window is over." && len(post.Attachments()) == 0
+		}))
+
+		undo := plugin.handleMoveUndoAction(f.rootPost.UserId, model.NewId())
+		assert.Equal(t, "Undo is no longer possible since the move is already complete.", undo.Update.Message)
+	})
+}
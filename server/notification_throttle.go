@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const notificationDMWindowKey = "notification_dm_window"
+
+const notificationDMWindow = time.Hour
+
+var errNotificationDMLimitReached = errors.New("notification DM limit reached")
+
+// now returns the current time; it is overridden in tests.
+var now = time.Now
+
+// reserveNotificationDM records a new Wrangler-originated DM in the
+// server-wide sliding window. It returns false if the configured hourly limit
+// has already been reached, in which case no DM should be sent.
+func (p *Plugin) reserveNotificationDM() (bool, error) {
+	max := p.getConfiguration().MaxNotificationDMsPerHourInt()
+	if max == 0 {
+		return true, nil
+	}
+
+	err := p.kvAtomicModify(notificationDMWindowKey, func(initial []byte) ([]byte, error) {
+		var timestamps []int64
+		if initial != nil {
+			err := json.Unmarshal(initial, &timestamps)
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to unmarshal notification DM window")
+			}
+		}
+
+		current := now()
+		cutoff := current.Add(-notificationDMWindow).UnixNano()
+		var recent []int64
+		for _, timestamp := range timestamps {
+			if timestamp > cutoff {
+				recent = append(recent, timestamp)
+			}
+		}
+		if len(recent) >= max {
+			return nil, errNotificationDMLimitReached
+		}
+
+		return json.Marshal(append(recent, current.UnixNano()))
+	})
+	if err == errNotificationDMLimitReached {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
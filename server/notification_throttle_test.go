@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationDMThrottle(t *testing.T) {
+	currentTime := time.Now()
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	api := &plugintest.API{}
+	newMockKVStore(api)
+	api.On("GetDirectChannel", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&model.Channel{Id: model.NewId()}, nil)
+	api.On("CreatePost", mock.Anything).Return(mockGeneratePost(), nil)
+	api.On("LogWarn",
+		mock.AnythingOfTypeArgument("string"),
+		mock.AnythingOfTypeArgument("string"),
+		mock.AnythingOfTypeArgument("string"),
+		mock.AnythingOfTypeArgument("string"),
+		mock.AnythingOfTypeArgument("string"),
+	).Return(nil)
+
+	var plugin Plugin
+	plugin.SetAPI(api)
+
+	t.Run("no limit", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{MaxNotificationDMsPerHour: ""})
+		for i := 0; i < 5; i++ {
+			allowed, err := plugin.reserveNotificationDM()
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		}
+		api.AssertNotCalled(t, "KVGet", notificationDMWindowKey)
+	})
+
+	t.Run("limit reached", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{MaxNotificationDMsPerHour: "2"})
+		for i := 0; i < 2; i++ {
+			allowed, err := plugin.reserveNotificationDM()
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		}
+
+		allowed, err := plugin.reserveNotificationDM()
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		t.Run("DM is skipped", func(t *testing.T) {
+			require.NoError(t, plugin.PostBotDM(model.NewId(), "message"))
+			api.AssertNotCalled(t, "CreatePost", mock.Anything)
+		})
+	})
+
+	t.Run("window slides", func(t *testing.T) {
+		currentTime = currentTime.Add(notificationDMWindow + time.Second)
+
+		allowed, err := plugin.reserveNotificationDM()
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		require.NoError(t, plugin.PostBotDM(model.NewId(), "message"))
+		api.AssertCalled(t, "CreatePost", mock.Anything)
+	})
+}
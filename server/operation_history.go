@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const operationHistoryKey = "operation_history"
+
+// operationHistoryPruneInterval is how often operations older than the history
+// retention are pruned.
+const operationHistoryPruneInterval = time.Hour
+
+// maxOperationHistoryRecords bounds the size of the stored operation history.
+// The oldest records are dropped first.
+const maxOperationHistoryRecords = 5000
+
+// Types of recorded operations.
+const (
+	operationMove  = "move"
+	operationCopy  = "copy"
+	operationMerge = "merge"
+)
+
+// operationRecord is a completed move, copy or merge kept in the operation
+// history.
+type operationRecord struct {
+	Type            string `json:"type"`
+	UserID          string `json:"user_id"`
+	SourceChannelID string `json:"source_channel_id"`
+	TargetChannelID string `json:"target_channel_id"`
+	PostCount       int    `json:"post_count"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// recordOperation adds a completed operation to the operation history and
+// returns its record. The history only keeps the records within the history
+// retention. Errors are logged as they must not fail the operation itself.
+func (p *Plugin) recordOperation(operationType, userID, sourceChannelID, targetChannelID string, postCount int) operationRecord {
+	record := operationRecord{
+		Type:            operationType,
+		UserID:          userID,
+		SourceChannelID: sourceChannelID,
+		TargetChannelID: targetChannelID,
+		PostCount:       postCount,
+		Timestamp:       now().UnixNano(),
+	}
+	cutoff := now().Add(-p.getConfiguration().HistoryRetention()).UnixNano()
+
+	err := p.kvAtomicModify(operationHistoryKey, func(initial []byte) ([]byte, error) {
+		records, err := unmarshalOperationHistory(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		kept := append(pruneOperationRecords(records, cutoff), record)
+		if len(kept) > maxOperationHistoryRecords {
+			kept = kept[len(kept)-maxOperationHistoryRecords:]
+		}
+
+		return json.Marshal(kept)
+	})
+	if err != nil {
+		p.API.LogError("Unable to record operation in history",
+			"error", err.Error(),
+			"operation", operationType,
+		)
+	}
+
+	return record
+}
+
+func unmarshalOperationHistory(data []byte) ([]operationRecord, error) {
+	var records []operationRecord
+	if data == nil {
+		return records, nil
+	}
+
+	err := json.Unmarshal(data, &records)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal operation history")
+	}
+
+	return records, nil
+}
+
+// pruneOperationRecords returns the records that happened after the cutoff.
+func pruneOperationRecords(records []operationRecord, cutoff int64) []operationRecord {
+	var kept []operationRecord
+	for _, record := range records {
+		if record.Timestamp > cutoff {
+			kept = append(kept, record)
+		}
+	}
+
+	return kept
+}
+
+// pruneOperationHistory removes the operations older than the history
+// retention from the operation history and returns how many were removed.
+func (p *Plugin) pruneOperationHistory() (int, error) {
+	cutoff := now().Add(-p.getConfiguration().HistoryRetention()).UnixNano()
+
+	// Nothing is stored before the first operation.
+	data, appErr := p.API.KVGet(operationHistoryKey)
+	if appErr != nil {
+		return 0, errors.Wrap(appErr, "unable to get operation history")
+	}
+	if data == nil {
+		return 0, nil
+	}
+
+	var pruned int
+	err := p.kvAtomicModify(operationHistoryKey, func(initial []byte) ([]byte, error) {
+		records, err := unmarshalOperationHistory(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		kept := pruneOperationRecords(records, cutoff)
+		pruned = len(records) - len(kept)
+
+		return json.Marshal(kept)
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to prune operation history")
+	}
+
+	return pruned, nil
+}
+
+// removeUserOperationHistory removes the operations run by the user from the
+// operation history and returns how many were removed.
+func (p *Plugin) removeUserOperationHistory(userID string) (int, error) {
+	// Nothing is stored before the first operation.
+	data, appErr := p.API.KVGet(operationHistoryKey)
+	if appErr != nil {
+		return 0, errors.Wrap(appErr, "unable to get operation history")
+	}
+	if data == nil {
+		return 0, nil
+	}
+
+	var removed int
+	err := p.kvAtomicModify(operationHistoryKey, func(initial []byte) ([]byte, error) {
+		records, err := unmarshalOperationHistory(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		var kept []operationRecord
+		for _, record := range records {
+			if record.UserID != userID {
+				kept = append(kept, record)
+			}
+		}
+		removed = len(records) - len(kept)
+
+		return json.Marshal(kept)
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to remove user operation history")
+	}
+
+	return removed, nil
+}
+
+// startHistoryPruning periodically prunes the operation history until
+// stopHistoryPruning is called.
+func (p *Plugin) startHistoryPruning() {
+	p.historyPruneStop = make(chan struct{})
+	p.historyPruneDone = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(operationHistoryPruneInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				pruned, err := p.pruneOperationHistory()
+				if err != nil {
+					p.API.LogError("Unable to prune operation history", "error", err.Error())
+				} else if pruned != 0 {
+					p.API.LogDebug("Pruned operation history", "pruned_count", pruned)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(p.historyPruneStop, p.historyPruneDone)
+}
+
+// stopHistoryPruning stops the pruning and waits for it to exit.
+func (p *Plugin) stopHistoryPruning() {
+	if p.historyPruneStop == nil {
+		return
+	}
+
+	close(p.historyPruneStop)
+	<-p.historyPruneDone
+	p.historyPruneStop = nil
+}
+
+// getOperationHistory returns the recorded operations that happened after the
+// given time.
+func (p *Plugin) getOperationHistory(since time.Time) ([]operationRecord, error) {
+	var records []operationRecord
+	_, err := p.kvGetJSON(operationHistoryKey, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []operationRecord
+	for _, record := range records {
+		if record.Timestamp > since.UnixNano() {
+			recent = append(recent, record)
+		}
+	}
+
+	return recent, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneOperationHistory(t *testing.T) {
+	currentTime := time.Now()
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	api := &plugintest.API{}
+	newMockKVStore(api)
+	mockLogs(api)
+
+	plugin := &Plugin{}
+	plugin.SetAPI(api)
+	plugin.setConfiguration(&configuration{StatsWindowDays: "7", HistoryRetentionDays: "30"})
+
+	t.Run("empty history", func(t *testing.T) {
+		pruned, err := plugin.pruneOperationHistory()
+		require.NoError(t, err)
+		assert.Equal(t, 0, pruned)
+	})
+
+	userID := model.NewId()
+	sourceID := model.NewId()
+	targetID := model.NewId()
+
+	// Insert aged operations. Records outside of the stats window are kept
+	// until they are older than the history retention.
+	var aged []operationRecord
+	for _, age := range []time.Duration{40, 31, 20, 1} {
+		aged = append(aged, operationRecord{
+			Type:            operationMove,
+			UserID:          userID,
+			SourceChannelID: sourceID,
+			TargetChannelID: targetID,
+			PostCount:       int(age),
+			Timestamp:       currentTime.Add(-age * 24 * time.Hour).UnixNano(),
+		})
+	}
+	require.NoError(t, plugin.kvSetJSON(operationHistoryKey, aged))
+
+	records, err := plugin.getOperationHistory(time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, records, 4)
+
+	pruned, err := plugin.pruneOperationHistory()
+	require.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+
+	records, err = plugin.getOperationHistory(time.Time{})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, 20, records[0].PostCount)
+	assert.Equal(t, 1, records[1].PostCount)
+
+	pruned, err = plugin.pruneOperationHistory()
+	require.NoError(t, err)
+	assert.Equal(t, 0, pruned)
+}
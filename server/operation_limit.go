@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// operationLimiter bounds how many move and copy operations run at the same
+// time across the server. The limit is passed on every acquire so that
+// configuration changes apply to the next operation without a restart. The
+// zero value is ready to use.
+type operationLimiter struct {
+	lock    sync.Mutex
+	running int
+	// released is closed and replaced whenever an operation finishes to wake
+	// up the operations waiting for a slot.
+	released chan struct{}
+}
+
+// tryAcquire takes a slot if fewer than limit operations are running. A limit
+// of 0 means no limit.
+func (l *operationLimiter) tryAcquire(limit int) (bool, <-chan struct{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if limit == 0 || l.running < limit {
+		l.running++
+		return true, nil
+	}
+	if l.released == nil {
+		l.released = make(chan struct{})
+	}
+
+	return false, l.released
+}
+
+// acquire waits until a slot is free or the context is done. When the slot
+// isn't free right away, queued is called once before waiting.
+func (l *operationLimiter) acquire(ctx context.Context, limit int, queued func()) error {
+	for notified := false; ; notified = true {
+		ok, released := l.tryAcquire(limit)
+		if ok {
+			return nil
+		}
+		if !notified && queued != nil {
+			queued()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// release frees the slot of a finished operation.
+func (l *operationLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.running--
+	if l.released != nil {
+		close(l.released)
+		l.released = nil
+	}
+}
+
+// acquireOperationSlot waits for a free operation slot and tells the user
+// their operation is queued if it can't start right away. It returns a
+// response for the user if the context is done before a slot frees up;
+// otherwise the returned function must be called once the operation is over.
+func (p *Plugin) acquireOperationSlot(ctx context.Context, verb string, extra *model.CommandArgs) (func(), *model.CommandResponse) {
+	err := p.operations.acquire(ctx, p.getConfiguration().MaxConcurrentOperationsInt(), func() {
+		p.API.SendEphemeralPost(extra.UserId, &model.Post{
+			UserId:    p.BotUserID,
+			ChannelId: extra.ChannelId,
+			Message:   fmt.Sprintf("Wrangler is busy with other operations, so your %s is queued. Please wait; it will start as soon as possible.", verb),
+		})
+	})
+	if err != nil {
+		p.API.LogWarn("Wrangler operation timed out while queued",
+			"user_id", extra.UserId,
+			"error", err.Error(),
+		)
+		return nil, getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+			"Error: Wrangler was busy with other operations and your %s could not start within the configured timeout of %s. Nothing was changed; try again later.",
+			verb, p.getConfiguration().OperationTimeout(),
+		))
+	}
+
+	return p.operations.release, nil
+}
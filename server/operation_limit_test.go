@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationLimiter(t *testing.T) {
+	t.Run("no limit", func(t *testing.T) {
+		var l operationLimiter
+		for i := 0; i < 5; i++ {
+			require.NoError(t, l.acquire(context.Background(), 0, func() { t.Fatal("unexpectedly queued") }))
+		}
+	})
+
+	t.Run("queued until released", func(t *testing.T) {
+		var l operationLimiter
+		require.NoError(t, l.acquire(context.Background(), 1, nil))
+
+		var queuedCount int
+		acquired := make(chan error)
+		go func() {
+			acquired <- l.acquire(context.Background(), 1, func() { queuedCount++ })
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("acquired a slot while the limit was reached")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		l.release()
+		require.NoError(t, <-acquired)
+		assert.Equal(t, 1, queuedCount)
+	})
+
+	t.Run("queued until the context is done", func(t *testing.T) {
+		var l operationLimiter
+		require.NoError(t, l.acquire(context.Background(), 1, nil))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, l.acquire(ctx, 1, nil))
+
+		l.release()
+		require.NoError(t, l.acquire(context.Background(), 1, nil))
+	})
+}
+
+func TestMoveThreadOperationLimit(t *testing.T) {
+	f := newThreadTestFixture(1)
+	f.api.On("SendEphemeralPost", f.rootPost.UserId, mock.AnythingOfType("*model.Post")).Return(&model.Post{})
+
+	plugin := &Plugin{BotUserID: model.NewId()}
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{MaxConcurrentOperations: "1", OperationTimeoutSeconds: "1"})
+
+	// Another operation holds the only slot.
+	require.NoError(t, plugin.operations.acquire(context.Background(), 1, nil))
+
+	resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+	assert.Equal(t, "Error: Wrangler was busy with other operations and your move could not start within the configured timeout of 1s. Nothing was changed; try again later.", resp.Text)
+	f.api.AssertCalled(t, "SendEphemeralPost", f.rootPost.UserId, mock.MatchedBy(func(post *model.Post) bool {
+		return post.ChannelId == f.originalChannel.Id && post.UserId == plugin.BotUserID
+	}))
+	f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+
+	plugin.operations.release()
+	resp, _, err = plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+	require.NoError(t, err)
+	assert.Contains(t, resp.Text, "A thread has been moved")
+}
+
+func TestMergeThreadOperationLimit(t *testing.T) {
+	f := newThreadTestFixture(1)
+	f.api.On("SendEphemeralPost", f.rootPost.UserId, mock.AnythingOfType("*model.Post")).Return(&model.Post{})
+	targetRoot := &model.Post{Id: model.NewId(), UserId: f.rootPost.UserId, ChannelId: f.targetChannel.Id, Message: "target"}
+	targetPostList := model.NewPostList()
+	targetPostList.AddPost(targetRoot)
+	targetPostList.AddOrder(targetRoot.Id)
+	f.api.On("GetPostThread", targetRoot.Id).Return(targetPostList, nil)
+
+	plugin := &Plugin{BotUserID: model.NewId()}
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{MaxConcurrentOperations: "1", OperationTimeoutSeconds: "1"})
+
+	// Another operation holds the only slot.
+	require.NoError(t, plugin.operations.acquire(context.Background(), 1, nil))
+
+	resp, isUserError, err := plugin.runMergeThreadCommand([]string{f.rootPost.Id, targetRoot.Id}, f.commandArgs())
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+	assert.Equal(t, "Error: Wrangler was busy with other operations and your merge could not start within the configured timeout of 1s. Nothing was changed; try again later.", resp.Text)
+	f.api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	f.api.AssertNotCalled(t, "DeletePost", mock.Anything)
+
+	plugin.operations.release()
+	resp, _, err = plugin.runMergeThreadCommand([]string{f.rootPost.Id, targetRoot.Id}, f.commandArgs())
+	require.NoError(t, err)
+	assert.Contains(t, resp.Text, "A thread has been merged")
+}
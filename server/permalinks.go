@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// permalinkScanPostCount is the number of recent channel messages that are
+// scanned for permalinks to moved messages.
+const permalinkScanPostCount = 200
+
+// permalinkRegexp matches Mattermost permalinks and captures the post ID.
+var permalinkRegexp = regexp.MustCompile(`/pl/([a-z0-9]{26})\b`)
+
+// permalinkedPostIDs returns the IDs of all posts linked to by permalinks in
+// the given message.
+func permalinkedPostIDs(message string) []string {
+	var ids []string
+	for _, match := range permalinkRegexp.FindAllStringSubmatch(message, -1) {
+		ids = append(ids, match[1])
+	}
+
+	return ids
+}
+
+// rewritePermalinks returns the message with permalinks to the posts in the
+// given map of original to new post IDs pointing at the new posts instead.
+func rewritePermalinks(message string, newPostIDs map[string]string) string {
+	return permalinkRegexp.ReplaceAllStringFunc(message, func(link string) string {
+		newPostID, ok := newPostIDs[permalinkRegexp.FindStringSubmatch(link)[1]]
+		if !ok {
+			return link
+		}
+
+		return "/pl/" + newPostID
+	})
+}
+
+// rewriteMovedPermalinks updates the recreated posts of a move that link to
+// posts of the same move so that the links point at the recreated posts
+// rather than at the originals that are about to be deleted. Failures are
+// logged as the move itself is unaffected.
+func (p *Plugin) rewriteMovedPermalinks(tracker *provenanceTracker) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	newPostIDs := make(map[string]string, len(tracker.ids))
+	for newPostID, originalPostID := range tracker.ids {
+		newPostIDs[originalPostID] = newPostID
+	}
+
+	for _, post := range tracker.linking {
+		message := rewritePermalinks(post.Message, newPostIDs)
+		if message == post.Message {
+			continue
+		}
+
+		post.Message = message
+		_, appErr := p.API.UpdatePost(post)
+		if appErr != nil {
+			p.API.LogError("Unable to rewrite permalinks of moved post", "error", appErr.Error(), "post_id", post.Id)
+		}
+	}
+}
+
+// findPermalinksToPosts scans recent messages of the given channel and returns
+// those containing permalinks to any of the provided posts. The provided posts
+// themselves are ignored.
+func (p *Plugin) findPermalinksToPosts(channelID string, posts []*model.Post) ([]*model.Post, error) {
+	targetIDs := make(map[string]bool)
+	for _, post := range posts {
+		targetIDs[post.Id] = true
+	}
+
+	channelPosts, appErr := p.API.GetPostsForChannel(channelID, 0, permalinkScanPostCount)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "unable to get recent channel posts to scan for permalinks")
+	}
+
+	var linkingPosts []*model.Post
+	for _, post := range channelPosts.ToSlice() {
+		if targetIDs[post.Id] {
+			continue
+		}
+		for _, id := range permalinkedPostIDs(post.Message) {
+			if targetIDs[id] {
+				linkingPosts = append(linkingPosts, post)
+				break
+			}
+		}
+	}
+
+	return linkingPosts, nil
+}
+
+func (p *Plugin) logBrokenPermalinks(linkingPosts []*model.Post, userID string) {
+	for _, post := range linkingPosts {
+		p.API.LogInfo("Wrangler move broke a permalink",
+			"user_id", userID,
+			"linking_post_id", post.Id,
+			"linking_user_id", post.UserId,
+		)
+	}
+}
+
+// getTeamName returns the name of the team with the given ID, or an empty
+// string if it can't be found.
+func (p *Plugin) getTeamName(teamID string) string {
+	team, appErr := p.API.GetTeam(teamID)
+	if appErr != nil {
+		return ""
+	}
+
+	return team.Name
+}
+
+func formatBrokenPermalinks(linkingPosts []*model.Post, siteURL, teamName string) string {
+	if len(linkingPosts) == 0 {
+		return "\nNo recent messages in the original channel linked to the moved messages.\n"
+	}
+
+	msg := fmt.Sprintf("\nThe following %d recent message(s) in the original channel contain permalinks that no longer resolve:\n", len(linkingPosts))
+	for _, post := range linkingPosts {
+		msg += fmt.Sprintf("- %s\n", makePostLink(siteURL, teamName, post.Id))
+	}
+
+	return msg
+}
@@ -21,6 +21,48 @@ type Plugin struct {
 	// configuration is the active plugin configuration. Consult getConfiguration and
 	// setConfiguration for usage.
 	configuration *configuration
+
+	// healthLock synchronizes access to the health state.
+	healthLock sync.RWMutex
+
+	// health is the state of the plugin found on activation. Consult getHealth
+	// and setHealth for usage.
+	health pluginHealth
+
+	// reminderStop and reminderDone control the reminder scheduler. Consult
+	// startReminderScheduler and stopReminderScheduler for usage.
+	reminderStop chan struct{}
+	reminderDone chan struct{}
+
+	// temporaryPostStop and temporaryPostDone control the cleanup of temporary
+	// posts. Consult startTemporaryPostCleanup and stopTemporaryPostCleanup for
+	// usage.
+	temporaryPostStop chan struct{}
+	temporaryPostDone chan struct{}
+
+	// historyPruneStop and historyPruneDone control the pruning of the
+	// operation history. Consult startHistoryPruning and stopHistoryPruning for
+	// usage.
+	historyPruneStop chan struct{}
+	historyPruneDone chan struct{}
+
+	// highImpactDigestStop and highImpactDigestDone control the posting of
+	// high-impact move digests. Consult startHighImpactMoveDigests and
+	// stopHighImpactMoveDigests for usage.
+	highImpactDigestStop chan struct{}
+	highImpactDigestDone chan struct{}
+
+	// operations limits the number of move and copy operations running at
+	// the same time. Consult acquireOperationSlot for usage.
+	operations operationLimiter
+
+	// undos tracks the moves that may still be undone. Consult
+	// waitForMoveUndo for usage.
+	undos moveUndoRegistry
+
+	// channelAutocomplete keeps the channel lists recently returned by the
+	// dynamic autocomplete. Consult handleDynamicChannels for usage.
+	channelAutocomplete autocompleteThrottle
 }
 
 // BuildHash is the full git hash of the build.
@@ -41,14 +83,23 @@ func (p *Plugin) OnActivate() error {
 		return errors.Wrap(err, "invalid config")
 	}
 
+	var health pluginHealth
+
+	var options []plugin.EnsureBotOption
+	err = p.checkProfileImage()
+	if err != nil {
+		p.API.LogError("Wrangler bot profile image is unavailable; the bot will be created without it", "error", err.Error())
+		health.issues = append(health.issues, "bot profile image is unavailable: "+err.Error())
+	} else {
+		health.profileImageAvailable = true
+		options = append(options, plugin.ProfileImagePath(profileImagePath))
+	}
+
 	bot := &model.Bot{
 		Username:    "wrangler",
 		DisplayName: "Wrangler",
 		Description: "Created by the Wrangler plugin.",
 	}
-	options := []plugin.EnsureBotOption{
-		plugin.ProfileImagePath("assets/profile.png"),
-	}
 
 	botID, err := p.Helpers.EnsureBot(bot, options...)
 	if err != nil {
@@ -56,5 +107,33 @@ func (p *Plugin) OnActivate() error {
 	}
 	p.BotUserID = botID
 
-	return p.API.RegisterCommand(getCommand(config.CommandAutoCompleteEnable))
+	err = p.checkBot()
+	if err != nil {
+		p.API.LogError("Wrangler bot account is unavailable; notifications and bot messages will fail", "error", err.Error())
+		health.issues = append(health.issues, "bot account is unavailable: "+err.Error())
+	}
+
+	p.setHealth(health)
+
+	err = p.API.RegisterCommand(getCommand(config))
+	if err != nil {
+		return err
+	}
+
+	p.startReminderScheduler()
+	p.startTemporaryPostCleanup()
+	p.startHistoryPruning()
+	p.startHighImpactMoveDigests()
+
+	return nil
+}
+
+// OnDeactivate runs when the plugin deactivates and stops background work.
+func (p *Plugin) OnDeactivate() error {
+	p.stopReminderScheduler()
+	p.stopTemporaryPostCleanup()
+	p.stopHistoryPruning()
+	p.stopHighImpactMoveDigests()
+
+	return nil
 }
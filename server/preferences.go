@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const (
+	preferencesKeyPrefix = "prefs_"
+
+	defaultActionMove = "move"
+	defaultActionCopy = "copy"
+)
+
+// userPreferences are the personal Wrangler settings of a user.
+type userPreferences struct {
+	// DefaultAction is the operation run by the '/wrangler thread' shorthand.
+	DefaultAction string `json:"default_action"`
+	// DefaultDestination is the channel ID that threads are moved or copied to
+	// when the destination is omitted.
+	DefaultDestination string `json:"default_destination"`
+	// SuccessVerbosity is how much detail the summaries of completed moves
+	// and copies include. The plugin configuration applies when empty.
+	SuccessVerbosity string `json:"success_verbosity"`
+}
+
+// getDefaultAction returns the default action, falling back to copying which
+// can't lose messages.
+func (prefs *userPreferences) getDefaultAction() string {
+	if prefs.DefaultAction == defaultActionMove {
+		return defaultActionMove
+	}
+
+	return defaultActionCopy
+}
+
+// getSuccessVerbosity returns the success message verbosity preference,
+// falling back to the given configured verbosity.
+func (prefs *userPreferences) getSuccessVerbosity(configured string) string {
+	if len(prefs.SuccessVerbosity) == 0 {
+		return configured
+	}
+
+	return prefs.SuccessVerbosity
+}
+
+func getPreferencesKey(userID string) string {
+	return preferencesKeyPrefix + userID
+}
+
+// getUserPreferences returns the stored preferences of the user, or empty
+// preferences if none were set.
+func (p *Plugin) getUserPreferences(userID string) (*userPreferences, error) {
+	prefs := &userPreferences{}
+	_, err := p.kvGetJSON(getPreferencesKey(userID), prefs)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get user preferences")
+	}
+
+	return prefs, nil
+}
+
+// setUserPreferences stores the preferences of the user.
+func (p *Plugin) setUserPreferences(userID string, prefs *userPreferences) error {
+	err := p.kvSetJSON(getPreferencesKey(userID), prefs)
+	if err != nil {
+		return errors.Wrap(err, "unable to set user preferences")
+	}
+
+	return nil
+}
+
+// deleteUserPreferences removes the stored preferences of the user and
+// returns false if none were set.
+func (p *Plugin) deleteUserPreferences(userID string) (bool, error) {
+	data, appErr := p.API.KVGet(getPreferencesKey(userID))
+	if appErr != nil {
+		return false, errors.Wrap(appErr, "unable to get user preferences")
+	}
+	if data == nil {
+		return false, nil
+	}
+
+	appErr = p.API.KVDelete(getPreferencesKey(userID))
+	if appErr != nil {
+		return false, errors.Wrap(appErr, "unable to delete user preferences")
+	}
+
+	return true, nil
+}
+
+// getSuccessVerbosity returns how much detail the summary of an operation
+// that was completed by the user includes. The operation is done at this
+// point, so the configured verbosity is used if the preferences can't be read.
+func (p *Plugin) getSuccessVerbosity(userID string) string {
+	configured := p.getConfiguration().SuccessMessageVerbosityValue()
+	prefs, err := p.getUserPreferences(userID)
+	if err != nil {
+		p.API.LogError("Unable to get user preferences for the success message verbosity",
+			"error", err.Error(),
+			"user_id", userID,
+		)
+		return configured
+	}
+
+	return prefs.getSuccessVerbosity(configured)
+}
+
+// checkDefaultDestination returns an error meant for the user if the channel
+// doesn't exist or the user can't post in it.
+func (p *Plugin) checkDefaultDestination(channelID, userID string) error {
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil || channel.DeleteAt != 0 {
+		return fmt.Errorf("channel with ID %s doesn't exist", channelID)
+	}
+	_, appErr = p.API.GetChannelMember(channelID, userID)
+	if appErr != nil {
+		return fmt.Errorf("you are not a member of channel with ID %s", channelID)
+	}
+	if !p.API.HasPermissionToChannel(userID, channelID, model.PERMISSION_CREATE_POST) {
+		return fmt.Errorf("you can't post in channel with ID %s", channelID)
+	}
+
+	return nil
+}
+
+// withDefaultDestination appends the default destination of the user to the
+// arguments of a move or copy command that only names the message. Other
+// arguments are returned unchanged so that they are validated as usual. A
+// response is returned when the default destination can no longer be used.
+func (p *Plugin) withDefaultDestination(args []string, flagSet *pflag.FlagSet, userID string) ([]string, *model.CommandResponse, error) {
+	if flagSet.Parse(args) != nil || flagSet.NArg() != 1 {
+		return args, nil, nil
+	}
+
+	prefs, err := p.getUserPreferences(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(prefs.DefaultDestination) == 0 {
+		return args, nil, nil
+	}
+
+	err = p.checkDefaultDestination(prefs.DefaultDestination, userID)
+	if err != nil {
+		return nil, getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf(
+			"Error: your %s preference can no longer be used: %s. Pass a destination channel ID or change it with '/wrangler prefs set %s CHANNEL_ID'.",
+			prefDefaultDestination, err.Error(), prefDefaultDestination,
+		)), nil
+	}
+
+	return append(append([]string{}, args...), prefs.DefaultDestination), nil, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const provenanceKeyPrefix = "provenance_"
+
+// maxProvenanceHops bounds how many earlier moves of a post are followed when
+// looking up where it originally came from.
+const maxProvenanceHops = 10
+
+// provenanceRecord records where a moved post came from.
+type provenanceRecord struct {
+	PostID            string `json:"post_id"`
+	OriginalPostID    string `json:"original_post_id"`
+	OriginalChannelID string `json:"original_channel_id"`
+	TargetChannelID   string `json:"target_channel_id"`
+	Operation         string `json:"operation"`
+	UserID            string `json:"user_id"`
+	MovedAt           int64  `json:"moved_at"`
+	// OriginalKept is set when the original post was kept on purpose, such
+	// as the root post of a replies-only move or a redirect.
+	OriginalKept bool `json:"original_kept,omitempty"`
+}
+
+func getProvenanceKey(postID string) string {
+	return provenanceKeyPrefix + postID
+}
+
+// provenanceTracker collects the original and new IDs of the posts recreated
+// by a move. It is safe for concurrent use by concurrent post creations.
+type provenanceTracker struct {
+	lock sync.Mutex
+	ids  map[string]string
+	// kept are the original posts that the move keeps on purpose.
+	kept map[string]bool
+	// linking are the recreated posts containing permalinks.
+	linking []*model.Post
+}
+
+func newProvenanceTracker() *provenanceTracker {
+	return &provenanceTracker{ids: make(map[string]string), kept: make(map[string]bool)}
+}
+
+// track records that the original post was recreated as the new post.
+func (t *provenanceTracker) track(original, newPost *model.Post) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.ids[newPost.Id] = original.Id
+	if permalinkRegexp.MatchString(newPost.Message) {
+		t.linking = append(t.linking, newPost.Clone())
+	}
+}
+
+// keep records that the original post is kept on purpose by the move, so that
+// it isn't reported as an original that failed to be deleted.
+func (t *provenanceTracker) keep(originalPostID string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.kept[originalPostID] = true
+}
+
+// newPostIDs returns the IDs of the posts recreated so far.
+func (t *provenanceTracker) newPostIDs() []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ids := make([]string, 0, len(t.ids))
+	for newPostID := range t.ids {
+		ids = append(ids, newPostID)
+	}
+
+	return ids
+}
+
+// recordProvenance stores a provenance record for each post recreated by a
+// completed move. The records expire with the history retention. Failures are
+// logged as the move itself already succeeded.
+func (p *Plugin) recordProvenance(tracker *provenanceTracker, operationType, userID, originalChannelID, targetChannelID string) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	expireInSeconds := int64(p.getConfiguration().HistoryRetention().Seconds())
+	movedAt := now().UnixNano()
+	for newPostID, originalPostID := range tracker.ids {
+		data, err := json.Marshal(provenanceRecord{
+			PostID:            newPostID,
+			OriginalPostID:    originalPostID,
+			OriginalChannelID: originalChannelID,
+			TargetChannelID:   targetChannelID,
+			Operation:         operationType,
+			UserID:            userID,
+			MovedAt:           movedAt,
+			OriginalKept:      tracker.kept[originalPostID],
+		})
+		if err != nil {
+			p.API.LogError("Unable to marshal provenance record", "error", err.Error(), "post_id", newPostID)
+			continue
+		}
+
+		appErr := p.API.KVSetWithExpiry(getProvenanceKey(newPostID), data, expireInSeconds)
+		if appErr != nil {
+			p.API.LogError("Unable to store provenance record", "error", appErr.Error(), "post_id", newPostID)
+		}
+	}
+}
+
+// getProvenance returns the moves of the post, most recent first, by following
+// the provenance records of the post and of the posts it was moved from.
+func (p *Plugin) getProvenance(postID string) ([]provenanceRecord, error) {
+	var records []provenanceRecord
+	for len(records) < maxProvenanceHops {
+		var record provenanceRecord
+		found, err := p.kvGetJSON(getProvenanceKey(postID), &record)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get provenance record")
+		}
+		if !found {
+			break
+		}
+
+		records = append(records, record)
+		postID = record.OriginalPostID
+	}
+
+	return records, nil
+}
+
+const threadCopiesKeyPrefix = "provenance_copies_"
+
+// threadCopy records a copy of a thread made into a channel.
+type threadCopy struct {
+	TargetChannelID string `json:"target_channel_id"`
+	PostID          string `json:"post_id"`
+	CopiedAt        int64  `json:"copied_at"`
+}
+
+// getThreadCopiesKey returns the key of the copies made of the thread with the
+// given original root post, which indexes the provenance by source rather
+// than by copied post.
+func getThreadCopiesKey(originalRootID string) string {
+	return threadCopiesKeyPrefix + originalRootID
+}
+
+// recordThreadCopy adds a completed copy of the thread to the copies of its
+// original root post. The copies expire with the history retention like the
+// provenance records. Failures are logged as the copy itself already
+// succeeded.
+func (p *Plugin) recordThreadCopy(originalRootID, targetChannelID, newRootID string) {
+	var copies []threadCopy
+	_, err := p.kvGetJSON(getThreadCopiesKey(originalRootID), &copies)
+	if err != nil {
+		p.API.LogError("Unable to get thread copies", "error", err.Error(), "post_id", originalRootID)
+		return
+	}
+	copies = append(copies, threadCopy{
+		TargetChannelID: targetChannelID,
+		PostID:          newRootID,
+		CopiedAt:        now().UnixNano(),
+	})
+
+	data, err := json.Marshal(copies)
+	if err != nil {
+		p.API.LogError("Unable to marshal thread copies", "error", err.Error(), "post_id", originalRootID)
+		return
+	}
+	appErr := p.API.KVSetWithExpiry(getThreadCopiesKey(originalRootID), data, int64(p.getConfiguration().HistoryRetention().Seconds()))
+	if appErr != nil {
+		p.API.LogError("Unable to store thread copies", "error", appErr.Error(), "post_id", originalRootID)
+	}
+}
+
+// findThreadCopy returns the ID of the root post of the most recent copy of
+// the thread in the target channel, or an empty string when the thread wasn't
+// copied there. Copies that were deleted since don't count.
+func (p *Plugin) findThreadCopy(originalRootID, targetChannelID string) (string, error) {
+	var copies []threadCopy
+	_, err := p.kvGetJSON(getThreadCopiesKey(originalRootID), &copies)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get thread copies")
+	}
+
+	for i := len(copies) - 1; i >= 0; i-- {
+		if copies[i].TargetChannelID != targetChannelID {
+			continue
+		}
+		post, appErr := p.API.GetPost(copies[i].PostID)
+		if appErr != nil || post.DeleteAt != 0 {
+			continue
+		}
+
+		return post.Id, nil
+	}
+
+	return "", nil
+}
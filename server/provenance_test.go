@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveThreadProvenance(t *testing.T) {
+	f := newThreadTestFixture(1)
+	var created []*model.Post
+	f.unsetMock("CreatePost")
+	f.api.On("CreatePost", mock.Anything).Return(func(post *model.Post) *model.Post {
+		newPost := post.Clone()
+		newPost.Id = model.NewId()
+		created = append(created, newPost)
+		return newPost
+	}, nil)
+
+	plugin := &Plugin{}
+	plugin.SetAPI(f.api)
+	plugin.setConfiguration(&configuration{})
+
+	resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+	assert.Contains(t, resp.Text, "A thread has been moved")
+	require.True(t, len(created) >= 2)
+	newRoot, newReply := created[0], created[1]
+
+	get := func(userID, postID string) (int, []provenanceRecord) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, routeAPIProvenance+"?post_id="+postID, nil)
+		r.Header.Set("Mattermost-User-Id", userID)
+		status, _ := plugin.serveHTTP(nil, w, r)
+		if status != http.StatusOK {
+			return status, nil
+		}
+
+		var resp struct {
+			Moves []provenanceRecord `json:"moves"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return status, resp.Moves
+	}
+
+	t.Run("moved reply", func(t *testing.T) {
+		f.api.On("GetPost", newReply.Id).Return(newReply, nil)
+
+		status, moves := get(f.rootPost.UserId, newReply.Id)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, moves, 1)
+		assert.Equal(t, f.replies[0].Id, moves[0].OriginalPostID)
+		assert.Equal(t, f.originalChannel.Id, moves[0].OriginalChannelID)
+		assert.Equal(t, f.targetChannel.Id, moves[0].TargetChannelID)
+		assert.Equal(t, operationMove, moves[0].Operation)
+		assert.Equal(t, f.rootPost.UserId, moves[0].UserID)
+	})
+
+	t.Run("moved twice", func(t *testing.T) {
+		movedAgain := &model.Post{Id: model.NewId(), ChannelId: f.originalChannel.Id}
+		f.api.On("GetPost", movedAgain.Id).Return(movedAgain, nil)
+		tracker := newProvenanceTracker()
+		tracker.track(newRoot, movedAgain)
+		plugin.recordProvenance(tracker, operationMerge, f.rootPost.UserId, f.targetChannel.Id, f.originalChannel.Id)
+
+		status, moves := get(f.rootPost.UserId, movedAgain.Id)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, moves, 2)
+		assert.Equal(t, newRoot.Id, moves[0].OriginalPostID)
+		assert.Equal(t, operationMerge, moves[0].Operation)
+		assert.Equal(t, f.rootPost.Id, moves[1].OriginalPostID)
+	})
+
+	t.Run("post that was never moved", func(t *testing.T) {
+		post := &model.Post{Id: model.NewId(), ChannelId: f.targetChannel.Id}
+		f.api.On("GetPost", post.Id).Return(post, nil)
+
+		status, moves := get(f.rootPost.UserId, post.Id)
+		require.Equal(t, http.StatusOK, status)
+		assert.Empty(t, moves)
+	})
+
+	t.Run("channel not readable", func(t *testing.T) {
+		f.unsetMock("HasPermissionToChannel")
+		f.api.On("HasPermissionToChannel", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).Return(false)
+
+		status, _ := get(f.rootPost.UserId, newReply.Id)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const remindersKey = "reminders"
+
+// reminderCheckInterval is how often the scheduler looks for due reminders.
+const reminderCheckInterval = time.Minute
+
+// reminder is a scheduled DM to a user pointing to a thread they moved.
+type reminder struct {
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	PostID   string `json:"post_id"`
+	PostLink string `json:"post_link"`
+	RemindAt int64  `json:"remind_at"`
+}
+
+func unmarshalReminders(data []byte) ([]reminder, error) {
+	var reminders []reminder
+	if data == nil {
+		return reminders, nil
+	}
+
+	err := json.Unmarshal(data, &reminders)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal reminders")
+	}
+
+	return reminders, nil
+}
+
+// addReminder schedules a reminder DM to the user with a link to the given
+// post and returns the ID of the new reminder.
+func (p *Plugin) addReminder(userID, postID, postLink string, after time.Duration) (string, error) {
+	r := reminder{
+		ID:       model.NewId(),
+		UserID:   userID,
+		PostID:   postID,
+		PostLink: postLink,
+		RemindAt: now().Add(after).UnixNano(),
+	}
+
+	err := p.kvAtomicModify(remindersKey, func(initial []byte) ([]byte, error) {
+		reminders, err := unmarshalReminders(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(append(reminders, r))
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to store reminder")
+	}
+
+	return r.ID, nil
+}
+
+// getReminders returns all scheduled reminders.
+func (p *Plugin) getReminders() ([]reminder, error) {
+	data, appErr := p.API.KVGet(remindersKey)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "unable to get reminders")
+	}
+
+	return unmarshalReminders(data)
+}
+
+// scheduledJobLimitReached returns true if the user already has the
+// configured maximum number of reminders scheduled. System admins are never
+// limited.
+func (p *Plugin) scheduledJobLimitReached(userID string) (bool, error) {
+	max := p.getConfiguration().MaxScheduledJobsPerUserInt()
+	if max == 0 {
+		return false, nil
+	}
+	if p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		return false, nil
+	}
+
+	reminders, err := p.getReminders()
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	for _, r := range reminders {
+		if r.UserID == userID {
+			count++
+		}
+	}
+
+	return count >= max, nil
+}
+
+// cancelReminder removes the reminder with the given ID if it belongs to the
+// user, or to anyone when no user ID is given. It returns false if no such
+// reminder is scheduled.
+func (p *Plugin) cancelReminder(userID, reminderID string) (bool, error) {
+	var found bool
+	err := p.kvAtomicModify(remindersKey, func(initial []byte) ([]byte, error) {
+		reminders, err := unmarshalReminders(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		found = false
+		var remaining []reminder
+		for _, r := range reminders {
+			if r.ID == reminderID && (len(userID) == 0 || r.UserID == userID) {
+				found = true
+				continue
+			}
+			remaining = append(remaining, r)
+		}
+
+		return json.Marshal(remaining)
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to cancel reminder")
+	}
+
+	return found, nil
+}
+
+// removeUserReminders removes all reminders scheduled by the user and returns
+// how many were removed.
+func (p *Plugin) removeUserReminders(userID string) (int, error) {
+	var removed int
+	err := p.kvAtomicModify(remindersKey, func(initial []byte) ([]byte, error) {
+		reminders, err := unmarshalReminders(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		removed = 0
+		var remaining []reminder
+		for _, r := range reminders {
+			if r.UserID == userID {
+				removed++
+				continue
+			}
+			remaining = append(remaining, r)
+		}
+
+		return json.Marshal(remaining)
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to remove user reminders")
+	}
+
+	return removed, nil
+}
+
+// takeDueReminders removes the reminders that are due from the store and
+// returns them. Taking them atomically ensures that each reminder is only sent
+// once when several servers run the scheduler.
+func (p *Plugin) takeDueReminders() ([]reminder, error) {
+	var due []reminder
+	err := p.kvAtomicModify(remindersKey, func(initial []byte) ([]byte, error) {
+		reminders, err := unmarshalReminders(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		due = nil
+		var remaining []reminder
+		current := now().UnixNano()
+		for _, r := range reminders {
+			if r.RemindAt <= current {
+				due = append(due, r)
+				continue
+			}
+			remaining = append(remaining, r)
+		}
+
+		return json.Marshal(remaining)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to take due reminders")
+	}
+
+	return due, nil
+}
+
+// sendDueReminders sends the reminders that are due. Reminders for threads
+// that were deleted in the meantime are dropped.
+func (p *Plugin) sendDueReminders() {
+	due, err := p.takeDueReminders()
+	if err != nil {
+		p.API.LogError("Unable to process reminders", "error", err.Error())
+		return
+	}
+
+	for _, r := range due {
+		post, appErr := p.API.GetPost(r.PostID)
+		if appErr != nil || post.DeleteAt != 0 {
+			p.API.LogInfo("Dropping reminder for a deleted thread",
+				"reminder_id", r.ID,
+				"post_id", r.PostID,
+			)
+			continue
+		}
+
+		err = p.PostBotDM(r.UserID, fmt.Sprintf("Reminder: follow up on the thread you moved: %s", r.PostLink))
+		if err != nil {
+			p.API.LogError("Unable to send reminder DM to user",
+				"error", err.Error(),
+				"user_id", r.UserID,
+			)
+		}
+	}
+}
+
+// startReminderScheduler periodically sends due reminders until
+// stopReminderScheduler is called.
+func (p *Plugin) startReminderScheduler() {
+	p.reminderStop = make(chan struct{})
+	p.reminderDone = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(reminderCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.sendDueReminders()
+			case <-stop:
+				return
+			}
+		}
+	}(p.reminderStop, p.reminderDone)
+}
+
+// stopReminderScheduler stops the scheduler and waits for it to exit.
+func (p *Plugin) stopReminderScheduler() {
+	if p.reminderStop == nil {
+		return
+	}
+
+	close(p.reminderStop)
+	<-p.reminderDone
+	p.reminderStop = nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminders(t *testing.T) {
+	currentTime := time.Now()
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	userID := model.NewId()
+	livePost := &model.Post{Id: model.NewId()}
+	deletedPost := &model.Post{Id: model.NewId(), DeleteAt: 1}
+
+	setup := func() (*plugintest.API, *Plugin) {
+		api := &plugintest.API{}
+		newMockKVStore(api)
+		mockLogs(api)
+		api.On("GetPost", livePost.Id).Return(livePost, nil)
+		api.On("GetPost", deletedPost.Id).Return(deletedPost, nil)
+		api.On("GetDirectChannel", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(&model.Channel{Id: model.NewId()}, nil)
+		api.On("CreatePost", mock.Anything).Return(mockGeneratePost(), nil)
+
+		plugin := &Plugin{}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(&configuration{})
+
+		return api, plugin
+	}
+
+	t.Run("due reminders are sent once", func(t *testing.T) {
+		api, plugin := setup()
+
+		_, err := plugin.addReminder(userID, livePost.Id, "link-1", time.Hour)
+		require.NoError(t, err)
+		_, err = plugin.addReminder(userID, livePost.Id, "link-2", 2*time.Hour)
+		require.NoError(t, err)
+
+		currentTime = currentTime.Add(90 * time.Minute)
+		plugin.sendDueReminders()
+		plugin.sendDueReminders()
+
+		api.AssertNumberOfCalls(t, "CreatePost", 1)
+		api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "Reminder: follow up on the thread you moved: link-1"
+		}))
+
+		due, err := plugin.takeDueReminders()
+		require.NoError(t, err)
+		assert.Empty(t, due)
+	})
+
+	t.Run("reminders for deleted threads are dropped", func(t *testing.T) {
+		api, plugin := setup()
+
+		_, err := plugin.addReminder(userID, deletedPost.Id, "link", time.Minute)
+		require.NoError(t, err)
+
+		currentTime = currentTime.Add(time.Hour)
+		plugin.sendDueReminders()
+
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		api, plugin := setup()
+
+		reminderID, err := plugin.addReminder(userID, livePost.Id, "link", time.Minute)
+		require.NoError(t, err)
+
+		resp, isUserError, err := plugin.runCancelReminderCommand([]string{reminderID}, &model.CommandArgs{UserId: model.NewId()})
+		require.NoError(t, err)
+		assert.True(t, isUserError)
+		assert.Contains(t, resp.Text, "no reminder with ID")
+
+		resp, isUserError, err = plugin.runCancelReminderCommand([]string{reminderID}, &model.CommandArgs{UserId: userID})
+		require.NoError(t, err)
+		assert.False(t, isUserError)
+		assert.Equal(t, "Reminder canceled", resp.Text)
+
+		currentTime = currentTime.Add(time.Hour)
+		plugin.sendDueReminders()
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+}
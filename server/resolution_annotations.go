@@ -0,0 +1,59 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultResolutionAnnotationText is the annotation used when a destination
+// channel only configures a reaction.
+const defaultResolutionAnnotationText = "✅ Resolved on {date} by {user}"
+
+// emojiNameRegexp matches the names of system and custom emoji, such as
+// white_check_mark or +1.
+var emojiNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_+\-]{1,64}$`)
+
+// resolutionAnnotation describes how the root post of a thread is marked as
+// resolved when the thread is moved into a given channel.
+type resolutionAnnotation struct {
+	// Text is appended to the root message. The {date} and {user}
+	// placeholders are replaced with the date of the move and the user who
+	// moved the thread.
+	Text string `json:"text"`
+	// Emoji, when set, is added as a reaction to the root message by the
+	// Wrangler bot.
+	Emoji string `json:"emoji"`
+}
+
+// render returns the annotation text for a move on the given date by the
+// given user, falling back to the default text.
+func (a resolutionAnnotation) render(date time.Time, username string) string {
+	text := a.Text
+	if len(strings.TrimSpace(text)) == 0 {
+		text = defaultResolutionAnnotationText
+	}
+
+	return strings.NewReplacer(
+		"{date}", date.UTC().Format("2006-01-02"),
+		"{user}", username,
+	).Replace(text)
+}
+
+// getResolutionAnnotation returns the annotation text and reaction for a
+// thread moved into the target channel by the given user. Both are empty when
+// the channel has no resolution annotation.
+func (p *Plugin) getResolutionAnnotation(targetChannelID, userID string) (string, string) {
+	annotation, ok := p.getConfiguration().ResolutionAnnotationsMap()[targetChannelID]
+	if !ok {
+		return "", ""
+	}
+
+	username := "someone"
+	user, appErr := p.API.GetUser(userID)
+	if appErr == nil {
+		username = "@" + user.Username
+	}
+
+	return annotation.render(time.Now(), username), strings.Trim(annotation.Emoji, ":")
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// routingEndpointTimeout bounds how long the routing endpoint is waited for.
+// It is a var so that tests can shorten it.
+var routingEndpointTimeout = 5 * time.Second
+
+// maxRoutingEndpointResponseBytes bounds how much of a response is read.
+const maxRoutingEndpointResponseBytes = 64 * 1024
+
+// routingLookupRequest is the thread metadata posted to the routing endpoint.
+type routingLookupRequest struct {
+	PostID     string `json:"post_id"`
+	ChannelID  string `json:"channel_id"`
+	TeamID     string `json:"team_id"`
+	UserID     string `json:"user_id"`
+	Message    string `json:"message"`
+	ReplyCount int    `json:"reply_count"`
+}
+
+// routingLookupResponse is the answer expected from the routing endpoint.
+type routingLookupResponse struct {
+	ChannelID string `json:"channel_id"`
+}
+
+// lookupRoutingEndpoint asks the configured routing endpoint which channel the
+// thread should be moved to. An error is returned when the endpoint can't be
+// reached in time or doesn't answer with a valid channel ID.
+func (p *Plugin) lookupRoutingEndpoint(wpl *WranglerPostList, extra *model.CommandArgs) (string, error) {
+	config := p.getConfiguration()
+
+	body, err := json.Marshal(routingLookupRequest{
+		PostID:     wpl.RootPost().Id,
+		ChannelID:  wpl.RootPost().ChannelId,
+		TeamID:     extra.TeamId,
+		UserID:     extra.UserId,
+		Message:    wpl.RootPost().Message,
+		ReplyCount: wpl.NumPosts() - 1,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal routing lookup request")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, config.RoutingEndpointURL, bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create routing lookup request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(config.RoutingEndpointAuthHeader) != 0 {
+		req.Header.Set("Authorization", config.RoutingEndpointAuthHeader)
+	}
+
+	client := &http.Client{Timeout: routingEndpointTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "routing lookup request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("routing endpoint responded with status %d", resp.StatusCode)
+	}
+
+	var lookup routingLookupResponse
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxRoutingEndpointResponseBytes)).Decode(&lookup)
+	// Drain the rest of the body so that the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxRoutingEndpointResponseBytes))
+	if err != nil {
+		return "", errors.Wrap(err, "routing endpoint response is not valid JSON")
+	}
+	if !model.IsValidId(lookup.ChannelID) {
+		return "", fmt.Errorf("routing endpoint responded with an invalid channel ID %q", lookup.ChannelID)
+	}
+
+	return lookup.ChannelID, nil
+}
+
+// validateRoutingEndpoint returns an error if the routing endpoint settings
+// are invalid.
+func validateRoutingEndpoint(endpointURL, authHeader string) error {
+	if len(endpointURL) == 0 {
+		if len(authHeader) != 0 {
+			return errors.New("RoutingEndpointAuthHeader requires RoutingEndpointURL to be set")
+		}
+		return nil
+	}
+
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return errors.Wrapf(err, "RoutingEndpointURL value %s is not a valid URL", endpointURL)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("RoutingEndpointURL value %s must be an absolute http or https URL", endpointURL)
+	}
+	if strings.ContainsAny(authHeader, "\r\n") {
+		return errors.New("RoutingEndpointAuthHeader must be a single line")
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const temporaryPostsKey = "temporary_posts"
+
+// temporaryPostCleanupInterval is how often expired temporary posts are
+// deleted.
+const temporaryPostCleanupInterval = time.Minute
+
+// temporaryPost is a bot post that is deleted once it expires.
+type temporaryPost struct {
+	PostID   string `json:"post_id"`
+	DeleteAt int64  `json:"delete_at"`
+}
+
+func unmarshalTemporaryPosts(data []byte) ([]temporaryPost, error) {
+	var posts []temporaryPost
+	if data == nil {
+		return posts, nil
+	}
+
+	err := json.Unmarshal(data, &posts)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal temporary posts")
+	}
+
+	return posts, nil
+}
+
+// getTemporaryResponse returns the response to a command whose output is only
+// useful for a short while. When temporary responses are configured, the
+// output is posted by the bot in its DM channel with the user and deleted
+// once it expires, and an empty response is returned. Otherwise, or if the
+// post can't be created, the output is returned as an ephemeral message.
+func (p *Plugin) getTemporaryResponse(userID, text string) *model.CommandResponse {
+	ttl := p.getConfiguration().TemporaryResponseTTL()
+	if ttl == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, text)
+	}
+
+	err := p.postTemporaryBotDM(userID, text, ttl)
+	if err != nil {
+		p.API.LogError("Unable to post temporary response; falling back to an ephemeral message",
+			"error", err.Error(),
+			"user_id", userID,
+		)
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, text)
+	}
+
+	return &model.CommandResponse{}
+}
+
+// postTemporaryBotDM posts a DM from the bot to the user that is deleted after
+// the given time.
+func (p *Plugin) postTemporaryBotDM(userID, message string, ttl time.Duration) error {
+	channel, appErr := p.API.GetDirectChannel(userID, p.BotUserID)
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to get direct channel")
+	}
+
+	post, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channel.Id,
+		Message:   message,
+	})
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to create temporary post")
+	}
+
+	tracked := temporaryPost{
+		PostID:   post.Id,
+		DeleteAt: now().Add(ttl).UnixNano(),
+	}
+	err := p.kvAtomicModify(temporaryPostsKey, func(initial []byte) ([]byte, error) {
+		posts, err := unmarshalTemporaryPosts(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(append(posts, tracked))
+	})
+	if err != nil {
+		// The post would never be cleaned up, so remove it right away.
+		appErr = p.API.DeletePost(post.Id)
+		if appErr != nil {
+			p.API.LogError("Unable to delete untracked temporary post", "error", appErr.Error(), "post_id", post.Id)
+		}
+		return errors.Wrap(err, "unable to track temporary post")
+	}
+
+	return nil
+}
+
+// takeExpiredTemporaryPosts removes the temporary posts that have expired from
+// the store and returns them. Taking them atomically ensures that each post is
+// only deleted once when several servers run the cleanup.
+func (p *Plugin) takeExpiredTemporaryPosts() ([]temporaryPost, error) {
+	var expired []temporaryPost
+	err := p.kvAtomicModify(temporaryPostsKey, func(initial []byte) ([]byte, error) {
+		posts, err := unmarshalTemporaryPosts(initial)
+		if err != nil {
+			return nil, err
+		}
+
+		expired = nil
+		var remaining []temporaryPost
+		current := now().UnixNano()
+		for _, post := range posts {
+			if post.DeleteAt <= current {
+				expired = append(expired, post)
+				continue
+			}
+			remaining = append(remaining, post)
+		}
+
+		return json.Marshal(remaining)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to take expired temporary posts")
+	}
+
+	return expired, nil
+}
+
+// deleteExpiredTemporaryPosts deletes the temporary posts that have expired.
+// Posts that were already deleted by the user are skipped.
+func (p *Plugin) deleteExpiredTemporaryPosts() {
+	expired, err := p.takeExpiredTemporaryPosts()
+	if err != nil {
+		p.API.LogError("Unable to process temporary posts", "error", err.Error())
+		return
+	}
+
+	for _, post := range expired {
+		appErr := p.API.DeletePost(post.PostID)
+		if appErr != nil {
+			p.API.LogInfo("Unable to delete expired temporary post",
+				"error", appErr.Error(),
+				"post_id", post.PostID,
+			)
+		}
+	}
+}
+
+// startTemporaryPostCleanup periodically deletes expired temporary posts until
+// stopTemporaryPostCleanup is called.
+func (p *Plugin) startTemporaryPostCleanup() {
+	p.temporaryPostStop = make(chan struct{})
+	p.temporaryPostDone = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(temporaryPostCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.deleteExpiredTemporaryPosts()
+			case <-stop:
+				return
+			}
+		}
+	}(p.temporaryPostStop, p.temporaryPostDone)
+}
+
+// stopTemporaryPostCleanup stops the cleanup and waits for it to exit.
+func (p *Plugin) stopTemporaryPostCleanup() {
+	if p.temporaryPostStop == nil {
+		return
+	}
+
+	close(p.temporaryPostStop)
+	<-p.temporaryPostDone
+	p.temporaryPostStop = nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemporaryResponses(t *testing.T) {
+	currentTime := time.Now()
+	now = func() time.Time { return currentTime }
+	defer func() { now = time.Now }()
+
+	userID := model.NewId()
+	dmChannel := &model.Channel{Id: model.NewId()}
+
+	setup := func(config *configuration) (*plugintest.API, *Plugin) {
+		api := &plugintest.API{}
+		newMockKVStore(api)
+		mockLogs(api)
+		api.On("GetDirectChannel", userID, "botid").Return(dmChannel, nil)
+		api.On("CreatePost", mock.Anything).Return(func(post *model.Post) *model.Post {
+			created := post.Clone()
+			created.Id = model.NewId()
+			return created
+		}, nil)
+		api.On("DeletePost", mock.AnythingOfType("string")).Return(nil)
+
+		plugin := &Plugin{BotUserID: "botid"}
+		plugin.SetAPI(api)
+		plugin.setConfiguration(config)
+
+		return api, plugin
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		api, plugin := setup(&configuration{})
+
+		resp := plugin.getTemporaryResponse(userID, "list output")
+		assert.Equal(t, model.COMMAND_RESPONSE_TYPE_EPHEMERAL, resp.ResponseType)
+		assert.Equal(t, "list output", resp.Text)
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+
+	t.Run("posted and deleted once expired", func(t *testing.T) {
+		api, plugin := setup(&configuration{TemporaryResponseMinutes: "10"})
+
+		resp := plugin.getTemporaryResponse(userID, "list output")
+		assert.Empty(t, resp.Text)
+		api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.UserId == "botid" && post.ChannelId == dmChannel.Id && post.Message == "list output"
+		}))
+
+		currentTime = currentTime.Add(5 * time.Minute)
+		plugin.deleteExpiredTemporaryPosts()
+		api.AssertNotCalled(t, "DeletePost", mock.Anything)
+
+		currentTime = currentTime.Add(10 * time.Minute)
+		plugin.deleteExpiredTemporaryPosts()
+		plugin.deleteExpiredTemporaryPosts()
+		api.AssertNumberOfCalls(t, "DeletePost", 1)
+
+		expired, err := plugin.takeExpiredTemporaryPosts()
+		require.NoError(t, err)
+		assert.Empty(t, expired)
+	})
+
+	t.Run("falls back to ephemeral", func(t *testing.T) {
+		api, plugin := setup(&configuration{TemporaryResponseMinutes: "10"})
+		api.ExpectedCalls = nil
+		mockLogs(api)
+		api.On("GetDirectChannel", userID, "botid").Return(nil, &model.AppError{Message: "no channel"})
+
+		resp := plugin.getTemporaryResponse(userID, "list output")
+		assert.Equal(t, model.COMMAND_RESPONSE_TYPE_EPHEMERAL, resp.ResponseType)
+		assert.Equal(t, "list output", resp.Text)
+	})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// threadStats are the activity stats of a thread at the time it was moved.
+type threadStats struct {
+	replies      int
+	participants int
+	first        time.Time
+	last         time.Time
+	// peakDay is the UTC day with the most messages and peakCount the number
+	// of messages posted that day. The earliest day wins ties.
+	peakDay   time.Time
+	peakCount int
+}
+
+// computeThreadStats returns the stats of the thread made of the root post
+// followed by its replies.
+func computeThreadStats(posts []*model.Post) threadStats {
+	var stats threadStats
+	if len(posts) == 0 {
+		return stats
+	}
+
+	stats.replies = len(posts) - 1
+	stats.participants = countAuthors(posts)
+
+	days := make(map[time.Time]int)
+	for i, post := range posts {
+		createAt := time.Unix(0, post.CreateAt*int64(time.Millisecond)).UTC()
+		if i == 0 || createAt.Before(stats.first) {
+			stats.first = createAt
+		}
+		if i == 0 || createAt.After(stats.last) {
+			stats.last = createAt
+		}
+
+		day := time.Date(createAt.Year(), createAt.Month(), createAt.Day(), 0, 0, 0, 0, time.UTC)
+		days[day]++
+		if days[day] > stats.peakCount || (days[day] == stats.peakCount && day.Before(stats.peakDay)) {
+			stats.peakDay = day
+			stats.peakCount = days[day]
+		}
+	}
+
+	return stats
+}
+
+// formatTimeSpan returns the duration rounded to a unit that reads naturally
+// for the age of a discussion.
+func formatTimeSpan(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("%d minute(s)", int(d/time.Minute))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hour(s)", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	}
+}
+
+// render returns the message of the summary post of the moved thread.
+func (s threadStats) render(threadLink string) string {
+	return fmt.Sprintf(
+		"#### Thread summary\n\nActivity of the [moved thread](%s) before it was moved:\n\n| Replies | Participants | Time span | Peak activity day |\n| -- | -- | -- | -- |\n| %d | %d | %s (%s to %s) | %s (%d message(s)) |",
+		threadLink, s.replies, s.participants,
+		formatTimeSpan(s.last.Sub(s.first)), s.first.Format("2006-01-02"), s.last.Format("2006-01-02"),
+		s.peakDay.Format("2006-01-02"), s.peakCount,
+	)
+}
+
+// postMovedThreadSummary posts a pinned summary of the activity of the moved
+// thread to the target channel when summaries are enabled and returns a note
+// for the move summary. The summary is timestamped right before the new root
+// post so that it is shown just above the moved thread.
+func (p *Plugin) postMovedThreadSummary(posts []*model.Post, targetChannel *model.Channel, newRootPost *model.Post, userID, newPostLink string) string {
+	if !p.getConfiguration().EnableMovedThreadSummary {
+		return ""
+	}
+
+	summaryPost := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: targetChannel.Id,
+		Message:   computeThreadStats(posts).render(newPostLink),
+		IsPinned:  true,
+	}
+	if newRootPost.CreateAt > 1 {
+		summaryPost.CreateAt = newRootPost.CreateAt - 1
+	}
+
+	_, appErr := p.API.CreatePost(summaryPost)
+	if appErr != nil {
+		p.API.LogError("Unable to post moved thread summary",
+			"error", appErr.Error(),
+			"user_id", userID,
+			"channel_id", targetChannel.Id,
+		)
+		return "\nThe thread was moved, but its activity summary could not be posted.\n"
+	}
+
+	return "\nA pinned summary of the thread activity was posted above the moved thread.\n"
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeThreadStats(t *testing.T) {
+	at := func(value string) int64 {
+		createAt, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return createAt.UnixNano() / int64(time.Millisecond)
+	}
+	userA, userB := model.NewId(), model.NewId()
+	posts := []*model.Post{
+		{UserId: userA, CreateAt: at("2020-06-01T10:00:00Z")},
+		{UserId: userB, CreateAt: at("2020-06-02T09:00:00Z")},
+		{UserId: userA, CreateAt: at("2020-06-02T18:00:00Z")},
+		{UserId: userB, CreateAt: at("2020-06-04T11:00:00Z")},
+	}
+
+	stats := computeThreadStats(posts)
+	assert.Equal(t, 3, stats.replies)
+	assert.Equal(t, 2, stats.participants)
+	assert.Equal(t, "2020-06-02", stats.peakDay.Format("2006-01-02"))
+	assert.Equal(t, 2, stats.peakCount)
+	assert.Equal(t,
+		"#### Thread summary\n\nActivity of the [moved thread](link) before it was moved:\n\n| Replies | Participants | Time span | Peak activity day |\n| -- | -- | -- | -- |\n| 3 | 2 | 3 days (2020-06-01 to 2020-06-04) | 2020-06-02 (2 message(s)) |",
+		stats.render("link"),
+	)
+
+	t.Run("ties go to the earliest day", func(t *testing.T) {
+		stats := computeThreadStats(posts[2:])
+		assert.Equal(t, "2020-06-02", stats.peakDay.Format("2006-01-02"))
+		assert.Equal(t, 1, stats.peakCount)
+	})
+}
+
+func TestFormatTimeSpan(t *testing.T) {
+	assert.Equal(t, "less than a minute", formatTimeSpan(30*time.Second))
+	assert.Equal(t, "45 minute(s)", formatTimeSpan(45*time.Minute))
+	assert.Equal(t, "30 hour(s)", formatTimeSpan(30*time.Hour))
+	assert.Equal(t, "3 days", formatTimeSpan(80*time.Hour))
+}
+
+func TestMoveThreadSummary(t *testing.T) {
+	f := newThreadTestFixture(2)
+	f.newPost.CreateAt = 1000
+
+	var plugin Plugin
+	plugin.SetAPI(f.api)
+	plugin.BotUserID = model.NewId()
+
+	t.Run("disabled", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{})
+
+		resp, _, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.NotContains(t, resp.Text, "summary of the thread activity")
+		f.api.AssertNotCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.IsPinned
+		}))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		plugin.setConfiguration(&configuration{EnableMovedThreadSummary: true})
+
+		resp, _, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+		require.NoError(t, err)
+		assert.Contains(t, resp.Text, "A pinned summary of the thread activity was posted above the moved thread.")
+		f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.IsPinned &&
+				post.UserId == plugin.BotUserID &&
+				post.ChannelId == f.targetChannel.Id &&
+				post.CreateAt == 999 &&
+				strings.HasSuffix(post.Message, "| 2 | 3 | less than a minute (1970-01-01 to 1970-01-01) | 1970-01-01 (3 message(s)) |")
+		}))
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ticketLinkPlaceholder is replaced by the matched ticket reference in the
+// ticket link URL template.
+const ticketLinkPlaceholder = "{ticket}"
+
+// ticketLinker rewrites the ticket references of moved messages as markdown
+// links to the issue tracker.
+type ticketLinker struct {
+	regexp      *regexp.Regexp
+	urlTemplate string
+}
+
+// link returns the message with every ticket reference rewritten as a link to
+// the ticket. References in code and in existing links or URLs are left
+// unchanged.
+func (l *ticketLinker) link(message string) string {
+	return mapOutsideCode(message, l.linkText)
+}
+
+func (l *ticketLinker) linkText(line string) string {
+	var b strings.Builder
+	var last int
+	for _, match := range l.regexp.FindAllStringIndex(line, -1) {
+		start, end := match[0], match[1]
+		if start == end || !l.linkable(line, start, end) {
+			continue
+		}
+		ticket := line[start:end]
+		b.WriteString(line[last:start])
+		b.WriteString("[" + ticket + "](" + strings.Replace(l.urlTemplate, ticketLinkPlaceholder, ticket, -1) + ")")
+		last = end
+	}
+	if last == 0 {
+		return line
+	}
+	b.WriteString(line[last:])
+
+	return b.String()
+}
+
+// linkable returns false if the match is in the text or target of an
+// existing markdown link or part of a URL.
+func (l *ticketLinker) linkable(line string, start, end int) bool {
+	if start > 0 && strings.ContainsAny(line[start-1:start], "[/") {
+		return false
+	}
+	if end < len(line) && strings.ContainsAny(line[end:end+1], "]/") {
+		return false
+	}
+	if word := line[strings.LastIndexAny(line[:start], " \t\n(")+1 : start]; strings.Contains(word, "://") {
+		return false
+	}
+
+	return true
+}
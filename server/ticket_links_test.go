@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketLinker(t *testing.T) {
+	linker, err := parseAndValidateTicketLinker(`[A-Z]+-[0-9]+`, "https://tracker.example.com/browse/{ticket}")
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		message  string
+		expected string
+	}{
+		"no references": {
+			message:  "nothing to link here",
+			expected: "nothing to link here",
+		},
+		"several references": {
+			message:  "PROJ-123 is blocked by OPS-7.",
+			expected: "[PROJ-123](https://tracker.example.com/browse/PROJ-123) is blocked by [OPS-7](https://tracker.example.com/browse/OPS-7).",
+		},
+		"existing link": {
+			message:  "see [PROJ-123](https://tracker.example.com/browse/PROJ-123)",
+			expected: "see [PROJ-123](https://tracker.example.com/browse/PROJ-123)",
+		},
+		"URL": {
+			message:  "see https://wiki.example.com/PROJ-123 and http://example.com?ticket=PROJ-4",
+			expected: "see https://wiki.example.com/PROJ-123 and http://example.com?ticket=PROJ-4",
+		},
+		"inline code": {
+			message:  "run `deploy PROJ-1` for PROJ-2",
+			expected: "run `deploy PROJ-1` for [PROJ-2](https://tracker.example.com/browse/PROJ-2)",
+		},
+		"fenced code": {
+			message:  "PROJ-1\n```\nPROJ-2\n```\nPROJ-3",
+			expected: "[PROJ-1](https://tracker.example.com/browse/PROJ-1)\n```\nPROJ-2\n```\n[PROJ-3](https://tracker.example.com/browse/PROJ-3)",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, linker.link(tc.message))
+		})
+	}
+}
+
+func TestMoveThreadLinksTickets(t *testing.T) {
+	for name, tc := range map[string]struct {
+		channels string
+		linked   bool
+	}{
+		"destination channel": {channels: "", linked: true},
+		"other channel":       {channels: model.NewId(), linked: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newThreadTestFixture(1)
+			f.replies[0].Message = "Fixed in PROJ-42"
+			channels := tc.channels
+			if len(channels) == 0 {
+				channels = model.NewId() + ", " + f.targetChannel.Id
+			}
+
+			plugin := &Plugin{}
+			plugin.SetAPI(f.api)
+			plugin.setConfiguration(&configuration{
+				TicketLinkRegex:       `PROJ-[0-9]+`,
+				TicketLinkURLTemplate: "https://tracker.example.com/browse/{ticket}",
+				TicketLinkChannels:    channels,
+			})
+			require.NoError(t, plugin.configuration.IsValid())
+
+			resp, isUserError, err := plugin.runMoveThreadCommand([]string{f.rootPost.Id, f.targetChannel.Id}, f.commandArgs())
+			require.NoError(t, err)
+			assert.False(t, isUserError)
+			assert.Contains(t, resp.Text, "A thread has been moved")
+
+			expected := "Fixed in PROJ-42"
+			if tc.linked {
+				expected = "Fixed in [PROJ-42](https://tracker.example.com/browse/PROJ-42)"
+			}
+			f.api.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+				return post.RootId == f.newPost.Id && post.Message == expected
+			}))
+		})
+	}
+}
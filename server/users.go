@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// resolveUserArg resolves a command argument referring to a user into that
+// user's ID. The argument can either be a username, optionally prefixed with
+// '@', or a raw user ID. The returned error is meant to be shown to the user
+// running the command.
+func (p *Plugin) resolveUserArg(arg string) (string, error) {
+	arg = strings.TrimSpace(arg)
+	if len(arg) == 0 {
+		return "", fmt.Errorf("Error: missing user; provide a @username or user ID")
+	}
+
+	if !strings.HasPrefix(arg, "@") && model.IsValidId(arg) {
+		user, appErr := p.API.GetUser(arg)
+		if appErr == nil {
+			return user.Id, nil
+		}
+		// User IDs are also valid usernames, so fall back to a username lookup.
+	}
+
+	username := strings.ToLower(strings.TrimPrefix(arg, "@"))
+	if !model.IsValidUsername(username) {
+		return "", fmt.Errorf("Error: %s is not a valid @username or user ID", arg)
+	}
+
+	user, appErr := p.API.GetUserByUsername(username)
+	if appErr != nil {
+		return "", fmt.Errorf("Error: unable to find user %s; ensure the username or user ID is correct", arg)
+	}
+
+	return user.Id, nil
+}
+
+// getUserLocation returns the time zone that timestamps are shown in to the
+// user, as set in their profile. The configured default time zone is used
+// when the user can't be found or their time zone is unknown.
+func (p *Plugin) getUserLocation(userID string) *time.Location {
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return p.getConfiguration().DefaultLocation()
+	}
+	timezone := user.GetPreferredTimezone()
+	if len(timezone) == 0 {
+		return p.getConfiguration().DefaultLocation()
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return p.getConfiguration().DefaultLocation()
+	}
+
+	return location
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveUserArg(t *testing.T) {
+	user := &model.User{Id: model.NewId(), Username: "jane.doe"}
+	notFound := model.NewAppError("test", "not found", nil, "", http.StatusNotFound)
+
+	api := &plugintest.API{}
+	api.On("GetUser", user.Id).Return(user, nil)
+	api.On("GetUser", mock.AnythingOfType("string")).Return(nil, notFound)
+	api.On("GetUserByUsername", user.Username).Return(user, nil)
+	api.On("GetUserByUsername", mock.AnythingOfType("string")).Return(nil, notFound)
+
+	plugin := &Plugin{}
+	plugin.SetAPI(api)
+
+	t.Run("username with @", func(t *testing.T) {
+		userID, err := plugin.resolveUserArg("@jane.doe")
+		require.NoError(t, err)
+		assert.Equal(t, user.Id, userID)
+	})
+
+	t.Run("username without @", func(t *testing.T) {
+		userID, err := plugin.resolveUserArg("Jane.Doe")
+		require.NoError(t, err)
+		assert.Equal(t, user.Id, userID)
+	})
+
+	t.Run("user ID", func(t *testing.T) {
+		userID, err := plugin.resolveUserArg(user.Id)
+		require.NoError(t, err)
+		assert.Equal(t, user.Id, userID)
+	})
+
+	t.Run("unknown username", func(t *testing.T) {
+		_, err := plugin.resolveUserArg("@john.doe")
+		require.Error(t, err)
+		assert.Equal(t, "Error: unable to find user @john.doe; ensure the username or user ID is correct", err.Error())
+	})
+
+	t.Run("unknown user ID", func(t *testing.T) {
+		_, err := plugin.resolveUserArg(model.NewId())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Error: unable to find user")
+	})
+
+	t.Run("invalid username", func(t *testing.T) {
+		_, err := plugin.resolveUserArg("@not a user")
+		require.Error(t, err)
+		assert.Equal(t, "Error: @not a user is not a valid @username or user ID", err.Error())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := plugin.resolveUserArg(" ")
+		require.Error(t, err)
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 )
@@ -13,6 +15,68 @@ func makePostLink(siteURL, teamName, postID string) string {
 	return fmt.Sprintf("%s/%s/pl/%s", siteURL, teamName, postID)
 }
 
+func makeChannelLink(siteURL, teamName, channelName string) string {
+	return fmt.Sprintf("%s/%s/channels/%s", siteURL, teamName, channelName)
+}
+
+// searchIndexedInBackground returns true if the server indexes new posts for
+// search asynchronously, in which case recreated posts only appear in search
+// results once the indexer has caught up. Database search covers new posts as
+// soon as they are saved.
+func searchIndexedInBackground(config *model.Config) bool {
+	if config.ElasticsearchSettings.EnableIndexing != nil && *config.ElasticsearchSettings.EnableIndexing {
+		return true
+	}
+	if config.BleveSettings.EnableIndexing != nil && *config.BleveSettings.EnableIndexing {
+		return true
+	}
+
+	return false
+}
+
+// formatUsernames returns the usernames of the map, keyed by user ID, as a
+// sorted list of mentions.
+func formatUsernames(usernames map[string]string) string {
+	var mentions []string
+	for _, username := range usernames {
+		mentions = append(mentions, "@"+username)
+	}
+	sort.Strings(mentions)
+
+	return strings.Join(mentions, ", ")
+}
+
+// splitCommandArgs splits a slash command into its space-separated arguments.
+// Text in double quotes is kept together as a single argument without the
+// quotes, so that flags can be given values with spaces. An unterminated quote
+// extends to the end of the command.
+func splitCommandArgs(command string) []string {
+	var args []string
+	var current strings.Builder
+	var quoted, inArg bool
+	for _, r := range command {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inArg = true
+		case r == ' ' && !quoted:
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
+
 func cleanPost(post *model.Post) {
 	post.Id = ""
 	post.CreateAt = 0
@@ -36,11 +100,11 @@ func cleanMessage(message string) string {
 }
 
 func trimMessage(message string, trimLength int) string {
-	if len(message) <= trimLength {
+	if utf8.RuneCountInString(message) <= trimLength {
 		return message
 	}
 
-	return fmt.Sprintf("%s...", message[:trimLength])
+	return fmt.Sprintf("%s...", string([]rune(message)[:trimLength]))
 }
 
 func prettyPrintJSON(in string) string {
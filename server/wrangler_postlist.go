@@ -1,9 +1,15 @@
 package main
 
 import (
+	"time"
+	"unicode/utf8"
+
 	"github.com/mattermost/mattermost-server/v5/model"
 )
 
+// maxPostFileCount is the number of file attachments a single post can hold.
+const maxPostFileCount = 5
+
 // WranglerPostList provides a list of posts along with metadata about those
 // posts.
 type WranglerPostList struct {
@@ -19,6 +25,12 @@ func (wpl *WranglerPostList) NumPosts() int {
 	return len(wpl.Posts)
 }
 
+// NumAuthors returns the number of distinct authors of the posts in a post
+// list.
+func (wpl *WranglerPostList) NumAuthors() int {
+	return len(wpl.ThreadUserIDs)
+}
+
 // RootPost returns the root post in a post list.
 func (wpl *WranglerPostList) RootPost() *model.Post {
 	if wpl.NumPosts() < 1 {
@@ -33,24 +45,139 @@ func (wpl *WranglerPostList) ContainsFileAttachments() bool {
 	return wpl.FileAttachmentCount != 0
 }
 
-func buildWranglerPostList(postList *model.PostList) *WranglerPostList {
-	wpl := &WranglerPostList{}
+// TrimReplies keeps the root post and at most the given number of replies,
+// dropping any later replies. It returns true if replies were dropped.
+func (wpl *WranglerPostList) TrimReplies(limit int) bool {
+	if wpl.NumPosts()-1 <= limit {
+		return false
+	}
 
-	postList.UniqueOrder()
-	postList.SortByCreateAt()
-	posts := postList.ToSlice()
+	wpl.Posts = wpl.Posts[:limit+1]
+	wpl.updateMetadata()
 
-	if len(posts) == 0 {
-		// Something was sorted wrong or an empty PostList was provided.
-		return wpl
+	return true
+}
+
+// RemoveRepliesByAuthors drops the replies created by any of the given user
+// IDs while keeping the root post. It returns the number of dropped replies.
+func (wpl *WranglerPostList) RemoveRepliesByAuthors(userIDs map[string]string) int {
+	if wpl.NumPosts() == 0 {
+		return 0
+	}
+
+	posts := []*model.Post{wpl.RootPost()}
+	for _, post := range wpl.Posts[1:] {
+		if _, ok := userIDs[post.UserId]; !ok {
+			posts = append(posts, post)
+		}
+	}
+	removed := wpl.NumPosts() - len(posts)
+	if removed != 0 {
+		wpl.Posts = posts
+		wpl.updateMetadata()
+	}
+
+	return removed
+}
+
+// FilterRepliesByDateRange drops the replies created outside of the given
+// date range while keeping the root post. It returns the number of kept
+// replies.
+func (wpl *WranglerPostList) FilterRepliesByDateRange(r dateRange) int {
+	if wpl.NumPosts() == 0 {
+		return 0
+	}
+
+	posts := []*model.Post{wpl.RootPost()}
+	for _, post := range wpl.Posts[1:] {
+		if r.contains(post.CreateAt) {
+			posts = append(posts, post)
+		}
+	}
+	if len(posts) != wpl.NumPosts() {
+		wpl.Posts = posts
+		wpl.updateMetadata()
+	}
+
+	return wpl.NumPosts() - 1
+}
+
+// CollapseConsecutivePosts merges consecutive posts by the same author that
+// were created within the given gap of each other into a single post. The
+// messages are joined with the separator and the file attachments of all
+// merged posts are kept. It returns the number of posts that were merged into
+// a previous post.
+func (wpl *WranglerPostList) CollapseConsecutivePosts(gap time.Duration, separator string) int {
+	var posts []*model.Post
+	var lastCreateAt int64
+	var merged int
+	var cloned bool
+
+	for _, post := range wpl.Posts {
+		if len(posts) != 0 {
+			previous := posts[len(posts)-1]
+			if canCollapsePosts(previous, post, lastCreateAt, gap, separator) {
+				if !cloned {
+					// Work on a copy to leave the original post untouched.
+					previous = previous.Clone()
+					previous.FileIds = append(model.StringArray{}, previous.FileIds...)
+					posts[len(posts)-1] = previous
+					cloned = true
+				}
+				previous.Message += separator + post.Message
+				previous.FileIds = append(previous.FileIds, post.FileIds...)
+				lastCreateAt = post.CreateAt
+				merged++
+				continue
+			}
+		}
+
+		posts = append(posts, post)
+		lastCreateAt = post.CreateAt
+		cloned = false
+	}
+
+	wpl.Posts = posts
+	wpl.updateMetadata()
+
+	return merged
+}
+
+// canCollapsePosts returns true if the post can be merged into the previous
+// post.
+func canCollapsePosts(previous, post *model.Post, lastCreateAt int64, gap time.Duration, separator string) bool {
+	if previous.UserId != post.UserId {
+		return false
+	}
+	if previous.IsSystemMessage() || post.IsSystemMessage() {
+		return false
+	}
+	if post.CreateAt-lastCreateAt > gap.Milliseconds() {
+		return false
+	}
+	if len(previous.FileIds)+len(post.FileIds) > maxPostFileCount {
+		return false
+	}
+	messageLength := utf8.RuneCountInString(previous.Message) + utf8.RuneCountInString(separator) + utf8.RuneCountInString(post.Message)
+
+	return messageLength <= model.POST_MESSAGE_MAX_RUNES_V2
+}
+
+// updateMetadata recomputes the post list metadata from its posts.
+func (wpl *WranglerPostList) updateMetadata() {
+	wpl.ThreadUserIDs = nil
+	wpl.FileAttachmentCount = 0
+	wpl.EarlistPostTimestamp = 0
+	wpl.LatestPostTimestamp = 0
+
+	if wpl.NumPosts() == 0 {
+		return
 	}
 
 	// A separate ID key map to ensure no duplicates.
 	idKeys := make(map[string]bool)
 
-	for i := range posts {
-		p := posts[len(posts)-i-1]
-
+	for _, p := range wpl.Posts {
 		// Add UserID to metadata if it's new.
 		if _, ok := idKeys[p.UserId]; !ok {
 			idKeys[p.UserId] = true
@@ -58,13 +185,44 @@ func buildWranglerPostList(postList *model.PostList) *WranglerPostList {
 		}
 
 		wpl.FileAttachmentCount += int64(len(p.FileIds))
-
-		wpl.Posts = append(wpl.Posts, p)
 	}
 
 	// Set metadata for earliest and latest posts
 	wpl.EarlistPostTimestamp = wpl.RootPost().CreateAt
 	wpl.LatestPostTimestamp = wpl.Posts[wpl.NumPosts()-1].CreateAt
+}
+
+// countAuthors returns the number of distinct authors of the given posts.
+func countAuthors(posts []*model.Post) int {
+	authors := make(map[string]bool)
+	for _, post := range posts {
+		authors[post.UserId] = true
+	}
+
+	return len(authors)
+}
+
+// buildWranglerPostList returns the posts of the thread in the order they were
+// originally posted. Posts are sorted by CreateAt rather than EditAt or
+// UpdateAt, so that editing, reacting to or pinning a post long after the
+// conversation doesn't move it, which keeps moved, copied and merged threads
+// coherent.
+func buildWranglerPostList(postList *model.PostList) *WranglerPostList {
+	wpl := &WranglerPostList{}
+
+	postList.UniqueOrder()
+	postList.SortByCreateAt()
+	posts := postList.ToSlice()
+
+	if len(posts) == 0 {
+		// Something was sorted wrong or an empty PostList was provided.
+		return wpl
+	}
+
+	for i := range posts {
+		wpl.Posts = append(wpl.Posts, posts[len(posts)-i-1])
+	}
+	wpl.updateMetadata()
 
 	return wpl
 }
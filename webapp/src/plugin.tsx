@@ -22,16 +22,24 @@ const setupUILater = (registry: PluginRegistry, store: Store<object, Action<obje
     const settings = await store.dispatch(getSettings());
 
     if (settings.data.enable_web_ui) {
-        registry.registerRootComponent(MoveThreadModal);
-        registry.registerLeftSidebarHeaderComponent(LeftSidebarAttachMessage);
-        registry.registerLeftSidebarHeaderComponent(LeftSidebarCopyToChannel);
-        registry.registerPostDropdownMenuComponent(MoveThreadDropdown);
-        registry.registerPostDropdownMenuComponent(AttachMessageDropdown);
-        registry.registerPostDropdownMenuComponent(CopyToChannelDropdown);
-        registry.registerChannelHeaderMenuAction(
-            'Copy Messages to Channel',
-            (channelId: string) => store.dispatch(startCopyToChannel(getChannel(store.getState(), channelId))),
-        );
+        const {operations} = settings.data;
+
+        if (operations.move) {
+            registry.registerRootComponent(MoveThreadModal);
+            registry.registerPostDropdownMenuComponent(MoveThreadDropdown);
+        }
+        if (operations.attach) {
+            registry.registerLeftSidebarHeaderComponent(LeftSidebarAttachMessage);
+            registry.registerPostDropdownMenuComponent(AttachMessageDropdown);
+        }
+        if (operations.copy) {
+            registry.registerLeftSidebarHeaderComponent(LeftSidebarCopyToChannel);
+            registry.registerPostDropdownMenuComponent(CopyToChannelDropdown);
+            registry.registerChannelHeaderMenuAction(
+                'Copy Messages to Channel',
+                (channelId: string) => store.dispatch(startCopyToChannel(getChannel(store.getState(), channelId))),
+            );
+        }
     }
 };
 
@@ -2,8 +2,16 @@ import {Channel} from 'mattermost-redux/types/channels';
 
 import id from '../plugin_id';
 
+export type Operations = {
+    move: boolean;
+    copy: boolean;
+    attach: boolean;
+    merge: boolean;
+}
+
 export type Settings = {
     enable_web_ui: boolean;
+    operations: Operations;
 }
 
 export type Channels = Array<Channel>